@@ -0,0 +1,186 @@
+// Package afero adapts a gistfs.FS to the afero.Fs interface, so that a gist
+// can be read by the tools built on afero rather than io/fs, such as viper:
+//
+//	v := viper.New()
+//	v.SetFs(afero.New(gfs))
+//
+// The filesystem is read-only: operations modifying it fail with
+// gistfs.ErrReadOnly, as gists can't be written file by file through
+// gistfs.
+package afero
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jhchabran/gistfs"
+	"github.com/spf13/afero"
+)
+
+// writeFlags are the flags of os.OpenFile asking for write access.
+const writeFlags = os.O_WRONLY | os.O_RDWR | os.O_CREATE | os.O_TRUNC | os.O_APPEND
+
+// fileSystem is the afero.Fs returned by New.
+type fileSystem struct {
+	fsys *gistfs.FS
+}
+
+// New returns a read-only afero.Fs serving the files of fsys, which must be
+// loaded beforehand. Names are slash-separated, and may be rooted.
+func New(fsys *gistfs.FS) afero.Fs {
+	return &fileSystem{fsys: fsys}
+}
+
+// fsName converts an afero name into the name of a file of fs.FS.
+func fsName(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return "."
+	}
+
+	return name
+}
+
+// readOnly returns the error of the operation op modifying the file name.
+func readOnly(op, name string) error {
+	return &fs.PathError{Op: op, Path: name, Err: gistfs.ErrReadOnly}
+}
+
+func (fsys *fileSystem) Name() string { return "gistfs" }
+
+func (fsys *fileSystem) Open(name string) (afero.File, error) {
+	f, err := fsys.fsys.Open(fsName(name))
+	if err != nil {
+		return nil, err
+	}
+
+	return &file{File: f, name: name}, nil
+}
+
+func (fsys *fileSystem) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if flag&writeFlags != 0 {
+		return nil, readOnly("open", name)
+	}
+
+	return fsys.Open(name)
+}
+
+func (fsys *fileSystem) Stat(name string) (os.FileInfo, error) {
+	return fs.Stat(fsys.fsys, fsName(name))
+}
+
+func (fsys *fileSystem) Create(name string) (afero.File, error) {
+	return nil, readOnly("create", name)
+}
+
+func (fsys *fileSystem) Mkdir(name string, perm os.FileMode) error {
+	return readOnly("mkdir", name)
+}
+
+func (fsys *fileSystem) MkdirAll(path string, perm os.FileMode) error {
+	return readOnly("mkdir", path)
+}
+
+func (fsys *fileSystem) Remove(name string) error {
+	return readOnly("remove", name)
+}
+
+func (fsys *fileSystem) RemoveAll(path string) error {
+	return readOnly("remove", path)
+}
+
+func (fsys *fileSystem) Rename(oldname, newname string) error {
+	return readOnly("rename", oldname)
+}
+
+func (fsys *fileSystem) Chmod(name string, mode os.FileMode) error {
+	return readOnly("chmod", name)
+}
+
+func (fsys *fileSystem) Chown(name string, uid, gid int) error {
+	return readOnly("chown", name)
+}
+
+func (fsys *fileSystem) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return readOnly("chtimes", name)
+}
+
+// file adapts an fs.File opened from a gistfs.FS to afero.File.
+type file struct {
+	fs.File
+	name string
+}
+
+func (f *file) Name() string { return f.name }
+
+func (f *file) ReadAt(b []byte, off int64) (int, error) {
+	if r, ok := f.File.(io.ReaderAt); ok {
+		return r.ReadAt(b, off)
+	}
+
+	return 0, &fs.PathError{Op: "read", Path: f.name, Err: errors.New("is a directory")}
+}
+
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	if s, ok := f.File.(io.Seeker); ok {
+		return s.Seek(offset, whence)
+	}
+
+	return 0, &fs.PathError{Op: "seek", Path: f.name, Err: errors.New("is a directory")}
+}
+
+// Readdir returns the FileInfo of the next count entries of a directory, or
+// of all the remaining ones if count <= 0.
+func (f *file) Readdir(count int) ([]os.FileInfo, error) {
+	d, ok := f.File.(fs.ReadDirFile)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: f.name, Err: errors.New("not a directory")}
+	}
+
+	entries, err := d.ReadDir(count)
+	infos := make([]os.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return infos, err
+		}
+		infos = append(infos, info)
+	}
+
+	return infos, err
+}
+
+// Readdirnames returns the names of the next n entries of a directory, or
+// of all the remaining ones if n <= 0.
+func (f *file) Readdirnames(n int) ([]string, error) {
+	infos, err := f.Readdir(n)
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name()
+	}
+
+	return names, err
+}
+
+func (f *file) Sync() error { return nil }
+
+func (f *file) Write(b []byte) (int, error) {
+	return 0, readOnly("write", f.name)
+}
+
+func (f *file) WriteAt(b []byte, off int64) (int, error) {
+	return 0, readOnly("write", f.name)
+}
+
+func (f *file) WriteString(s string) (int, error) {
+	return 0, readOnly("write", f.name)
+}
+
+func (f *file) Truncate(size int64) error {
+	return readOnly("truncate", f.name)
+}
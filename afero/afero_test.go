@@ -0,0 +1,112 @@
+package afero
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/jhchabran/gistfs"
+	"github.com/jhchabran/gistfs/gisttest"
+	"github.com/spf13/afero"
+)
+
+func TestFs(t *testing.T) {
+	gfs, _ := gisttest.NewFS(t, map[string]string{
+		"a.txt":  "0123456789",
+		"b.yaml": "b: 1\n",
+	}, gistfs.WithCompression())
+	afs := New(gfs)
+
+	t.Run("OK read", func(t *testing.T) {
+		for _, name := range []string{"a.txt", "/a.txt"} {
+			b, err := afero.ReadFile(afs, name)
+			if err != nil {
+				t.Fatalf("Reading %s, expected no error but got %#v", name, err)
+			}
+			if got, want := string(b), "0123456789"; got != want {
+				t.Fatalf("Reading %s, got %#v, want %#v", name, got, want)
+			}
+		}
+	})
+
+	t.Run("OK read at", func(t *testing.T) {
+		f, err := afs.Open("a.txt")
+		if err != nil {
+			t.Fatalf("Opening, expected no error but got %#v", err)
+		}
+		defer f.Close()
+
+		b := make([]byte, 3)
+		if _, err := f.ReadAt(b, 4); err != nil || string(b) != "456" {
+			t.Fatalf("Reading at 4, got %#v (%v), want %#v", string(b), err, "456")
+		}
+		if _, err := f.Seek(-2, io.SeekEnd); err != nil {
+			t.Fatalf("Seeking, expected no error but got %#v", err)
+		}
+		if b, _ := io.ReadAll(f); string(b) != "89" {
+			t.Fatalf("Reading after seeking, got %#v, want %#v", string(b), "89")
+		}
+	})
+
+	t.Run("OK readdir", func(t *testing.T) {
+		infos, err := afero.ReadDir(afs, "/")
+		if err != nil {
+			t.Fatalf("Listing the root, expected no error but got %#v", err)
+		}
+		if len(infos) != 2 || infos[0].Name() != "a.txt" || infos[1].Name() != "b.yaml" {
+			t.Fatalf("Listing the root, got %v, want a.txt and b.yaml", infos)
+		}
+
+		f, _ := afs.Open(".")
+		defer f.Close()
+		names, err := f.Readdirnames(1)
+		if err != nil || len(names) != 1 || names[0] != "a.txt" {
+			t.Fatalf("Listing one name, got %v (%v), want [a.txt]", names, err)
+		}
+	})
+
+	t.Run("OK walk", func(t *testing.T) {
+		var names []string
+		err := afero.Walk(afs, "/", func(path string, info os.FileInfo, err error) error {
+			names = append(names, path)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("Walking, expected no error but got %#v", err)
+		}
+		if got, want := len(names), 3; got != want {
+			t.Fatalf("Walking, got %v, want the root and 2 files", names)
+		}
+	})
+
+	t.Run("NOK read-only", func(t *testing.T) {
+		errs := map[string]error{
+			"write file": afero.WriteFile(afs, "c.txt", []byte("c"), 0644),
+			"mkdir":      afs.MkdirAll("dir", 0755),
+			"remove":     afs.Remove("a.txt"),
+			"rename":     afs.Rename("a.txt", "c.txt"),
+			"chmod":      afs.Chmod("a.txt", 0644),
+		}
+		_, errs["create"] = afs.Create("c.txt")
+		_, errs["open for writing"] = afs.OpenFile("a.txt", os.O_WRONLY, 0)
+
+		f, _ := afs.Open("a.txt")
+		defer f.Close()
+		_, errs["write"] = f.WriteString("a")
+		errs["truncate"] = f.Truncate(0)
+
+		for op, err := range errs {
+			if !errors.Is(err, gistfs.ErrReadOnly) {
+				t.Fatalf("Attempting to %s, got %#v, want gistfs.ErrReadOnly", op, err)
+			}
+		}
+	})
+
+	t.Run("NOK not found", func(t *testing.T) {
+		if _, err := afs.Stat("c.txt"); !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("Stating a missing file, got %#v, want fs.ErrNotExist", err)
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package gistfs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"io"
+	"sort"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// WriteZip writes a zip archive of all the files of the loaded gist to w.
+// Content deferred by LoadMetadata is downloaded first, with the context set
+// by WithReadContext.
+func (fsys *FS) WriteZip(w io.Writer) error {
+	s, err := fsys.archivedState()
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	for _, f := range fsys.sortedFiles(s) {
+		fh := &zip.FileHeader{
+			Name:     f.GetFilename(),
+			Method:   zip.Deflate,
+			Modified: s.gist.GetUpdatedAt(),
+		}
+		fh.SetMode(fsys.fileMode(f.GetFilename()))
+
+		fw, err := zw.CreateHeader(fh)
+		if err != nil {
+			return err
+		}
+
+		content, err := fsys.content(s, &f)
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.WriteString(fw, content); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// WriteTar writes a tar archive of all the files of the loaded gist to w,
+// downloading content deferred by LoadMetadata first as WriteZip does.
+func (fsys *FS) WriteTar(w io.Writer) error {
+	s, err := fsys.archivedState()
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	for _, f := range fsys.sortedFiles(s) {
+		content, err := fsys.content(s, &f)
+		if err != nil {
+			return err
+		}
+
+		err = tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     f.GetFilename(),
+			Mode:     int64(fsys.fileMode(f.GetFilename())),
+			Size:     int64(len(content)),
+			ModTime:  s.gist.GetUpdatedAt(),
+		})
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.WriteString(tw, content); err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
+// archivedState returns the state of the filesystem to be archived, once
+// the content of all its files was downloaded.
+func (fsys *FS) archivedState() (*state, error) {
+	s := fsys.state()
+	if s.gist == nil {
+		return nil, ErrNotLoaded
+	}
+	if !s.extra.deferContent {
+		return s, nil
+	}
+
+	if err := fsys.Prefetch(fsys.readContext(), s.index.paths...); err != nil {
+		return nil, err
+	}
+
+	return fsys.state(), nil
+}
+
+// sortedFiles returns the files of the gist of s, sorted by name.
+func (fsys *FS) sortedFiles(s *state) []github.GistFile {
+	files := make([]github.GistFile, 0, len(s.gist.Files))
+	for _, f := range s.gist.Files {
+		files = append(files, f)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].GetFilename() < files[j].GetFilename() })
+
+	return files
+}
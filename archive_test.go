@@ -0,0 +1,122 @@
+package gistfs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"testing"
+)
+
+var archiveFiles = map[string]string{
+	"a.txt": "foobar\nbarfoo",
+	"b.txt": "olala\n12345\nabcde",
+}
+
+func TestWriteZip(t *testing.T) {
+	_, client := newFakeGist(t, archiveFiles)
+	gfs := NewWithClient(client, referenceGistID)
+	gfs.Load(context.Background())
+
+	var buf bytes.Buffer
+	if err := gfs.WriteZip(&buf); err != nil {
+		t.Fatalf("Writing a zip archive, expected no error but got %#v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Reading the zip archive, expected no error but got %#v", err)
+	}
+
+	if got, want := len(zr.File), len(archiveFiles); got != want {
+		t.Fatalf("Reading the zip archive, got %d files, want %d", got, want)
+	}
+
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		b, _ := io.ReadAll(rc)
+		rc.Close()
+
+		if got, want := string(b), archiveFiles[f.Name]; got != want {
+			t.Fatalf("Reading %#v from the zip archive, got %#v, want %#v", f.Name, got, want)
+		}
+
+		if got, want := f.Modified, fakeUpdatedAt; !got.Equal(want) {
+			t.Fatalf("Reading %#v from the zip archive, got modtime %v, want %v", f.Name, got, want)
+		}
+	}
+
+	t.Run("NOK not loaded", func(t *testing.T) {
+		if err := New(referenceGistID).WriteZip(io.Discard); err != ErrNotLoaded {
+			t.Fatalf("Writing a zip archive without loading, got %#v, want %#v", err, ErrNotLoaded)
+		}
+	})
+}
+
+func TestWriteTar(t *testing.T) {
+	_, client := newFakeGist(t, archiveFiles)
+	gfs := NewWithClient(client, referenceGistID)
+	gfs.Load(context.Background())
+
+	var buf bytes.Buffer
+	if err := gfs.WriteTar(&buf); err != nil {
+		t.Fatalf("Writing a tar archive, expected no error but got %#v", err)
+	}
+
+	tr := tar.NewReader(&buf)
+	n := 0
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Reading the tar archive, expected no error but got %#v", err)
+		}
+		n++
+
+		b, _ := io.ReadAll(tr)
+		if got, want := string(b), archiveFiles[hdr.Name]; got != want {
+			t.Fatalf("Reading %#v from the tar archive, got %#v, want %#v", hdr.Name, got, want)
+		}
+
+		if got, want := hdr.Size, int64(len(archiveFiles[hdr.Name])); got != want {
+			t.Fatalf("Reading %#v from the tar archive, got size %d, want %d", hdr.Name, got, want)
+		}
+	}
+
+	if got, want := n, len(archiveFiles); got != want {
+		t.Fatalf("Reading the tar archive, got %d files, want %d", got, want)
+	}
+}
+
+func TestWriteArchiveDeferred(t *testing.T) {
+	getter := &stubGetter{
+		gist: &Gist{
+			ID: referenceGistID,
+			Files: map[string]GistFile{
+				"big.txt": {Filename: "big.txt", Content: "big", Size: 9, Truncated: true, RawURL: "https://raw/big.txt"},
+			},
+		},
+		raw: map[string]string{"https://raw/big.txt": "big file!"},
+	}
+
+	gfs := NewWithGetter(getter, referenceGistID)
+	if err := gfs.LoadMetadata(context.Background()); err != nil {
+		t.Fatalf("Loading metadata, expected no error but got %#v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := gfs.WriteTar(&buf); err != nil {
+		t.Fatalf("Writing a tar archive, expected no error but got %#v", err)
+	}
+
+	tr := tar.NewReader(&buf)
+	if _, err := tr.Next(); err != nil {
+		t.Fatalf("Reading the tar archive, expected no error but got %#v", err)
+	}
+	if b, _ := io.ReadAll(tr); string(b) != "big file!" {
+		t.Fatalf("Reading deferred content from the tar archive, got %#v, want %#v", string(b), "big file!")
+	}
+}
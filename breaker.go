@@ -0,0 +1,80 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by Load when the circuit breaker configured
+// with WithCircuitBreaker is preventing requests to Github.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// WithCircuitBreaker stops making requests to Github for cooldown once
+// threshold consecutive loads failed, making Load fail with ErrCircuitOpen
+// instead. The already loaded content keeps being served in the meantime.
+//
+// Once cooldown elapsed, a single load is let through: if it succeeds,
+// requests flow again, otherwise the breaker stays open for another cooldown.
+// Values of threshold below 1 open the breaker on the first failure.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(fsys *FS) {
+		if threshold < 1 {
+			threshold = 1
+		}
+		fsys.breaker = &circuitBreaker{
+			threshold: threshold,
+			cooldown:  cooldown,
+		}
+	}
+}
+
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	mu        sync.Mutex
+}
+
+// allow reports whether a request can be made at the given time.
+func (b *circuitBreaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+
+	if now.Sub(b.openedAt) < b.cooldown {
+		return false
+	}
+
+	// let a single trial request through, keeping the breaker open for
+	// concurrent ones until it completes.
+	b.openedAt = now
+
+	return true
+}
+
+// record updates the breaker with the outcome of a request.
+func (b *circuitBreaker) record(err error, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		b.failures = 0
+		return
+	}
+
+	// cancellations come from the caller and say nothing about Github health.
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = now
+	}
+}
@@ -0,0 +1,79 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWithCircuitBreaker(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+	clock := newFakeClock()
+
+	gfs := NewWithClient(client, referenceGistID, WithCircuitBreaker(2, time.Minute))
+	gfs.now = clock.Now
+	gfs.Load(context.Background())
+
+	fg.SetStatus(http.StatusBadGateway)
+	gfs.Load(context.Background())
+	gfs.Load(context.Background())
+
+	t.Run("OK open after threshold", func(t *testing.T) {
+		before := fg.Requests()
+
+		if err := gfs.Load(context.Background()); !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Loading with an open breaker, got %#v, want %#v", err, ErrCircuitOpen)
+		}
+
+		if got, want := fg.Requests(), before; got != want {
+			t.Fatalf("Loading with an open breaker, got %d API requests, want %d", got, want)
+		}
+
+		b, err := gfs.ReadFile("a.txt")
+		if err != nil || string(b) != "a" {
+			t.Fatalf("Reading with an open breaker, got %#v (%v), want the cached %#v", string(b), err, "a")
+		}
+	})
+
+	t.Run("OK trial after cooldown", func(t *testing.T) {
+		clock.Advance(2 * time.Minute)
+
+		if err := gfs.Load(context.Background()); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Loading after cooldown, got %#v, want the API error", err)
+		}
+
+		if err := gfs.Load(context.Background()); !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Loading after a failed trial, got %#v, want %#v", err, ErrCircuitOpen)
+		}
+	})
+
+	t.Run("OK closed after successful trial", func(t *testing.T) {
+		fg.SetStatus(0)
+		clock.Advance(2 * time.Minute)
+
+		for i := 0; i < 2; i++ {
+			if err := gfs.Load(context.Background()); err != nil {
+				t.Fatalf("Loading after recovery, expected no error but got %#v", err)
+			}
+		}
+	})
+	t.Run("OK non-positive threshold", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		gfs := NewWithClient(client, referenceGistID, WithCircuitBreaker(0, time.Minute))
+		gfs.now = clock.Now
+
+		for i := 0; i < 2; i++ {
+			if err := gfs.Load(context.Background()); err != nil {
+				t.Fatalf("Loading with a threshold of 0, expected no error but got %#v", err)
+			}
+		}
+
+		fg.SetStatus(http.StatusBadGateway)
+		gfs.Load(context.Background())
+		if err := gfs.Load(context.Background()); !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Loading after a failure with a threshold of 0, got %#v, want %#v", err, ErrCircuitOpen)
+		}
+	})
+}
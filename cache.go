@@ -0,0 +1,170 @@
+package gistfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Cache stores loaded gists, so that they can be shared by several
+// processes and survive restarts. Implementations must be safe for
+// concurrent use.
+//
+// Values are opaque, and keys are derived from the ID of the gist and the
+// revision the filesystem is pinned to, if any.
+type Cache interface {
+	// Get returns the value stored at key, and whether there is one.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value at key.
+	Set(ctx context.Context, key string, value []byte) error
+}
+
+// WithCache stores the loaded gist in c, along with the ETag identifying
+// its content.
+//
+// The first load of the filesystem restores the gist from c if it holds
+// one, and only downloads it again if it changed since. If Github can't be
+// reached, the gist from c is served and Load returns the error, just like
+// with a failed refresh. Because revisions never change, a filesystem
+// pinned to one with WithRevision doesn't reach Github at all when c holds
+// it.
+//
+// Failing to get or set a value is logged, but doesn't fail loads.
+func WithCache(c Cache) Option {
+	return func(fsys *FS) {
+		fsys.cache = c
+	}
+}
+
+// cacheKey is the key the gist served by the filesystem is cached at.
+func (fsys *FS) cacheKey() string {
+	if fsys.revision != "" {
+		return "gistfs/" + fsys.GetID() + "@" + fsys.revision
+	}
+
+	return "gistfs/" + fsys.GetID()
+}
+
+// restoreCache loads the filesystem from its cache, returning whether it
+// held the gist.
+func (fsys *FS) restoreCache(ctx context.Context) bool {
+	b, ok, err := fsys.cache.Get(ctx, fsys.cacheKey())
+	if err == nil && ok {
+		err = fsys.LoadSnapshot(bytes.NewReader(b))
+	}
+	if err != nil {
+		fsys.log(ctx, levelWarn, "cache get failed", "key", fsys.cacheKey(), "error", err)
+		return false
+	}
+
+	return ok
+}
+
+// storeCache stores the loaded gist in the cache of the filesystem.
+func (fsys *FS) storeCache(ctx context.Context) {
+	var b bytes.Buffer
+	err := fsys.SaveSnapshot(&b)
+	if err == nil {
+		err = fsys.cache.Set(ctx, fsys.cacheKey(), b.Bytes())
+	}
+	if err != nil {
+		fsys.log(ctx, levelWarn, "cache set failed", "key", fsys.cacheKey(), "error", err)
+	}
+}
+
+// MemoryCache is a Cache holding values in memory, to share gists between
+// filesystems of a process.
+type MemoryCache struct {
+	mu     sync.RWMutex
+	values map[string][]byte
+}
+
+// NewMemoryCache returns an empty MemoryCache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{values: make(map[string][]byte)}
+}
+
+// Get implements Cache.
+func (c *MemoryCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	v, ok := c.values[key]
+	return v, ok, nil
+}
+
+// Set implements Cache.
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[key] = append([]byte(nil), value...)
+	return nil
+}
+
+// WithDiskCache stores the loaded gist in dir, along with its ETag, as
+// WithCache does with a DiskCache. A restarted process then only needs
+// a conditional request, which doesn't count against the rate limit, to
+// check that the gist did not change, rather than downloading it again.
+func WithDiskCache(dir string) Option {
+	return WithCache(NewDiskCache(dir))
+}
+
+// DiskCache is a Cache storing values in files of a directory, one per key.
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache returns a DiskCache storing values in dir, which is created
+// when the first value is stored if needed. Under GOOS=js, it requires
+// a runtime providing a filesystem, such as Node.js.
+func NewDiskCache(dir string) *DiskCache {
+	return &DiskCache{dir: dir}
+}
+
+// path returns the path of the file storing the value at key.
+func (c *DiskCache) path(key string) string {
+	return filepath.Join(c.dir, url.PathEscape(key))
+}
+
+// Get implements Cache.
+func (c *DiskCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	b, err := os.ReadFile(c.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return b, true, nil
+}
+
+// Set implements Cache. Values are written to a temporary file first, so
+// that concurrent readers never see a partial one.
+func (c *DiskCache) Set(ctx context.Context, key string, value []byte) error {
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(value); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), c.path(key))
+}
@@ -0,0 +1,152 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// failingCache is a Cache whose operations always fail.
+type failingCache struct{}
+
+func (failingCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	return nil, false, errors.New("cache down")
+}
+
+func (failingCache) Set(ctx context.Context, key string, value []byte) error {
+	return errors.New("cache down")
+}
+
+func TestWithCache(t *testing.T) {
+	t.Run("OK shared", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		cache := NewMemoryCache()
+
+		if err := NewWithClient(client, referenceGistID, WithCache(cache)).Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+		if _, ok, _ := cache.Get(context.Background(), "gistfs/"+referenceGistID); !ok {
+			t.Fatal("Loading, expected the gist to be cached")
+		}
+
+		gfs := NewWithClient(client, referenceGistID, WithCache(cache))
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading from the cache, expected no error but got %#v", err)
+		}
+		if fg.LastHeader().Get("If-None-Match") == "" {
+			t.Fatal("Loading from the cache, got an unconditional request, want a conditional one")
+		}
+		if b, err := gfs.ReadFile("a.txt"); err != nil || string(b) != "a" {
+			t.Fatalf("Reading, got %#v (%v), want %#v", string(b), err, "a")
+		}
+	})
+
+	t.Run("OK changed", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		cache := NewMemoryCache()
+
+		NewWithClient(client, referenceGistID, WithCache(cache)).Load(context.Background())
+		fg.SetFiles(map[string]string{"a.txt": "b"})
+
+		gfs := NewWithClient(client, referenceGistID, WithCache(cache))
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading from the cache, expected no error but got %#v", err)
+		}
+		if b, err := gfs.ReadFile("a.txt"); err != nil || string(b) != "b" {
+			t.Fatalf("Reading, got %#v (%v), want %#v", string(b), err, "b")
+		}
+	})
+
+	t.Run("OK offline", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		cache := NewMemoryCache()
+
+		NewWithClient(client, referenceGistID, WithCache(cache)).Load(context.Background())
+		fg.SetStatus(http.StatusBadGateway)
+
+		gfs := NewWithClient(client, referenceGistID, WithCache(cache), WithRetry(testRetryPolicy))
+		if err := gfs.Load(context.Background()); err == nil {
+			t.Fatal("Loading while Github is down, expected an error but got none")
+		}
+		if b, err := gfs.ReadFile("a.txt"); err != nil || string(b) != "a" {
+			t.Fatalf("Reading, got %#v (%v), want the cached %#v", string(b), err, "a")
+		}
+	})
+
+	t.Run("OK revision", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		cache := NewMemoryCache()
+		rev := fg.Revisions()[0]
+
+		NewWithClient(client, referenceGistID, WithCache(cache), WithRevision(rev)).Load(context.Background())
+		requests := fg.Requests()
+
+		gfs := NewWithClient(client, referenceGistID, WithCache(cache), WithRevision(rev))
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading from the cache, expected no error but got %#v", err)
+		}
+		if got := fg.Requests(); got != requests {
+			t.Fatalf("Loading a cached revision, got %d requests, want none", got-requests)
+		}
+		if b, err := gfs.ReadFile("a.txt"); err != nil || string(b) != "a" {
+			t.Fatalf("Reading, got %#v (%v), want %#v", string(b), err, "a")
+		}
+	})
+
+	t.Run("OK failing cache", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+
+		gfs := NewWithClient(client, referenceGistID, WithCache(failingCache{}))
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+	})
+}
+
+func TestWithDiskCache(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+	dir := filepath.Join(t.TempDir(), "cache")
+
+	if err := NewWithClient(client, referenceGistID, WithDiskCache(dir)).Load(context.Background()); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Listing the cache, got %v (%v), want a single file", entries, err)
+	}
+
+	gfs := NewWithClient(client, referenceGistID, WithDiskCache(dir))
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading from the cache, expected no error but got %#v", err)
+	}
+	if fg.LastHeader().Get("If-None-Match") == "" {
+		t.Fatal("Loading from the cache, got an unconditional request, want a conditional one")
+	}
+	if b, err := gfs.ReadFile("a.txt"); err != nil || string(b) != "a" {
+		t.Fatalf("Reading, got %#v (%v), want %#v", string(b), err, "a")
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	c := NewDiskCache(t.TempDir())
+	ctx := context.Background()
+
+	if _, ok, err := c.Get(ctx, "gistfs/missing"); ok || err != nil {
+		t.Fatalf("Getting a missing key, got %v (%v), want no value and no error", ok, err)
+	}
+
+	for _, v := range []string{"first", "second"} {
+		if err := c.Set(ctx, "gistfs/id@rev", []byte(v)); err != nil {
+			t.Fatalf("Setting, expected no error but got %#v", err)
+		}
+
+		b, ok, err := c.Get(ctx, "gistfs/id@rev")
+		if !ok || err != nil || string(b) != v {
+			t.Fatalf("Getting, got %#v (%v, %v), want %#v", string(b), ok, err, v)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package gistfs
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing/fstest"
+	"time"
+)
+
+// CacheFS serves a copy of another filesystem held in memory, such as one
+// reading files over the network, and refreshes it the way FS refreshes
+// a gist: it serves the copy for a TTL, then keeps serving it while
+// refreshing it in the background, and reports the files a refresh changed
+// to watchers.
+//
+// The whole filesystem is copied by each load, which suits small
+// filesystems whose content is read often but changes rarely.
+type CacheFS struct {
+	src fs.FS
+
+	// current holds the *cacheState served, which reads load without
+	// locking.
+	current atomic.Value
+	// loadMu serializes loads.
+	loadMu sync.Mutex
+
+	freshness freshness
+	watchers  watchers
+
+	// now returns the current time, and is overridden in tests.
+	now func() time.Time
+}
+
+// CacheOption configures a CacheFS when it is created.
+type CacheOption func(*CacheFS)
+
+// WithCacheTTL makes the loaded copy fresh for d. Reads happening after that
+// delay still serve the loaded copy, but trigger a refresh in the
+// background, as WithTTL does for a FS.
+func WithCacheTTL(d time.Duration) CacheOption {
+	return func(c *CacheFS) {
+		c.freshness.ttl = d
+	}
+}
+
+// WithCacheStaleIfError keeps serving the loaded copy for d after it expired
+// when refreshing it fails, instead of failing reads with the refresh error,
+// as WithStaleIfError does for a FS.
+func WithCacheStaleIfError(d time.Duration) CacheOption {
+	return func(c *CacheFS) {
+		c.freshness.staleIfError = d
+	}
+}
+
+// cacheState is what a CacheFS serves. It is never modified once stored.
+type cacheState struct {
+	// files is the copy of the source filesystem, nil until loaded.
+	files fstest.MapFS
+	// loadedAt is when files was last copied.
+	loadedAt time.Time
+	// refreshErr is the error of the last load, if it failed.
+	refreshErr error
+}
+
+// NewCacheFS returns a CacheFS serving a copy of src. It must be loaded
+// once with Load before being used.
+func NewCacheFS(src fs.FS, opts ...CacheOption) *CacheFS {
+	c := &CacheFS{src: src, now: time.Now}
+	c.current.Store(&cacheState{})
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+func (c *CacheFS) state() *cacheState {
+	return c.current.Load().(*cacheState)
+}
+
+// Load copies the source filesystem, and notifies watchers of the files
+// that changed since the previous load. When it fails, the previous copy is
+// still served, within the limits set by WithCacheStaleIfError.
+func (c *CacheFS) Load(ctx context.Context) error {
+	c.loadMu.Lock()
+	defer c.loadMu.Unlock()
+
+	old := c.state()
+
+	files, err := copyFS(ctx, c.src)
+	if err != nil {
+		s := *old
+		s.refreshErr = err
+		c.current.Store(&s)
+		return err
+	}
+
+	c.current.Store(&cacheState{files: files, loadedAt: c.now()})
+	c.watchers.notify(diffFiles(old.files, files))
+
+	return nil
+}
+
+// Watch returns a channel on which a ChangeEvent is sent for every file
+// that was added, modified or removed by a subsequent Load, as FS.Watch
+// does.
+func (c *CacheFS) Watch(ctx context.Context) (<-chan ChangeEvent, error) {
+	return c.watchers.watch(ctx)
+}
+
+// IsLoaded reports whether a load succeeded.
+func (c *CacheFS) IsLoaded() bool {
+	return c.state().files != nil
+}
+
+// Stale reports whether the last refresh failed, meaning that the copy
+// being served may be older than the source filesystem.
+func (c *CacheFS) Stale() bool {
+	s := c.state()
+	return s.files != nil && s.refreshErr != nil
+}
+
+// Open implements fs.FS.
+func (c *CacheFS) Open(name string) (fs.File, error) {
+	files, err := c.files()
+	if err != nil {
+		return nil, err
+	}
+
+	return files.Open(name)
+}
+
+// ReadFile implements fs.ReadFileFS.
+func (c *CacheFS) ReadFile(name string) ([]byte, error) {
+	files, err := c.files()
+	if err != nil {
+		return nil, err
+	}
+
+	return files.ReadFile(name)
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (c *CacheFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	files, err := c.files()
+	if err != nil {
+		return nil, err
+	}
+
+	return files.ReadDir(name)
+}
+
+// Stat implements fs.StatFS.
+func (c *CacheFS) Stat(name string) (fs.FileInfo, error) {
+	files, err := c.files()
+	if err != nil {
+		return nil, err
+	}
+
+	return files.Stat(name)
+}
+
+// files returns the copy to serve reads from, starting a refresh in the
+// background if it expired. It fails with ErrNotLoaded before the first
+// load, and with the error of the last refresh once the copy can no longer
+// be served as stale.
+func (c *CacheFS) files() (fstest.MapFS, error) {
+	s := c.state()
+	if s.files == nil {
+		return nil, ErrNotLoaded
+	}
+
+	c.freshness.revalidate(c.now(), s.loadedAt, func() {
+		_ = c.Load(context.Background())
+	})
+
+	if err := c.freshness.expiredErr(c.now(), s.loadedAt, s.refreshErr); err != nil {
+		return nil, err
+	}
+
+	return s.files, nil
+}
+
+// copyFS reads all the files of src into memory.
+func copyFS(ctx context.Context, src fs.FS) (fstest.MapFS, error) {
+	files := fstest.MapFS{}
+
+	err := fs.WalkDir(src, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if name == "." {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		f := &fstest.MapFile{Mode: info.Mode(), ModTime: info.ModTime(), Sys: info.Sys()}
+		if !d.IsDir() {
+			if f.Data, err = fs.ReadFile(src, name); err != nil {
+				return err
+			}
+		}
+		files[name] = f
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// diffFiles compares the files of two copies and returns the changes needed
+// to go from old to new, ignoring directories.
+func diffFiles(old, new fstest.MapFS) []ChangeEvent {
+	var events []ChangeEvent
+	for name, f := range new {
+		if f.Mode.IsDir() {
+			continue
+		}
+
+		prev, ok := old[name]
+		switch {
+		case !ok || prev.Mode.IsDir():
+			events = append(events, ChangeEvent{Op: Added, Name: name})
+		case !bytes.Equal(prev.Data, f.Data):
+			events = append(events, ChangeEvent{Op: Modified, Name: name})
+		}
+	}
+
+	for name, f := range old {
+		if f.Mode.IsDir() {
+			continue
+		}
+		if next, ok := new[name]; !ok || next.Mode.IsDir() {
+			events = append(events, ChangeEvent{Op: Removed, Name: name})
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].Name < events[j].Name })
+
+	return events
+}
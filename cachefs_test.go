@@ -0,0 +1,132 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// swappableFS serves the files it is last given, or fails with err if set.
+type swappableFS struct {
+	mu    sync.Mutex
+	files fstest.MapFS
+	err   error
+}
+
+func (s *swappableFS) set(files fstest.MapFS, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.files, s.err = files, err
+}
+
+func (s *swappableFS) Open(name string) (fs.File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.files.Open(name)
+}
+
+func TestCacheFS(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("NOK not loaded", func(t *testing.T) {
+		c := NewCacheFS(fstest.MapFS{})
+
+		if _, err := c.ReadFile("a.txt"); !errors.Is(err, ErrNotLoaded) {
+			t.Fatalf("Reading, got %#v, want ErrNotLoaded", err)
+		}
+	})
+
+	t.Run("OK fstest", func(t *testing.T) {
+		c := NewCacheFS(fstest.MapFS{
+			"a.txt":     {Data: []byte("a")},
+			"dir/b.txt": {Data: []byte("b")},
+		})
+		if err := c.Load(ctx); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		if err := fstest.TestFS(c, "a.txt", "dir/b.txt"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("OK stale while revalidate", func(t *testing.T) {
+		src := &swappableFS{files: fstest.MapFS{"a.txt": {Data: []byte("a")}, "b.txt": {Data: []byte("b")}}}
+		clock := newFakeClock()
+
+		c := NewCacheFS(src, WithCacheTTL(time.Minute))
+		c.now = clock.Now
+		if err := c.Load(ctx); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		wctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		events, err := c.Watch(wctx)
+		if err != nil {
+			t.Fatalf("Watching, expected no error but got %#v", err)
+		}
+
+		src.set(fstest.MapFS{"a.txt": {Data: []byte("A")}, "c.txt": {Data: []byte("c")}}, nil)
+
+		clock.Advance(30 * time.Second)
+		if b, _ := c.ReadFile("a.txt"); string(b) != "a" {
+			t.Fatalf("Reading within TTL, got %q, want %q", b, "a")
+		}
+
+		clock.Advance(time.Minute)
+		if b, _ := c.ReadFile("a.txt"); string(b) != "a" {
+			t.Fatalf("Reading after TTL, got %q, want the stale %q", b, "a")
+		}
+
+		want := []ChangeEvent{{Op: Modified, Name: "a.txt"}, {Op: Removed, Name: "b.txt"}, {Op: Added, Name: "c.txt"}}
+		for _, w := range want {
+			if got := <-events; got != w {
+				t.Fatalf("Watching, got %+v, want %+v", got, w)
+			}
+		}
+
+		if b, _ := c.ReadFile("a.txt"); string(b) != "A" {
+			t.Fatalf("Reading after refresh, got %q, want %q", b, "A")
+		}
+	})
+
+	t.Run("OK stale if error", func(t *testing.T) {
+		src := &swappableFS{files: fstest.MapFS{"a.txt": {Data: []byte("a")}}}
+		clock := newFakeClock()
+
+		c := NewCacheFS(src, WithCacheTTL(time.Minute), WithCacheStaleIfError(time.Hour))
+		c.now = clock.Now
+		if err := c.Load(ctx); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		errDown := errors.New("down")
+		src.set(nil, errDown)
+		clock.Advance(2 * time.Minute)
+		if err := c.Load(ctx); !errors.Is(err, errDown) {
+			t.Fatalf("Refreshing, got %#v, want %#v", err, errDown)
+		}
+
+		if b, err := c.ReadFile("a.txt"); err != nil || string(b) != "a" {
+			t.Fatalf("Reading within stale window, got %q, %#v, want %q", b, err, "a")
+		}
+		if !c.Stale() {
+			t.Fatal("Stale, got false, want true")
+		}
+
+		clock.Advance(2 * time.Hour)
+		if _, err := c.ReadFile("a.txt"); !errors.Is(err, errDown) {
+			t.Fatalf("Reading after stale window, got %#v, want %#v", err, errDown)
+		}
+	})
+}
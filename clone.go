@@ -0,0 +1,52 @@
+package gistfs
+
+import (
+	"testing/fstest"
+)
+
+// Clone returns a filesystem serving the same gist, with the same options,
+// detached from fsys: refreshing either one does not affect the other. The
+// clone is never refreshed on its own, even with WithTTL, so that it can be
+// handed to code that must not reach the network. Calling Load on it fetches
+// the gist again.
+func (fsys *FS) Clone() *FS {
+	clone := newFS(fsys.client, fsys.GetID(), fsys.opts)
+	clone.freshness.ttl = 0
+
+	s := *fsys.state()
+	s.gist, s.extra = clone.interner.internGist(s.gist, s.extra)
+	clone.current.Store(&s)
+
+	return clone
+}
+
+// ToMapFS returns a copy of the files served by the filesystem, virtual ones
+// included, as a fstest.MapFS. The Sys field of each file is its
+// *FileMetadata.
+//
+// Files whose content was deferred by LoadMetadata only hold its beginning,
+// unless they were read or prefetched before.
+func (fsys *FS) ToMapFS() (fstest.MapFS, error) {
+	s := fsys.state()
+	if s.gist == nil {
+		return nil, ErrNotLoaded
+	}
+
+	mfs := make(fstest.MapFS, len(s.index.paths))
+	for i, p := range s.index.paths {
+		f := s.index.files[i]
+		content, err := fsys.content(s, &f)
+		if err != nil {
+			return nil, err
+		}
+
+		mfs[p] = &fstest.MapFile{
+			Data:    []byte(content),
+			Mode:    fsys.fileMode(p),
+			ModTime: s.gist.GetUpdatedAt(),
+			Sys:     newFileMetadata(&f),
+		}
+	}
+
+	return mfs, nil
+}
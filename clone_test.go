@@ -0,0 +1,85 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestClone(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+	gfs := NewWithClient(client, referenceGistID, WithMetaFile())
+
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+
+	clone := gfs.Clone()
+
+	t.Run("OK detached", func(t *testing.T) {
+		fg.SetFiles(map[string]string{"a.txt": "changed"})
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Refreshing, expected no error but got %#v", err)
+		}
+
+		b, err := clone.ReadFile("a.txt")
+		if err != nil {
+			t.Fatalf("Reading a clone, expected no error but got %#v", err)
+		}
+		if got, want := string(b), "a"; got != want {
+			t.Fatalf("Reading a clone after refreshing the original, got %#v, want %#v", got, want)
+		}
+
+		if _, err := clone.Open(MetaFile); err != nil {
+			t.Fatalf("Opening a virtual file of a clone, expected no error but got %#v", err)
+		}
+	})
+
+	t.Run("OK load", func(t *testing.T) {
+		if err := clone.Load(context.Background()); err != nil {
+			t.Fatalf("Loading a clone, expected no error but got %#v", err)
+		}
+
+		b, _ := clone.ReadFile("a.txt")
+		if got, want := string(b), "changed"; got != want {
+			t.Fatalf("Reading a reloaded clone, got %#v, want %#v", got, want)
+		}
+	})
+}
+
+func TestToMapFS(t *testing.T) {
+	_, client := newFakeGist(t, map[string]string{"a.txt": "a", "b.txt": "b"})
+	gfs := NewWithClient(client, referenceGistID, WithCompression())
+
+	t.Run("NOK not loaded", func(t *testing.T) {
+		if _, err := gfs.ToMapFS(); !errors.Is(err, ErrNotLoaded) {
+			t.Fatalf("Exporting before loading, got %#v, want %#v", err, ErrNotLoaded)
+		}
+	})
+
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		mfs, err := gfs.ToMapFS()
+		if err != nil {
+			t.Fatalf("Exporting, expected no error but got %#v", err)
+		}
+
+		if err := fstest.TestFS(mfs, "a.txt", "b.txt"); err != nil {
+			t.Fatalf("Testing the exported filesystem, expected no error but got %v", err)
+		}
+
+		b, _ := fs.ReadFile(mfs, "b.txt")
+		if got, want := string(b), "b"; got != want {
+			t.Fatalf("Reading an exported file, got %#v, want %#v", got, want)
+		}
+
+		if _, ok := mfs["a.txt"].Sys.(*FileMetadata); !ok {
+			t.Fatalf("Exporting, got Sys %#v, want a *FileMetadata", mfs["a.txt"].Sys)
+		}
+	})
+}
@@ -0,0 +1,162 @@
+// Command gistfs-embed downloads a gist and generates a Go file holding its
+// files in an fstest.MapFS, to be embedded into binaries, which can then
+// serve the gist even when Github is unreachable. It is meant to be run by
+// go generate:
+//
+//	//go:generate go run github.com/jhchabran/gistfs/cmd/gistfs-embed -o gist.go -revision <sha> <gist>
+//
+// where gist is a gist ID or URL, as understood by gistfs.ParseURL. Pinning
+// a revision, either with -revision or in the URL, keeps builds
+// reproducible.
+//
+// The package of the generated file defaults to the one go generate runs
+// for. Secret gists are read with the personal access token held by the
+// GITHUB_TOKEN environment variable, if set.
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing/fstest"
+
+	"github.com/google/go-github/v33/github"
+	"github.com/jhchabran/gistfs"
+	"golang.org/x/oauth2"
+)
+
+// errUsage is returned when the command is invoked with invalid arguments,
+// once its usage has been printed.
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	err := run(context.Background(), os.Args[1:], os.Stderr, os.Getenv)
+	switch {
+	case errors.Is(err, errUsage):
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "gistfs-embed:", err)
+		os.Exit(1)
+	}
+}
+
+// run generates the file described by args, printing usage errors to
+// stderr.
+func run(ctx context.Context, args []string, stderr io.Writer, getenv func(string) string) error {
+	fset := flag.NewFlagSet("gistfs-embed", flag.ContinueOnError)
+	fset.SetOutput(stderr)
+	output := fset.String("o", "gist.go", "write the generated code to `file`")
+	pkg := fset.String("pkg", getenv("GOPACKAGE"), "declare the generated code in package `name`, defaulting to the one of go generate")
+	name := fset.String("var", "Gist", "hold the files in variable `name`")
+	revision := fset.String("revision", "", "embed the gist as of revision `sha`, instead of the one the gist URL points to, if any")
+	fset.Usage = func() {
+		fmt.Fprintln(stderr, "usage: gistfs-embed [-o file] [-pkg name] [-var name] [-revision sha] <gist>")
+		fset.PrintDefaults()
+	}
+	if err := fset.Parse(args); err != nil {
+		return errUsage
+	}
+	if fset.NArg() != 1 || !token.IsIdentifier(*pkg) || !token.IsIdentifier(*name) {
+		fset.Usage()
+		return errUsage
+	}
+
+	id, rev, err := gistfs.ParseURL(fset.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *revision != "" {
+		rev = *revision
+	}
+
+	client, err := githubClient(ctx, getenv)
+	if err != nil {
+		return err
+	}
+
+	var opts []gistfs.Option
+	if rev != "" {
+		opts = append(opts, gistfs.WithRevision(rev))
+	}
+
+	fsys := gistfs.NewWithClient(client, id, opts...)
+	if err := fsys.Load(ctx); err != nil {
+		return err
+	}
+
+	files, err := fsys.ToMapFS()
+	if err != nil {
+		return err
+	}
+
+	src, err := generate(*pkg, *name, id, rev, files)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(*output, src, 0644)
+}
+
+// generate returns the source of a Go file of package pkg declaring the
+// variable name, holding files, those of the gist id as of revision rev.
+func generate(pkg, name, id, rev string, files fstest.MapFS) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by gistfs-embed; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	fmt.Fprintf(&buf, "import (\n\t\"testing/fstest\"\n\t\"time\"\n)\n\n")
+
+	fmt.Fprintf(&buf, "// %s holds the files of the gist %s", name, id)
+	if rev != "" {
+		fmt.Fprintf(&buf, " as of revision %s", rev)
+	}
+	fmt.Fprintf(&buf, ".\nvar %s = fstest.MapFS{\n", name)
+
+	names := make([]string, 0, len(files))
+	for n := range files {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	for _, n := range names {
+		f := files[n]
+		fmt.Fprintf(&buf, "%s: {\n", strconv.Quote(n))
+		fmt.Fprintf(&buf, "Data: []byte(%s),\n", strconv.Quote(string(f.Data)))
+		fmt.Fprintf(&buf, "Mode: %#o,\n", f.Mode)
+		fmt.Fprintf(&buf, "ModTime: time.Unix(%d, 0).UTC(),\n", f.ModTime.Unix())
+		fmt.Fprintf(&buf, "},\n")
+	}
+	fmt.Fprintf(&buf, "}\n")
+
+	return format.Source(buf.Bytes())
+}
+
+// githubClient returns a Github client authenticated with GITHUB_TOKEN and
+// making its requests to GITHUB_API_URL, if set.
+func githubClient(ctx context.Context, getenv func(string) string) (*github.Client, error) {
+	var httpClient *http.Client
+	if token := getenv("GITHUB_TOKEN"); token != "" {
+		httpClient = oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))
+	}
+
+	client := github.NewClient(httpClient)
+	if api := getenv("GITHUB_API_URL"); api != "" {
+		u, err := url.Parse(strings.TrimSuffix(api, "/") + "/")
+		if err != nil {
+			return nil, fmt.Errorf("invalid GITHUB_API_URL: %w", err)
+		}
+		client.BaseURL = u
+	}
+
+	return client, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jhchabran/gistfs/gisttest"
+)
+
+func TestRun(t *testing.T) {
+	srv := gisttest.NewServer(t)
+	g := srv.AddGist(gisttest.GistID, map[string]string{"a.txt": "a", "b.json": "{\"b\": \"`\"}\n"})
+	revision := strings.Repeat("1", 40)
+	g.AddRevision(revision, map[string]string{"a.txt": "old a"})
+
+	env := map[string]string{"GITHUB_API_URL": srv.URL, "GOPACKAGE": "config"}
+	getenv := func(key string) string { return env[key] }
+
+	t.Run("OK", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "gist.go")
+		if err := run(context.Background(), []string{"-o", out, "-var", "Defaults", gisttest.GistID}, &bytes.Buffer{}, getenv); err != nil {
+			t.Fatalf("Generating, expected no error but got %#v", err)
+		}
+
+		b, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatalf("Reading the generated file, expected no error but got %#v", err)
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), out, b, 0)
+		if err != nil {
+			t.Fatalf("Parsing the generated file, expected no error but got %#v", err)
+		}
+		if got, want := f.Name.Name, "config"; got != want {
+			t.Fatalf("Generating, got package %#v, want %#v", got, want)
+		}
+
+		for _, want := range []string{
+			"// Code generated by gistfs-embed; DO NOT EDIT.",
+			"var Defaults = fstest.MapFS{",
+			`"a.txt": {`,
+			`Data:    []byte("a"),`,
+			`Data:    []byte("{\"b\": \"` + "`" + `\"}\n"),`,
+			"Mode:    0444,",
+		} {
+			if !strings.Contains(string(b), want) {
+				t.Fatalf("Generating, expected the file to contain %#v but got\n%s", want, b)
+			}
+		}
+	})
+
+	t.Run("OK revision", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "gist.go")
+		if err := run(context.Background(), []string{"-o", out, "-revision", revision, gisttest.GistID}, &bytes.Buffer{}, getenv); err != nil {
+			t.Fatalf("Generating, expected no error but got %#v", err)
+		}
+
+		b, _ := os.ReadFile(out)
+		if !strings.Contains(string(b), "as of revision "+revision) || !strings.Contains(string(b), `[]byte("old a")`) {
+			t.Fatalf("Generating a revision, expected its content but got\n%s", b)
+		}
+	})
+
+	t.Run("NOK usage", func(t *testing.T) {
+		for _, args := range [][]string{nil, {"-var", "not valid", gisttest.GistID}, {"-pkg", "", gisttest.GistID}, {"-pkg", "my-pkg", gisttest.GistID}, {"-pkg", "func", gisttest.GistID}} {
+			if err := run(context.Background(), args, &bytes.Buffer{}, getenv); !errors.Is(err, errUsage) {
+				t.Fatalf("Generating with %v, got %#v, want errUsage", args, err)
+			}
+		}
+	})
+}
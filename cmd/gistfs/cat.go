@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"flag"
+)
+
+// cat prints the content of files of a gist, one after the other.
+func (c *cli) cat(ctx context.Context, fset *flag.FlagSet, args []string) error {
+	var gf gistFlags
+	gf.register(fset)
+	if err := c.parse(fset, args, 2); err != nil {
+		return err
+	}
+
+	fsys, err := c.openGist(ctx, fset.Arg(0), gf)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range fset.Args()[1:] {
+		b, err := fsys.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		if _, err := c.stdout.Write(b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
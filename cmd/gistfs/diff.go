@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines around changes in a hunk.
+const diffContext = 3
+
+// diff prints the unified diff between a gist and a local directory, or
+// between two revisions of a gist.
+func (c *cli) diff(ctx context.Context, fset *flag.FlagSet, args []string) error {
+	from := fset.String("from", "", "compare the gist as of revision `sha`, instead of its latest revision")
+	to := fset.String("to", "", "compare to revision `sha` of the gist, instead of its latest revision, when no directory is given")
+	if err := c.parse(fset, args, 1); err != nil {
+		return err
+	}
+	if fset.NArg() > 2 || (fset.NArg() == 2 && *to != "") || (fset.NArg() == 1 && *from == "" && *to == "") {
+		fset.Usage()
+		return errUsage
+	}
+
+	fsys, err := c.openGist(ctx, fset.Arg(0), gistFlags{revision: *from})
+	if err != nil {
+		return err
+	}
+	old, err := readFiles(fsys)
+	if err != nil {
+		return err
+	}
+
+	var new map[string][]byte
+	if dir := fset.Arg(1); dir != "" {
+		new, err = readFiles(os.DirFS(dir))
+		delete(new, syncStateFile)
+	} else {
+		fsys, err = c.openGist(ctx, fset.Arg(0), gistFlags{revision: *to})
+		if err == nil {
+			new, err = readFiles(fsys)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	names := map[string]bool{}
+	for name := range old {
+		names[name] = true
+	}
+	for name := range new {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		oldContent, ok := old[name]
+		oldName := "a/" + name
+		if !ok {
+			oldName = "/dev/null"
+		}
+
+		newContent, ok := new[name]
+		newName := "b/" + name
+		if !ok {
+			newName = "/dev/null"
+		}
+
+		fmt.Fprint(c.stdout, unifiedDiff(oldName, newName, string(oldContent), string(newContent)))
+	}
+
+	return nil
+}
+
+// lineEdit is a line of an edit script, kept, deleted or inserted according
+// to its op: ' ', '-' or '+'.
+type lineEdit struct {
+	op   byte
+	line string
+}
+
+// splitLines splits s into lines, keeping their terminating newline.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// diffLines returns the shortest edit script turning a into b, computed
+// with the Myers algorithm.
+func diffLines(a, b []string) []lineEdit {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+
+	var trace [][]int
+	done := false
+	for d := 0; d <= max && !done; d++ {
+		trace = append(trace, append([]int(nil), v...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+	}
+
+	// Walk the trace back from the end to recover the edits.
+	var edits []lineEdit
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		prevK := k - 1
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, lineEdit{op: ' ', line: a[x-1]})
+			x--
+			y--
+		}
+
+		if x == prevX {
+			edits = append(edits, lineEdit{op: '+', line: b[y-1]})
+			y--
+		} else {
+			edits = append(edits, lineEdit{op: '-', line: a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		edits = append(edits, lineEdit{op: ' ', line: a[x-1]})
+		x--
+		y--
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+
+	return edits
+}
+
+// unifiedDiff returns the unified diff turning from, named fromName, into
+// to, named toName, or an empty string if they are equal.
+func unifiedDiff(fromName, toName, from, to string) string {
+	if from == to {
+		return ""
+	}
+
+	edits := diffLines(splitLines(from), splitLines(to))
+
+	// aPos and bPos hold the number of lines of from and to before each edit.
+	aPos := make([]int, len(edits)+1)
+	bPos := make([]int, len(edits)+1)
+	for i, e := range edits {
+		aPos[i+1], bPos[i+1] = aPos[i], bPos[i]
+		if e.op != '+' {
+			aPos[i+1]++
+		}
+		if e.op != '-' {
+			bPos[i+1]++
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			i++
+			continue
+		}
+
+		// Changes separated by less than twice the context share a hunk.
+		end := i
+		for j := i + 1; j < len(edits); j++ {
+			if edits[j].op == ' ' {
+				continue
+			}
+			if j-end-1 > 2*diffContext {
+				break
+			}
+			end = j
+		}
+
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		stop := end + diffContext + 1
+		if stop > len(edits) {
+			stop = len(edits)
+		}
+
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n",
+			hunkRange(aPos[start], aPos[stop]-aPos[start]),
+			hunkRange(bPos[start], bPos[stop]-bPos[start]))
+		for _, e := range edits[start:stop] {
+			sb.WriteByte(e.op)
+			sb.WriteString(e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		i = stop
+	}
+
+	return sb.String()
+}
+
+// hunkRange formats the range of lines of a hunk, given the number of lines
+// before it and its length.
+func hunkRange(before, length int) string {
+	switch length {
+	case 0:
+		return fmt.Sprintf("%d,0", before)
+	case 1:
+		return fmt.Sprintf("%d", before+1)
+	default:
+		return fmt.Sprintf("%d,%d", before+1, length)
+	}
+}
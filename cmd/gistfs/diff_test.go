@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jhchabran/gistfs/gisttest"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		want     string
+	}{
+		{
+			name: "equal",
+			from: "a\nb\n",
+			to:   "a\nb\n",
+			want: "",
+		},
+		{
+			name: "change",
+			from: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			to:   "1\n2\n3\n4\nfive\n6\n7\n8\n9\n10\n",
+			want: "--- a/f\n+++ b/f\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "separate hunks",
+			from: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			to:   "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n",
+			want: "--- a/f\n+++ b/f\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -9,4 +9,3 @@\n 9\n 10\n 11\n-12\n",
+		},
+		{
+			name: "created",
+			from: "",
+			to:   "a\n",
+			want: "--- a/f\n+++ b/f\n@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			name: "no newline at end",
+			from: "a\nb\n",
+			to:   "a\nb",
+			want: "--- a/f\n+++ b/f\n@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("OK "+test.name, func(t *testing.T) {
+			if got := unifiedDiff("a/f", "b/f", test.from, test.to); got != test.want {
+				t.Fatalf("Diffing, got\n%s\nwant\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	srv := gisttest.NewServer(t)
+	g := srv.AddGist(gisttest.GistID, map[string]string{"a.txt": "a\nb\n", "b.txt": "b\n"})
+	revision := strings.Repeat("1", 40)
+	g.AddRevision(revision, map[string]string{"a.txt": "a\n"})
+
+	run := func(args ...string) (string, error) {
+		c, stdout := newTestCLI(srv)
+		err := c.run(context.Background(), append([]string{"diff"}, args...))
+		return stdout.String(), err
+	}
+
+	t.Run("OK directory", func(t *testing.T) {
+		dir := t.TempDir()
+		for name, content := range map[string]string{"a.txt": "a\nc\n", "c.txt": "c\n", syncStateFile: "{}"} {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		out, err := run(gisttest.GistID, dir)
+		if err != nil {
+			t.Fatalf("Diffing with a directory, expected no error but got %#v", err)
+		}
+
+		want := "--- a/a.txt\n+++ b/a.txt\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n" +
+			"--- a/b.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-b\n" +
+			"--- /dev/null\n+++ b/c.txt\n@@ -0,0 +1 @@\n+c\n"
+		if out != want {
+			t.Fatalf("Diffing with a directory, got\n%s\nwant\n%s", out, want)
+		}
+	})
+
+	t.Run("OK revisions", func(t *testing.T) {
+		out, err := run("-from", revision, gisttest.GistID)
+		if err != nil {
+			t.Fatalf("Diffing revisions, expected no error but got %#v", err)
+		}
+
+		want := "--- a/a.txt\n+++ b/a.txt\n@@ -1 +1,2 @@\n a\n+b\n" +
+			"--- /dev/null\n+++ b/b.txt\n@@ -0,0 +1 @@\n+b\n"
+		if out != want {
+			t.Fatalf("Diffing revisions, got\n%s\nwant\n%s", out, want)
+		}
+	})
+
+	t.Run("NOK usage", func(t *testing.T) {
+		for _, args := range [][]string{{gisttest.GistID}, {"-to", revision, gisttest.GistID, "dir"}} {
+			if _, err := run(args...); !errors.Is(err, errUsage) {
+				t.Fatalf("Diffing with %v, got %#v, want errUsage", args, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/fs"
+	"text/tabwriter"
+	"time"
+)
+
+// ls lists the files of a gist, or of one of its directories.
+func (c *cli) ls(ctx context.Context, fset *flag.FlagSet, args []string) error {
+	var gf gistFlags
+	long := fset.Bool("l", false, "print the mode, size and modification time of files")
+	gf.register(fset)
+	if err := c.parse(fset, args, 1); err != nil {
+		return err
+	}
+
+	fsys, err := c.openGist(ctx, fset.Arg(0), gf)
+	if err != nil {
+		return err
+	}
+
+	dir := "."
+	if fset.NArg() > 1 {
+		dir = fset.Arg(1)
+	}
+
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+
+	if !*long {
+		for _, entry := range entries {
+			fmt.Fprintln(c.stdout, entry.Name())
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 8, 1, ' ', tabwriter.AlignRight)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(tw, "%s\t%d\t%s\t %s\t\n", info.Mode(), info.Size(), info.ModTime().Format(time.RFC3339), info.Name())
+	}
+
+	return tw.Flush()
+}
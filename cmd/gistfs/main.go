@@ -0,0 +1,200 @@
+// Command gistfs reads gists from the command line.
+//
+// Usage:
+//
+//	gistfs <command> [flags] <gist> [arguments]
+//
+// where gist is a gist ID or URL, as understood by gistfs.ParseURL. The
+// commands are:
+//
+//	ls     list the files of a gist
+//	cat    print the content of files of a gist
+//	stat   print the metadata of files of a gist
+//	mount  mount a gist as a local filesystem
+//	push   upload the files of a local directory to a gist
+//	pull   download the files of a gist to a local directory
+//	sync   sync the files of a local directory with a gist both ways
+//	watch  download the files of a gist as it changes
+//	serve  serve gists over HTTP
+//	new    create a gist from a local directory or stdin
+//	diff   compare a gist to a local directory or two of its revisions
+//
+// Secret gists are read with the personal access token held by the
+// GITHUB_TOKEN environment variable, if set. GITHUB_API_URL points the
+// command to another Github API, such as the one of a Github Enterprise
+// server.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/google/go-github/v33/github"
+	"github.com/jhchabran/gistfs"
+	"golang.org/x/oauth2"
+)
+
+// errUsage is returned when a command is invoked with invalid arguments,
+// once its usage has been printed.
+var errUsage = errors.New("invalid usage")
+
+// command is a subcommand of gistfs.
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(c *cli, ctx context.Context, fset *flag.FlagSet, args []string) error
+}
+
+var commands = []command{
+	{name: "ls", args: "[-l] [-revision sha] <gist> [dir]", summary: "list the files of a gist", run: (*cli).ls},
+	{name: "cat", args: "[-revision sha] <gist> <file>...", summary: "print the content of files of a gist", run: (*cli).cat},
+	{name: "stat", args: "[-revision sha] <gist> <file>...", summary: "print the metadata of files of a gist", run: (*cli).stat},
+	{name: "mount", args: "[-refresh interval] [-revision sha] [-webdav addr] <gist> [dir]", summary: "mount a gist as a local filesystem", run: (*cli).mount},
+	{name: "push", args: "[-dry-run] [-force] <gist> <dir>", summary: "upload the files of a local directory to a gist", run: (*cli).push},
+	{name: "pull", args: "[-dry-run] [-force] <gist> <dir>", summary: "download the files of a gist to a local directory", run: (*cli).pull},
+	{name: "sync", args: "[-dry-run] <gist> <dir>", summary: "sync the files of a local directory with a gist both ways", run: (*cli).syncBoth},
+	{name: "watch", args: "[-interval interval] [-webhook addr] <gist> <dir>", summary: "download the files of a gist as it changes", run: (*cli).watch},
+	{name: "serve", args: "[-addr addr] [-listing] [-markdown] [-archive route] [-refresh interval] [-tls-cert file -tls-key file] [-revision sha] <gist>...", summary: "serve gists over HTTP", run: (*cli).serve},
+	{name: "new", args: "[-description text] [-public] [-name name] [dir|-]", summary: "create a gist from a local directory or stdin", run: (*cli).newGist},
+	{name: "diff", args: "[-from sha] <gist> <dir> | -from sha [-to sha] <gist>", summary: "compare a gist to a local directory or two of its revisions", run: (*cli).diff},
+}
+
+// cli runs commands, reading their input from stdin and writing their output
+// to stdout and errors to stderr.
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+}
+
+func main() {
+	c := &cli{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr, getenv: os.Getenv}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := c.run(ctx, os.Args[1:])
+	switch {
+	case errors.Is(err, errUsage):
+		stop()
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "gistfs:", err)
+		stop()
+		os.Exit(1)
+	}
+}
+
+// run runs the command named by the first argument.
+func (c *cli) run(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		for _, cmd := range commands {
+			if cmd.name == args[0] {
+				return cmd.run(c, ctx, c.flagSet(cmd), args[1:])
+			}
+		}
+	}
+
+	fmt.Fprintln(c.stderr, "usage: gistfs <command> [flags] <gist> [arguments]")
+	fmt.Fprintln(c.stderr, "\ncommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(c.stderr, "  %-6s %s\n", cmd.name, cmd.summary)
+	}
+
+	return errUsage
+}
+
+// flagSet returns the flag set of cmd, printing its usage to stderr.
+func (c *cli) flagSet(cmd command) *flag.FlagSet {
+	fset := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fset.SetOutput(c.stderr)
+	fset.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: gistfs %s %s\n", cmd.name, cmd.args)
+		fset.PrintDefaults()
+	}
+
+	return fset
+}
+
+// parse parses the arguments of a command, which needs at least min
+// positional arguments.
+func (c *cli) parse(fset *flag.FlagSet, args []string, min int) error {
+	if err := fset.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if fset.NArg() < min {
+		fset.Usage()
+		return errUsage
+	}
+
+	return nil
+}
+
+// gistFlags are the flags selecting the gist read by a command.
+type gistFlags struct {
+	revision string
+}
+
+func (g *gistFlags) register(fset *flag.FlagSet) {
+	fset.StringVar(&g.revision, "revision", "", "read the gist as of revision `sha`, instead of the one the gist URL points to, if any")
+}
+
+// openGist returns the loaded filesystem of the gist that ref, an ID or an
+// URL, points to, created with opts.
+func (c *cli) openGist(ctx context.Context, ref string, flags gistFlags, opts ...gistfs.Option) (*gistfs.FS, error) {
+	id, revision, err := gistfs.ParseURL(ref)
+	if err != nil {
+		return nil, err
+	}
+	if flags.revision != "" {
+		revision = flags.revision
+	}
+
+	client, err := c.githubClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if revision != "" {
+		opts = append(opts, gistfs.WithRevision(revision))
+	}
+
+	fsys := gistfs.NewWithClient(client, id, opts...)
+	if err := fsys.Load(ctx); err != nil {
+		return nil, err
+	}
+
+	return fsys, nil
+}
+
+// githubClient returns a Github client authenticated with GITHUB_TOKEN and
+// making its requests to GITHUB_API_URL, if set.
+func (c *cli) githubClient(ctx context.Context) (*github.Client, error) {
+	var httpClient *http.Client
+	if token := c.getenv("GITHUB_TOKEN"); token != "" {
+		httpClient = oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))
+	}
+
+	client := github.NewClient(httpClient)
+	if api := c.getenv("GITHUB_API_URL"); api != "" {
+		u, err := url.Parse(strings.TrimSuffix(api, "/") + "/")
+		if err != nil {
+			return nil, fmt.Errorf("invalid GITHUB_API_URL: %w", err)
+		}
+		client.BaseURL = u
+	}
+
+	return client, nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/jhchabran/gistfs/gisttest"
+)
+
+// newTestCLI returns a cli reading gists from srv, and its output.
+func newTestCLI(srv *gisttest.Server) (*cli, *bytes.Buffer) {
+	var stdout bytes.Buffer
+	env := map[string]string{"GITHUB_API_URL": srv.URL}
+
+	return &cli{
+		stdin:  strings.NewReader(""),
+		stdout: &stdout,
+		stderr: &bytes.Buffer{},
+		getenv: func(key string) string { return env[key] },
+	}, &stdout
+}
+
+func TestCommands(t *testing.T) {
+	srv := gisttest.NewServer(t)
+	g := srv.AddGist(gisttest.GistID, map[string]string{
+		"a.txt": "a\n",
+		"b.go":  "package b\n",
+	})
+	revision := strings.Repeat("1", 40)
+	g.AddRevision(revision, map[string]string{"a.txt": "old a\n"})
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "ls",
+			args: []string{"ls", gisttest.GistID},
+			want: []string{"a.txt\nb.go\n"},
+		},
+		{
+			name: "ls long",
+			args: []string{"ls", "-l", "https://gist.github.com/jhchabran/" + gisttest.GistID},
+			want: []string{"-r--r--r--", " 2 ", " a.txt\n", " 10 ", " b.go\n"},
+		},
+		{
+			name: "cat",
+			args: []string{"cat", gisttest.GistID, "b.go", "a.txt"},
+			want: []string{"package b\na\n"},
+		},
+		{
+			name: "cat revision flag",
+			args: []string{"cat", "-revision", revision, gisttest.GistID, "a.txt"},
+			want: []string{"old a\n"},
+		},
+		{
+			name: "cat revision URL",
+			args: []string{"cat", "https://gist.github.com/jhchabran/" + gisttest.GistID + "/" + revision, "a.txt"},
+			want: []string{"old a\n"},
+		},
+		{
+			name: "stat",
+			args: []string{"stat", gisttest.GistID, "b.go"},
+			want: []string{"Name:     b.go\n", "Size:     10\n", "Mode:     -r--r--r--\n", "Raw URL:  "},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("OK "+test.name, func(t *testing.T) {
+			c, stdout := newTestCLI(srv)
+			if err := c.run(context.Background(), test.args); err != nil {
+				t.Fatalf("Running %v, expected no error but got %#v", test.args, err)
+			}
+
+			for _, want := range test.want {
+				if !strings.Contains(stdout.String(), want) {
+					t.Fatalf("Running %v, expected output to contain %#v but got %#v", test.args, want, stdout.String())
+				}
+			}
+		})
+	}
+
+	t.Run("OK token", func(t *testing.T) {
+		c, _ := newTestCLI(srv)
+		getenv := c.getenv
+		c.getenv = func(key string) string {
+			if key == "GITHUB_TOKEN" {
+				return "secret"
+			}
+			return getenv(key)
+		}
+
+		if err := c.run(context.Background(), []string{"ls", gisttest.GistID}); err != nil {
+			t.Fatalf("Listing with a token, expected no error but got %#v", err)
+		}
+		if got, want := srv.LastHeader().Get("Authorization"), "Bearer secret"; got != want {
+			t.Fatalf("Listing with a token, got Authorization %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("NOK usage", func(t *testing.T) {
+		for _, args := range [][]string{nil, {"nope"}, {"cat", gisttest.GistID}, {"ls", "-nope", gisttest.GistID}} {
+			c, _ := newTestCLI(srv)
+			if err := c.run(context.Background(), args); !errors.Is(err, errUsage) {
+				t.Fatalf("Running %v, got %#v, want errUsage", args, err)
+			}
+		}
+	})
+
+	t.Run("NOK missing file", func(t *testing.T) {
+		c, _ := newTestCLI(srv)
+		if err := c.run(context.Background(), []string{"cat", gisttest.GistID, "c.txt"}); !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("Reading a missing file, got %#v, want fs.ErrNotExist", err)
+		}
+	})
+
+	t.Run("NOK invalid gist", func(t *testing.T) {
+		c, _ := newTestCLI(srv)
+		if err := c.run(context.Background(), []string{"ls", "https://example.com/"}); err == nil {
+			t.Fatalf("Listing an invalid gist URL, expected an error but got none")
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/jhchabran/gistfs"
+	"github.com/jhchabran/gistfs/webdav"
+)
+
+// mount mounts a gist as a local filesystem with FUSE, or serves it over
+// WebDAV, until ctx is done.
+func (c *cli) mount(ctx context.Context, fset *flag.FlagSet, args []string) error {
+	var gf gistFlags
+	refresh := fset.Duration("refresh", 0, "refresh the gist when it is accessed once `interval` has elapsed since it was loaded")
+	addr := fset.String("webdav", "", "serve the gist over WebDAV on `addr` instead of mounting it with FUSE")
+	gf.register(fset)
+	if err := c.parse(fset, args, 1); err != nil {
+		return err
+	}
+	if *addr == "" && fset.NArg() < 2 {
+		fset.Usage()
+		return errUsage
+	}
+
+	var opts []gistfs.Option
+	if *refresh > 0 {
+		opts = append(opts, gistfs.WithTTL(*refresh))
+	}
+
+	fsys, err := c.openGist(ctx, fset.Arg(0), gf, opts...)
+	if err != nil {
+		return err
+	}
+
+	if *addr != "" {
+		return c.serveWebDAV(ctx, fsys, *addr)
+	}
+
+	return c.mountFUSE(ctx, fsys, fset.Arg(1))
+}
+
+// serveWebDAV serves fsys over WebDAV on addr until ctx is done.
+func (c *cli) serveWebDAV(ctx context.Context, fsys *gistfs.FS, addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{Handler: webdav.Handler(fsys)}
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(c.stdout, "serving gist %s over WebDAV at http://%s/\n", fsys.GetID(), l.Addr())
+
+	if err := srv.Serve(l); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jhchabran/gistfs"
+	"github.com/jhchabran/gistfs/fuse"
+)
+
+// mountFUSE mounts fsys at dir until ctx is done or it is unmounted.
+func (c *cli) mountFUSE(ctx context.Context, fsys *gistfs.FS, dir string) error {
+	srv, err := fuse.Mount(dir, fsys)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "mounted gist %s at %s\n", fsys.GetID(), dir)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = srv.Unmount()
+		case <-done:
+		}
+	}()
+
+	srv.Wait()
+
+	return nil
+}
@@ -0,0 +1,16 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jhchabran/gistfs"
+)
+
+// mountFUSE fails, as FUSE is only supported on Linux and macOS.
+func (c *cli) mountFUSE(ctx context.Context, fsys *gistfs.FS, dir string) error {
+	return errors.New("mounting with FUSE is not supported on this platform, use -webdav instead")
+}
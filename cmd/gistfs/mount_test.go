@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jhchabran/gistfs/gisttest"
+)
+
+func TestMount(t *testing.T) {
+	srv := gisttest.NewServer(t)
+	srv.AddGist(gisttest.GistID, map[string]string{"a.txt": "a"})
+
+	t.Run("OK webdav", func(t *testing.T) {
+		c, _ := newTestCLI(srv)
+		r, w := io.Pipe()
+		c.stdout = w
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		errc := make(chan error, 1)
+		go func() {
+			errc <- c.run(ctx, []string{"mount", "-refresh", "1m", "-webdav", "127.0.0.1:0", gisttest.GistID})
+			w.Close()
+		}()
+
+		line, err := bufio.NewReader(r).ReadString('\n')
+		if err != nil {
+			t.Fatalf("Mounting over WebDAV, expected the server address to be printed but got %#v", err)
+		}
+		url := line[strings.Index(line, "http://") : len(line)-1]
+
+		resp, err := http.Get(url + "a.txt")
+		if err != nil {
+			t.Fatalf("Reading a file over WebDAV, expected no error but got %#v", err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if got, want := string(b), "a"; got != want {
+			t.Fatalf("Reading a file over WebDAV, got %#v, want %#v", got, want)
+		}
+
+		cancel()
+		if err := <-errc; err != nil {
+			t.Fatalf("Stopping the WebDAV server, expected no error but got %#v", err)
+		}
+	})
+
+	t.Run("NOK usage", func(t *testing.T) {
+		c, _ := newTestCLI(srv)
+		if err := c.run(context.Background(), []string{"mount", gisttest.GistID}); !errors.Is(err, errUsage) {
+			t.Fatalf("Mounting without a directory, got %#v, want errUsage", err)
+		}
+	})
+}
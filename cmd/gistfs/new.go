@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"testing/fstest"
+
+	"github.com/jhchabran/gistfs"
+)
+
+// defaultStdinName is the name of the file created from stdin, which is
+// the one Github gives to unnamed files.
+const defaultStdinName = "gistfile1.txt"
+
+// newGist creates a gist from the files of a local directory, or from stdin,
+// and prints its URL.
+func (c *cli) newGist(ctx context.Context, fset *flag.FlagSet, args []string) error {
+	description := fset.String("description", "", "describe the gist with `text`")
+	public := fset.Bool("public", false, "create a public gist instead of a secret one")
+	name := fset.String("name", defaultStdinName, "name the file read from stdin `name`")
+	if err := c.parse(fset, args, 0); err != nil {
+		return err
+	}
+	if fset.NArg() > 1 {
+		fset.Usage()
+		return errUsage
+	}
+
+	var src fs.FS
+	if dir := fset.Arg(0); dir != "" && dir != "-" {
+		src = os.DirFS(dir)
+	} else {
+		b, err := io.ReadAll(c.stdin)
+		if err != nil {
+			return err
+		}
+		src = fstest.MapFS{*name: &fstest.MapFile{Data: b, Mode: 0644}}
+	}
+
+	client, err := c.githubClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	fsys, report, err := gistfs.CreateFromFS(ctx, client, src, *description, *public)
+	if report != nil {
+		for _, skipped := range report.Skipped {
+			fmt.Fprintf(c.stderr, "skipped %s: %s\n", skipped.Name, skipped.Reason)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(c.stdout, fsys.HTMLURL())
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jhchabran/gistfs"
+	"github.com/jhchabran/gistfs/gisttest"
+)
+
+func TestNew(t *testing.T) {
+	srv := gisttest.NewServer(t)
+
+	// created returns the files of the gist a command printed the URL of.
+	created := func(t *testing.T, out string) map[string]string {
+		t.Helper()
+		url := strings.TrimSpace(out)
+		if !strings.HasPrefix(url, "https://gist.github.com/") {
+			t.Fatalf("Creating a gist, got output %#v, want its URL", out)
+		}
+
+		fsys := gistfs.NewWithClient(srv.GithubClient(), url[strings.LastIndex(url, "/")+1:])
+		if err := fsys.Load(context.Background()); err != nil {
+			t.Fatalf("Loading the created gist, expected no error but got %#v", err)
+		}
+
+		files := map[string]string{}
+		entries, _ := fsys.ReadDir(".")
+		for _, entry := range entries {
+			b, _ := fsys.ReadFile(entry.Name())
+			files[entry.Name()] = string(b)
+		}
+		return files
+	}
+
+	t.Run("OK directory", func(t *testing.T) {
+		dir := t.TempDir()
+		for name, content := range map[string]string{"a.txt": "a", "empty.txt": ""} {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		c, stdout := newTestCLI(srv)
+		if err := c.run(context.Background(), []string{"new", "-description", "notes", dir}); err != nil {
+			t.Fatalf("Creating a gist, expected no error but got %#v", err)
+		}
+
+		files := created(t, stdout.String())
+		if len(files) != 1 || files["a.txt"] != "a" {
+			t.Fatalf("Creating a gist, got files %v, want only a.txt", files)
+		}
+		if got := c.stderr.(*bytes.Buffer).String(); !strings.Contains(got, "skipped empty.txt") {
+			t.Fatalf("Creating a gist, expected the empty file to be reported as skipped but got %#v", got)
+		}
+	})
+
+	t.Run("OK stdin", func(t *testing.T) {
+		c, stdout := newTestCLI(srv)
+		c.stdin = strings.NewReader("hello")
+		if err := c.run(context.Background(), []string{"new", "-name", "hello.txt"}); err != nil {
+			t.Fatalf("Creating a gist, expected no error but got %#v", err)
+		}
+
+		if files := created(t, stdout.String()); files["hello.txt"] != "hello" {
+			t.Fatalf("Creating a gist from stdin, got files %v, want hello.txt", files)
+		}
+	})
+
+	t.Run("NOK nothing to store", func(t *testing.T) {
+		c, _ := newTestCLI(srv)
+		c.stdin = strings.NewReader("")
+		if err := c.run(context.Background(), []string{"new"}); err == nil {
+			t.Fatalf("Creating a gist from an empty stdin, expected an error but got none")
+		}
+	})
+}
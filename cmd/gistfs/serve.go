@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/jhchabran/gistfs"
+)
+
+// indexTemplate renders the page listing the gists served by serve.
+var indexTemplate = template.Must(template.New("index").Parse(`<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Gists</title>
+</head>
+<body>
+<ul>
+{{range .}}<li><a href="{{.GetID}}/">{{or .Description .GetID}}</a></li>
+{{end}}</ul>
+</body>
+</html>
+`))
+
+// serve serves gists over HTTP until ctx is done. A single gist is served
+// at the root, several ones under their ID.
+func (c *cli) serve(ctx context.Context, fset *flag.FlagSet, args []string) error {
+	var gf gistFlags
+	addr := fset.String("addr", ":8080", "listen on `addr`")
+	listing := fset.Bool("listing", false, "list the files of gists without an index.html")
+	markdown := fset.Bool("markdown", false, "render Markdown files to HTML")
+	archive := fset.String("archive", "", "serve a zip archive of each gist at `route`, such as /archive.zip")
+	refresh := fset.Duration("refresh", time.Minute, "refresh gists when they are accessed once `interval` has elapsed since they were loaded, 0 to never refresh them")
+	certFile := fset.String("tls-cert", "", "serve HTTPS with the certificate of `file`, along with -tls-key")
+	keyFile := fset.String("tls-key", "", "serve HTTPS with the private key of `file`, along with -tls-cert")
+	gf.register(fset)
+	if err := c.parse(fset, args, 1); err != nil {
+		return err
+	}
+	if (*certFile == "") != (*keyFile == "") {
+		fmt.Fprintln(c.stderr, "-tls-cert and -tls-key must be set together")
+		fset.Usage()
+		return errUsage
+	}
+
+	var opts []gistfs.Option
+	if *refresh > 0 {
+		opts = append(opts, gistfs.WithTTL(*refresh))
+	}
+
+	var hopts []gistfs.HandlerOption
+	if *listing {
+		hopts = append(hopts, gistfs.WithListing())
+	}
+	if *markdown {
+		hopts = append(hopts, gistfs.WithMarkdown(nil))
+	}
+	if *archive != "" {
+		hopts = append(hopts, gistfs.WithArchive(*archive))
+	}
+
+	fss := make([]*gistfs.FS, 0, fset.NArg())
+	for _, ref := range fset.Args() {
+		fsys, err := c.openGist(ctx, ref, gf, opts...)
+		if err != nil {
+			return err
+		}
+		fss = append(fss, fsys)
+	}
+
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+
+	scheme := "http"
+	if *certFile != "" {
+		scheme = "https"
+	}
+
+	var handler http.Handler
+	if len(fss) == 1 {
+		handler = gistfs.Handler(fss[0], hopts...)
+		fmt.Fprintf(c.stdout, "serving gist %s at %s://%s/\n", fss[0].GetID(), scheme, l.Addr())
+	} else {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_ = indexTemplate.Execute(w, fss)
+		})
+		for _, fsys := range fss {
+			prefix := "/" + fsys.GetID()
+			mux.Handle(prefix+"/", http.StripPrefix(prefix, gistfs.Handler(fsys, hopts...)))
+			fmt.Fprintf(c.stdout, "serving gist %s at %s://%s%s/\n", fsys.GetID(), scheme, l.Addr(), prefix)
+		}
+		handler = mux
+	}
+
+	srv := &http.Server{Handler: handler}
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	if *certFile != "" {
+		err = srv.ServeTLS(l, *certFile, *keyFile)
+	} else {
+		err = srv.Serve(l)
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
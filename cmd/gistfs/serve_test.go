@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jhchabran/gistfs/gisttest"
+)
+
+func TestServe(t *testing.T) {
+	srv := gisttest.NewServer(t)
+	srv.AddGist("abc", map[string]string{"a.md": "# A"})
+	srv.AddGist("def", map[string]string{"index.html": "<p>def</p>"})
+
+	get := func(t *testing.T, url string) string {
+		t.Helper()
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatalf("Getting %s, expected no error but got %#v", url, err)
+		}
+		defer resp.Body.Close()
+
+		b, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Getting %s, got status %d, want %d", url, resp.StatusCode, http.StatusOK)
+		}
+		return string(b)
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		c, _ := newTestCLI(srv)
+		r, w := io.Pipe()
+		c.stdout = w
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		errc := make(chan error, 1)
+		go func() {
+			errc <- c.run(ctx, []string{"serve", "-addr", "127.0.0.1:0", "-markdown", "abc", "def"})
+			w.Close()
+		}()
+
+		urls := map[string]string{}
+		br := bufio.NewReader(r)
+		for i := 0; i < 2; i++ {
+			line, err := br.ReadString('\n')
+			if err != nil {
+				t.Fatalf("Serving, expected the gist URLs to be printed but got %#v", err)
+			}
+			fields := strings.Fields(line)
+			urls[fields[2]] = fields[4]
+		}
+
+		if body := get(t, urls["abc"]+"a.md"); !strings.Contains(body, "<h1>A</h1>") {
+			t.Fatalf("Getting a Markdown file, expected it rendered but got %s", body)
+		}
+		if got, want := get(t, urls["def"]), "<p>def</p>"; got != want {
+			t.Fatalf("Getting the index of a gist, got %#v, want %#v", got, want)
+		}
+		if body := get(t, strings.TrimSuffix(urls["abc"], "abc/")); !strings.Contains(body, `<a href="abc/">`) {
+			t.Fatalf("Getting the index of gists, expected a link to abc but got %s", body)
+		}
+
+		cancel()
+		if err := <-errc; err != nil {
+			t.Fatalf("Stopping the server, expected no error but got %#v", err)
+		}
+	})
+
+	t.Run("NOK tls", func(t *testing.T) {
+		c, _ := newTestCLI(srv)
+		if err := c.run(context.Background(), []string{"serve", "-tls-cert", "cert.pem", "abc"}); !errors.Is(err, errUsage) {
+			t.Fatalf("Serving with a certificate but no key, got %#v, want errUsage", err)
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/jhchabran/gistfs"
+)
+
+// stat prints the metadata of files of a gist.
+func (c *cli) stat(ctx context.Context, fset *flag.FlagSet, args []string) error {
+	var gf gistFlags
+	gf.register(fset)
+	if err := c.parse(fset, args, 2); err != nil {
+		return err
+	}
+
+	fsys, err := c.openGist(ctx, fset.Arg(0), gf)
+	if err != nil {
+		return err
+	}
+
+	for i, name := range fset.Args()[1:] {
+		info, err := fs.Stat(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		if i > 0 {
+			fmt.Fprintln(c.stdout)
+		}
+		fmt.Fprintf(c.stdout, "Name:     %s\n", info.Name())
+		fmt.Fprintf(c.stdout, "Size:     %d\n", info.Size())
+		fmt.Fprintf(c.stdout, "Mode:     %s\n", info.Mode())
+		fmt.Fprintf(c.stdout, "Modified: %s\n", info.ModTime().Format(time.RFC3339))
+
+		if meta, ok := info.Sys().(*gistfs.FileMetadata); ok {
+			fmt.Fprintf(c.stdout, "Language: %s\n", meta.Language)
+			fmt.Fprintf(c.stdout, "Raw URL:  %s\n", meta.RawURL)
+			if meta.Truncated {
+				fmt.Fprintln(c.stdout, "Truncated: true")
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing/fstest"
+
+	"github.com/jhchabran/gistfs"
+)
+
+// syncStateFile is the file of a synced directory recording its last sync.
+const syncStateFile = ".gistfs-sync.json"
+
+// syncState records the files of a directory as of its last sync with a
+// gist, to tell which side changed them since.
+type syncState struct {
+	Gist string `json:"gist"`
+	// Files holds the SHA-256 of the content of files, by name.
+	Files map[string]string `json:"files"`
+}
+
+// syncMode is the direction in which files are synced.
+type syncMode int
+
+const (
+	syncPush syncMode = iota
+	syncPull
+	syncBoth
+)
+
+// syncOp is the operation applied to a file.
+type syncOp string
+
+const (
+	opPush     syncOp = "push"
+	opPull     syncOp = "pull"
+	opConflict syncOp = "conflict"
+)
+
+// syncAction is the operation to apply to a file.
+type syncAction struct {
+	name string
+	op   syncOp
+}
+
+// planSync returns the actions syncing files in the given mode, given the
+// hashes of their local and remote content and of their content at the last
+// sync. A file changed on the side it would be copied to since the last sync
+// is a conflict, unless force is set. Files missing on one side are copied
+// from the other side rather than deleted.
+func planSync(mode syncMode, local, remote, base map[string]string, force bool) []syncAction {
+	names := map[string]bool{}
+	for name := range local {
+		names[name] = true
+	}
+	for name := range remote {
+		names[name] = true
+	}
+
+	var actions []syncAction
+	for name := range names {
+		l, lok := local[name]
+		r, rok := remote[name]
+		b, bok := base[name]
+		if l == r {
+			continue
+		}
+
+		var op syncOp
+		switch mode {
+		case syncPush:
+			if !lok {
+				continue
+			}
+			op = opPush
+			if rok && (!bok || r != b) && !force {
+				op = opConflict
+			}
+		case syncPull:
+			if !rok {
+				continue
+			}
+			op = opPull
+			if lok && (!bok || l != b) && !force {
+				op = opConflict
+			}
+		case syncBoth:
+			switch {
+			case !rok || (bok && r == b):
+				op = opPush
+			case !lok || (bok && l == b):
+				op = opPull
+			default:
+				op = opConflict
+			}
+		}
+
+		actions = append(actions, syncAction{name: name, op: op})
+	}
+
+	sort.Slice(actions, func(i, j int) bool { return actions[i].name < actions[j].name })
+
+	return actions
+}
+
+func (c *cli) push(ctx context.Context, fset *flag.FlagSet, args []string) error {
+	return c.sync(ctx, fset, args, syncPush)
+}
+
+func (c *cli) pull(ctx context.Context, fset *flag.FlagSet, args []string) error {
+	return c.sync(ctx, fset, args, syncPull)
+}
+
+func (c *cli) syncBoth(ctx context.Context, fset *flag.FlagSet, args []string) error {
+	return c.sync(ctx, fset, args, syncBoth)
+}
+
+// sync syncs the files at the root of a local directory with a gist.
+func (c *cli) sync(ctx context.Context, fset *flag.FlagSet, args []string, mode syncMode) error {
+	dryRun := fset.Bool("dry-run", false, "print the changes without applying them")
+	force := new(bool)
+	if mode != syncBoth {
+		force = fset.Bool("force", false, "overwrite files changed on both sides")
+	}
+	if err := c.parse(fset, args, 2); err != nil {
+		return err
+	}
+	dir := fset.Arg(1)
+
+	fsys, err := c.openGist(ctx, fset.Arg(0), gistFlags{}, gistfs.WithWritable())
+	if err != nil {
+		return err
+	}
+
+	state, err := readSyncState(dir, fsys.GetID())
+	if err != nil {
+		return err
+	}
+
+	local, err := readFiles(os.DirFS(dir))
+	if err != nil {
+		return err
+	}
+	delete(local, syncStateFile)
+
+	remote, err := readFiles(fsys)
+	if err != nil {
+		return err
+	}
+
+	actions := planSync(mode, hashFiles(local), hashFiles(remote), state.Files, *force)
+
+	pushed := fstest.MapFS{}
+	conflicts := 0
+	for _, a := range actions {
+		switch a.op {
+		case opPush:
+			pushed[a.name] = &fstest.MapFile{Data: local[a.name], Mode: 0644}
+			fmt.Fprintf(c.stdout, "push     %s\n", a.name)
+		case opPull:
+			fmt.Fprintf(c.stdout, "pull     %s\n", a.name)
+		case opConflict:
+			conflicts++
+			fmt.Fprintf(c.stdout, "conflict %s: changed both locally and in the gist\n", a.name)
+		}
+	}
+
+	if *dryRun {
+		return nil
+	}
+
+	for _, a := range actions {
+		if a.op != opPull {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(dir, a.name), remote[a.name], 0644); err != nil {
+			return err
+		}
+		local[a.name] = remote[a.name]
+	}
+
+	if len(pushed) > 0 {
+		report, err := fsys.UpdateFromFS(ctx, pushed)
+		if err != nil {
+			return err
+		}
+		for _, skipped := range report.Skipped {
+			fmt.Fprintf(c.stderr, "skipped %s: %s\n", skipped.Name, skipped.Reason)
+			delete(pushed, skipped.Name)
+		}
+		for name, f := range pushed {
+			remote[name] = f.Data
+		}
+	}
+
+	// Files identical on both sides are now in sync, other ones keep their
+	// last synced content, if any.
+	localHashes, remoteHashes := hashFiles(local), hashFiles(remote)
+	for name, h := range localHashes {
+		if remoteHashes[name] == h {
+			state.Files[name] = h
+		}
+	}
+	if err := writeSyncState(dir, state); err != nil {
+		return err
+	}
+
+	if conflicts > 0 {
+		return fmt.Errorf("%d conflicting files left untouched", conflicts)
+	}
+
+	return nil
+}
+
+// readFiles returns the content of the regular files at the root of fsys.
+func readFiles(fsys fs.FS) (map[string][]byte, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		b, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		files[entry.Name()] = b
+	}
+
+	return files, nil
+}
+
+// hashFiles returns the SHA-256 of the content of files.
+func hashFiles(files map[string][]byte) map[string]string {
+	hashes := make(map[string]string, len(files))
+	for name, b := range files {
+		sum := sha256.Sum256(b)
+		hashes[name] = hex.EncodeToString(sum[:])
+	}
+
+	return hashes
+}
+
+// readSyncState reads the sync state of dir, which must be synced with the
+// gist id if it was synced before.
+func readSyncState(dir, id string) (*syncState, error) {
+	state := &syncState{Gist: id, Files: map[string]string{}}
+
+	b, err := os.ReadFile(filepath.Join(dir, syncStateFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, state); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", syncStateFile, err)
+	}
+	if state.Gist != id {
+		return nil, fmt.Errorf("%s is synced with gist %s", dir, state.Gist)
+	}
+	if state.Files == nil {
+		state.Files = map[string]string{}
+	}
+
+	return state, nil
+}
+
+// writeSyncState records the sync state of dir.
+func writeSyncState(dir string, state *syncState) error {
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, syncStateFile), append(b, '\n'), 0644)
+}
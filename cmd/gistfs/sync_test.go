@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jhchabran/gistfs/gisttest"
+)
+
+func TestPlanSync(t *testing.T) {
+	// a: unchanged, b: changed locally, c: changed remotely, d: changed on
+	// both sides, e: local only, f: remote only.
+	local := map[string]string{"a": "1", "b": "2", "c": "1", "d": "2", "e": "1"}
+	remote := map[string]string{"a": "1", "b": "1", "c": "2", "d": "3", "f": "1"}
+	base := map[string]string{"a": "1", "b": "1", "c": "1", "d": "1"}
+
+	tests := []struct {
+		name  string
+		mode  syncMode
+		force bool
+		want  []syncAction
+	}{
+		{
+			name: "push",
+			mode: syncPush,
+			want: []syncAction{{"b", opPush}, {"c", opConflict}, {"d", opConflict}, {"e", opPush}},
+		},
+		{
+			name:  "push force",
+			mode:  syncPush,
+			force: true,
+			want:  []syncAction{{"b", opPush}, {"c", opPush}, {"d", opPush}, {"e", opPush}},
+		},
+		{
+			name: "pull",
+			mode: syncPull,
+			want: []syncAction{{"b", opConflict}, {"c", opPull}, {"d", opConflict}, {"f", opPull}},
+		},
+		{
+			name: "sync",
+			mode: syncBoth,
+			want: []syncAction{{"b", opPush}, {"c", opPull}, {"d", opConflict}, {"e", opPush}, {"f", opPull}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("OK "+test.name, func(t *testing.T) {
+			got := planSync(test.mode, local, remote, base, test.force)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("Planning, got %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	t.Run("OK no base", func(t *testing.T) {
+		got := planSync(syncPull, map[string]string{"a": "1"}, map[string]string{"a": "2"}, nil, false)
+		if want := []syncAction{{"a", opConflict}}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("Pulling over a file never synced, got %v, want %v", got, want)
+		}
+	})
+}
+
+func TestSync(t *testing.T) {
+	srv := gisttest.NewServer(t)
+	g := srv.AddGist(gisttest.GistID, map[string]string{"a.txt": "a", "b.txt": "b"})
+	dir := t.TempDir()
+
+	run := func(args ...string) (string, error) {
+		c, stdout := newTestCLI(srv)
+		err := c.run(context.Background(), args)
+		return stdout.String(), err
+	}
+	readFile := func(name string) string {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Reading %s, expected no error but got %#v", name, err)
+		}
+		return string(b)
+	}
+
+	t.Run("OK pull", func(t *testing.T) {
+		out, err := run("pull", gisttest.GistID, dir)
+		if err != nil {
+			t.Fatalf("Pulling, expected no error but got %#v", err)
+		}
+		if got, want := out, "pull     a.txt\npull     b.txt\n"; got != want {
+			t.Fatalf("Pulling, got output %#v, want %#v", got, want)
+		}
+		if got, want := readFile("a.txt"), "a"; got != want {
+			t.Fatalf("Pulling, got a.txt %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK dry run", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(dir, "c.txt"), []byte("c"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := run("push", "-dry-run", gisttest.GistID, dir)
+		if err != nil {
+			t.Fatalf("Pushing, expected no error but got %#v", err)
+		}
+		if got, want := out, "push     c.txt\n"; got != want {
+			t.Fatalf("Pushing, got output %#v, want %#v", got, want)
+		}
+		if got := srv.Requests(); got > 2 {
+			t.Fatalf("Pushing with -dry-run, got %d requests, want only the gist to be loaded", got)
+		}
+	})
+
+	t.Run("OK push", func(t *testing.T) {
+		if _, err := run("push", gisttest.GistID, dir); err != nil {
+			t.Fatalf("Pushing, expected no error but got %#v", err)
+		}
+
+		out, err := run("cat", gisttest.GistID, "c.txt")
+		if err != nil || out != "c" {
+			t.Fatalf("Reading a pushed file, got %#v (%v), want %#v", out, err, "c")
+		}
+	})
+
+	t.Run("NOK conflict", func(t *testing.T) {
+		g.SetFiles(map[string]string{"a.txt": "remote a", "b.txt": "remote b", "c.txt": "c"})
+		if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("local a"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := run("sync", gisttest.GistID, dir)
+		if err == nil || !strings.Contains(err.Error(), "1 conflicting files") {
+			t.Fatalf("Syncing, got %#v, want a conflict error", err)
+		}
+		if got, want := out, "conflict a.txt: changed both locally and in the gist\npull     b.txt\n"; got != want {
+			t.Fatalf("Syncing, got output %#v, want %#v", got, want)
+		}
+		if got, want := readFile("a.txt"), "local a"; got != want {
+			t.Fatalf("Syncing, got a.txt %#v, want the conflicting file untouched", got)
+		}
+		if got, want := readFile("b.txt"), "remote b"; got != want {
+			t.Fatalf("Syncing, got b.txt %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK force", func(t *testing.T) {
+		if _, err := run("pull", "-force", gisttest.GistID, dir); err != nil {
+			t.Fatalf("Pulling with -force, expected no error but got %#v", err)
+		}
+		if got, want := readFile("a.txt"), "remote a"; got != want {
+			t.Fatalf("Pulling with -force, got a.txt %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("NOK other gist", func(t *testing.T) {
+		srv.AddGist("abc", map[string]string{"a.txt": "a"})
+		if _, err := run("pull", "abc", dir); err == nil {
+			t.Fatalf("Pulling another gist in a synced directory, expected an error but got none")
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jhchabran/gistfs"
+)
+
+// watch downloads the files of a gist into a local directory, then keeps
+// them up to date as the gist changes, until ctx is done.
+func (c *cli) watch(ctx context.Context, fset *flag.FlagSet, args []string) error {
+	interval := fset.Duration("interval", time.Minute, "poll the gist every `interval`, 0 to only rely on the webhook")
+	addr := fset.String("webhook", "", "reload the gist on the deliveries of a Github webhook received on `addr`, authenticated with GISTFS_WEBHOOK_SECRET")
+	if err := c.parse(fset, args, 2); err != nil {
+		return err
+	}
+	if *interval <= 0 && *addr == "" {
+		fmt.Fprintln(c.stderr, "either -interval or -webhook must be set")
+		fset.Usage()
+		return errUsage
+	}
+	dir := fset.Arg(1)
+
+	fsys, err := c.openGist(ctx, fset.Arg(0), gistFlags{})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events, err := fsys.Watch(ctx)
+	if err != nil {
+		return err
+	}
+
+	entries, err := fsys.ReadDir(".")
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := c.applyEvent(fsys, dir, gistfs.ChangeEvent{Op: gistfs.Added, Name: entry.Name()}); err != nil {
+			return err
+		}
+	}
+
+	errc := make(chan error, 1)
+	if *addr != "" {
+		l, err := net.Listen("tcp", *addr)
+		if err != nil {
+			return err
+		}
+
+		srv := &http.Server{Handler: gistfs.NewWebhookHandler([]byte(c.getenv("GISTFS_WEBHOOK_SECRET")), fsys)}
+		go func() {
+			<-ctx.Done()
+			_ = srv.Close()
+		}()
+		go func() {
+			if err := srv.Serve(l); !errors.Is(err, http.ErrServerClosed) {
+				errc <- err
+			}
+		}()
+
+		fmt.Fprintf(c.stderr, "listening for webhook deliveries on http://%s/\n", l.Addr())
+	}
+
+	if *interval > 0 {
+		go c.poll(ctx, fsys, *interval)
+	}
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := c.applyEvent(fsys, dir, ev); err != nil {
+				return err
+			}
+		case err := <-errc:
+			return err
+		}
+	}
+}
+
+// poll reloads fsys every interval until ctx is done, reporting failures
+// to stderr.
+func (c *cli) poll(ctx context.Context, fsys *gistfs.FS, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := fsys.Load(ctx); err != nil && ctx.Err() == nil {
+				fmt.Fprintln(c.stderr, "gistfs: reloading gist:", err)
+			}
+		}
+	}
+}
+
+// applyEvent reflects a change of a file of fsys in dir, and prints it.
+func (c *cli) applyEvent(fsys *gistfs.FS, dir string, ev gistfs.ChangeEvent) error {
+	p := filepath.Join(dir, filepath.FromSlash(ev.Name))
+
+	switch ev.Op {
+	case gistfs.Added, gistfs.Modified:
+		b, err := fsys.ReadFile(ev.Name)
+		if errors.Is(err, fs.ErrNotExist) {
+			// removed by a later load, whose event is coming.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(p, b, 0644); err != nil {
+			return err
+		}
+	case gistfs.Removed:
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	fmt.Fprintf(c.stdout, "%-8s %s\n", ev.Op, ev.Name)
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jhchabran/gistfs/gisttest"
+)
+
+func TestWatch(t *testing.T) {
+	srv := gisttest.NewServer(t)
+	g := srv.AddGist(gisttest.GistID, map[string]string{"a.txt": "a", "b.txt": "b"})
+
+	// start runs the watch command with args until the test ends, returning
+	// readers of its output and errors.
+	start := func(t *testing.T, args ...string) (stdout, stderr *bufio.Reader) {
+		c, _ := newTestCLI(srv)
+		outr, outw := io.Pipe()
+		errr, errw := io.Pipe()
+		c.stdout, c.stderr = outw, errw
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		t.Cleanup(func() {
+			cancel()
+			<-done
+		})
+
+		go func() {
+			defer close(done)
+			if err := c.run(ctx, append([]string{"watch"}, args...)); err != nil {
+				t.Errorf("Watching, expected no error but got %#v", err)
+			}
+			outw.Close()
+			errw.Close()
+		}()
+
+		return bufio.NewReader(outr), bufio.NewReader(errr)
+	}
+
+	expectLines := func(t *testing.T, r *bufio.Reader, want ...string) {
+		t.Helper()
+		for _, w := range want {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				t.Fatalf("Watching, expected %#v but got %#v", w, err)
+			}
+			if got := strings.TrimRight(line, "\n"); got != w {
+				t.Fatalf("Watching, got %#v, want %#v", got, w)
+			}
+		}
+	}
+
+	readFile := func(t *testing.T, dir, name string) string {
+		t.Helper()
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Reading %s, expected no error but got %#v", name, err)
+		}
+		return string(b)
+	}
+
+	t.Run("OK poll", func(t *testing.T) {
+		dir := t.TempDir()
+		stdout, _ := start(t, "-interval", "10ms", gisttest.GistID, dir)
+		expectLines(t, stdout, "added    a.txt", "added    b.txt")
+
+		g.SetFiles(map[string]string{"a.txt": "new a", "c.txt": "c"})
+		expectLines(t, stdout, "modified a.txt", "removed  b.txt", "added    c.txt")
+
+		if got, want := readFile(t, dir, "a.txt"), "new a"; got != want {
+			t.Fatalf("Watching, got a.txt %#v, want %#v", got, want)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "b.txt")); !os.IsNotExist(err) {
+			t.Fatalf("Watching, expected b.txt to be removed but got %#v", err)
+		}
+	})
+
+	t.Run("OK webhook", func(t *testing.T) {
+		dir := t.TempDir()
+		stdout, stderr := start(t, "-interval", "0", "-webhook", "127.0.0.1:0", gisttest.GistID, dir)
+		expectLines(t, stdout, "added    a.txt", "added    c.txt")
+
+		line, err := stderr.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Watching, expected the webhook address to be printed but got %#v", err)
+		}
+		url := strings.TrimSpace(line[strings.Index(line, "http://"):])
+
+		g.SetFiles(map[string]string{"a.txt": "newer a", "c.txt": "c"})
+
+		// the secret is unset, so deliveries are signed with an empty key.
+		body := `{"gist":{"id":"` + gisttest.GistID + `"}}`
+		mac := hmac.New(sha256.New, nil)
+		mac.Write([]byte(body))
+		req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+		req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Delivering a webhook, expected no error but got %#v", err)
+		}
+		resp.Body.Close()
+		if got, want := resp.StatusCode, http.StatusNoContent; got != want {
+			t.Fatalf("Delivering a webhook, got status %d, want %d", got, want)
+		}
+
+		expectLines(t, stdout, "modified a.txt")
+		if got, want := readFile(t, dir, "a.txt"), "newer a"; got != want {
+			t.Fatalf("Watching, got a.txt %#v, want %#v", got, want)
+		}
+	})
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jhchabran/gistfs"
+)
+
+// gistInfo describes a gist in responses of the API.
+type gistInfo struct {
+	gistfs.DebugState
+	Description string     `json:"description"`
+	Owner       string     `json:"owner,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Stale       bool       `json:"stale"`
+	Entries     []fileInfo `json:"entries,omitempty"`
+}
+
+// fileInfo describes a file or directory of a gist in responses of the API.
+type fileInfo struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	Mode      string    `json:"mode"`
+	ModTime   time.Time `json:"mod_time"`
+	IsDir     bool      `json:"is_dir"`
+	Language  string    `json:"language,omitempty"`
+	MIMEType  string    `json:"mime_type,omitempty"`
+	Truncated bool      `json:"truncated,omitempty"`
+}
+
+// fileContent is the response of the read endpoint.
+type fileContent struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// api serves the JSON API and the files of gists.
+type api struct {
+	// fss are the served filesystems, in the order they were given.
+	fss      []*gistfs.FS
+	byID     map[string]*gistfs.FS
+	handlers map[string]http.Handler
+}
+
+func newAPI(fss []*gistfs.FS) *api {
+	a := &api{
+		fss:      fss,
+		byID:     make(map[string]*gistfs.FS, len(fss)),
+		handlers: make(map[string]http.Handler, len(fss)),
+	}
+	for _, fsys := range fss {
+		a.byID[fsys.GetID()] = fsys
+		a.handlers[fsys.GetID()] = gistfs.Handler(fsys)
+	}
+
+	return a
+}
+
+func (a *api) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	p := r.URL.Path
+	switch {
+	case p == "/healthz":
+		a.health(w, r)
+	case p == "/api/gists" || p == "/api/gists/":
+		a.list(w, r)
+	case strings.HasPrefix(p, "/api/gists/"):
+		a.gist(w, r, strings.TrimPrefix(p, "/api/gists/"))
+	case strings.HasPrefix(p, "/gists/"):
+		id := strings.SplitN(strings.TrimPrefix(p, "/gists/"), "/", 2)[0]
+		h, ok := a.handlers[id]
+		if !ok {
+			writeError(w, gistfs.ErrGistNotFound)
+			return
+		}
+		http.StripPrefix("/gists/"+id, h).ServeHTTP(w, r)
+	default:
+		writeError(w, fs.ErrNotExist)
+	}
+}
+
+// health replies with 200 if all gists are loaded, 503 otherwise.
+func (a *api) health(w http.ResponseWriter, r *http.Request) {
+	for _, fsys := range a.fss {
+		if !fsys.IsLoaded() {
+			writeError(w, gistfs.ErrNotLoaded)
+			return
+		}
+	}
+
+	writeJSON(w, map[string]string{"status": "ok"})
+}
+
+// list replies with the description of all gists.
+func (a *api) list(w http.ResponseWriter, r *http.Request) {
+	gists := make([]gistInfo, 0, len(a.fss))
+	for _, fsys := range a.fss {
+		gists = append(gists, describeGist(fsys))
+	}
+
+	writeJSON(w, gists)
+}
+
+// gist serves the endpoints of a gist, route being the path following
+// /api/gists/.
+func (a *api) gist(w http.ResponseWriter, r *http.Request, route string) {
+	parts := strings.SplitN(route, "/", 3)
+	fsys, ok := a.byID[parts[0]]
+	if !ok {
+		writeError(w, gistfs.ErrGistNotFound)
+		return
+	}
+
+	if len(parts) == 1 || (len(parts) == 2 && parts[1] == "") {
+		info := describeGist(fsys)
+		entries, err := fs.ReadDir(fsys, ".")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		info.Entries, err = describeEntries(entries)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, info)
+		return
+	}
+
+	var name string
+	if len(parts) == 3 {
+		name = strings.TrimSuffix(parts[2], "/")
+	}
+
+	switch parts[1] {
+	case "ls":
+		if name == "" {
+			name = "."
+		}
+		entries, err := fs.ReadDir(fsys, name)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		infos, err := describeEntries(entries)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, infos)
+	case "stat":
+		fi, err := fs.Stat(fsys, name)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, describeFile(fi))
+	case "read":
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, fileContent{Name: name, Content: string(b)})
+	default:
+		writeError(w, fs.ErrNotExist)
+	}
+}
+
+// describeGist returns the description of the gist of fsys, without its
+// files.
+func describeGist(fsys *gistfs.FS) gistInfo {
+	return gistInfo{
+		DebugState:  fsys.DebugState(),
+		Description: fsys.Description(),
+		Owner:       fsys.Owner(),
+		UpdatedAt:   fsys.UpdatedAt(),
+		Stale:       fsys.Stale(),
+	}
+}
+
+func describeEntries(entries []fs.DirEntry) ([]fileInfo, error) {
+	infos := make([]fileInfo, 0, len(entries))
+	for _, e := range entries {
+		fi, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, describeFile(fi))
+	}
+
+	return infos, nil
+}
+
+func describeFile(fi fs.FileInfo) fileInfo {
+	info := fileInfo{
+		Name:    fi.Name(),
+		Size:    fi.Size(),
+		Mode:    fi.Mode().String(),
+		ModTime: fi.ModTime(),
+		IsDir:   fi.IsDir(),
+	}
+	if meta, ok := fi.Sys().(*gistfs.FileMetadata); ok {
+		info.Language = meta.Language
+		info.MIMEType = meta.MIMEType
+		info.Truncated = meta.Truncated
+	}
+
+	return info
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError replies with the JSON description of err, with the matching
+// HTTP status.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusBadGateway
+	switch {
+	case errors.Is(err, gistfs.ErrStale):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, gistfs.ErrGistNotFound), errors.Is(err, fs.ErrNotExist):
+		status = http.StatusNotFound
+	case errors.Is(err, gistfs.ErrNotLoaded):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, fs.ErrInvalid):
+		status = http.StatusBadRequest
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
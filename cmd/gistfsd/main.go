@@ -0,0 +1,176 @@
+// Command gistfsd serves gists over an HTTP JSON API, so that services not
+// written in Go can read them through a single process keeping them loaded
+// and refreshed.
+//
+// Usage:
+//
+//	gistfsd [-addr addr] [-refresh interval] [-rate qps] [-cache dir] <gist>...
+//
+// where gist is a gist ID or URL, as understood by gistfs.ParseURL. Only the
+// gists given on the command line are served. They are loaded at startup
+// and refreshed in the background with conditional requests, which don't
+// count against the rate limit of the Github API when gists did not change.
+//
+// The endpoints are:
+//
+//	GET /api/gists                     list the gists
+//	GET /api/gists/<id>                describe a gist and its files
+//	GET /api/gists/<id>/ls/[dir]       list the files of a directory of a gist
+//	GET /api/gists/<id>/stat/<file>    describe a file of a gist
+//	GET /api/gists/<id>/read/<file>    read a file of a gist
+//	GET /gists/<id>/<file>             serve a file of a gist as is
+//	GET /healthz                       report whether all gists are loaded
+//
+// Secret gists are read with the personal access token held by the
+// GITHUB_TOKEN environment variable, if set. GITHUB_API_URL points the
+// command to another Github API, such as the one of a Github Enterprise
+// server.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+	"github.com/jhchabran/gistfs"
+	"golang.org/x/oauth2"
+)
+
+// errUsage is returned when the command is invoked with invalid arguments,
+// once its usage has been printed.
+var errUsage = errors.New("invalid usage")
+
+// cli runs the daemon, writing its output to stdout and errors to stderr.
+type cli struct {
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+}
+
+func main() {
+	c := &cli{stdout: os.Stdout, stderr: os.Stderr, getenv: os.Getenv}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := c.run(ctx, os.Args[1:])
+	switch {
+	case errors.Is(err, errUsage):
+		stop()
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "gistfsd:", err)
+		stop()
+		os.Exit(1)
+	}
+}
+
+// run loads the gists given by args and serves them until ctx is done.
+func (c *cli) run(ctx context.Context, args []string) error {
+	fset := flag.NewFlagSet("gistfsd", flag.ContinueOnError)
+	fset.SetOutput(c.stderr)
+	fset.Usage = func() {
+		fmt.Fprintln(c.stderr, "usage: gistfsd [-addr addr] [-refresh interval] [-rate qps] [-cache dir] <gist>...")
+		fset.PrintDefaults()
+	}
+	addr := fset.String("addr", ":8080", "listen on `addr`")
+	refresh := fset.Duration("refresh", time.Minute, "refresh gists every `interval`, 0 to never refresh them")
+	rate := fset.Float64("rate", 1, "make at most `qps` requests per second to the Github API, 0 for no limit")
+	cacheDir := fset.String("cache", "", "store loaded gists in `dir`, to only check they did not change after a restart")
+	if err := fset.Parse(args); err != nil {
+		return errUsage
+	}
+	if fset.NArg() == 0 {
+		fset.Usage()
+		return errUsage
+	}
+
+	client, err := c.githubClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	var opts []gistfs.Option
+	if *rate > 0 {
+		opts = append(opts, gistfs.WithRateLimit(*rate, 1))
+	}
+	if *cacheDir != "" {
+		opts = append(opts, gistfs.WithDiskCache(*cacheDir))
+	}
+	m := gistfs.NewManager(client, opts...)
+
+	fss := make([]*gistfs.FS, 0, fset.NArg())
+	for _, ref := range fset.Args() {
+		id, revision, err := gistfs.ParseURL(ref)
+		if err != nil {
+			return err
+		}
+		if revision != "" {
+			return fmt.Errorf("%s: serving a revision of a gist is not supported", ref)
+		}
+
+		fsys, err := m.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		fss = append(fss, fsys)
+	}
+
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+
+	if *refresh > 0 {
+		go m.RefreshEvery(ctx, *refresh)
+	}
+
+	srv := &http.Server{Handler: newAPI(fss)}
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(c.stdout, "serving %d gists at http://%s/\n", len(fss), l.Addr())
+
+	err = srv.Serve(l)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// githubClient returns a Github client authenticated with GITHUB_TOKEN and
+// making its requests to GITHUB_API_URL, if set.
+func (c *cli) githubClient(ctx context.Context) (*github.Client, error) {
+	var httpClient *http.Client
+	if token := c.getenv("GITHUB_TOKEN"); token != "" {
+		httpClient = oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))
+	}
+
+	client := github.NewClient(httpClient)
+	if api := c.getenv("GITHUB_API_URL"); api != "" {
+		u, err := url.Parse(strings.TrimSuffix(api, "/") + "/")
+		if err != nil {
+			return nil, fmt.Errorf("invalid GITHUB_API_URL: %w", err)
+		}
+		client.BaseURL = u
+	}
+
+	return client, nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jhchabran/gistfs/gisttest"
+)
+
+func TestRun(t *testing.T) {
+	srv := gisttest.NewServer(t)
+	srv.AddGist("abc", map[string]string{"a.txt": "a\n", "b.json": `{"b":1}`})
+	srv.AddGist("def", map[string]string{"index.html": "<p>def</p>"})
+
+	r, w := io.Pipe()
+	c := &cli{
+		stdout: w,
+		stderr: &bytes.Buffer{},
+		getenv: func(key string) string {
+			if key == "GITHUB_API_URL" {
+				return srv.URL
+			}
+			return ""
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- c.run(ctx, []string{"-addr", "127.0.0.1:0", "-rate", "0", "abc", "def"})
+		w.Close()
+	}()
+
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil {
+		t.Fatalf("Running, expected the URL to be printed but got %#v", err)
+	}
+	fields := strings.Fields(line)
+	base := strings.TrimSuffix(fields[len(fields)-1], "/")
+
+	get := func(t *testing.T, path string, wantStatus int, v interface{}) string {
+		t.Helper()
+		resp, err := http.Get(base + path)
+		if err != nil {
+			t.Fatalf("Getting %s, expected no error but got %#v", path, err)
+		}
+		defer resp.Body.Close()
+
+		b, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != wantStatus {
+			t.Fatalf("Getting %s, got status %d, want %d: %s", path, resp.StatusCode, wantStatus, b)
+		}
+		if v != nil {
+			if err := json.Unmarshal(b, v); err != nil {
+				t.Fatalf("Getting %s, expected JSON but got %#v", path, err)
+			}
+		}
+		return string(b)
+	}
+
+	t.Run("list", func(t *testing.T) {
+		var gists []gistInfo
+		get(t, "/api/gists", http.StatusOK, &gists)
+		if len(gists) != 2 || gists[0].ID != "abc" || gists[1].ID != "def" || !gists[0].Loaded || gists[0].Files != 2 {
+			t.Errorf("Listing gists, got %+v", gists)
+		}
+	})
+
+	t.Run("gist", func(t *testing.T) {
+		var gist gistInfo
+		get(t, "/api/gists/abc", http.StatusOK, &gist)
+		if len(gist.Entries) != 2 || gist.Entries[0].Name != "a.txt" || gist.Entries[1].Name != "b.json" {
+			t.Errorf("Describing gist, got entries %+v", gist.Entries)
+		}
+	})
+
+	t.Run("ls", func(t *testing.T) {
+		var entries []fileInfo
+		get(t, "/api/gists/abc/ls/", http.StatusOK, &entries)
+		if len(entries) != 2 {
+			t.Errorf("Listing files, got %+v", entries)
+		}
+	})
+
+	t.Run("stat", func(t *testing.T) {
+		var info fileInfo
+		get(t, "/api/gists/abc/stat/a.txt", http.StatusOK, &info)
+		if info.Name != "a.txt" || info.Size != 2 || info.IsDir {
+			t.Errorf("Stating a.txt, got %+v", info)
+		}
+	})
+
+	t.Run("read", func(t *testing.T) {
+		var content fileContent
+		get(t, "/api/gists/abc/read/b.json", http.StatusOK, &content)
+		if content.Content != `{"b":1}` {
+			t.Errorf("Reading b.json, got %q", content.Content)
+		}
+	})
+
+	t.Run("static", func(t *testing.T) {
+		if body := get(t, "/gists/def/", http.StatusOK, nil); body != "<p>def</p>" {
+			t.Errorf("Serving index.html, got %q", body)
+		}
+	})
+
+	t.Run("healthz", func(t *testing.T) {
+		get(t, "/healthz", http.StatusOK, nil)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		for _, path := range []string{"/api/gists/xyz", "/api/gists/abc/read/missing.txt", "/gists/xyz/a.txt", "/other"} {
+			var resp struct{ Error string }
+			get(t, path, http.StatusNotFound, &resp)
+			if resp.Error == "" {
+				t.Errorf("Getting %s, expected an error to be described", path)
+			}
+		}
+	})
+
+	cancel()
+	if err := <-errc; err != nil {
+		t.Errorf("Running, expected no error once stopped but got %#v", err)
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	c := &cli{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}, getenv: func(string) string { return "" }}
+	if err := c.run(context.Background(), nil); !errors.Is(err, errUsage) {
+		t.Errorf("Running without gists, got %#v, want errUsage", err)
+	}
+}
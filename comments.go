@@ -0,0 +1,133 @@
+package gistfs
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// CommentsDir is the directory holding the virtual files added by
+// WithComments.
+const CommentsDir = ".comments"
+
+// commentsPerPage is the number of comments requested per page, the maximum
+// allowed by Github.
+const commentsPerPage = 100
+
+// GistComment is a comment on a gist.
+type GistComment struct {
+	ID        int64
+	Author    string
+	Body      string
+	CreatedAt time.Time
+}
+
+// CommentLister is implemented by the GistGetters able to list the comments
+// of a gist, which WithComments requires. The default getter and the one
+// returned by NewHTTPGetter implement it.
+type CommentLister interface {
+	// ListComments returns all the comments of the gist identified by id,
+	// oldest first.
+	ListComments(ctx context.Context, id string) ([]GistComment, error)
+}
+
+// WithComments fetches the comments of the gist whenever it is loaded, and
+// exposes them as virtual Markdown files in CommentsDir, one per comment.
+// Each file starts with a header giving the author and date of the comment,
+// followed by a blank line and the comment itself. Files are named after the
+// date and ID of their comment, so listing them gives the discussion in order.
+func WithComments() Option {
+	return func(fsys *FS) {
+		fsys.withComments = true
+		fsys.virtuals = append(fsys.virtuals, commentFiles)
+	}
+}
+
+// fetchComments retrieves the comments of the gist through the getter.
+func (fsys *FS) fetchComments(ctx context.Context) ([]GistComment, error) {
+	lister, ok := fsys.getter.(CommentLister)
+	if !ok {
+		return nil, &Error{
+			Op:  "list comments",
+			ID:  fsys.GetID(),
+			Err: fmt.Errorf("%T does not implement CommentLister", fsys.getter),
+		}
+	}
+
+	var comments []GistComment
+	err := fsys.call(ctx, "list comments", func() (*github.Response, error) {
+		var err error
+		comments, err = lister.ListComments(ctx, fsys.GetID())
+		return nil, err
+	})
+
+	return comments, err
+}
+
+func commentFiles(fsys *FS, s *state) map[string]github.GistFile {
+	files := make(map[string]github.GistFile, len(s.comments))
+	for _, c := range s.comments {
+		p := CommentsDir + "/" + c.CreatedAt.UTC().Format("20060102T150405Z") + "-" + strconv.FormatInt(c.ID, 10) + ".md"
+		content := fmt.Sprintf("Author: %s\nDate: %s\n\n%s\n", c.Author, c.CreatedAt.UTC().Format(time.RFC3339), c.Body)
+		files[p] = virtualFile(p, []byte(content), "Markdown")
+	}
+
+	return files
+}
+
+func (g *githubGetter) ListComments(ctx context.Context, id string) ([]GistComment, error) {
+	var comments []GistComment
+
+	opts := &github.ListOptions{PerPage: commentsPerPage}
+	for {
+		page, resp, err := g.fsys.client.Gists.ListComments(ctx, id, opts)
+		g.fsys.recordResponse(resp)
+		g.fsys.traceResponse(ctx, resp)
+		if err != nil {
+			return nil, err
+		}
+
+		comments = append(comments, fromGithubComments(page)...)
+
+		if resp.NextPage == 0 {
+			return comments, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+func (g *httpGetter) ListComments(ctx context.Context, id string) ([]GistComment, error) {
+	var comments []GistComment
+
+	for page := 1; ; page++ {
+		var batch []*github.GistComment
+		path := fmt.Sprintf("gists/%s/comments?per_page=%d&page=%d", id, commentsPerPage, page)
+		if _, err := g.do(ctx, path, nil, &batch); err != nil {
+			return nil, err
+		}
+
+		comments = append(comments, fromGithubComments(batch)...)
+
+		if len(batch) < commentsPerPage {
+			return comments, nil
+		}
+	}
+}
+
+// fromGithubComments converts comments returned by go-github.
+func fromGithubComments(comments []*github.GistComment) []GistComment {
+	out := make([]GistComment, 0, len(comments))
+	for _, c := range comments {
+		out = append(out, GistComment{
+			ID:        c.GetID(),
+			Author:    c.GetUser().GetLogin(),
+			Body:      c.GetBody(),
+			CreatedAt: c.GetCreatedAt(),
+		})
+	}
+
+	return out
+}
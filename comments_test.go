@@ -0,0 +1,72 @@
+package gistfs
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestWithComments(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+	fg.AddComment("alice", "first!")
+	for i := 0; i < commentsPerPage; i++ {
+		fg.AddComment("bob", fmt.Sprintf("comment %d", i))
+	}
+
+	getter, _ := NewHTTPGetter(fg.Client(), fg.URL)
+
+	tests := []struct {
+		name string
+		fsys *FS
+	}{
+		{"default getter", NewWithClient(client, referenceGistID, WithComments())},
+		{"http getter", NewWithGetter(getter, referenceGistID, WithComments())},
+	}
+
+	for _, test := range tests {
+		t.Run("OK "+test.name, func(t *testing.T) {
+			if err := test.fsys.Load(context.Background()); err != nil {
+				t.Fatalf("Loading with comments, expected no error but got %#v", err)
+			}
+
+			entries, err := fs.ReadDir(test.fsys, CommentsDir)
+			if err != nil {
+				t.Fatalf("Reading comments, expected no error but got %#v", err)
+			}
+
+			if got, want := len(entries), commentsPerPage+1; got != want {
+				t.Fatalf("Reading comments, got %d files, want %d", got, want)
+			}
+
+			b, err := fs.ReadFile(test.fsys, CommentsDir+"/"+entries[0].Name())
+			if err != nil {
+				t.Fatalf("Reading a comment, expected no error but got %#v", err)
+			}
+
+			content := string(b)
+			if !strings.HasPrefix(content, "Author: alice\nDate: ") || !strings.HasSuffix(content, "\n\nfirst!\n") {
+				t.Fatalf("Reading the first comment, got %#v", content)
+			}
+		})
+	}
+
+	t.Run("OK without comments", func(t *testing.T) {
+		gfs := NewWithClient(client, referenceGistID)
+		gfs.Load(context.Background())
+
+		if _, err := gfs.Open(CommentsDir); err == nil {
+			t.Fatal("Opening comments without the option, got no error, want one")
+		}
+	})
+
+	t.Run("NOK getter without comments", func(t *testing.T) {
+		getter := &stubGetter{gist: &Gist{ID: referenceGistID}}
+		gfs := NewWithGetter(getter, referenceGistID, WithComments())
+
+		if err := gfs.Load(context.Background()); err == nil {
+			t.Fatal("Loading comments through a getter not listing them, got no error, want one")
+		}
+	})
+}
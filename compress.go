@@ -0,0 +1,169 @@
+package gistfs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// WithCompression keeps the content of files gzip-compressed in memory, and
+// decompresses it when they are opened or read. It trades some CPU on reads
+// for less memory, which suits services holding many large gists that are
+// rarely read.
+func WithCompression() Option {
+	return func(fsys *FS) {
+		fsys.compress = true
+	}
+}
+
+// compressGist returns a copy of gist whose files have their content
+// compressed in the returned map, by name, rather than in their Content
+// field. Files compressed already, whose content is in prev, are kept as
+// is, while truncated files are left uncompressed.
+func compressGist(gist *github.Gist, prev map[github.GistFilename]string) (*github.Gist, map[github.GistFilename]string) {
+	if gist == nil {
+		return nil, nil
+	}
+
+	compressed := make(map[github.GistFilename]string, len(gist.Files))
+	c := *gist
+	c.Files = make(map[github.GistFilename]github.GistFile, len(gist.Files))
+	for name, f := range gist.Files {
+		switch {
+		case f.Content == nil:
+			if z, ok := prev[name]; ok {
+				compressed[name] = z
+			}
+		case !isTruncated(f) && f.GetFilename() == string(name):
+			compressed[name] = gzipString(f.GetContent())
+			f.Content = nil
+		}
+		c.Files[name] = f
+	}
+
+	return &c, compressed
+}
+
+// gzipString returns s, compressed.
+func gzipString(s string) string {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+
+	// Writing to a bytes.Buffer does not fail.
+	_, _ = io.WriteString(zw, s)
+	_ = zw.Close()
+
+	return buf.String()
+}
+
+// gunzipReader decompresses its source on first Read, so that files that
+// are opened but never read are not decompressed. It can be reset to read
+// another source, reusing its decompressor.
+type gunzipReader struct {
+	src     string
+	started bool
+	pos     int64
+	sr      strings.Reader
+	zr      *gzip.Reader
+}
+
+// reset makes g read src from the start.
+func (g *gunzipReader) reset(src string) {
+	g.src = src
+	g.started = false
+	g.pos = 0
+}
+
+// seek moves g to the offset abs of the decompressed content, restarting
+// from the start if it is behind.
+func (g *gunzipReader) seek(abs int64) error {
+	if abs < g.pos {
+		g.reset(g.src)
+	}
+
+	_, err := io.CopyN(io.Discard, g, abs-g.pos)
+	if err == io.EOF {
+		// Seeking past the end is allowed, further reads hitting EOF.
+		g.pos = abs
+		return nil
+	}
+	return err
+}
+
+func (g *gunzipReader) Read(b []byte) (int, error) {
+	if !g.started {
+		g.sr.Reset(g.src)
+
+		if g.zr == nil {
+			zr, err := gzip.NewReader(&g.sr)
+			if err != nil {
+				return 0, err
+			}
+			g.zr = zr
+		} else if err := g.zr.Reset(&g.sr); err != nil {
+			return 0, err
+		}
+		g.started = true
+	}
+
+	n, err := g.zr.Read(b)
+	g.pos += int64(n)
+	return n, err
+}
+
+// contentReader returns a reader of the content of f, a file served by the
+// filesystem in state s.
+func (fsys *FS) contentReader(s *state, f *github.GistFile) io.Reader {
+	if z, ok := fsys.compressedContent(s, f); ok {
+		return &gunzipReader{src: z}
+	}
+
+	return strings.NewReader(f.GetContent())
+}
+
+// content returns the content of f, a file served by the filesystem in state
+// s, decompressing it if needed.
+func (fsys *FS) content(s *state, f *github.GistFile) (string, error) {
+	if _, ok := fsys.compressedContent(s, f); !ok {
+		return f.GetContent(), nil
+	}
+
+	b, err := io.ReadAll(fsys.contentReader(s, f))
+	return string(b), err
+}
+
+// compressedContent returns the compressed content of f, if it is stored
+// compressed.
+func (fsys *FS) compressedContent(s *state, f *github.GistFile) (string, bool) {
+	if f.Content != nil {
+		return "", false
+	}
+
+	z, ok := s.extra.compressed[github.GistFilename(f.GetFilename())]
+	return z, ok
+}
+
+// plainGist returns the gist of s, with the content of its files
+// decompressed.
+func (fsys *FS) plainGist(s *state) (*github.Gist, error) {
+	if len(s.extra.compressed) == 0 {
+		return s.gist, nil
+	}
+
+	plain := *s.gist
+	plain.Files = make(map[github.GistFilename]github.GistFile, len(s.gist.Files))
+	for name, f := range s.gist.Files {
+		content, err := fsys.content(s, &f)
+		if err != nil {
+			return nil, err
+		}
+
+		f.Content = github.String(content)
+		plain.Files[name] = f
+	}
+
+	return &plain, nil
+}
@@ -0,0 +1,95 @@
+package gistfs
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithCompression(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{
+		"a.txt": strings.Repeat("a", 1000),
+		"b.txt": "b",
+	})
+	gfs := NewWithClient(client, referenceGistID, WithCompression())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := gfs.Watch(ctx)
+	if err != nil {
+		t.Fatalf("Watching, expected no error but got %#v", err)
+	}
+
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+	receiveEvents(t, ch, 2)
+
+	t.Run("OK stored compressed", func(t *testing.T) {
+		f := gfs.loadedGist().Files["a.txt"]
+		if f.Content != nil {
+			t.Fatalf("Loading with compression, got content %#v stored as is, want it compressed", f.GetContent())
+		}
+
+		if got, max := len(gfs.state().extra.compressed["a.txt"]), 100; got > max {
+			t.Fatalf("Loading with compression, got %d bytes stored, want at most %d", got, max)
+		}
+	})
+
+	t.Run("OK read", func(t *testing.T) {
+		b, err := gfs.ReadFile("a.txt")
+		if err != nil {
+			t.Fatalf("Reading, expected no error but got %#v", err)
+		}
+		if got, want := string(b), strings.Repeat("a", 1000); got != want {
+			t.Fatalf("Reading, got %d bytes, want %d", len(got), len(want))
+		}
+
+		f, err := gfs.Open("b.txt")
+		if err != nil {
+			t.Fatalf("Opening, expected no error but got %#v", err)
+		}
+		defer f.Close()
+
+		b, _ = io.ReadAll(f)
+		if got, want := string(b), "b"; got != want {
+			t.Fatalf("Reading an opened file, got %#v, want %#v", got, want)
+		}
+
+		info, _ := f.Stat()
+		if got, want := info.Size(), int64(1); got != want {
+			t.Fatalf("Stat, got size %d, want %d", got, want)
+		}
+	})
+
+	t.Run("OK JSON", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := gfs.WriteJSON(&buf); err != nil {
+			t.Fatalf("Writing JSON, expected no error but got %#v", err)
+		}
+
+		if !strings.Contains(buf.String(), `"content": "b"`) {
+			t.Fatalf("Writing JSON, got %s, want decompressed content", buf.String())
+		}
+	})
+
+	t.Run("OK watch", func(t *testing.T) {
+		fg.SetFiles(map[string]string{
+			"a.txt": strings.Repeat("a", 1000),
+			"b.txt": "bb",
+		})
+
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Refreshing, expected no error but got %#v", err)
+		}
+
+		want := []ChangeEvent{{Modified, "b.txt"}}
+		if got := receiveEvents(t, ch, len(want)); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Watching a refresh, got %v, want %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,213 @@
+// Package config uses a file of a gist as a configuration store, decoding
+// it into a struct and decoding it again whenever the filesystem loads
+// a new revision of it:
+//
+//	gfs := gistfs.New(id, gistfs.WithTTL(time.Minute))
+//	cfg, err := config.New(ctx, gfs, "config.yaml", new(Settings))
+//	...
+//	settings := cfg.Value().(*Settings)
+//
+// JSON, YAML and TOML files are decoded according to their extension.
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/jhchabran/gistfs"
+	"gopkg.in/yaml.v3"
+)
+
+// Decoder decodes data into the value v points to.
+type Decoder func(data []byte, v interface{}) error
+
+// Decoders of the supported formats.
+var (
+	JSON Decoder = json.Unmarshal
+	YAML Decoder = yaml.Unmarshal
+	TOML Decoder = func(data []byte, v interface{}) error {
+		_, err := toml.NewDecoder(bytes.NewReader(data)).Decode(v)
+		return err
+	}
+)
+
+// decoders are the decoders of the supported formats by extension.
+var decoders = map[string]Decoder{
+	".json": JSON,
+	".yaml": YAML,
+	".yml":  YAML,
+	".toml": TOML,
+}
+
+// Option configures a Config.
+type Option func(*Config)
+
+// WithDecoder decodes the file with dec, whatever its extension.
+func WithDecoder(dec Decoder) Option {
+	return func(c *Config) {
+		c.decode = dec
+	}
+}
+
+// OnChange calls fn with the new value each time the decoded value changes.
+// Calls happen one at a time, from a goroutine owned by the Config.
+func OnChange(fn func(v interface{})) Option {
+	return func(c *Config) {
+		c.onChange = append(c.onChange, fn)
+	}
+}
+
+// Config holds the value decoded from a file of a gist, and decodes it again
+// when it changes.
+type Config struct {
+	fsys     *gistfs.FS
+	name     string
+	typ      reflect.Type
+	decode   Decoder
+	onChange []func(v interface{})
+	changes  chan interface{}
+
+	mu    sync.RWMutex
+	value interface{}
+	err   error
+}
+
+// New decodes the file name of fsys into v, a non-nil pointer such as
+// new(Settings), and keeps decoding it into new values of the same type as
+// the file changes, until ctx is done. The filesystem must be loaded.
+//
+// v is never modified after New returns: the current value is returned by
+// Value.
+func New(ctx context.Context, fsys *gistfs.FS, name string, v interface{}, opts ...Option) (*Config, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return nil, fmt.Errorf("config: decoding %s into non-pointer %T", name, v)
+	}
+
+	c := &Config{
+		fsys:    fsys,
+		name:    name,
+		typ:     rv.Type().Elem(),
+		decode:  decoders[strings.ToLower(path.Ext(name))],
+		changes: make(chan interface{}, 1),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.decode == nil {
+		return nil, fmt.Errorf("config: no decoder for %s", name)
+	}
+
+	if err := c.read(v); err != nil {
+		return nil, err
+	}
+	c.value = v
+
+	events, err := fsys.Watch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	go c.watch(events)
+
+	return c, nil
+}
+
+// Value returns a pointer to the value decoded from the current content of
+// the file, which must not be modified. If decoding it failed after
+// a change, the last value decoded successfully is returned, and Err
+// reports why.
+func (c *Config) Value() interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.value
+}
+
+// Err returns the error of the last attempt at decoding the file, or nil if
+// it succeeded.
+func (c *Config) Err() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.err
+}
+
+// Changes returns a channel receiving the new value each time the decoded
+// value changes. Receivers lagging behind only get the latest value.
+func (c *Config) Changes() <-chan interface{} {
+	return c.changes
+}
+
+func (c *Config) read(v interface{}) error {
+	b, err := c.fsys.ReadFile(c.name)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+
+	if err := c.decode(b, v); err != nil {
+		return fmt.Errorf("config: decoding %s: %w", c.name, err)
+	}
+
+	return nil
+}
+
+// watch decodes the file again after each change of the gist, until events
+// is closed. Changes coming from a single load are handled at once.
+func (c *Config) watch(events <-chan gistfs.ChangeEvent) {
+	for range events {
+		if !drain(events) {
+			return
+		}
+
+		v := reflect.New(c.typ).Interface()
+		err := c.read(v)
+
+		c.mu.Lock()
+		changed := err == nil && !reflect.DeepEqual(v, c.value)
+		if changed {
+			c.value = v
+		}
+		c.err = err
+		c.mu.Unlock()
+
+		if changed {
+			c.notify(v)
+		}
+	}
+}
+
+// notify calls the OnChange callbacks and sends v on the channel returned by
+// Changes, replacing a value not received yet.
+func (c *Config) notify(v interface{}) {
+	for _, fn := range c.onChange {
+		fn(v)
+	}
+
+	select {
+	case <-c.changes:
+	default:
+	}
+	c.changes <- v
+}
+
+// drain receives the events already waiting on events, returning false if
+// it is closed.
+func drain(events <-chan gistfs.ChangeEvent) bool {
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return false
+			}
+		default:
+			return true
+		}
+	}
+}
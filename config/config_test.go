@@ -0,0 +1,144 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jhchabran/gistfs"
+	"github.com/jhchabran/gistfs/gisttest"
+)
+
+type settings struct {
+	Name  string `json:"name" yaml:"name" toml:"name"`
+	Port  int    `json:"port" yaml:"port" toml:"port"`
+	Debug bool   `json:"debug" yaml:"debug" toml:"debug"`
+}
+
+func TestNew(t *testing.T) {
+	want := settings{Name: "api", Port: 8080, Debug: true}
+
+	tests := map[string]string{
+		"config.json": `{"name": "api", "port": 8080, "debug": true}`,
+		"config.yaml": "name: api\nport: 8080\ndebug: true\n",
+		"config.toml": "name = \"api\"\nport = 8080\ndebug = true\n",
+	}
+	for name, content := range tests {
+		t.Run("OK "+name, func(t *testing.T) {
+			gfs, _ := gisttest.NewFS(t, map[string]string{name: content})
+
+			var s settings
+			cfg, err := New(context.Background(), gfs, name, &s)
+			if err != nil {
+				t.Fatalf("Decoding, expected no error but got %#v", err)
+			}
+			if s != want || cfg.Value() != &s {
+				t.Fatalf("Decoding, got %+v, want %+v", s, want)
+			}
+		})
+	}
+
+	t.Run("NOK invalid", func(t *testing.T) {
+		gfs, _ := gisttest.NewFS(t, map[string]string{"config.json": "{"})
+
+		if _, err := New(context.Background(), gfs, "config.json", new(settings)); err == nil {
+			t.Fatal("Decoding, expected an error but got none")
+		}
+	})
+
+	t.Run("NOK unknown format", func(t *testing.T) {
+		gfs, _ := gisttest.NewFS(t, map[string]string{"config.ini": "name=api"})
+
+		if _, err := New(context.Background(), gfs, "config.ini", new(settings)); err == nil {
+			t.Fatal("Decoding, expected an error but got none")
+		}
+	})
+
+	t.Run("OK custom decoder", func(t *testing.T) {
+		gfs, _ := gisttest.NewFS(t, map[string]string{"config.ini": "name: api"})
+
+		var s settings
+		if _, err := New(context.Background(), gfs, "config.ini", &s, WithDecoder(YAML)); err != nil || s.Name != "api" {
+			t.Fatalf("Decoding, got %+v (%v), want the name decoded", s, err)
+		}
+	})
+}
+
+func TestChanges(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := gisttest.NewServer(t)
+	gist := srv.AddGist(gisttest.GistID, map[string]string{
+		"config.json": `{"name": "api"}`,
+		"notes.txt":   "notes",
+	})
+
+	gfs := gistfs.NewWithClient(srv.GithubClient(), gisttest.GistID)
+	if err := gfs.Load(ctx); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+
+	called := make(chan interface{}, 10)
+	cfg, err := New(ctx, gfs, "config.json", new(settings), OnChange(func(v interface{}) { called <- v }))
+	if err != nil {
+		t.Fatalf("Decoding, expected no error but got %#v", err)
+	}
+
+	load := func(files map[string]string) {
+		t.Helper()
+
+		gist.SetFiles(files)
+		if err := gfs.Load(ctx); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+	}
+
+	t.Run("OK change", func(t *testing.T) {
+		load(map[string]string{"config.json": `{"name": "web"}`, "notes.txt": "notes"})
+
+		select {
+		case v := <-cfg.Changes():
+			if got := v.(*settings).Name; got != "web" {
+				t.Fatalf("Receiving a change, got %#v, want %#v", got, "web")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Receiving a change, timed out")
+		}
+		if v := <-called; v.(*settings).Name != "web" {
+			t.Fatalf("Calling back, got %+v, want the new value", v)
+		}
+		if got := cfg.Value().(*settings).Name; got != "web" {
+			t.Fatalf("Getting the value, got %#v, want %#v", got, "web")
+		}
+	})
+
+	t.Run("OK unrelated change", func(t *testing.T) {
+		load(map[string]string{"config.json": `{"name":"web"}`, "notes.txt": "more notes"})
+		load(map[string]string{"config.json": `{"name": "db"}`, "notes.txt": "more notes"})
+
+		select {
+		case v := <-cfg.Changes():
+			if got := v.(*settings).Name; got != "db" {
+				t.Fatalf("Receiving a change, got %#v, want only the one to %#v", got, "db")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Receiving a change, timed out")
+		}
+	})
+
+	t.Run("NOK invalid change", func(t *testing.T) {
+		load(map[string]string{"config.json": `{`})
+
+		deadline := time.Now().Add(time.Second)
+		for cfg.Err() == nil {
+			if time.Now().After(deadline) {
+				t.Fatal("Decoding an invalid change, expected an error but got none")
+			}
+			time.Sleep(time.Millisecond)
+		}
+		if got := cfg.Value().(*settings).Name; got != "db" {
+			t.Fatalf("Getting the value, got %#v, want the last valid one %#v", got, "db")
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package gistfs
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DebugState is a summary of the state of a filesystem, meant for health
+// endpoints and troubleshooting. It can be encoded in JSON as is.
+type DebugState struct {
+	// ID is the ID of the gist.
+	ID string `json:"id"`
+	// Loaded reports whether a gist is being served.
+	Loaded bool `json:"loaded"`
+	// Revision is the SHA of the revision being served, if known.
+	Revision string `json:"revision,omitempty"`
+	// Files is the number of files of the gist.
+	Files int `json:"files"`
+	// Bytes is the total size of the files of the gist.
+	Bytes int `json:"bytes"`
+	// LoadedAt is when the gist being served was loaded, or the zero time.
+	LoadedAt time.Time `json:"loaded_at"`
+	// LastError is the error of the last load or refresh, if it failed.
+	LastError string `json:"last_error,omitempty"`
+	// RateLimit is the rate limit state reported by the last response
+	// from the Github API.
+	RateLimit RateLimit `json:"rate_limit"`
+}
+
+// DebugState returns a summary of the state of the filesystem. It never
+// fails, even if the filesystem is not loaded.
+func (fsys *FS) DebugState() DebugState {
+	s := fsys.state()
+
+	d := DebugState{
+		ID:        fsys.GetID(),
+		Loaded:    s.gist != nil,
+		RateLimit: fsys.RateLimit(),
+	}
+	if s.gist != nil {
+		d.Revision = s.extra.sha
+		d.Files = len(s.gist.Files)
+		d.Bytes = gistSize(s.gist)
+		d.LoadedAt = s.loadedAt
+	}
+	if s.refreshErr != nil {
+		d.LastError = s.refreshErr.Error()
+	}
+
+	return d
+}
+
+// String formats d on a single line, such as:
+//
+//	gist aa5a315d61ae9438b18d loaded revision 57a7f1 files=2 bytes=1024 loaded_at=2021-01-01T00:00:00Z
+func (d DebugState) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "gist %s", d.ID)
+	if !d.Loaded {
+		b.WriteString(" not loaded")
+	} else {
+		b.WriteString(" loaded")
+		if d.Revision != "" {
+			fmt.Fprintf(&b, " revision %s", d.Revision)
+		}
+		fmt.Fprintf(&b, " files=%d bytes=%d loaded_at=%s", d.Files, d.Bytes, d.LoadedAt.Format(time.RFC3339))
+	}
+	if d.RateLimit.Limit != 0 {
+		fmt.Fprintf(&b, " rate_limit=%d/%d", d.RateLimit.Remaining, d.RateLimit.Limit)
+	}
+	if d.LastError != "" {
+		fmt.Fprintf(&b, " last_error=%q", d.LastError)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,70 @@
+package gistfs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDebugState(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "abc", "b.txt": "de"})
+	clock := newFakeClock()
+
+	gfs := NewWithClient(client, referenceGistID)
+	gfs.now = clock.Now
+
+	t.Run("OK not loaded", func(t *testing.T) {
+		d := gfs.DebugState()
+		if d.Loaded || d.ID != referenceGistID {
+			t.Fatalf("DebugState, got %#v, want a not loaded state", d)
+		}
+
+		if got, want := d.String(), "gist "+referenceGistID+" not loaded"; got != want {
+			t.Fatalf("DebugState.String, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK loaded", func(t *testing.T) {
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Load, expected no error but got %#v", err)
+		}
+
+		d := gfs.DebugState()
+		if !d.Loaded || d.Files != 2 || d.Bytes != 5 || !d.LoadedAt.Equal(clock.Now()) || d.LastError != "" {
+			t.Fatalf("DebugState, got %#v, want a loaded state with 2 files of 5 bytes", d)
+		}
+		if d.RateLimit.Limit == 0 {
+			t.Fatalf("DebugState, got %#v, want the rate limit reported by Github", d)
+		}
+
+		if s := d.String(); !strings.Contains(s, "files=2 bytes=5 loaded_at=2021-01-01T00:00:00Z") {
+			t.Fatalf("DebugState.String, got %#v, want the files, bytes and load time", s)
+		}
+	})
+
+	t.Run("OK failed refresh", func(t *testing.T) {
+		clock.Advance(time.Minute)
+		fg.FailNext(1, http.StatusNotFound)
+		if err := gfs.Load(context.Background()); err == nil {
+			t.Fatal("Load, expected an error but got none")
+		}
+
+		d := gfs.DebugState()
+		if !d.Loaded || d.LastError == "" || d.LoadedAt.Equal(clock.Now()) {
+			t.Fatalf("DebugState, got %#v, want the previous load and the last error", d)
+		}
+
+		b, err := json.Marshal(d)
+		if err != nil {
+			t.Fatalf("Encoding, expected no error but got %#v", err)
+		}
+		for _, key := range []string{`"last_error":`, `"loaded_at":`, `"remaining":`} {
+			if !strings.Contains(string(b), key) {
+				t.Fatalf("Encoding, got %s, want a %s key", b, key)
+			}
+		}
+	})
+}
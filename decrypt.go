@@ -0,0 +1,106 @@
+package gistfs
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v33/github"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// ErrDecrypt is returned when reading a file whose content can't be
+// decrypted, such as one encrypted with another key.
+var ErrDecrypt = errors.New("cannot decrypt content")
+
+// WithDecryptor decrypts the content of the files with fn when they are
+// read, so that secrets kept encrypted in a gist can be read like any other
+// file. fn is given the name of each file in the gist and its content, and
+// returns the content to serve instead, or the content as is for files it
+// doesn't decrypt. Returning an error fails the read.
+//
+// Unlike WithTransform, decryption happens on every read, so that the
+// plaintext is never held by the loaded gist, caches, snapshots or
+// archives. For the same reason, hashes and the sizes reported by ReadDir
+// and Sizes are the ones of the encrypted content, while opened files report
+// their decrypted size.
+//
+// SecretboxDecryptor returns a decryptor for files encrypted with
+// SecretboxEncrypt.
+func WithDecryptor(fn func(name string, ciphertext []byte) ([]byte, error)) Option {
+	return func(fsys *FS) {
+		fsys.decryptor = fn
+	}
+}
+
+// decryptFile returns f, a file served by the filesystem in state s, with
+// its content decrypted by the decryptor of the filesystem, if any.
+func (fsys *FS) decryptFile(s *state, f github.GistFile) (github.GistFile, error) {
+	if fsys.decryptor == nil {
+		return f, nil
+	}
+
+	content, err := fsys.content(s, &f)
+	if err != nil {
+		return f, err
+	}
+
+	plain, err := fsys.decryptor(f.GetFilename(), []byte(content))
+	if err != nil {
+		return f, &Error{Op: "read", ID: fsys.GetID(), Err: fmt.Errorf("decrypting %s: %w", f.GetFilename(), err)}
+	}
+
+	f.Content = github.String(string(plain))
+	f.Size = github.Int(len(plain))
+
+	return f, nil
+}
+
+// secretboxPrefix starts the content of files encrypted by SecretboxEncrypt,
+// so that they can be told apart from files stored in plaintext.
+const secretboxPrefix = "gistfs:secretbox:"
+
+// secretboxNonceSize is the size of the nonces of NaCl secretbox.
+const secretboxNonceSize = 24
+
+// SecretboxEncrypt encrypts plaintext with key using NaCl secretbox, and
+// returns it as text to be stored in a gist and decrypted by the decryptor
+// returned by SecretboxDecryptor.
+func SecretboxEncrypt(key *[32]byte, plaintext []byte) ([]byte, error) {
+	var nonce [secretboxNonceSize]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+
+	box := secretbox.Seal(nonce[:], plaintext, &nonce, key)
+	return []byte(secretboxPrefix + base64.StdEncoding.EncodeToString(box) + "\n"), nil
+}
+
+// SecretboxDecryptor returns a decryptor for WithDecryptor, decrypting with
+// key the files encrypted by SecretboxEncrypt. Other files are served as is,
+// so that a gist can mix encrypted and plaintext files. Files that fail to
+// decrypt fail reads with ErrDecrypt.
+func SecretboxDecryptor(key *[32]byte) func(name string, ciphertext []byte) ([]byte, error) {
+	return func(name string, ciphertext []byte) ([]byte, error) {
+		text := strings.TrimSpace(string(ciphertext))
+		if !strings.HasPrefix(text, secretboxPrefix) {
+			return ciphertext, nil
+		}
+
+		box, err := base64.StdEncoding.DecodeString(text[len(secretboxPrefix):])
+		if err != nil || len(box) < secretboxNonceSize {
+			return nil, ErrDecrypt
+		}
+
+		var nonce [secretboxNonceSize]byte
+		copy(nonce[:], box)
+		plain, ok := secretbox.Open(nil, box[secretboxNonceSize:], &nonce, key)
+		if !ok {
+			return nil, ErrDecrypt
+		}
+
+		return plain, nil
+	}
+}
@@ -0,0 +1,73 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestWithDecryptor(t *testing.T) {
+	var key, otherKey [32]byte
+	copy(key[:], "0123456789abcdef0123456789abcdef")
+	copy(otherKey[:], "fedcba9876543210fedcba9876543210")
+
+	secret, err := SecretboxEncrypt(&key, []byte("hunter2"))
+	if err != nil {
+		t.Fatalf("Encrypting, expected no error but got %#v", err)
+	}
+	wrong, _ := SecretboxEncrypt(&otherKey, []byte("nope"))
+
+	_, client := newFakeGist(t, map[string]string{
+		"secret.txt": string(secret),
+		"plain.txt":  "plain",
+		"wrong.txt":  string(wrong),
+	})
+	gfs := NewWithClient(client, referenceGistID, WithDecryptor(SecretboxDecryptor(&key)))
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+
+	t.Run("OK ReadFile", func(t *testing.T) {
+		for name, want := range map[string]string{"secret.txt": "hunter2", "plain.txt": "plain"} {
+			b, err := gfs.ReadFile(name)
+			if err != nil || string(b) != want {
+				t.Errorf("Reading %s, got %q and %#v, want %q", name, b, err, want)
+			}
+		}
+	})
+
+	t.Run("OK Open", func(t *testing.T) {
+		f, err := gfs.Open("secret.txt")
+		if err != nil {
+			t.Fatalf("Opening, expected no error but got %#v", err)
+		}
+		defer f.Close()
+
+		b, _ := io.ReadAll(f)
+		info, _ := f.Stat()
+		if string(b) != "hunter2" || info.Size() != 7 {
+			t.Errorf("Reading an opened file, got %q of size %d, want %q of size 7", b, info.Size(), "hunter2")
+		}
+	})
+
+	t.Run("OK plaintext not held", func(t *testing.T) {
+		f := gfs.Gist().Files["secret.txt"]
+		if got := f.GetContent(); strings.Contains(got, "hunter2") {
+			t.Errorf("Loaded gist, expected the content to stay encrypted but got %q", got)
+		}
+	})
+
+	t.Run("NOK wrong key", func(t *testing.T) {
+		_, err := gfs.ReadFile("wrong.txt")
+		var e *Error
+		if !errors.Is(err, ErrDecrypt) || !errors.As(err, &e) {
+			t.Fatalf("Reading with the wrong key, got %#v, want an *Error matching ErrDecrypt", err)
+		}
+		if _, err := fs.Stat(gfs, "wrong.txt"); !errors.Is(err, ErrDecrypt) {
+			t.Fatalf("Stating with the wrong key, got %#v, want ErrDecrypt", err)
+		}
+	})
+}
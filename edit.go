@@ -0,0 +1,103 @@
+package gistfs
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// Delete deletes the gist on Github. The filesystem is then emptied, its
+// watchers being notified of the removal of all files, and reads fail with
+// ErrNotLoaded. The clone kept by WithGitSnapshot is removed, as by Close.
+//
+// It requires the filesystem to be created with WithWritable.
+func (fsys *FS) Delete(ctx context.Context) error {
+	if !fsys.writable {
+		return ErrReadOnly
+	}
+
+	err := fsys.call(ctx, "delete", func() (*github.Response, error) {
+		return fsys.client.Gists.Delete(ctx, fsys.GetID())
+	})
+	if err != nil {
+		return err
+	}
+
+	fsys.setGist(nil, "", gistExtra{}, fsys.now())
+	fsys.publish(ctx)
+
+	return fsys.Close()
+}
+
+// SetDescription changes the description of the gist on Github, and
+// refreshes the filesystem with the updated gist.
+//
+// It requires the filesystem to be created with WithWritable. Note that
+// Github does not allow changing the visibility of an existing gist.
+func (fsys *FS) SetDescription(ctx context.Context, description string) error {
+	if !fsys.writable {
+		return ErrReadOnly
+	}
+
+	return fsys.edit(ctx, "set description", &github.Gist{Description: &description})
+}
+
+// edit applies changes to the gist on Github, and refreshes the filesystem
+// with the updated gist.
+func (fsys *FS) edit(ctx context.Context, op string, changes *github.Gist) error {
+	var updated restGist
+	err := fsys.call(ctx, op, func() (*github.Response, error) {
+		req, err := fsys.client.NewRequest(http.MethodPatch, "gists/"+fsys.GetID(), changes)
+		if err != nil {
+			return nil, err
+		}
+
+		return fsys.client.Do(ctx, req, &updated)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := fsys.storeEdited(ctx, op, updated.toGist()); err != nil {
+		return err
+	}
+	fsys.publish(ctx)
+
+	return nil
+}
+
+// storeEdited refreshes the filesystem with gist, as returned by the API
+// once changed. As on Load, the content of the files the API truncated is
+// downloaded, unless the filesystem defers it, and the gist is checked
+// according to the options before being served.
+func (fsys *FS) storeEdited(ctx context.Context, op string, gist *Gist) error {
+	extra := newGistExtra(gist)
+	err := fsys.checkSize(gist)
+	if err != nil {
+		err = &Error{Op: op, ID: fsys.GetID(), Err: err}
+	} else if fsys.state().extra.deferContent && !hasUnknownSizes(gist) {
+		extra.deferContent = true
+	} else {
+		err = fsys.call(ctx, op, func() (*github.Response, error) {
+			return nil, fsys.fillTruncated(ctx, gist)
+		})
+	}
+
+	var stored *github.Gist
+	if err == nil {
+		stored = gist.toGithubGist()
+		err = fsys.checkGist(stored, &extra, true)
+	}
+	if err == nil {
+		stored, err = fsys.transformGist(stored)
+	}
+	if err != nil {
+		fsys.update(func(s *state) { s.refreshErr = err })
+		return err
+	}
+
+	fsys.setGist(stored, "", extra, fsys.now())
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDelete(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		gfs := NewWithClient(client, referenceGistID, WithWritable())
+		gfs.Load(context.Background())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events, _ := gfs.Watch(ctx)
+
+		if err := gfs.Delete(context.Background()); err != nil {
+			t.Fatalf("Deleting, expected no error but got %#v", err)
+		}
+
+		if got, want := <-events, (ChangeEvent{Op: Removed, Name: "a.txt"}); got != want {
+			t.Fatalf("Deleting, got event %v, want %v", got, want)
+		}
+
+		if _, err := gfs.ReadFile("a.txt"); err != ErrNotLoaded {
+			t.Fatalf("Reading after deleting, got %#v, want %#v", err, ErrNotLoaded)
+		}
+
+		if err := gfs.Load(context.Background()); !errors.Is(err, ErrGistNotFound) {
+			t.Fatalf("Loading after deleting, got %#v, want %#v", err, ErrGistNotFound)
+		}
+	})
+
+	t.Run("NOK read-only", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		gfs := NewWithClient(client, referenceGistID)
+		gfs.Load(context.Background())
+
+		if err := gfs.Delete(context.Background()); !errors.Is(err, ErrReadOnly) {
+			t.Fatalf("Deleting a read-only gist, got %#v, want %#v", err, ErrReadOnly)
+		}
+
+		if _, err := gfs.ReadFile("a.txt"); err != nil {
+			t.Fatalf("Reading after failing to delete, expected no error but got %#v", err)
+		}
+	})
+}
+
+func TestSetDescription(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		gfs := NewWithClient(client, referenceGistID, WithWritable())
+		gfs.Load(context.Background())
+
+		if err := gfs.SetDescription(context.Background(), "generated"); err != nil {
+			t.Fatalf("Setting the description, expected no error but got %#v", err)
+		}
+
+		if got, want := gfs.Description(), "generated"; got != want {
+			t.Fatalf("Setting the description, got %#v, want %#v", got, want)
+		}
+
+		if got, want := fg.Description, "generated"; got != want {
+			t.Fatalf("Setting the description, got %#v on Github, want %#v", got, want)
+		}
+
+		b, _ := gfs.ReadFile("a.txt")
+		if got, want := string(b), "a"; got != want {
+			t.Fatalf("Reading after setting the description, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK truncated by the API", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"large.txt": "0123456789"})
+		gfs := NewWithClient(client, referenceGistID, WithWritable())
+		gfs.Load(context.Background())
+		fg.SetTruncateSize(4)
+
+		if err := gfs.SetDescription(context.Background(), "generated"); err != nil {
+			t.Fatalf("Setting the description, expected no error but got %#v", err)
+		}
+
+		b, _ := gfs.ReadFile("large.txt")
+		if got, want := string(b), "0123456789"; got != want {
+			t.Fatalf("Reading a file truncated by the API after setting the description, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("NOK read-only", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		gfs := NewWithClient(client, referenceGistID)
+
+		if err := gfs.SetDescription(context.Background(), "nope"); !errors.Is(err, ErrReadOnly) {
+			t.Fatalf("Setting the description of a read-only gist, got %#v, want %#v", err, ErrReadOnly)
+		}
+	})
+}
@@ -0,0 +1,208 @@
+package gistfs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// ErrRateLimited is an error that signals that Github rejected a request
+// because a rate limit was exceeded. Errors matching it with errors.Is can
+// be inspected with errors.As to find when the limit resets.
+var ErrRateLimited = errors.New("rate limited")
+
+// Error records a failed operation on a gist. Errors returned by operations
+// that reach Github are of this type, wrapping the cause of the failure.
+type Error struct {
+	// Op is the failed operation, such as "load".
+	Op string
+	// ID is the ID of the gist.
+	ID string
+	// RequestID is the ID Github gave to the failed request, if any, which
+	// helps when reaching out to Github support.
+	RequestID string
+	// Err is the cause of the failure.
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.RequestID == "" {
+		return fmt.Sprintf("%s gist %s: %v", e.Op, e.ID, e.Err)
+	}
+
+	return fmt.Sprintf("%s gist %s (request %s): %v", e.Op, e.ID, e.RequestID, e.Err)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// ErrStale is matched by the errors returned when reading a gist older than
+// allowed by WithMaxAge or WithMaxAgeRefresh.
+var ErrStale = errors.New("gist is stale")
+
+// StaleError is returned when reading a gist older than allowed by
+// WithMaxAge or WithMaxAgeRefresh. It matches ErrStale with errors.Is.
+type StaleError struct {
+	// Age is how old the loaded gist is, and MaxAge how old it may be.
+	Age    time.Duration
+	MaxAge time.Duration
+	// Err is the error of the last refresh, if it failed.
+	Err error
+}
+
+func (e *StaleError) Error() string {
+	msg := fmt.Sprintf("%v: loaded %v ago, more than %v", ErrStale, e.Age.Round(time.Second), e.MaxAge)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+
+	return msg
+}
+
+// Is makes StaleError match ErrStale.
+func (e *StaleError) Is(target error) bool { return target == ErrStale }
+
+func (e *StaleError) Unwrap() error { return e.Err }
+
+// requestID returns the ID of the Github request that produced resp or err.
+func requestID(resp *github.Response, err error) string {
+	const header = "X-GitHub-Request-Id"
+
+	if resp != nil && resp.Response != nil {
+		return resp.Header.Get(header)
+	}
+
+	var respErr *github.ErrorResponse
+	if errors.As(err, &respErr) && respErr.Response != nil {
+		return respErr.Response.Header.Get(header)
+	}
+
+	return ""
+}
+
+// Errors returned by Load when Github rejects a request for the gist. They
+// wrap the error returned by the Github client, which can still be
+// inspected with errors.As.
+var (
+	// ErrGistNotFound signals that the gist does not exist, or is secret and
+	// not visible with the current credentials.
+	ErrGistNotFound = errors.New("gist not found")
+	// ErrUnauthorized signals that the credentials are invalid, or do not
+	// grant access to the gist.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrGistDeleted signals that the gist existed but has been deleted.
+	ErrGistDeleted = errors.New("gist deleted")
+)
+
+// statusError wraps an error returned by the Github client with the sentinel
+// error matching its HTTP status.
+type statusError struct {
+	sentinel error
+	err      error
+}
+
+func (e *statusError) Error() string        { return fmt.Sprintf("%v: %v", e.sentinel, e.err) }
+func (e *statusError) Unwrap() error        { return e.err }
+func (e *statusError) Is(target error) bool { return target == e.sentinel }
+
+// RateLimitError is returned when Github rejected a request because of its
+// rate limits. It matches ErrRateLimited with errors.Is.
+type RateLimitError struct {
+	// Reset is when requests will be accepted again, or the zero time if
+	// Github did not tell.
+	Reset time.Time
+	// Err is the underlying error returned by the Github client.
+	Err error
+}
+
+func (e *RateLimitError) Error() string {
+	if e.Reset.IsZero() {
+		return fmt.Sprintf("%v: %v", ErrRateLimited, e.Err)
+	}
+
+	return fmt.Sprintf("%v until %v: %v", ErrRateLimited, e.Reset.Format(time.RFC3339), e.Err)
+}
+
+func (e *RateLimitError) Unwrap() error { return e.Err }
+
+// Is makes RateLimitError match ErrRateLimited.
+func (e *RateLimitError) Is(target error) bool { return target == ErrRateLimited }
+
+// RateLimit is the state of the Github API rate limit, as reported by the
+// last response received by a filesystem.
+type RateLimit struct {
+	// Limit is the number of requests allowed per hour.
+	Limit int `json:"limit"`
+	// Remaining is the number of requests left before the limit is reached.
+	Remaining int `json:"remaining"`
+	// Reset is when Remaining goes back to Limit.
+	Reset time.Time `json:"reset"`
+}
+
+// RateLimit returns the rate limit state reported by the last response
+// from the Github API. It is the zero value until a response is received.
+func (fsys *FS) RateLimit() RateLimit {
+	fsys.mu.RLock()
+	defer fsys.mu.RUnlock()
+
+	return fsys.rate
+}
+
+// recordResponse keeps track of the rate limit state reported by resp,
+// which may be nil if the request did not get a response.
+func (fsys *FS) recordResponse(resp *github.Response) {
+	if resp == nil || resp.Response == nil || resp.Rate.Limit == 0 {
+		return
+	}
+
+	if fsys.metrics != nil {
+		fsys.metrics.SetRateLimitRemaining(resp.Rate.Remaining)
+	}
+
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+
+	fsys.rate = RateLimit{
+		Limit:     resp.Rate.Limit,
+		Remaining: resp.Rate.Remaining,
+		Reset:     resp.Rate.Reset.Time,
+	}
+}
+
+// wrapError converts errors returned by the Github client into the errors
+// exposed by this package.
+func (fsys *FS) wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var rateErr *github.RateLimitError
+	if errors.As(err, &rateErr) {
+		return &RateLimitError{Reset: rateErr.Rate.Reset.Time, Err: err}
+	}
+
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		var reset time.Time
+		if abuseErr.RetryAfter != nil {
+			reset = fsys.now().Add(*abuseErr.RetryAfter)
+		}
+		return &RateLimitError{Reset: reset, Err: err}
+	}
+
+	var respErr *github.ErrorResponse
+	if errors.As(err, &respErr) && respErr.Response != nil {
+		switch respErr.Response.StatusCode {
+		case http.StatusNotFound:
+			return &statusError{sentinel: ErrGistNotFound, err: err}
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return &statusError{sentinel: ErrUnauthorized, err: err}
+		case http.StatusGone:
+			return &statusError{sentinel: ErrGistDeleted, err: err}
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,119 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v33/github"
+	"github.com/jhchabran/gistfs/internal/gistserver"
+)
+
+func TestRateLimit(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+	gfs := NewWithClient(client, referenceGistID)
+
+	t.Run("OK zero before any request", func(t *testing.T) {
+		if got, want := gfs.RateLimit(), (RateLimit{}); got != want {
+			t.Fatalf("Rate limit before loading, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK after load", func(t *testing.T) {
+		gfs.Load(context.Background())
+
+		got := gfs.RateLimit()
+		limit := gistserver.DefaultRateLimit
+		if got.Limit != limit || got.Remaining != limit-1 || !got.Reset.Equal(fakeRateReset) {
+			t.Fatalf("Rate limit after loading, got %#v, want %d/%d resetting at %v", got, limit-1, limit, fakeRateReset)
+		}
+	})
+
+	t.Run("NOK rate limited", func(t *testing.T) {
+		fg.SetRateLimit(gistserver.DefaultRateLimit, 0)
+
+		err := gfs.Load(context.Background())
+		if !errors.Is(err, ErrRateLimited) {
+			t.Fatalf("Loading while rate limited, got %#v, want %#v", err, ErrRateLimited)
+		}
+
+		var rateErr *RateLimitError
+		if !errors.As(err, &rateErr) {
+			t.Fatalf("Loading while rate limited, got %#v, want a %T", err, rateErr)
+		}
+
+		if got, want := rateErr.Reset, fakeRateReset; !got.Equal(want) {
+			t.Fatalf("Loading while rate limited, got reset %v, want %v", got, want)
+		}
+
+		if got, want := gfs.RateLimit().Remaining, 0; got != want {
+			t.Fatalf("Rate limit after being limited, got %d remaining, want %d", got, want)
+		}
+	})
+}
+
+func TestStatusErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusNotFound, ErrGistNotFound},
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusForbidden, ErrUnauthorized},
+		{http.StatusGone, ErrGistDeleted},
+	}
+
+	for _, test := range tests {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		fg.SetStatus(test.status)
+
+		err := NewWithClient(client, referenceGistID).Load(context.Background())
+		if !errors.Is(err, test.want) {
+			t.Fatalf("Loading with status %d, got %#v, want %#v", test.status, err, test.want)
+		}
+
+		var respErr *github.ErrorResponse
+		if !errors.As(err, &respErr) {
+			t.Fatalf("Loading with status %d, got %#v, want it to wrap a %T", test.status, err, respErr)
+		}
+	}
+
+	t.Run("OK graphql not found", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+
+		err := NewWithClient(client, "unknown", WithGraphQL("jhchabran")).Load(context.Background())
+		if !errors.Is(err, ErrGistNotFound) {
+			t.Fatalf("Loading an unknown gist through GraphQL, got %#v, want %#v", err, ErrGistNotFound)
+		}
+	})
+}
+
+func TestError(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+	fg.SetStatus(http.StatusNotFound)
+
+	err := NewWithClient(client, referenceGistID).Load(context.Background())
+
+	var gistErr *Error
+	if !errors.As(err, &gistErr) {
+		t.Fatalf("Loading a missing gist, got %#v, want a %T", err, gistErr)
+	}
+
+	if got, want := gistErr.Op, "load"; got != want {
+		t.Fatalf("Loading a missing gist, got op %#v, want %#v", got, want)
+	}
+
+	if got, want := gistErr.ID, referenceGistID; got != want {
+		t.Fatalf("Loading a missing gist, got ID %#v, want %#v", got, want)
+	}
+
+	if got, want := gistErr.RequestID, "FAKE:1"; got != want {
+		t.Fatalf("Loading a missing gist, got request ID %#v, want %#v", got, want)
+	}
+
+	if !strings.Contains(err.Error(), referenceGistID) {
+		t.Fatalf("Loading a missing gist, got message %#v, want it to mention the gist ID", err.Error())
+	}
+}
@@ -0,0 +1,135 @@
+package gistfs
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// ExportToRepo commits the files served by the filesystem to the directory
+// dir of branch in the repository owner/repo, through the git data API, so
+// that a gist can graduate into a repository. It returns the SHA of the
+// commit, or the one of the last commit of branch if it already held the
+// same files.
+//
+// The directory ends up holding the same files as the gist: files of the
+// gist replace the ones of the directory, and files of the directory that
+// are not in the gist are removed. An empty dir exports to the root of the
+// repository. The commit is authored by the user the client of the
+// filesystem is authenticated as, who must be allowed to push to branch.
+func (fsys *FS) ExportToRepo(ctx context.Context, owner, repo, dir, branch string) (string, error) {
+	if s := fsys.state(); s.gist == nil {
+		return "", ErrNotLoaded
+	}
+
+	dir = strings.Trim(dir, "/")
+	if dir == "" {
+		dir = "."
+	}
+	if !fs.ValidPath(dir) {
+		return "", &Error{Op: "export", ID: fsys.GetID(), Err: fmt.Errorf("invalid directory %q", dir)}
+	}
+
+	files := map[string]string{}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		b, err := fsys.ReadFileContext(ctx, name)
+		files[path.Join(dir, name)] = string(b)
+		return err
+	})
+	if err != nil {
+		return "", &Error{Op: "export", ID: fsys.GetID(), Err: err}
+	}
+
+	var head *github.Commit
+	var base *github.Tree
+	err = fsys.call(ctx, "export", func() (*github.Response, error) {
+		ref, resp, err := fsys.client.Git.GetRef(ctx, owner, repo, "heads/"+branch)
+		if err != nil {
+			return resp, err
+		}
+		if head, resp, err = fsys.client.Git.GetCommit(ctx, owner, repo, ref.GetObject().GetSHA()); err != nil {
+			return resp, err
+		}
+		base, resp, err = fsys.client.Git.GetTree(ctx, owner, repo, head.GetTree().GetSHA(), true)
+		return resp, err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	entries := exportEntries(files, dir, base)
+
+	var commit *github.Commit
+	err = fsys.call(ctx, "export", func() (*github.Response, error) {
+		tree, resp, err := fsys.client.Git.CreateTree(ctx, owner, repo, base.GetSHA(), entries)
+		if err != nil {
+			return resp, err
+		}
+		if tree.GetSHA() == base.GetSHA() {
+			commit = head
+			return resp, nil
+		}
+
+		commit, resp, err = fsys.client.Git.CreateCommit(ctx, owner, repo, &github.Commit{
+			Message: github.String("Export gist " + fsys.GetID()),
+			Tree:    &github.Tree{SHA: tree.SHA},
+			Parents: []*github.Commit{{SHA: head.SHA}},
+		})
+		if err != nil {
+			return resp, err
+		}
+
+		_, resp, err = fsys.client.Git.UpdateRef(ctx, owner, repo, &github.Reference{
+			Ref:    github.String("refs/heads/" + branch),
+			Object: &github.GitObject{SHA: commit.SHA},
+		}, false)
+		return resp, err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return commit.GetSHA(), nil
+}
+
+// exportEntries returns the entries of the tree storing files, by path, and
+// removing the other files of base within dir.
+func exportEntries(files map[string]string, dir string, base *github.Tree) []*github.TreeEntry {
+	var entries []*github.TreeEntry
+	for p, content := range files {
+		entries = append(entries, &github.TreeEntry{
+			Path:    github.String(p),
+			Mode:    github.String("100644"),
+			Type:    github.String("blob"),
+			Content: github.String(content),
+		})
+	}
+
+	for _, e := range base.Entries {
+		p := e.GetPath()
+		if e.GetType() != "blob" || (dir != "." && !strings.HasPrefix(p, dir+"/")) {
+			continue
+		}
+		if _, ok := files[p]; !ok {
+			// A tree entry without SHA nor content removes the file.
+			entries = append(entries, &github.TreeEntry{
+				Path: github.String(p),
+				Mode: github.String("100644"),
+				Type: github.String("blob"),
+			})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].GetPath() < entries[j].GetPath() })
+
+	return entries
+}
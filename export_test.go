@@ -0,0 +1,72 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestExportToRepo(t *testing.T) {
+	ctx := context.Background()
+
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "a", "b.txt": "b"})
+	repo := fg.AddRepo("jhchabran", "tools", "main", map[string]string{
+		"README.md":      "tools",
+		"gist/old.txt":   "old",
+		"gist/b.txt":     "previous",
+		"gists/keep.txt": "keep",
+	})
+
+	gfs := NewWithClient(client, referenceGistID)
+	gfs.Load(ctx)
+
+	t.Run("OK", func(t *testing.T) {
+		sha, err := gfs.ExportToRepo(ctx, "jhchabran", "tools", "gist", "main")
+		if err != nil {
+			t.Fatalf("Exporting, expected no error but got %#v", err)
+		}
+		if sha == "" {
+			t.Fatal("Exporting, got no commit SHA")
+		}
+
+		want := map[string]string{
+			"README.md":      "tools",
+			"gist/a.txt":     "a",
+			"gist/b.txt":     "b",
+			"gists/keep.txt": "keep",
+		}
+		if got := repo.Files("main"); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Exporting, got files %v, want %v", got, want)
+		}
+		if got, want := repo.Message("main"), "Export gist "+referenceGistID; got != want {
+			t.Fatalf("Exporting, got commit message %q, want %q", got, want)
+		}
+
+		again, err := gfs.ExportToRepo(ctx, "jhchabran", "tools", "/gist/", "main")
+		if err != nil {
+			t.Fatalf("Exporting again, expected no error but got %#v", err)
+		}
+		if again != sha {
+			t.Fatalf("Exporting unchanged files, got commit %s, want the last one %s", again, sha)
+		}
+	})
+
+	t.Run("NOK unknown branch", func(t *testing.T) {
+		if _, err := gfs.ExportToRepo(ctx, "jhchabran", "tools", "gist", "missing"); err == nil {
+			t.Fatal("Exporting, expected an error but got none")
+		}
+	})
+
+	t.Run("NOK invalid directory", func(t *testing.T) {
+		if _, err := gfs.ExportToRepo(ctx, "jhchabran", "tools", "../gist", "main"); err == nil {
+			t.Fatal("Exporting, expected an error but got none")
+		}
+	})
+
+	t.Run("NOK not loaded", func(t *testing.T) {
+		if _, err := New(referenceGistID).ExportToRepo(ctx, "jhchabran", "tools", "", "main"); !errors.Is(err, ErrNotLoaded) {
+			t.Fatalf("Exporting, got %#v, want ErrNotLoaded", err)
+		}
+	})
+}
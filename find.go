@@ -0,0 +1,100 @@
+package gistfs
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// gistsPerPage is the number of gists requested per page when listing the
+// gists of a user, the maximum allowed by Github.
+const gistsPerPage = 100
+
+// FindByDescription returns the loaded filesystem of the most recently
+// updated gist of user whose description matches pattern, created with
+// client, or an unauthenticated one if nil, and opts. It lets gists be
+// referred to by a meaningful description rather than by their ID.
+//
+// Pattern has the syntax of path.Match, so that a description without
+// special characters only matches itself; note that '*' does not match '/'.
+// Secret gists are only listed when client is authenticated as user. If no
+// gist matches, the returned *Error matches ErrGistNotFound with errors.Is.
+func FindByDescription(ctx context.Context, client *github.Client, user, pattern string, opts ...Option) (*FS, error) {
+	fss, err := findByDescription(ctx, client, user, pattern, true, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return fss[0], nil
+}
+
+// FindAllByDescription returns the loaded filesystems of all the gists of
+// user whose description matches pattern, as FindByDescription does, most
+// recently updated first. It returns no error if no gist matches.
+func FindAllByDescription(ctx context.Context, client *github.Client, user, pattern string, opts ...Option) ([]*FS, error) {
+	return findByDescription(ctx, client, user, pattern, false, opts)
+}
+
+// findByDescription lists the gists of user until it finds one matching
+// pattern if first is set, or all of them otherwise, and loads them.
+func findByDescription(ctx context.Context, client *github.Client, user, pattern string, first bool, opts []Option) ([]*FS, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, &Error{Op: "find", Err: err}
+	}
+	if client == nil {
+		client = github.NewClient(nil)
+	}
+
+	// The listing is made through a filesystem, so that it is throttled and
+	// retried as set by opts.
+	lister := NewWithClient(client, "", opts...)
+
+	var fss []*FS
+	listOpts := &github.GistListOptions{ListOptions: github.ListOptions{PerPage: gistsPerPage}}
+	for page := 1; page != 0; {
+		var batch []*github.Gist
+		var next int
+
+		listOpts.Page = page
+		err := lister.call(ctx, "find", func() (*github.Response, error) {
+			var resp *github.Response
+			var err error
+			batch, resp, err = client.Gists.List(ctx, user, listOpts)
+			if resp != nil {
+				next = resp.NextPage
+			}
+			return resp, err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, g := range batch {
+			if ok, _ := path.Match(pattern, g.GetDescription()); !ok {
+				continue
+			}
+
+			fss = append(fss, NewWithClient(client, g.GetID(), opts...))
+			if first {
+				break
+			}
+		}
+		if first && len(fss) > 0 {
+			break
+		}
+
+		page = next
+	}
+
+	if first && len(fss) == 0 {
+		return nil, &Error{Op: "find", Err: fmt.Errorf("no gist of %s described as %q: %w", user, pattern, ErrGistNotFound)}
+	}
+
+	if err := LoadAll(ctx, fss...); err != nil {
+		return nil, err
+	}
+
+	return fss, nil
+}
@@ -0,0 +1,64 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jhchabran/gistfs/internal/gistserver"
+)
+
+func TestFindByDescription(t *testing.T) {
+	srv := gistserver.New()
+	defer srv.Close()
+
+	for id, desc := range map[string]string{"a1": "config: staging", "a2": "config: prod", "a3": "notes"} {
+		g := srv.AddGist(id, map[string]string{"f.txt": id})
+		g.Description = desc
+		g.Owner = "alice"
+	}
+	other := srv.AddGist("b1", map[string]string{"f.txt": "b1"})
+	other.Description = "config: prod"
+	other.Owner = "bob"
+
+	ctx := context.Background()
+	client := srv.GithubClient()
+
+	t.Run("OK first", func(t *testing.T) {
+		fsys, err := FindByDescription(ctx, client, "alice", "config: prod")
+		if err != nil {
+			t.Fatalf("Finding, expected no error but got %#v", err)
+		}
+		if fsys.GetID() != "a2" || !fsys.IsLoaded() {
+			t.Fatalf("Finding, got gist %s loaded %v, want a2 loaded", fsys.GetID(), fsys.IsLoaded())
+		}
+	})
+
+	t.Run("OK all", func(t *testing.T) {
+		fss, err := FindAllByDescription(ctx, client, "alice", "config: *")
+		if err != nil {
+			t.Fatalf("Finding, expected no error but got %#v", err)
+		}
+		if len(fss) != 2 || fss[0].GetID() != "a1" || fss[1].GetID() != "a2" {
+			t.Fatalf("Finding, got %d gists, want a1 and a2", len(fss))
+		}
+		if b, _ := fss[1].ReadFile("f.txt"); string(b) != "a2" {
+			t.Errorf("Reading a found gist, got %q, want %q", b, "a2")
+		}
+	})
+
+	t.Run("NOK no match", func(t *testing.T) {
+		if _, err := FindByDescription(ctx, client, "alice", "missing"); !errors.Is(err, ErrGistNotFound) {
+			t.Fatalf("Finding, got %#v, want ErrGistNotFound", err)
+		}
+		if fss, err := FindAllByDescription(ctx, client, "alice", "missing"); err != nil || len(fss) != 0 {
+			t.Fatalf("Finding all, got %d gists and %#v, want none and no error", len(fss), err)
+		}
+	})
+
+	t.Run("NOK bad pattern", func(t *testing.T) {
+		if _, err := FindByDescription(ctx, client, "alice", "["); err == nil {
+			t.Fatal("Finding with a malformed pattern, expected an error")
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package gistfs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// forksPerPage is the number of forks requested per page, the maximum
+// allowed by Github.
+const forksPerPage = 100
+
+// ForkParent returns the gist the loaded gist was forked from, or nil if it
+// is not a fork, if the filesystem is not loaded, or if the backend fetching
+// the gist does not tell.
+func (fsys *FS) ForkParent() *GistRef {
+	s := fsys.state()
+	if s.extra.forkOf == nil {
+		return nil
+	}
+
+	ref := *s.extra.forkOf
+	return &ref
+}
+
+// Forks lists the forks of the gist, oldest first. Forks of forks are not
+// included.
+func (fsys *FS) Forks(ctx context.Context) ([]GistRef, error) {
+	var forks []GistRef
+
+	for page := 1; page != 0; {
+		var batch []*github.Gist
+		var next int
+
+		err := fsys.call(ctx, "list forks", func() (*github.Response, error) {
+			u := fmt.Sprintf("gists/%s/forks?per_page=%d&page=%d", fsys.GetID(), forksPerPage, page)
+			req, err := fsys.client.NewRequest(http.MethodGet, u, nil)
+			if err != nil {
+				return nil, err
+			}
+
+			resp, err := fsys.client.Do(ctx, req, &batch)
+			if resp != nil {
+				next = resp.NextPage
+			}
+			return resp, err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, g := range batch {
+			forks = append(forks, GistRef{
+				ID:        g.GetID(),
+				Owner:     g.GetOwner().GetLogin(),
+				HTMLURL:   g.GetHTMLURL(),
+				CreatedAt: g.GetCreatedAt(),
+				UpdatedAt: g.GetUpdatedAt(),
+			})
+		}
+
+		page = next
+	}
+
+	return forks, nil
+}
+
+// Fork forks the gist under the user the filesystem is authenticated as, and
+// returns a filesystem for the fork, created with the same client and options
+// and loaded with its content.
+func (fsys *FS) Fork(ctx context.Context) (*FS, error) {
+	var fork *github.Gist
+	err := fsys.call(ctx, "fork", func() (resp *github.Response, err error) {
+		fork, resp, err = fsys.client.Gists.Fork(ctx, fsys.GetID())
+		return resp, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	forked := newFS(fsys.client, fork.GetID(), fsys.opts)
+	if err := forked.Load(ctx); err != nil {
+		return nil, err
+	}
+
+	return forked, nil
+}
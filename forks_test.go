@@ -0,0 +1,109 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestForks(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+	fg.Owner = "jhchabran"
+	for _, id := range []string{"fork2", "fork1"} {
+		fork := fg.AddGist(id, map[string]string{"a.txt": "forked"})
+		fork.Owner = "someone"
+		fork.ForkOf = referenceGistID
+	}
+
+	t.Run("OK parent", func(t *testing.T) {
+		gfs := NewWithClient(client, "fork1")
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading a fork, expected no error but got %#v", err)
+		}
+
+		parent := gfs.ForkParent()
+		if parent == nil {
+			t.Fatal("Loading a fork, got no parent, want one")
+		}
+
+		if got, want := *parent, (GistRef{
+			ID:        referenceGistID,
+			Owner:     "jhchabran",
+			HTMLURL:   "https://gist.github.com/" + referenceGistID,
+			CreatedAt: parent.CreatedAt,
+			UpdatedAt: fakeUpdatedAt,
+		}); got != want {
+			t.Fatalf("Loading a fork, got parent %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("OK not a fork", func(t *testing.T) {
+		gfs := NewWithClient(client, referenceGistID)
+		if got := gfs.ForkParent(); got != nil {
+			t.Fatalf("Parent before loading, got %+v, want nil", got)
+		}
+
+		gfs.Load(context.Background())
+		if got := gfs.ForkParent(); got != nil {
+			t.Fatalf("Parent of a gist which is not a fork, got %+v, want nil", got)
+		}
+	})
+
+	t.Run("OK list", func(t *testing.T) {
+		forks, err := NewWithClient(client, referenceGistID).Forks(context.Background())
+		if err != nil {
+			t.Fatalf("Listing forks, expected no error but got %#v", err)
+		}
+
+		if got, want := len(forks), 2; got != want {
+			t.Fatalf("Listing forks, got %d, want %d", got, want)
+		}
+
+		if got, want := forks[0].ID+","+forks[0].Owner, "fork1,someone"; got != want {
+			t.Fatalf("Listing forks, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("NOK", func(t *testing.T) {
+		fg.SetStatus(http.StatusNotFound)
+		defer fg.SetStatus(0)
+
+		_, err := NewWithClient(client, referenceGistID).Forks(context.Background())
+		if !errors.Is(err, ErrGistNotFound) {
+			t.Fatalf("Listing forks of a missing gist, got %#v, want %#v", err, ErrGistNotFound)
+		}
+	})
+}
+
+func TestFork(t *testing.T) {
+	_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+
+	gfs := NewWithClient(client, referenceGistID, WithWritable())
+
+	fork, err := gfs.Fork(context.Background())
+	if err != nil {
+		t.Fatalf("Forking, expected no error but got %#v", err)
+	}
+
+	if fork.GetID() == referenceGistID {
+		t.Fatal("Forking, got a filesystem for the same gist, want one for the fork")
+	}
+
+	b, err := fork.ReadFile("a.txt")
+	if err != nil {
+		t.Fatalf("Reading the fork, expected no error but got %#v", err)
+	}
+
+	if got, want := string(b), "a"; got != want {
+		t.Fatalf("Reading the fork, got %#v, want %#v", got, want)
+	}
+
+	if parent := fork.ForkParent(); parent == nil || parent.ID != referenceGistID {
+		t.Fatalf("Forking, got parent %+v, want %#v", parent, referenceGistID)
+	}
+
+	if !fork.writable {
+		t.Fatal("Forking, got a fork without the options of its parent")
+	}
+}
@@ -0,0 +1,205 @@
+// Package frontmatter reads Markdown files starting with front matter, such
+// as the posts of a blog stored in a gist, exposing their metadata apart from
+// their body:
+//
+//	gfs := gistfs.New(id)
+//	...
+//	posts, err := frontmatter.List(gfs)
+//	for _, post := range posts {
+//		fmt.Println(post.Date().Format("2006-01-02"), post.Title())
+//	}
+//
+// Front matter is YAML between "---" lines, or TOML between "+++" lines.
+// Files can be read from any fs.FS.
+package frontmatter
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the format of front matter.
+type Format string
+
+// Formats of front matter, told apart by their delimiter.
+const (
+	// None is the format of documents without front matter.
+	None Format = ""
+	YAML Format = "yaml"
+	TOML Format = "toml"
+)
+
+// formats are the formats of front matter by the line surrounding it.
+var formats = map[string]Format{
+	"---": YAML,
+	"+++": TOML,
+}
+
+// ErrUnterminated is returned when front matter is not followed by its
+// closing delimiter.
+var ErrUnterminated = errors.New("front matter is not terminated")
+
+// Document is a Markdown file, split into its front matter and its body.
+type Document struct {
+	// Name is the path of the file, empty for documents given to Parse.
+	Name string
+	// Format is the format of the front matter, None if there is none.
+	Format Format
+	// Meta holds the front matter decoded into a map, and is empty if there
+	// is none.
+	Meta map[string]interface{}
+	// Body is the content following the front matter.
+	Body []byte
+
+	raw []byte
+}
+
+// Parse splits b into its front matter, decoded into Meta, and its body.
+// Content without front matter is returned whole as Body.
+func Parse(b []byte) (*Document, error) {
+	doc := &Document{Meta: map[string]interface{}{}, Body: b}
+
+	delim, rest := cutLine(b)
+	format, ok := formats[string(delim)]
+	if !ok {
+		return doc, nil
+	}
+
+	start := len(b) - len(rest)
+	for len(rest) > 0 {
+		end := len(b) - len(rest)
+
+		var line []byte
+		line, rest = cutLine(rest)
+		if !bytes.Equal(line, delim) {
+			continue
+		}
+
+		doc.Format, doc.raw, doc.Body = format, b[start:end], rest
+		if err := doc.Decode(&doc.Meta); err != nil {
+			return nil, err
+		}
+		return doc, nil
+	}
+
+	return nil, ErrUnterminated
+}
+
+// cutLine returns the first line of b, without its line ending, and what
+// follows it.
+func cutLine(b []byte) (line, rest []byte) {
+	i := bytes.IndexByte(b, '\n')
+	if i < 0 {
+		return b, nil
+	}
+
+	return bytes.TrimSuffix(b[:i], []byte("\r")), b[i+1:]
+}
+
+// Decode decodes the front matter into the value v points to, such as
+// a struct with yaml or toml field tags. It does nothing for documents
+// without front matter.
+func (d *Document) Decode(v interface{}) error {
+	switch d.Format {
+	case YAML:
+		return yaml.Unmarshal(d.raw, v)
+	case TOML:
+		_, err := toml.NewDecoder(bytes.NewReader(d.raw)).Decode(v)
+		return err
+	}
+
+	return nil
+}
+
+// Title returns the "title" field of the front matter, or an empty string.
+func (d *Document) Title() string {
+	title, _ := d.Meta["title"].(string)
+	return title
+}
+
+// Date returns the "date" field of the front matter, given as a timestamp
+// or as a string in the RFC 3339 or "2006-01-02" layouts, or the zero time.
+func (d *Document) Date() time.Time {
+	switch date := d.Meta["date"].(type) {
+	case time.Time:
+		return date
+	case string:
+		for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+			if t, err := time.Parse(layout, date); err == nil {
+				return t
+			}
+		}
+	}
+
+	return time.Time{}
+}
+
+// Read reads and parses the named file of fsys.
+func Read(fsys fs.FS, name string) (*Document, error) {
+	b, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := Parse(b)
+	if err != nil {
+		return nil, &fs.PathError{Op: "parse", Path: name, Err: err}
+	}
+	doc.Name = name
+
+	return doc, nil
+}
+
+// List reads and parses all the Markdown files of fsys, whose extension is
+// ".md" or ".markdown", and returns them sorted by date, the most recent
+// first, then by title and by name. Files without a date come last.
+func List(fsys fs.FS) ([]*Document, error) {
+	var docs []*Document
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !isMarkdown(name) {
+			return err
+		}
+
+		doc, err := Read(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		docs = append(docs, doc)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(docs, func(i, j int) bool {
+		a, b := docs[i], docs[j]
+		if da, db := a.Date(), b.Date(); !da.Equal(db) {
+			return da.After(db)
+		}
+		if a.Title() != b.Title() {
+			return a.Title() < b.Title()
+		}
+		return a.Name < b.Name
+	})
+
+	return docs, nil
+}
+
+// isMarkdown reports whether name is the one of a Markdown file.
+func isMarkdown(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".md", ".markdown":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,99 @@
+package frontmatter
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/jhchabran/gistfs/gisttest"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]struct {
+		content string
+		format  Format
+		title   string
+		body    string
+	}{
+		"yaml":    {"---\ntitle: Hello\ndate: 2024-01-02\n---\n# Hello\n", YAML, "Hello", "# Hello\n"},
+		"toml":    {"+++\r\ntitle = \"Hello\"\r\n+++\r\nbody", TOML, "Hello", "body"},
+		"none":    {"# Hello\n", None, "", "# Hello\n"},
+		"empty":   {"---\n---\nbody", YAML, "", "body"},
+		"nothing": {"", None, "", ""},
+	}
+	for name, tt := range tests {
+		t.Run("OK "+name, func(t *testing.T) {
+			doc, err := Parse([]byte(tt.content))
+			if err != nil {
+				t.Fatalf("Parsing, expected no error but got %#v", err)
+			}
+
+			if doc.Format != tt.format || doc.Title() != tt.title || string(doc.Body) != tt.body {
+				t.Fatalf("Parsing, got %q front matter titled %q and body %q, want %q titled %q and %q", doc.Format, doc.Title(), doc.Body, tt.format, tt.title, tt.body)
+			}
+		})
+	}
+
+	t.Run("OK decode", func(t *testing.T) {
+		doc, _ := Parse([]byte("---\ntitle: Hello\ntags: [a, b]\n---\n"))
+
+		var meta struct {
+			Title string   `yaml:"title"`
+			Tags  []string `yaml:"tags"`
+		}
+		if err := doc.Decode(&meta); err != nil || meta.Title != "Hello" || len(meta.Tags) != 2 {
+			t.Fatalf("Decoding, got %+v (%v), want the front matter", meta, err)
+		}
+	})
+
+	t.Run("NOK unterminated", func(t *testing.T) {
+		if _, err := Parse([]byte("---\ntitle: Hello\n")); !errors.Is(err, ErrUnterminated) {
+			t.Fatalf("Parsing unterminated front matter, got %#v, want %#v", err, ErrUnterminated)
+		}
+	})
+}
+
+func TestList(t *testing.T) {
+	gfs, _ := gisttest.NewFS(t, map[string]string{
+		"old.md":     "---\ntitle: Old\ndate: 2023-05-01\n---\nold",
+		"new.md":     "+++\ntitle = \"New\"\ndate = 2024-02-03T10:00:00Z\n+++\nnew",
+		"draft.md":   "---\ntitle: Draft\n---\ndraft",
+		"about.md":   "---\ntitle: About\n---\nabout",
+		"notes.txt":  "---\ntitle: Notes\n---\n",
+		"style.css":  "body {}",
+		"readme.MD":  "no front matter",
+		"bad.md.txt": "---\n",
+	})
+
+	docs, err := List(gfs)
+	if err != nil {
+		t.Fatalf("Listing, expected no error but got %#v", err)
+	}
+
+	want := []string{"new.md", "old.md", "readme.MD", "about.md", "draft.md"}
+	if len(docs) != len(want) {
+		t.Fatalf("Listing, got %d documents, want %v", len(docs), want)
+	}
+	for i, doc := range docs {
+		if doc.Name != want[i] {
+			t.Fatalf("Listing, got %s at %d, want %s", doc.Name, i, want[i])
+		}
+	}
+
+	if got, want := docs[0].Date(), time.Date(2024, 2, 3, 10, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("Listing, got date %v, want %v", got, want)
+	}
+	if got, want := docs[1].Date(), time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("Listing, got date %v, want %v", got, want)
+	}
+
+	t.Run("NOK unterminated", func(t *testing.T) {
+		gfs, _ := gisttest.NewFS(t, map[string]string{"bad.md": "---\ntitle: Bad\n"})
+
+		var pathErr *fs.PathError
+		if _, err := List(gfs); !errors.As(err, &pathErr) || pathErr.Path != "bad.md" || !errors.Is(err, ErrUnterminated) {
+			t.Fatalf("Listing a document with unterminated front matter, got %#v, want a *fs.PathError", err)
+		}
+	})
+}
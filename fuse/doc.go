@@ -0,0 +1,15 @@
+// Package fuse mounts a gistfs.FS as a local read-only filesystem, using
+// FUSE:
+//
+//	srv, err := fuse.Mount("/mnt/gist", gfs)
+//	if err != nil {
+//		panic(err)
+//	}
+//	srv.Wait()
+//
+// Files and directories are looked up in the FS on each access, so that a
+// filesystem refreshing itself, as one created WithTTL, is reflected in the
+// mount once the kernel cache of entries and attributes expires.
+//
+// It is only available on Linux and macOS.
+package fuse
@@ -0,0 +1,152 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package fuse
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path"
+	"syscall"
+	"time"
+
+	fusefs "github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+	"github.com/jhchabran/gistfs"
+)
+
+// cacheTimeout is how long the kernel caches entries and attributes, hence
+// how long a refresh of the filesystem may take to be visible.
+const cacheTimeout = time.Second
+
+// node is a file or directory of the mounted filesystem.
+type node struct {
+	fusefs.Inode
+
+	fsys *gistfs.FS
+	name string
+}
+
+var (
+	_ fusefs.NodeLookuper  = (*node)(nil)
+	_ fusefs.NodeReaddirer = (*node)(nil)
+	_ fusefs.NodeGetattrer = (*node)(nil)
+	_ fusefs.NodeOpener    = (*node)(nil)
+	_ fusefs.NodeReader    = (*node)(nil)
+)
+
+// Mount mounts fsys, which must be loaded beforehand, read-only at dir. The
+// returned server serves requests until it is unmounted with its Unmount
+// method.
+func Mount(dir string, fsys *gistfs.FS) (*fuse.Server, error) {
+	timeout := cacheTimeout
+	root := &node{fsys: fsys, name: "."}
+
+	return fusefs.Mount(dir, root, &fusefs.Options{
+		MountOptions: fuse.MountOptions{
+			FsName:  "gist:" + fsys.GetID(),
+			Name:    "gistfs",
+			Options: []string{"ro"},
+		},
+		EntryTimeout: &timeout,
+		AttrTimeout:  &timeout,
+	})
+}
+
+// errno returns the errno matching err.
+func errno(err error) syscall.Errno {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, fs.ErrNotExist):
+		return syscall.ENOENT
+	case errors.Is(err, fs.ErrPermission):
+		return syscall.EACCES
+	default:
+		return syscall.EIO
+	}
+}
+
+// fillAttr fills out with the attributes of a file described by info.
+func fillAttr(info fs.FileInfo, out *fuse.Attr) {
+	out.Mode = uint32(info.Mode().Perm())
+	if info.IsDir() {
+		out.Mode |= syscall.S_IFDIR
+	} else {
+		out.Mode |= syscall.S_IFREG
+		out.Size = uint64(info.Size())
+	}
+	out.SetTimes(nil, timePtr(info.ModTime()), nil)
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func (n *node) Lookup(ctx context.Context, name string, out *fuse.EntryOut) (*fusefs.Inode, syscall.Errno) {
+	p := path.Join(n.name, name)
+
+	info, err := fs.Stat(n.fsys, p)
+	if err != nil {
+		return nil, errno(err)
+	}
+	fillAttr(info, &out.Attr)
+
+	child := &node{fsys: n.fsys, name: p}
+	return n.NewInode(ctx, child, fusefs.StableAttr{Mode: out.Attr.Mode & syscall.S_IFMT}), 0
+}
+
+func (n *node) Readdir(ctx context.Context) (fusefs.DirStream, syscall.Errno) {
+	entries, err := n.fsys.ReadDirContext(ctx, n.name)
+	if err != nil {
+		return nil, errno(err)
+	}
+
+	list := make([]fuse.DirEntry, 0, len(entries))
+	for _, entry := range entries {
+		mode := uint32(syscall.S_IFREG)
+		if entry.IsDir() {
+			mode = syscall.S_IFDIR
+		}
+		list = append(list, fuse.DirEntry{Name: entry.Name(), Mode: mode})
+	}
+
+	return fusefs.NewListDirStream(list), 0
+}
+
+func (n *node) Getattr(ctx context.Context, f fusefs.FileHandle, out *fuse.AttrOut) syscall.Errno {
+	info, err := fs.Stat(n.fsys, n.name)
+	if err != nil {
+		return errno(err)
+	}
+	fillAttr(info, &out.Attr)
+
+	return 0
+}
+
+// Open denies write access. Files are read through the node, with no handle.
+func (n *node) Open(ctx context.Context, flags uint32) (fusefs.FileHandle, uint32, syscall.Errno) {
+	if flags&(syscall.O_WRONLY|syscall.O_RDWR|syscall.O_TRUNC|syscall.O_APPEND) != 0 {
+		return nil, 0, syscall.EROFS
+	}
+
+	return nil, 0, 0
+}
+
+func (n *node) Read(ctx context.Context, f fusefs.FileHandle, dest []byte, off int64) (fuse.ReadResult, syscall.Errno) {
+	content, err := n.fsys.ReadFileContext(ctx, n.name)
+	if err != nil {
+		return nil, errno(err)
+	}
+
+	if off >= int64(len(content)) {
+		return fuse.ReadResultData(nil), 0
+	}
+	content = content[off:]
+	if len(content) > len(dest) {
+		content = content[:len(dest)]
+	}
+
+	return fuse.ReadResultData(content), 0
+}
@@ -0,0 +1,114 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package fuse
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/hanwen/go-fuse/v2/fuse"
+	"github.com/jhchabran/gistfs/gisttest"
+)
+
+func TestNode(t *testing.T) {
+	gfs, _ := gisttest.NewFS(t, map[string]string{"a.txt": "0123456789"})
+	ctx := context.Background()
+	root := &node{fsys: gfs, name: "."}
+	file := &node{fsys: gfs, name: "a.txt"}
+
+	t.Run("OK getattr", func(t *testing.T) {
+		var out fuse.AttrOut
+		if errno := file.Getattr(ctx, nil, &out); errno != 0 {
+			t.Fatalf("Getting the attributes of a file, expected no error but got %v", errno)
+		}
+		if got, want := out.Mode, uint32(syscall.S_IFREG|0444); got != want {
+			t.Fatalf("Getting the attributes of a file, got mode %o, want %o", got, want)
+		}
+		if got, want := out.Size, uint64(10); got != want {
+			t.Fatalf("Getting the attributes of a file, got size %d, want %d", got, want)
+		}
+
+		if errno := root.Getattr(ctx, nil, &out); errno != 0 {
+			t.Fatalf("Getting the attributes of the root, expected no error but got %v", errno)
+		}
+		if out.Mode&syscall.S_IFDIR == 0 {
+			t.Fatalf("Getting the attributes of the root, got mode %o, want a directory", out.Mode)
+		}
+	})
+
+	t.Run("OK readdir", func(t *testing.T) {
+		ds, errno := root.Readdir(ctx)
+		if errno != 0 {
+			t.Fatalf("Listing the root, expected no error but got %v", errno)
+		}
+
+		var names []string
+		for ds.HasNext() {
+			entry, _ := ds.Next()
+			names = append(names, entry.Name)
+		}
+		if len(names) != 1 || names[0] != "a.txt" {
+			t.Fatalf("Listing the root, got %v, want [a.txt]", names)
+		}
+	})
+
+	t.Run("OK read", func(t *testing.T) {
+		for off, want := range map[int64]string{0: "0123", 8: "89", 20: ""} {
+			res, errno := file.Read(ctx, nil, make([]byte, 4), off)
+			if errno != 0 {
+				t.Fatalf("Reading at %d, expected no error but got %v", off, errno)
+			}
+
+			b, _ := res.Bytes(nil)
+			if got := string(b); got != want {
+				t.Fatalf("Reading at %d, got %#v, want %#v", off, got, want)
+			}
+		}
+	})
+
+	t.Run("NOK write", func(t *testing.T) {
+		if _, _, errno := file.Open(ctx, syscall.O_RDWR); errno != syscall.EROFS {
+			t.Fatalf("Opening a file for writing, got %v, want EROFS", errno)
+		}
+	})
+
+	t.Run("NOK not found", func(t *testing.T) {
+		var out fuse.AttrOut
+		missing := &node{fsys: gfs, name: "b.txt"}
+		if errno := missing.Getattr(ctx, nil, &out); errno != syscall.ENOENT {
+			t.Fatalf("Getting the attributes of a missing file, got %v, want ENOENT", errno)
+		}
+	})
+}
+
+func TestMount(t *testing.T) {
+	if _, err := exec.LookPath("fusermount"); err != nil {
+		t.Skip("fusermount is not available")
+	}
+
+	gfs, _ := gisttest.NewFS(t, map[string]string{"a.txt": "a"})
+	dir := t.TempDir()
+
+	srv, err := Mount(dir, gfs)
+	if err != nil {
+		t.Skipf("Mounting is not permitted: %v", err)
+	}
+	defer srv.Unmount()
+
+	b, err := os.ReadFile(filepath.Join(dir, "a.txt"))
+	if err != nil {
+		t.Fatalf("Reading a mounted file, expected no error but got %#v", err)
+	}
+	if got, want := string(b), "a"; got != want {
+		t.Fatalf("Reading a mounted file, got %#v, want %#v", got, want)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "b.txt"), []byte("b"), 0644); err == nil {
+		t.Fatalf("Writing a mounted file, expected an error but got none")
+	}
+}
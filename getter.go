@@ -0,0 +1,355 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// GistGetter retrieves gists from a backend. The filesystem fetches gists
+// through a GistGetter backed by the go-github client by default, and
+// NewWithGetter allows providing another one, to mock the Github API in
+// tests, to use another version of go-github or an entirely different store.
+//
+// Implementations must be safe for concurrent use.
+type GistGetter interface {
+	// GetGist returns the latest revision of the gist identified by id,
+	// along with an etag identifying its content. If etag is not empty and
+	// the gist content still matches it, GetGist may return ErrNotModified
+	// instead. Implementations not supporting conditional requests can
+	// ignore etag and return an empty one.
+	GetGist(ctx context.Context, id, etag string) (*Gist, string, error)
+	// GetGistRevision returns the gist identified by id as it was at the
+	// given revision SHA.
+	GetGistRevision(ctx context.Context, id, sha string) (*Gist, error)
+	// GetRaw returns the raw content of a file, given its GistFile.RawURL.
+	// It is used to retrieve truncated files, whose content is too large to
+	// be returned along with the gist.
+	GetRaw(ctx context.Context, rawURL string) ([]byte, error)
+}
+
+// ErrNotModified is returned by a GistGetter when the gist content matches
+// the etag given to GetGist.
+var ErrNotModified = errors.New("gist not modified")
+
+// Gist is a gist as returned by a GistGetter.
+type Gist struct {
+	ID          string
+	Description string
+	Public      bool
+	Owner       string
+	HTMLURL     string
+	GitPullURL  string
+	Comments    int
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Files       map[string]GistFile
+	// Revision is the SHA of the revision of the gist, if known.
+	Revision string
+	// ForkOf is the gist this one was forked from, if any.
+	ForkOf *GistRef
+}
+
+// GistRef identifies a gist related to another one, such as the one it was
+// forked from.
+type GistRef struct {
+	ID        string    `json:"id"`
+	Owner     string    `json:"owner,omitempty"`
+	HTMLURL   string    `json:"html_url,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GistFile is a file of a Gist.
+type GistFile struct {
+	Filename string
+	Type     string
+	Language string
+	RawURL   string
+	// Size is the size of the file, or 0 if unknown, in which case the file
+	// must be Truncated.
+	Size int
+	// Truncated is set when Content only holds the beginning of the file,
+	// which must then be fetched from RawURL.
+	Truncated bool
+	Content   string
+}
+
+// NewWithGetter returns a FS based on a given Gist ID, fetching it through
+// getter rather than the go-github client.
+//
+// Options configuring the Github client, such as WithToken, have no effect on
+// fetching the gist, which is up to getter.
+func NewWithGetter(getter GistGetter, id string, opts ...Option) *FS {
+	return newFS(nil, id, append([]Option{withGetter(getter)}, opts...))
+}
+
+func withGetter(getter GistGetter) Option {
+	return func(fsys *FS) {
+		fsys.getter = getter
+	}
+}
+
+// githubGetter is the GistGetter fetching gists with the go-github client of
+// the filesystem, recording the rate limit reported by each response.
+type githubGetter struct {
+	fsys *FS
+}
+
+func (g *githubGetter) GetGist(ctx context.Context, id, etag string) (*Gist, string, error) {
+	client := g.fsys.client
+
+	req, err := client.NewRequest(http.MethodGet, "gists/"+id, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	gist := new(restGist)
+	resp, err := client.Do(ctx, req, gist)
+	g.fsys.recordResponse(resp)
+	g.fsys.traceResponse(ctx, resp)
+	if resp != nil && resp.Response != nil && resp.StatusCode == http.StatusNotModified {
+		return nil, etag, ErrNotModified
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return gist.toGist(), resp.Header.Get("ETag"), nil
+}
+
+func (g *githubGetter) GetGistRevision(ctx context.Context, id, sha string) (*Gist, error) {
+	req, err := g.fsys.client.NewRequest(http.MethodGet, "gists/"+id+"/"+sha, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	gist := new(restGist)
+	resp, err := g.fsys.client.Do(ctx, req, gist)
+	g.fsys.recordResponse(resp)
+	g.fsys.traceResponse(ctx, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return gist.toGist(), nil
+}
+
+func (g *githubGetter) GetRaw(ctx context.Context, rawURL string) ([]byte, error) {
+	client := g.fsys.client
+
+	req, err := client.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &rawBuffer{max: rawLimit(ctx)}
+	resp, err := client.Do(ctx, req, buf)
+	g.fsys.recordResponse(resp)
+	g.fsys.traceResponse(ctx, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.buf.Bytes(), nil
+}
+
+// restGist is a gist as returned by the REST API, along with its history
+// and the gist it was forked from, which go-github v33 does not decode.
+type restGist struct {
+	github.Gist
+	History []struct {
+		Version string `json:"version"`
+	} `json:"history"`
+	ForkOf *github.Gist `json:"fork_of"`
+}
+
+func (g *restGist) toGist() *Gist {
+	gist := fromGithubGist(&g.Gist)
+	if len(g.History) > 0 {
+		gist.Revision = g.History[0].Version
+	}
+
+	if g.ForkOf != nil {
+		gist.ForkOf = &GistRef{
+			ID:        g.ForkOf.GetID(),
+			Owner:     g.ForkOf.GetOwner().GetLogin(),
+			HTMLURL:   g.ForkOf.GetHTMLURL(),
+			CreatedAt: g.ForkOf.GetCreatedAt(),
+			UpdatedAt: g.ForkOf.GetUpdatedAt(),
+		}
+	}
+
+	return gist
+}
+
+// fromGithubGist converts a gist returned by go-github.
+func fromGithubGist(g *github.Gist) *Gist {
+	gist := &Gist{
+		ID:          g.GetID(),
+		Description: g.GetDescription(),
+		Public:      g.GetPublic(),
+		Owner:       g.GetOwner().GetLogin(),
+		HTMLURL:     g.GetHTMLURL(),
+		GitPullURL:  g.GetGitPullURL(),
+		Comments:    g.GetComments(),
+		CreatedAt:   g.GetCreatedAt(),
+		UpdatedAt:   g.GetUpdatedAt(),
+		Files:       make(map[string]GistFile, len(g.Files)),
+	}
+
+	for name, f := range g.Files {
+		gist.Files[string(name)] = GistFile{
+			Filename:  f.GetFilename(),
+			Type:      f.GetType(),
+			Language:  f.GetLanguage(),
+			RawURL:    f.GetRawURL(),
+			Size:      f.GetSize(),
+			Truncated: len(f.GetContent()) < f.GetSize(),
+			Content:   f.GetContent(),
+		}
+	}
+
+	return gist
+}
+
+// isTruncated reports whether the content of f, a file of a loaded gist, was
+// truncated by the API, which go-github v33 does not expose. Files whose
+// content is not held in Content, because it is stored compressed, are not
+// truncated.
+func isTruncated(f github.GistFile) bool {
+	return f.Content != nil && len(*f.Content) < f.GetSize()
+}
+
+// toGithubGist converts a gist to the go-github representation the
+// filesystem is built on.
+func (g *Gist) toGithubGist() *github.Gist {
+	gist := &github.Gist{
+		ID:          github.String(g.ID),
+		Description: github.String(g.Description),
+		Public:      github.Bool(g.Public),
+		HTMLURL:     github.String(g.HTMLURL),
+		GitPullURL:  github.String(g.GitPullURL),
+		Comments:    github.Int(g.Comments),
+		Files:       make(map[github.GistFilename]github.GistFile, len(g.Files)),
+	}
+
+	if g.Owner != "" {
+		gist.Owner = &github.User{Login: github.String(g.Owner)}
+	}
+	if !g.CreatedAt.IsZero() {
+		gist.CreatedAt = &g.CreatedAt
+	}
+	if !g.UpdatedAt.IsZero() {
+		gist.UpdatedAt = &g.UpdatedAt
+	}
+
+	for name, f := range g.Files {
+		filename := f.Filename
+		if filename == "" {
+			filename = name
+		}
+
+		gist.Files[github.GistFilename(name)] = github.GistFile{
+			Filename: github.String(filename),
+			Type:     optionalString(f.Type),
+			Language: optionalString(f.Language),
+			RawURL:   optionalString(f.RawURL),
+			Size:     github.Int(f.Size),
+			Content:  github.String(f.Content),
+		}
+	}
+
+	return gist
+}
+
+// optionalString returns a pointer to s, or nil if s is empty.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// hasUnknownSizes reports whether the size of some files of gist is unknown.
+func hasUnknownSizes(gist *Gist) bool {
+	for _, f := range gist.Files {
+		if f.Truncated && f.Size == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fillTruncated replaces the content of the truncated files of gist with
+// their raw content, downloading them concurrently. Each download waits for
+// the limiter of the filesystem, as the request of the gist did. It fails
+// once the content held and downloaded so far exceeds the limit set by
+// WithMaxTotalSize, which checkSize can't enforce when sizes are unknown.
+func (fsys *FS) fillTruncated(ctx context.Context, gist *Gist) error {
+	var (
+		names                 []string
+		inlineBytes, allBytes int64
+	)
+	for name, f := range gist.Files {
+		allBytes += int64(f.Size)
+		if f.Truncated && f.RawURL != "" {
+			names = append(names, name)
+		} else {
+			inlineBytes += int64(len(f.Content))
+		}
+	}
+
+	p := fsys.startProgress("load", len(gist.Files), allBytes)
+	p.add("", len(gist.Files)-len(names), inlineBytes)
+
+	total := inlineBytes
+	contents := make([][]byte, len(names))
+	err := fsys.fetchAll(ctx, len(names), func(ctx context.Context, i int) error {
+		if err := fsys.wait(ctx); err != nil {
+			return err
+		}
+
+		ctx, span := fsys.startSpan(ctx, "gistfs.GetRaw", names[i])
+		start := fsys.now()
+		b, err := fsys.getRaw(ctx, gist.Files[names[i]].RawURL)
+		fsys.logRaw(ctx, names[i], start, len(b), err)
+		endSpan(span, len(b), err)
+		if err != nil {
+			return err
+		}
+
+		if err := fsys.checkFileSize(names[i], int64(len(b))); err != nil {
+			return err
+		}
+		if err := fsys.checkTotalSize(atomic.AddInt64(&total, int64(len(b)))); err != nil {
+			return err
+		}
+
+		contents[i] = b
+		p.add(names[i], 1, int64(len(b)))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, name := range names {
+		f := gist.Files[name]
+		f.Content = string(contents[i])
+		f.Size = len(contents[i])
+		f.Truncated = false
+		gist.Files[name] = f
+	}
+
+	return nil
+}
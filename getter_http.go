@@ -0,0 +1,133 @@
+package gistfs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// defaultBaseURL is the address of the Github REST API.
+const defaultBaseURL = "https://api.github.com/"
+
+// httpGetter is a GistGetter calling the Github REST API with a plain
+// http.Client.
+type httpGetter struct {
+	client  *http.Client
+	baseURL *url.URL
+	// userAgent is sent as the User-Agent header of requests, if set.
+	userAgent string
+}
+
+// NewHTTPGetter returns a GistGetter calling the Github REST API at baseURL
+// with httpClient, without going through a go-github client. An empty baseURL
+// targets api.github.com.
+//
+// It allows applications depending on another major version of go-github to
+// share the authentication and transport of their own client with the
+// filesystem, through the http.Client it exposes:
+//
+//	getter, err := gistfs.NewHTTPGetter(client.Client(), client.BaseURL.String())
+//	...
+//	fsys := gistfs.NewWithGetter(getter, id)
+func NewHTTPGetter(httpClient *http.Client, baseURL string) (GistGetter, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &httpGetter{client: httpClient, baseURL: u}, nil
+}
+
+func (g *httpGetter) GetGist(ctx context.Context, id, etag string) (*Gist, string, error) {
+	header := http.Header{}
+	if etag != "" {
+		header.Set("If-None-Match", etag)
+	}
+
+	gist := new(restGist)
+	resp, err := g.do(ctx, "gists/"+id, header, gist)
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, etag, ErrNotModified
+	}
+
+	return gist.toGist(), resp.Header.Get("ETag"), nil
+}
+
+func (g *httpGetter) GetGistRevision(ctx context.Context, id, sha string) (*Gist, error) {
+	gist := new(restGist)
+	if _, err := g.do(ctx, "gists/"+id+"/"+sha, nil, gist); err != nil {
+		return nil, err
+	}
+
+	return gist.toGist(), nil
+}
+
+func (g *httpGetter) GetRaw(ctx context.Context, rawURL string) ([]byte, error) {
+	var b []byte
+	_, err := g.do(ctx, rawURL, nil, &b)
+
+	return b, err
+}
+
+// do sends a GET request to path, relative to the base URL, and decodes the
+// JSON response into v, or copies it as is when v is a *[]byte. Error
+// responses are turned into the errors go-github returns, which the
+// filesystem knows how to handle.
+func (g *httpGetter) do(ctx context.Context, path string, header http.Header, v interface{}) (*http.Response, error) {
+	u, err := g.baseURL.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	if g.userAgent != "" {
+		req.Header.Set("User-Agent", g.userAgent)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return resp, nil
+	}
+
+	if err := github.CheckResponse(resp); err != nil {
+		return resp, err
+	}
+
+	if b, ok := v.(*[]byte); ok {
+		*b, err = readRaw(ctx, resp.Body)
+		return resp, err
+	}
+
+	return resp, json.NewDecoder(resp.Body).Decode(v)
+}
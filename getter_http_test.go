@@ -0,0 +1,60 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestNewHTTPGetter(t *testing.T) {
+	fg, _ := newFakeGist(t, map[string]string{"a.txt": "a"})
+	fg.AddRevision("abc", map[string]string{"a.txt": "old"})
+
+	getter, err := NewHTTPGetter(fg.Client(), fg.URL)
+	if err != nil {
+		t.Fatalf("Creating a getter, expected no error but got %#v", err)
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		gfs := NewWithGetter(getter, referenceGistID)
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading over http, expected no error but got %#v", err)
+		}
+
+		b, _ := gfs.ReadFile("a.txt")
+		if got, want := string(b), "a"; got != want {
+			t.Fatalf("Reading a file, got %#v, want %#v", got, want)
+		}
+
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Refreshing over http, expected no error but got %#v", err)
+		}
+
+		if got, want := fg.LastHeader().Get("If-None-Match"), ""; got == want {
+			t.Fatal("Refreshing over http, got an unconditional request, want a conditional one")
+		}
+	})
+
+	t.Run("OK revision", func(t *testing.T) {
+		gfs := NewWithGetter(getter, referenceGistID, WithRevision("abc"))
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading a revision over http, expected no error but got %#v", err)
+		}
+
+		b, _ := gfs.ReadFile("a.txt")
+		if got, want := string(b), "old"; got != want {
+			t.Fatalf("Reading a revision, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("NOK not found", func(t *testing.T) {
+		fg.SetStatus(http.StatusNotFound)
+		defer fg.SetStatus(0)
+
+		err := NewWithGetter(getter, referenceGistID).Load(context.Background())
+		if !errors.Is(err, ErrGistNotFound) {
+			t.Fatalf("Loading a missing gist over http, got %#v, want %#v", err, ErrGistNotFound)
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// stubGetter is a GistGetter serving a gist from memory.
+type stubGetter struct {
+	gist *Gist
+	etag string
+	raw  map[string]string
+
+	mu       sync.Mutex
+	etags    []string
+	rawCalls int
+}
+
+func (g *stubGetter) GetGist(ctx context.Context, id, etag string) (*Gist, string, error) {
+	g.mu.Lock()
+	g.etags = append(g.etags, etag)
+	g.mu.Unlock()
+
+	if g.gist == nil || g.gist.ID != id {
+		return nil, "", ErrGistNotFound
+	}
+
+	if etag != "" && etag == g.etag {
+		return nil, etag, ErrNotModified
+	}
+
+	// Hand out a copy, as the filesystem fills truncated files in place.
+	gist := *g.gist
+	gist.Files = make(map[string]GistFile, len(g.gist.Files))
+	for name, f := range g.gist.Files {
+		gist.Files[name] = f
+	}
+
+	return &gist, g.etag, nil
+}
+
+func (g *stubGetter) GetGistRevision(ctx context.Context, id, sha string) (*Gist, error) {
+	if sha != "abc" {
+		return nil, ErrGistNotFound
+	}
+
+	return &Gist{ID: id, Files: map[string]GistFile{"a.txt": {Content: "old"}}}, nil
+}
+
+func (g *stubGetter) GetRaw(ctx context.Context, rawURL string) ([]byte, error) {
+	g.mu.Lock()
+	g.rawCalls++
+	g.mu.Unlock()
+
+	content, ok := g.raw[rawURL]
+	if !ok {
+		return nil, errors.New("no raw content")
+	}
+
+	return []byte(content), nil
+}
+
+func TestNewWithGetter(t *testing.T) {
+	getter := &stubGetter{
+		gist: &Gist{
+			ID:          referenceGistID,
+			Description: "stubbed",
+			Files: map[string]GistFile{
+				"a.txt":   {Filename: "a.txt", Content: "a", Size: 1},
+				"big.txt": {Filename: "big.txt", Content: "big", Size: 9, Truncated: true, RawURL: "https://raw/big.txt"},
+			},
+		},
+		etag: `"v1"`,
+		raw:  map[string]string{"https://raw/big.txt": "big file!"},
+	}
+
+	gfs := NewWithGetter(getter, referenceGistID)
+
+	t.Run("OK", func(t *testing.T) {
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading through a getter, expected no error but got %#v", err)
+		}
+
+		b, _ := gfs.ReadFile("a.txt")
+		if got, want := string(b), "a"; got != want {
+			t.Fatalf("Reading a file, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK truncated file", func(t *testing.T) {
+		b, _ := gfs.ReadFile("big.txt")
+		if got, want := string(b), "big file!"; got != want {
+			t.Fatalf("Reading a truncated file, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK not modified", func(t *testing.T) {
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Refreshing an unchanged gist, expected no error but got %#v", err)
+		}
+
+		if got, want := getter.etags[len(getter.etags)-1], `"v1"`; got != want {
+			t.Fatalf("Refreshing, got etag %#v, want %#v", got, want)
+		}
+
+		b, _ := gfs.ReadFile("big.txt")
+		if got, want := string(b), "big file!"; got != want {
+			t.Fatalf("Reading after an unchanged refresh, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK revision", func(t *testing.T) {
+		pinned := NewWithGetter(getter, referenceGistID, WithRevision("abc"))
+		if err := pinned.Load(context.Background()); err != nil {
+			t.Fatalf("Loading a revision, expected no error but got %#v", err)
+		}
+
+		b, _ := pinned.ReadFile("a.txt")
+		if got, want := string(b), "old"; got != want {
+			t.Fatalf("Reading a revision, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("NOK error", func(t *testing.T) {
+		err := NewWithGetter(getter, "unknown").Load(context.Background())
+
+		var gistErr *Error
+		if !errors.As(err, &gistErr) || !errors.Is(err, ErrGistNotFound) {
+			t.Fatalf("Loading an unknown gist, got %#v, want an *Error wrapping %#v", err, ErrGistNotFound)
+		}
+	})
+}
@@ -1,16 +1,22 @@
 package gistfs
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"net/http"
+	"path"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/go-github/v33/github"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/oauth2"
 )
 
 // Ensure io/fs interfaces are implemented
@@ -21,6 +27,8 @@ var (
 	_ fs.FileInfo    = (*file)(nil)
 	_ fs.DirEntry    = (*file)(nil)
 	_ fs.ReadDirFile = (*file)(nil)
+	_ io.Seeker      = (*file)(nil)
+	_ io.ReaderAt    = (*file)(nil)
 )
 
 // ErrNotLoaded is an error that signals that the filesystem is being used
@@ -29,132 +37,953 @@ var ErrNotLoaded = fmt.Errorf("gist not loaded: %w", fs.ErrInvalid)
 
 // FS represents a filesystem based on a Github Gist.
 type FS struct {
-	id     string
 	client *github.Client
-	gist   *github.Gist
-	mu     sync.RWMutex
+
+	// current holds the *state served by the filesystem, which reads load
+	// without locking.
+	current atomic.Value
+	// mu serializes changes to current, and guards rate.
+	mu sync.RWMutex
+
+	// opts are the options the filesystem was created with.
+	opts []Option
+
+	// freshness tells how long the loaded gist is served, and refreshes it.
+	freshness freshness
+	// maxAge is how old the loaded gist can be to be served, if set, and
+	// maxAgeRefresh loads it again when it is older, rather than failing.
+	maxAge        time.Duration
+	maxAgeRefresh bool
+	// loadTimeout bounds loads given a context without deadline, if set.
+	loadTimeout time.Duration
+	readCtx     context.Context
+	progress    func(ProgressEvent)
+	decryptor   func(name string, ciphertext []byte) ([]byte, error)
+	// fallback serves reads while the gist can't be, if set.
+	fallback fs.FS
+	// cache stores loaded gists across processes, if set.
+	cache Cache
+	// notifier tells other instances that the gist changed, if set.
+	notifier Notifier
+	// lru evicts the loaded gist when other filesystems are used, if set.
+	lru *LRU
+	// idleTimeout unloads the gist once it is not read for that long, if
+	// set, with idle.
+	idleTimeout time.Duration
+	idle        *idleTimer
+	// baseHTTPClient is the http.Client used by the client built by New.
+	baseHTTPClient *http.Client
+	// userAgent is sent with every request made by the filesystem, unless
+	// the Github client was provided by the caller.
+	userAgent string
+	// requestHooks are called around the requests of the client built by
+	// New, if set.
+	requestHooks *RequestHooks
+	// tokenSource authenticates the requests of the client built by New.
+	tokenSource oauth2.TokenSource
+	// getter fetches the gist, using client unless given to NewWithGetter.
+	getter GistGetter
+	// anonymous loads the gist from gist.github.com, see NewAnonymous.
+	anonymous bool
+
+	// revision is the SHA of the revision the filesystem is pinned to, if any.
+	revision string
+	// graphQLOwner is the login of the gist owner, set when the gist is
+	// fetched through the GraphQL API rather than the REST one.
+	graphQLOwner string
+
+	// rate is the rate limit state reported by the last response.
+	rate RateLimit
+
+	// retryPolicy tells how failed requests are retried.
+	retryPolicy RetryPolicy
+
+	// breaker stops requests after repeated failures, if configured.
+	breaker *circuitBreaker
+
+	// limiter throttles requests to Github, if configured.
+	limiter *Limiter
+
+	// writable allows operations that modify the gist.
+	writable bool
+	// writeDebounce is how long WriteFile waits for other writes before
+	// storing the files in writes, if set.
+	writeDebounce time.Duration
+	writes        writeBuffer
+	// gitWrites pushes changes to files to gitRemote, described by gitCommit
+	// by default, rather than through the REST API.
+	gitWrites bool
+	gitCommit GitCommit
+	gitRemote string
+	// gitSnapshot reads files from the git repository of the gist, rather
+	// than from the REST API, fetched into gitRepo.
+	gitSnapshot bool
+	gitRepo     gitRepo
+
+	// compress stores the content of files compressed.
+	compress bool
+	// interner deduplicates the content of files.
+	interner *Interner
+
+	// maxFileSize and maxTotalSize limit the size of the gist, if set.
+	maxFileSize  int64
+	maxTotalSize int64
+	// fetchConcurrency is how many files are downloaded at once.
+	fetchConcurrency int
+
+	// fileHashes holds the *fileHashes of the last loaded gist hashed.
+	fileHashes atomic.Value
+
+	// transforms rewrite the content of files when they are loaded.
+	transforms []contentTransform
+	// gunzipNames is set to decompress gzipped files, see WithGunzip.
+	gunzipNames GzipNames
+
+	// virtuals generate the virtual files served along the gist ones.
+	virtuals []virtualFiles
+	// defaultMode is the mode of files, unless extModes holds one for their
+	// extension.
+	defaultMode fs.FileMode
+	extModes    map[string]fs.FileMode
+	// pathSeparator is replaced by slashes in file names to serve them in
+	// directories, if set.
+	pathSeparator string
+	// caseInsensitive makes lookups ignore the case of names.
+	caseInsensitive bool
+	// windowsPaths accepts paths separated by backslashes.
+	windowsPaths bool
+
+	// verifyIntegrity checks content against the blob SHAs of files.
+	verifyIntegrity bool
+	// verifySignature checks the signature of the manifest of the gist,
+	// signedManifest, held by signatureFile.
+	verifySignature func(message, signature []byte) error
+	signedManifest  string
+	signatureFile   string
+
+	// visibility restricts the gists loaded by their visibility.
+	visibility visibility
+
+	// withComments fetches the comments of the gist on load.
+	withComments bool
+
+	// logger logs loads, refreshes, raw fetches and retries, if set.
+	logger logger
+
+	// tracer records spans, if set.
+	tracer trace.Tracer
+	// metrics receives measurements of the filesystem, if set.
+	metrics MetricsRecorder
+
+	// now returns the current time, and is overridden in tests.
+	now func() time.Time
+
+	// loading is the Load call in progress, if any.
+	loading *loadCall
+	loadMu  sync.Mutex
+
+	watchers watchers
 }
 
 // New returns a FS based on a given Gist ID, without the username portion.
 // Example "https://gist.github.com/jhchabran/ded2f6727d98e6b0095e62a7813aa7cf"
 //    id = "ded2f6727d98e6b0095e62a7813aa7cf"
-func New(id string) *FS {
-	return &FS{
-		client: github.NewClient(nil),
-		id:     id,
-	}
+func New(id string, opts ...Option) *FS {
+	return newFS(nil, id, opts)
 }
 
 // NewWithClient returns a FS based on a given Gist ID and a given Github Client.
 // Providing an authenticated client or a client with a custom http.Client are
 // possible use cases.
-func NewWithClient(client *github.Client, id string) *FS {
-	return &FS{
+//
+// Options configuring the http.Client, such as WithToken, have no effect on
+// the given client.
+func NewWithClient(client *github.Client, id string, opts ...Option) *FS {
+	return newFS(client, id, opts)
+}
+
+// NewWithHTTPClient returns a FS based on a given Gist ID, whose requests to
+// the Github API are made with httpClient. Caching transports, proxies or
+// instrumented clients are possible use cases.
+func NewWithHTTPClient(httpClient *http.Client, id string, opts ...Option) *FS {
+	return newFS(nil, id, append([]Option{withBaseHTTPClient(httpClient)}, opts...))
+}
+
+// NewFromGist returns a FS serving an already fetched gist, without making
+// any request to the Github API. The filesystem is ready for use, and calling
+// Load refreshes it from Github.
+//
+// Files whose content the gist lacks, as for gists listed with
+// Gists.List, are downloaded from their raw URL when first read, as after
+// LoadMetadata.
+//
+// The gist is checked as Load checks the gists it fetches. If it is not
+// allowed by the options, for example by WithPublicOnly or
+// WithSignedManifest, or if its files can't be decompressed as set by
+// WithGunzip, the filesystem is not loaded, and DebugState reports why.
+func NewFromGist(gist *github.Gist, opts ...Option) *FS {
+	fsys := New(gist.GetID(), opts...)
+
+	gist, deferred := deferMissingContent(gist)
+	err := fsys.restore(gist, "", gistExtra{deferContent: deferred}, fsys.now(), false)
+	if err != nil {
+		fsys.update(func(s *state) { s.refreshErr = err })
+	}
+
+	return fsys
+}
+
+// deferMissingContent returns gist with the files it holds without content
+// made truncated, so that their content is fetched when first read, and
+// reports whether there were any.
+func deferMissingContent(gist *github.Gist) (*github.Gist, bool) {
+	var deferred bool
+	for _, f := range gist.Files {
+		if f.Content == nil && f.GetSize() > 0 {
+			deferred = true
+			break
+		}
+	}
+	if !deferred {
+		return gist, false
+	}
+
+	// The gist belongs to the caller, so a copy is modified.
+	g := *gist
+	g.Files = make(map[github.GistFilename]github.GistFile, len(gist.Files))
+	for name, f := range gist.Files {
+		if f.Content == nil && f.GetSize() > 0 {
+			f.Content = github.String("")
+		}
+		g.Files[name] = f
+	}
+
+	return &g, true
+}
+
+func newFS(client *github.Client, id string, opts []Option) *FS {
+	fsys := &FS{
 		client: client,
-		id:     id,
+		opts:   opts,
+		now:    time.Now,
+
+		defaultMode: 0444,
+	}
+	fsys.current.Store(&state{id: id})
+
+	for _, opt := range opts {
+		opt(fsys)
+	}
+
+	if fsys.client == nil {
+		fsys.client = github.NewClient(fsys.httpClient())
+		if fsys.userAgent != "" {
+			fsys.client.UserAgent = fsys.userAgent
+		}
+	}
+
+	if fsys.getter == nil && fsys.anonymous {
+		// gistWebURL is valid, so this can't fail.
+		g, _ := NewRawGetter(fsys.anonymousHTTPClient(), gistWebURL)
+		g.(*rawGetter).userAgent = fsys.userAgent
+		fsys.getter = g
+	}
+	if fsys.getter == nil {
+		fsys.getter = &githubGetter{fsys: fsys}
 	}
+
+	if fsys.interner == nil {
+		fsys.interner = NewInterner()
+	}
+
+	return fsys
 }
 
 // GetID returns the Github Gist ID that the filesystem was created with
 func (fsys *FS) GetID() string {
-	return fsys.id
+	return fsys.state().id
 }
 
 // Load fetches the gist content from github, making the file system ready
 // for use. If the underlying Github API call fails, it will return its error.
+//
+// Calling Load on an already loaded filesystem refreshes its content, and
+// notifies watchers of the files that changed in between.
+//
+// Concurrent calls are collapsed into a single API request, whose result is
+// shared among all callers.
 func (fsys *FS) Load(ctx context.Context) error {
+	return fsys.loadShared(ctx, false)
+}
+
+// loadShared runs load, collapsing concurrent calls. A call fetching the
+// content of all files is shared with callers deferring it, but not the other
+// way around.
+func (fsys *FS) loadShared(ctx context.Context, deferContent bool) error {
+	for {
+		fsys.loadMu.Lock()
+		c := fsys.loading
+		if c == nil {
+			break
+		}
+		fsys.loadMu.Unlock()
+
+		select {
+		case <-c.done:
+			// A load canceled by the caller it ran for is tried again by
+			// the ones still waiting for it.
+			if errors.Is(c.err, context.Canceled) && ctx.Err() == nil {
+				continue
+			}
+			if !c.deferContent || deferContent {
+				return c.err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	c := &loadCall{done: make(chan struct{}), deferContent: deferContent}
+	fsys.loading = c
+	fsys.loadMu.Unlock()
+
+	c.err = fsys.load(ctx, deferContent)
+
+	fsys.loadMu.Lock()
+	fsys.loading = nil
+	fsys.loadMu.Unlock()
+	close(c.done)
+
+	return c.err
+}
+
+// loadCall is a Load in progress, that concurrent callers wait for.
+type loadCall struct {
+	done         chan struct{}
+	deferContent bool
+	err          error
+}
+
+func (fsys *FS) load(ctx context.Context, deferContent bool) (err error) {
+	ctx, cancel := fsys.withLoadTimeout(ctx)
+	defer cancel()
+
+	ctx, span := fsys.startSpan(ctx, "gistfs.Load", "")
+	size := -1
+	start := fsys.now()
+	defer func() {
+		endSpan(span, size, err)
+		if fsys.metrics != nil {
+			fsys.metrics.ObserveLoad(fsys.GetID(), fsys.now().Sub(start), err)
+		}
+	}()
+
+	if fsys.cache != nil && fsys.state().gist == nil {
+		// Revisions never change, so a cached one is as good as a fetched one.
+		if fsys.restoreCache(ctx) && fsys.revision != "" {
+			return nil
+		}
+	}
+
+	if fsys.breaker != nil && !fsys.breaker.allow(fsys.now()) {
+		err := &Error{Op: "load", ID: fsys.GetID(), Err: ErrCircuitOpen}
+		fsys.update(func(s *state) { s.refreshErr = err })
+
+		return err
+	}
+
+	gist, etag, extra, err := fsys.fetch(ctx, deferContent)
+
+	var comments []GistComment
+	if err == nil && fsys.withComments {
+		comments, err = fsys.fetchComments(ctx)
+	}
+
+	if fsys.breaker != nil {
+		fsys.breaker.record(err, fsys.now())
+	}
+	if err == nil {
+		err = fsys.checkGist(gist, &extra, gist != fsys.state().gist)
+	}
+	if err == nil && gist != fsys.state().gist {
+		gist, err = fsys.transformGist(gist)
+	}
+	if err != nil {
+		fsys.update(func(s *state) { s.refreshErr = err })
+		fsys.logLoad(ctx, start, nil, false, err)
+
+		return err
+	}
+
+	// The loaded gist is returned as is when Github reports it unchanged.
+	notModified := gist == fsys.state().gist
+	fsys.logLoad(ctx, start, gist, notModified, nil)
+	size = gistSize(gist)
+	span.SetAttributes(attribute.Bool("gist.not_modified", notModified))
+
+	if fsys.withComments {
+		fsys.update(func(s *state) { s.comments = comments })
+	}
+
+	loaded := fsys.IsLoaded()
+	if changes := fsys.setGist(gist, etag, extra, fsys.now()); loaded && len(changes) > 0 {
+		fsys.publish(ctx)
+	}
+
+	if fsys.cache != nil && !notModified && !extra.deferContent {
+		fsys.storeCache(ctx)
+	}
+
+	return nil
+}
+
+// checkGist checks that gist can be served, according to the options of the
+// filesystem. Its integrity and signature are only verified if verify is
+// set, for content that was not verified before, the hashes of the signed
+// manifest being stored in extra.
+func (fsys *FS) checkGist(gist *github.Gist, extra *gistExtra, verify bool) error {
+	if fsys.visibility != anyVisibility {
+		if err := fsys.checkVisibility(gist); err != nil {
+			return err
+		}
+	}
+	if fsys.caseInsensitive {
+		if err := fsys.checkCaseCollisions(gist); err != nil {
+			return err
+		}
+	}
+	if !verify {
+		return nil
+	}
+
+	if fsys.verifyIntegrity {
+		if err := fsys.verifyGist(gist); err != nil {
+			return err
+		}
+	}
+	if fsys.verifySignature != nil {
+		signed, err := fsys.verifySignedGist(gist)
+		if err != nil {
+			return err
+		}
+		extra.signed = signed
+	}
+
+	return nil
+}
+
+// withLoadTimeout returns ctx with the deadline set by WithLoadTimeout, if
+// any and if ctx has none.
+func (fsys *FS) withLoadTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if fsys.loadTimeout <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, fsys.loadTimeout)
+}
+
+// setGist replaces the gist served by the filesystem, notifies watchers of
+// the files that changed, and returns these changes.
+func (fsys *FS) setGist(gist *github.Gist, etag string, extra gistExtra, loadedAt time.Time) []ChangeEvent {
+	if fsys.compress {
+		gist, extra.compressed = compressGist(gist, fsys.state().extra.compressed)
+	}
+	gist, extra = fsys.interner.internGist(gist, extra)
+
+	old := fsys.update(func(s *state) {
+		s.gist = gist
+		s.etag = etag
+		s.extra = extra
+		s.loadedAt = loadedAt
+		s.refreshErr = nil
+		s.evicted = nil
+	})
+
+	fsys.interner.releaseGist(old.gist, old.extra)
+	var changes []ChangeEvent
+	if old.evicted != nil {
+		changes = old.evicted.changes(gist, extra)
+	} else {
+		changes = diffGists(old.gist, old.extra, gist, extra)
+	}
+	fsys.notify(changes)
+
+	// A deleted gist is no longer held, so there is nothing to evict or to
+	// unload.
+	if gist == nil {
+		if fsys.lru != nil {
+			fsys.lru.remove(fsys)
+		}
+		fsys.stopIdleTimer()
+
+		return changes
+	}
+
+	if fsys.lru != nil {
+		fsys.lru.touch(fsys, int64(gistSize(gist)))
+	}
+	fsys.startIdleTimer()
+
+	return changes
+}
+
+// state is what the filesystem serves: the loaded gist and what comes with
+// it. It is never modified once stored, but replaced as a whole, so that
+// reads can use it without locking.
+type state struct {
+	// id is the ID of the gist, set when the filesystem is created, or once
+	// the gist is known for filesystems created without one.
+	id   string
+	gist *github.Gist
+	// etag identifies the content of gist for conditional requests.
+	etag string
+	// extra is what is known about gist beyond what github.Gist holds.
+	extra gistExtra
+	// loadedAt is when gist was last fetched.
+	loadedAt time.Time
+	// refreshErr is the error of the last load, if it failed.
+	refreshErr error
+	// comments are the comments of gist, if withComments is set.
+	comments []GistComment
+	// evicted is set when gist was evicted by the LRU of the filesystem.
+	evicted *evictedGist
+	// index holds the files served for gist, and is nil when gist is.
+	index *fileIndex
+}
+
+// state returns the current state of the filesystem.
+func (fsys *FS) state() *state {
+	return fsys.current.Load().(*state)
+}
+
+// update replaces the state of the filesystem with a copy modified by fn,
+// and returns the previous one. The files of the gist are indexed again if
+// fn replaced it.
+func (fsys *FS) update(fn func(s *state)) *state {
 	fsys.mu.Lock()
 	defer fsys.mu.Unlock()
 
-	gist, _, err := fsys.client.Gists.Get(ctx, fsys.id)
+	old := fsys.state()
+	s := *old
+	fn(&s)
+	if s.gist != old.gist {
+		s.index = nil
+		if s.gist != nil {
+			s.index = fsys.newFileIndex(&s)
+		}
+	}
+	fsys.current.Store(&s)
+
+	return old
+}
+
+// gistExtra holds what is known about a gist beyond what github.Gist
+// represents.
+type gistExtra struct {
+	// sha is the SHA of the revision of the gist, if known.
+	sha string
+	// forkOf is the gist it was forked from, if any.
+	forkOf *GistRef
+	// deferContent is set when the content of truncated files was not
+	// fetched, see LoadMetadata.
+	deferContent bool
+	// compressed holds the gzip-compressed content of files by name, when
+	// stored with WithCompression.
+	compressed map[github.GistFilename]string
+	// signed holds the SHA-256 of files by name, as given by the manifest
+	// verified with WithSignedManifest.
+	signed map[github.GistFilename]string
+}
+
+func newGistExtra(gist *Gist) gistExtra {
+	return gistExtra{sha: gist.Revision, forkOf: gist.ForkOf}
+}
+
+// fetch retrieves the gist from the Github API, using the configured backend.
+// The returned etag identifies the fetched content when the backend supports
+// conditional requests. If deferContent is set, truncated files are left as
+// is rather than fetched from their raw URL.
+func (fsys *FS) fetch(ctx context.Context, deferContent bool) (gist *github.Gist, etag string, extra gistExtra, err error) {
+	// Files read from git don't need to be fetched from their raw URL.
+	deferRaw := deferContent || fsys.gitSnapshot
+
+	err = fsys.call(ctx, "load", func() (resp *github.Response, err error) {
+		switch {
+		case fsys.revision != "":
+			gist, extra, err = fsys.getRevision(ctx, deferRaw)
+		case fsys.graphQLOwner != "":
+			gist, resp, err = fsys.fetchGraphQL(ctx)
+			if err == nil {
+				err = fsys.checkSize(fromGithubGist(gist))
+			}
+			if err == nil {
+				fsys.reportFetched(gist)
+			}
+		default:
+			gist, etag, extra, err = fsys.getGist(ctx, deferRaw)
+		}
+		return resp, err
+	})
+
+	if err == nil && fsys.gitSnapshot && !deferContent && gist != fsys.state().gist {
+		gist, extra, err = fsys.readGitSnapshot(ctx, gist, extra)
+		if err == nil {
+			fsys.reportFetched(gist)
+		}
+	}
+
+	return gist, etag, extra, err
+}
+
+// getGist fetches the latest revision of the gist through the getter. If
+// a gist is already loaded, the request is conditional, so that Github
+// answers with no content and without counting it against the rate limit
+// when the gist did not change, in which case the loaded gist is returned.
+func (fsys *FS) getGist(ctx context.Context, deferContent bool) (*github.Gist, string, gistExtra, error) {
+	s := fsys.state()
+	current, etag, extra := s.gist, s.etag, s.extra
+
+	// The loaded gist can't be reused if it lacks content that is now needed.
+	if current == nil || (extra.deferContent && !deferContent) {
+		etag = ""
+	}
+
+	gist, etag, err := fsys.getter.GetGist(ctx, fsys.GetID(), etag)
+	if errors.Is(err, ErrNotModified) && current != nil {
+		return current, etag, extra, nil
+	}
+	if err != nil {
+		return nil, "", gistExtra{}, err
+	}
+
+	if err := fsys.checkSize(gist); err != nil {
+		return nil, "", gistExtra{}, err
+	}
+
+	// Files of unknown size can't be told truncated once loaded, so their
+	// content is not deferred.
+	extra = newGistExtra(gist)
+	if deferContent && !hasUnknownSizes(gist) {
+		extra.deferContent = true
+	} else if err := fsys.fillTruncated(ctx, gist); err != nil {
+		return nil, "", gistExtra{}, err
+	}
+
+	return gist.toGithubGist(), etag, extra, nil
+}
+
+// getRevision fetches the revision the filesystem is pinned to through the
+// getter.
+func (fsys *FS) getRevision(ctx context.Context, deferContent bool) (*github.Gist, gistExtra, error) {
+	gist, err := fsys.getter.GetGistRevision(ctx, fsys.GetID(), fsys.revision)
 	if err != nil {
+		return nil, gistExtra{}, err
+	}
+
+	if err := fsys.checkSize(gist); err != nil {
+		return nil, gistExtra{}, err
+	}
+
+	if !deferContent {
+		if err := fsys.fillTruncated(ctx, gist); err != nil {
+			return nil, gistExtra{}, err
+		}
+	}
+
+	if gist.Revision == "" {
+		gist.Revision = fsys.revision
+	}
+
+	extra := newGistExtra(gist)
+	extra.deferContent = deferContent
+
+	return gist.toGithubGist(), extra, nil
+}
+
+// call performs a request to the Github API through fn, waiting for the rate
+// limiter and retrying according to the retry policy. The rate limit state
+// reported by the response is recorded, and errors are converted into the
+// ones exposed by this package, wrapped in an *Error describing op.
+func (fsys *FS) call(ctx context.Context, op string, fn func() (*github.Response, error)) error {
+	var resp *github.Response
+	err := fsys.retry(ctx, func() error {
+		if err := fsys.wait(ctx); err != nil {
+			return err
+		}
+
+		var err error
+		resp, err = fn()
+		fsys.recordResponse(resp)
+		fsys.traceResponse(ctx, resp)
 		return err
+	})
+	if err == nil {
+		return nil
 	}
 
-	fsys.gist = gist
+	if fsys.metrics != nil {
+		fsys.metrics.ObserveAPIError(fsys.GetID(), op)
+	}
 
-	return nil
+	return &Error{
+		Op:        op,
+		ID:        fsys.GetID(),
+		RequestID: requestID(resp, err),
+		Err:       fsys.wrapError(err),
+	}
 }
 
 // file represents a file stored in a Gist and implements fs.File methods.
 // It is built out of a github.GistFile.
+//
+// Files returned by Open are recycled once closed, so that serving files
+// does not produce garbage.
 type file struct {
-	gistFile *github.GistFile
+	// fsys is the filesystem the file was opened from, and is nil for
+	// files only used as an fs.FileInfo.
+	fsys     *FS
+	name     string
+	gistFile github.GistFile
 	modtime  time.Time
+	mode     fs.FileMode
 	reader   io.Reader
-	mu       sync.Mutex
+	// ctx is the context the file was opened with. Reads fail once it is
+	// done.
+	ctx    context.Context
+	closed bool
+	mu     sync.Mutex
+
+	// r backs reader. It is taken from readersPool when the file is opened
+	// and given back when it is closed.
+	r *fileReaders
+}
+
+// fileReaders are the readers of the content of an open file, reused by
+// the files opened after it is closed.
+type fileReaders struct {
+	content strings.Reader
+	gunzip  gunzipReader
+}
+
+// readersPool holds the readers of closed files, to be reused by Open.
+// Files themselves are not reused, as closed ones may still be held.
+var readersPool = sync.Pool{
+	New: func() interface{} { return new(fileReaders) },
 }
 
 // Open opens the named file for reading and return it as an fs.File.
 func (fsys *FS) Open(name string) (fs.File, error) {
-	fsys.mu.RLock()
-	defer fsys.mu.RUnlock()
+	return fsys.OpenContext(fsys.readContext(), name)
+}
 
-	if fsys.gist == nil {
-		return nil, ErrNotLoaded
+// OpenContext opens the named file as Open does, making the requests it
+// needs, such as downloading content deferred by LoadMetadata, with ctx.
+// Reads of the returned file fail with the error of ctx once it is done,
+// which ties it to the lifetime of a request being served.
+func (fsys *FS) OpenContext(ctx context.Context, name string) (fs.File, error) {
+	ctx, span := fsys.startSpan(ctx, "gistfs.Open", name)
+	f, err := fsys.open(ctx, name)
+	endSpan(span, -1, err)
+
+	return f, err
+}
+
+func (fsys *FS) open(ctx context.Context, name string) (fs.File, error) {
+	name, err := fsys.cleanPath("open", name)
+	if err != nil {
+		return nil, err
 	}
 
-	if name == "./" || name == "." {
-		return fsys.openRoot(), nil
+	if err := fsys.reloadEvicted(ctx); err != nil && fsys.fallback == nil {
+		return nil, err
 	}
+	fsys.revalidate()
+	fsys.refreshTooOld(ctx)
 
-	f, ok := fsys.gist.Files[github.GistFilename(name)]
-	if !ok {
-		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	if err := fsys.fetchDeferred(ctx, name, nil); err != nil {
+		return nil, err
 	}
 
-	return fsys.wrapFile(&f), nil
+	s := fsys.state()
+	if err := fsys.unavailableErr(s); err != nil {
+		if fsys.fallback != nil {
+			return fsys.fallback.Open(name)
+		}
+		return nil, err
+	}
+	fsys.used()
+
+	p, f, ok := fsys.lookup(s, name)
+	if ok {
+		f, err := fsys.decryptFile(s, f)
+		if err != nil {
+			return nil, err
+		}
+		return fsys.openFile(ctx, s, p, f), nil
+	}
+
+	if d := fsys.openDir(s, p); d != nil {
+		return d, nil
+	}
+
+	return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
 }
 
-// wrapFile wraps a github.GistFile into a file, which implements
-// the fs.File interface.
-func (fsys *FS) wrapFile(f *github.GistFile) *file {
-	return &file{
-		gistFile: f,
-		reader:   bytes.NewReader([]byte(f.GetContent())),
-		modtime:  fsys.gist.GetUpdatedAt(),
+// lookup returns the file served at name, along with the path name refers
+// to, which is the one of a directory if no file is served there.
+func (fsys *FS) lookup(s *state, name string) (string, github.GistFile, bool) {
+	p := fsys.resolve(s, name)
+	f, ok := s.index.file(p)
+	return p, f, ok
+}
+
+// openFile returns a file served at path p, reading the content held by the
+// gist, which is immutable, rather than a copy of it, until ctx is done.
+func (fsys *FS) openFile(ctx context.Context, s *state, p string, gf github.GistFile) *file {
+	f := &file{
+		fsys:     fsys,
+		ctx:      ctx,
+		name:     path.Base(p),
+		mode:     fsys.fileMode(p),
+		gistFile: gf,
+		modtime:  s.gist.GetUpdatedAt(),
+		r:        readersPool.Get().(*fileReaders),
+	}
+
+	if z, ok := fsys.compressedContent(s, &gf); ok {
+		f.r.gunzip.reset(z)
+		f.reader = &f.r.gunzip
+	} else {
+		f.r.content.Reset(gf.GetContent())
+		f.reader = &f.r.content
+	}
+
+	if fsys.metrics != nil {
+		fsys.metrics.AddOpenFiles(fsys.GetID(), 1)
 	}
+
+	return f
+}
+
+// fileInfo returns a file served at path p, which can't be read, to be used
+// as an fs.FileInfo or an fs.DirEntry.
+func (fsys *FS) fileInfo(s *state, p string, gf github.GistFile) *file {
+	return &file{name: path.Base(p), gistFile: gf, modtime: s.gist.GetUpdatedAt(), mode: fsys.fileMode(p)}
 }
 
 // ReadFile reads and returns the content of the named file.
 func (fsys *FS) ReadFile(name string) ([]byte, error) {
-	fsys.mu.RLock()
-	defer fsys.mu.RUnlock()
+	return fsys.ReadFileContext(fsys.readContext(), name)
+}
 
-	if fsys.gist == nil {
-		return nil, ErrNotLoaded
+// ReadFileContext reads and returns the content of the named file as
+// ReadFile does, making the requests it needs with ctx.
+func (fsys *FS) ReadFileContext(ctx context.Context, name string) ([]byte, error) {
+	ctx, span := fsys.startSpan(ctx, "gistfs.ReadFile", name)
+	b, err := fsys.readFile(ctx, name)
+	endSpan(span, len(b), err)
+	if fsys.metrics != nil && len(b) > 0 {
+		fsys.metrics.AddBytesServed(fsys.GetID(), len(b))
+	}
+
+	return b, err
+}
+
+func (fsys *FS) readFile(ctx context.Context, name string) ([]byte, error) {
+	name, err := fsys.cleanPath("read", name)
+	if err != nil {
+		return nil, err
 	}
 
-	gistFile, ok := fsys.gist.Files[github.GistFilename(name)]
+	if err := fsys.reloadEvicted(ctx); err != nil && fsys.fallback == nil {
+		return nil, err
+	}
+	fsys.revalidate()
+	fsys.refreshTooOld(ctx)
+
+	if err := fsys.fetchDeferred(ctx, name, nil); err != nil {
+		return nil, err
+	}
+
+	s := fsys.state()
+	if err := fsys.unavailableErr(s); err != nil {
+		if fsys.fallback != nil {
+			return fs.ReadFile(fsys.fallback, name)
+		}
+		return nil, err
+	}
+	fsys.used()
+
+	_, gistFile, ok := fsys.lookup(s, name)
 	if !ok {
 		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
 	}
 
-	return []byte(gistFile.GetContent()), nil
+	gistFile, err = fsys.decryptFile(s, gistFile)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := fsys.content(s, &gistFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(content), nil
 }
 
-// ReadDir reads and returns the entire named directory, which contains
-// all files that are stored in the Gist supporting the filesystem.
+// ReadDir reads and returns the entire named directory, sorted by filename.
 //
-// Becaus a Github Gist can't have folders, the only directory that exists
-// is the root directory, named "." or "./".
+// Because a Github Gist can't have folders, the root directory, named "." or
+// "./", contains all files that are stored in the Gist supporting the
+// filesystem. Other directories only hold virtual files, such as the one
+// added by WithMetaFile.
 func (fsys *FS) ReadDir(name string) ([]fs.DirEntry, error) {
-	fsys.mu.RLock()
-	defer fsys.mu.RUnlock()
+	return fsys.ReadDirContext(fsys.readContext(), name)
+}
+
+// ReadDirContext reads the named directory as ReadDir does, making the
+// requests it needs, such as loading a gist evicted by an LRU again, with
+// ctx.
+func (fsys *FS) ReadDirContext(ctx context.Context, name string) ([]fs.DirEntry, error) {
+	name, err := fsys.cleanPath("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fsys.reloadEvicted(ctx); err != nil && fsys.fallback == nil {
+		return nil, err
+	}
+	fsys.revalidate()
+	fsys.refreshTooOld(ctx)
 
-	if fsys.gist == nil {
-		return nil, ErrNotLoaded
+	s := fsys.state()
+	if err := fsys.unavailableErr(s); err != nil {
+		if fsys.fallback != nil {
+			return fs.ReadDir(fsys.fallback, name)
+		}
+		return nil, err
 	}
+	fsys.used()
 
-	if name != "." && name != "./" {
+	d := fsys.openDir(s, fsys.resolve(s, name))
+	if d == nil {
 		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
 	}
 
-	return fsys.openRoot().(*rootDir).ReadDir(-1)
+	return d.ReadDir(-1)
 }
 
 func (f *file) isClosed() bool {
-	return f.reader == nil
+	return f.closed
+}
+
+// compressed reports whether f reads content stored compressed.
+func (f *file) compressed() bool {
+	return f.r != nil && f.reader == &f.r.gunzip
 }
 
 func (f *file) Read(b []byte) (int, error) {
@@ -164,22 +993,138 @@ func (f *file) Read(b []byte) (int, error) {
 	if f.isClosed() {
 		return 0, fs.ErrClosed
 	}
+	if err := f.ctxErr(); err != nil {
+		return 0, err
+	}
+	if f.reader == nil {
+		return 0, io.EOF
+	}
 
-	return f.reader.Read(b)
+	n, err := f.reader.Read(b)
+	f.served(n)
+	return n, err
 }
 
-func (f *file) Close() error {
+// ctxErr returns the error of the context the file was opened with, if it
+// is done.
+func (f *file) ctxErr() error {
+	if f.ctx == nil {
+		return nil
+	}
+
+	return f.ctx.Err()
+}
+
+// served reports that n bytes were read from the file.
+func (f *file) served(n int) {
+	if n > 0 && f.fsys != nil && f.fsys.metrics != nil {
+		f.fsys.metrics.AddBytesServed(f.fsys.GetID(), n)
+	}
+}
+
+// Seek sets the offset for the next Read on the file. Seeking backwards in
+// a file stored compressed decompresses it again from its start.
+func (f *file) Seek(offset int64, whence int) (int64, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	f.gistFile = nil
+	if f.isClosed() {
+		return 0, fs.ErrClosed
+	}
+	if f.r == nil {
+		return 0, errors.New("gistfs.file.Seek: file is not open")
+	}
+	if !f.compressed() {
+		return f.r.content.Seek(offset, whence)
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.r.gunzip.pos
+	case io.SeekEnd:
+		offset += f.Size()
+	default:
+		return 0, errors.New("gistfs.file.Seek: invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("gistfs.file.Seek: negative position")
+	}
+
+	return offset, f.r.gunzip.seek(offset)
+}
+
+// ReadAt reads len(b) bytes of the file starting at offset off, regardless
+// of the offset of Read. Reading a file stored compressed decompresses it
+// from its start.
+func (f *file) ReadAt(b []byte, off int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.isClosed() {
+		return 0, fs.ErrClosed
+	}
+	if off < 0 {
+		return 0, errors.New("gistfs.file.ReadAt: negative offset")
+	}
+	if err := f.ctxErr(); err != nil {
+		return 0, err
+	}
+	if f.r == nil {
+		return 0, io.EOF
+	}
+	if !f.compressed() {
+		n, err := f.r.content.ReadAt(b, off)
+		f.served(n)
+		return n, err
+	}
+
+	g := &gunzipReader{src: f.r.gunzip.src}
+	if err := g.seek(off); err != nil {
+		return 0, err
+	}
+
+	n, err := io.ReadFull(g, b)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	f.served(n)
+	return n, err
+}
+
+// Close closes the file, whose reads fail with fs.ErrClosed afterwards.
+// Closing it again does nothing.
+func (f *file) Close() error {
+	f.mu.Lock()
+	if f.closed || f.reader == nil {
+		f.closed = true
+		f.mu.Unlock()
+		return nil
+	}
+
+	if f.fsys != nil && f.fsys.metrics != nil {
+		f.fsys.metrics.AddOpenFiles(f.fsys.GetID(), -1)
+	}
+
+	r := f.r
+	f.closed = true
+	f.fsys = nil
+	f.ctx = nil
+	f.gistFile = github.GistFile{}
 	f.reader = nil
+	f.r = nil
+	f.mu.Unlock()
+
+	r.content.Reset("")
+	r.gunzip.reset("")
+	readersPool.Put(r)
 
 	return nil
 }
 
 // Stat provides stat about the file. The modtime notably, is set to
-// when the underlying Gist was last updated.
+// when the underlying Gist was last updated. The returned fs.FileInfo
+// remains valid once the file is closed.
 func (f *file) Stat() (fs.FileInfo, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -188,20 +1133,34 @@ func (f *file) Stat() (fs.FileInfo, error) {
 		return nil, fs.ErrClosed
 	}
 
-	return f, nil
+	return &file{name: f.name, gistFile: f.gistFile, modtime: f.modtime, mode: f.mode}, nil
 }
 
-func (f *file) Name() string { return f.gistFile.GetFilename() }
+func (f *file) Name() string { return f.name }
 func (f *file) Size() int64  { return int64(f.gistFile.GetSize()) }
 
-// Mode always return 0444.
-func (f *file) Mode() fs.FileMode { return 0444 }
+// Mode returns 0444, unless the filesystem was created with WithFileMode or
+// WithFileModes.
+func (f *file) Mode() fs.FileMode { return f.mode }
 
 // ModTime always return the time of the underlying gist last update.
 func (f *file) ModTime() time.Time { return f.modtime }
 
-func (f *file) IsDir() bool                { return false }
-func (f *file) Sys() interface{}           { return f.gistFile }
+func (f *file) IsDir() bool { return false }
+
+// Sys returns the *FileMetadata of the file, or nil once it is closed.
+func (f *file) Sys() interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.isClosed() {
+		return nil
+	}
+
+	gf := f.gistFile
+	return newFileMetadata(&gf)
+}
+
 func (f *file) Type() fs.FileMode          { return f.Mode().Type() }
 func (f *file) Info() (fs.FileInfo, error) { return f, nil }
 
@@ -213,39 +1172,72 @@ func (f *file) ReadDir(count int) ([]fs.DirEntry, error) {
 	}
 }
 
-type rootDir struct {
-	files   []*file
+// dir represents a directory of the filesystem and implements fs.File
+// methods. Its entries are the files and directories directly under it.
+type dir struct {
+	name    string
+	entries []fs.DirEntry
 	offset  int
 	modtime time.Time
-	mu      sync.Mutex
+	// meta describes the gist, for the root directory only.
+	meta *GistMetadata
+	mu   sync.Mutex
 }
 
-func (fsys *FS) openRoot() fs.File {
-	files := make([]*file, 0, len(fsys.gist.Files))
-	for _, f := range fsys.gist.Files {
-		files = append(files, fsys.wrapFile(&f))
+// openDir returns the directory at path p, or nil if no file is stored
+// under it. The root directory always exists.
+func (fsys *FS) openDir(s *state, p string) *dir {
+	entries, ok := s.index.dirs[p]
+	if !ok {
+		return nil
 	}
 
-	return &rootDir{
-		files:   files,
-		modtime: fsys.gist.GetUpdatedAt(),
+	// Entries are shared by all the directories opened for s, and copied
+	// by ReadDir.
+	d := &dir{
+		name:    p,
+		entries: entries,
+		modtime: s.gist.GetUpdatedAt(),
+	}
+	if p == "." {
+		d.meta = newGistMetadata(s)
 	}
+
+	return d
+}
+
+func (d *dir) Close() error               { return nil }
+func (d *dir) Stat() (fs.FileInfo, error) { return d, nil }
+func (d *dir) Info() (fs.FileInfo, error) { return d, nil }
+
+// Name returns "./" for the root directory, and the base name of other
+// directories.
+func (d *dir) Name() string {
+	if d.name == "." {
+		return "./"
+	}
+	return path.Base(d.name)
 }
 
-func (d *rootDir) Close() error               { return nil }
-func (d *rootDir) Stat() (fs.FileInfo, error) { return d, nil }
-func (d *rootDir) Name() string               { return "./" }
-func (d *rootDir) Size() int64                { return 0 }
-func (d *rootDir) Mode() fs.FileMode          { return fs.ModeDir | 0444 }
+func (d *dir) Size() int64       { return 0 }
+func (d *dir) Mode() fs.FileMode { return fs.ModeDir | 0444 }
 
 // ModTime always return the time of the underlying gist last update.
-func (d *rootDir) ModTime() time.Time { return d.modtime }
+func (d *dir) ModTime() time.Time { return d.modtime }
 
-func (d *rootDir) IsDir() bool       { return true }
-func (d *rootDir) Type() fs.FileMode { return d.Mode().Type() }
-func (d *rootDir) Sys() interface{}  { return nil }
+func (d *dir) IsDir() bool       { return true }
+func (d *dir) Type() fs.FileMode { return d.Mode().Type() }
 
-func (d *rootDir) Read(b []byte) (int, error) {
+// Sys returns the *GistMetadata of the gist for the root directory, and nil
+// for other directories.
+func (d *dir) Sys() interface{} {
+	if d.meta == nil {
+		return nil
+	}
+	return d.meta
+}
+
+func (d *dir) Read(b []byte) (int, error) {
 	return 0, &fs.PathError{
 		Op:   "read",
 		Path: d.Name(),
@@ -253,11 +1245,11 @@ func (d *rootDir) Read(b []byte) (int, error) {
 	}
 }
 
-func (d *rootDir) ReadDir(count int) ([]fs.DirEntry, error) {
+func (d *dir) ReadDir(count int) ([]fs.DirEntry, error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	n := len(d.files) - d.offset
+	n := len(d.entries) - d.offset
 
 	if count > 0 && n > count {
 		n = count
@@ -269,12 +1261,10 @@ func (d *rootDir) ReadDir(count int) ([]fs.DirEntry, error) {
 		}
 	}
 
-	files := make([]fs.DirEntry, n)
-	for i := range files {
-		files[i] = d.files[d.offset+i]
-	}
+	entries := make([]fs.DirEntry, n)
+	copy(entries, d.entries[d.offset:])
 
 	d.offset += n
 
-	return files, nil
+	return entries, nil
 }
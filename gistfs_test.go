@@ -3,28 +3,80 @@ package gistfs
 import (
 	"context"
 	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"io/fs"
 	"net/http"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/go-github/v33/github"
 	"github.com/gregjones/httpcache"
+	"github.com/jhchabran/gistfs/gisttest/recorder"
+	"github.com/jhchabran/gistfs/internal/gistserver"
+	"golang.org/x/oauth2"
 )
 
 var referenceGistID = "ded2f6727d98e6b0095e62a7813aa7cf"
 var approxModTime, _ = time.Parse("2000-12-31", "2020-01-02") // when the gist was last edited
 
-// Avoid burning rate limit by using a caching http client.
-func cachingClient() *github.Client {
-	c := &http.Client{
-		Transport: httpcache.NewMemoryCacheTransport(),
+var record = flag.Bool("record", false, "record interactions with the Github API to the golden files in testdata")
+
+// referenceClient returns a client serving the reference gist from its
+// golden file, or recording it again from the Github API with -record.
+func referenceClient(t *testing.T) *github.Client {
+	t.Helper()
+
+	mode := recorder.ModeReplay
+	if *record {
+		mode = recorder.ModeRecord
+	}
+
+	// Avoid burning rate limit by using a caching transport when recording.
+	rec, err := recorder.New("testdata/reference_gist.json", mode, httpcache.NewMemoryCacheTransport())
+	if err != nil {
+		t.Fatalf("Opening golden file, expected no error but got %#v", err)
 	}
 
-	return github.NewClient(c)
+	t.Cleanup(func() {
+		if err := rec.Save(); err != nil {
+			t.Errorf("Saving golden file, expected no error but got %#v", err)
+		}
+	})
+
+	return github.NewClient(rec.Client())
 }
 
-var cacheClient = cachingClient()
+// fakeUpdatedAt is when the gists served by the fake API were last updated.
+var fakeUpdatedAt = gistserver.UpdatedAt
+
+// fakeRateReset is when the rate limit of the fake API resets.
+var fakeRateReset = gistserver.RateReset
+
+// fakeGist is a gist served by an in-process fake of the Github Gists API,
+// whose files can be changed between loads.
+type fakeGist struct {
+	*gistserver.Server
+	*gistserver.Gist
+}
+
+// newFakeGist starts a fake Gists API serving a gist made of the given files,
+// and returns a client pointed at it.
+func newFakeGist(t *testing.T, files map[string]string) (*fakeGist, *github.Client) {
+	t.Helper()
+
+	srv := gistserver.New()
+	t.Cleanup(srv.Close)
+
+	return &fakeGist{
+		Server: srv,
+		Gist:   srv.AddGist(referenceGistID, files),
+	}, srv.GithubClient()
+}
 
 func TestErrorNotLoaded(t *testing.T) {
 	if !errors.Is(ErrNotLoaded, fs.ErrInvalid) {
@@ -41,16 +93,201 @@ func TestNew(t *testing.T) {
 	})
 
 	t.Run("NewWithClient OK", func(t *testing.T) {
-		gfs := NewWithClient(cacheClient, referenceGistID)
+		gfs := NewWithClient(referenceClient(t), referenceGistID)
 		if got, want := gfs.GetID(), referenceGistID; got != want {
 			t.Fatalf("NewWithClient returned a FS with ID=%#v, want %#v", got, want)
 		}
 	})
+
+	t.Run("NewWithHTTPClient OK", func(t *testing.T) {
+		gfs := NewWithHTTPClient(http.DefaultClient, referenceGistID)
+		if got, want := gfs.GetID(), referenceGistID; got != want {
+			t.Fatalf("NewWithHTTPClient returned a FS with ID=%#v, want %#v", got, want)
+		}
+	})
+}
+
+func TestNewFromGist(t *testing.T) {
+	content := "foobar"
+	gist := &github.Gist{
+		ID: github.String(referenceGistID),
+		Files: map[github.GistFilename]github.GistFile{
+			"a.txt": {Filename: github.String("a.txt"), Content: &content},
+		},
+	}
+
+	gfs := NewFromGist(gist)
+
+	if got, want := gfs.GetID(), referenceGistID; got != want {
+		t.Fatalf("NewFromGist returned a FS with ID=%#v, want %#v", got, want)
+	}
+
+	b, err := gfs.ReadFile("a.txt")
+	if err != nil {
+		t.Fatalf("Reading without loading, expected no error but got %#v", err)
+	}
+
+	if got, want := string(b), content; got != want {
+		t.Fatalf("Reading without loading, got %#v, want %#v", got, want)
+	}
+
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "refreshed"})
+	gfs.client.BaseURL = client.BaseURL
+
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Refreshing, expected no error but got %#v", err)
+	}
+
+	if got, want := fg.Requests(), 1; got != want {
+		t.Fatalf("Refreshing, got %d API requests, want %d", got, want)
+	}
+
+	b, _ = gfs.ReadFile("a.txt")
+	if got, want := string(b), "refreshed"; got != want {
+		t.Fatalf("Reading after refresh, got %#v, want %#v", got, want)
+	}
+}
+
+func TestNewFromGistListed(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "hello", "b.txt": "world"})
+
+	// Gists listed by Gists.List come without the content of their files.
+	gist, _, err := client.Gists.Get(context.Background(), referenceGistID)
+	if err != nil {
+		t.Fatalf("Getting the gist, expected no error but got %#v", err)
+	}
+	for name, f := range gist.Files {
+		f.Content = nil
+		gist.Files[name] = f
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		gfs := NewFromGist(gist)
+
+		requests := fg.Requests()
+		b, err := gfs.ReadFile("a.txt")
+		if err != nil || string(b) != "hello" {
+			t.Fatalf("Reading a listed file, got %q (%v), want %q", b, err, "hello")
+		}
+		if got, want := fg.Requests()-requests, 1; got != want {
+			t.Fatalf("Reading a listed file, got %d requests, want %d", got, want)
+		}
+
+		info, err := gfs.Stat("a.txt")
+		if err != nil {
+			t.Fatalf("Stating a listed file, expected no error but got %#v", err)
+		}
+		if got, want := info.Size(), int64(5); got != want {
+			t.Fatalf("Stating a listed file, got size %d, want %d", got, want)
+		}
+	})
+
+	t.Run("NOK checks", func(t *testing.T) {
+		gfs := NewFromGist(gist, WithPublicOnly())
+		if gfs.IsLoaded() {
+			t.Fatal("NewFromGist with a secret gist and WithPublicOnly, got a loaded FS, want it not loaded")
+		}
+		if got := gfs.DebugState().LastError; !strings.Contains(got, ErrVisibility.Error()) {
+			t.Fatalf("NewFromGist with a secret gist and WithPublicOnly, got last error %#v, want %#v", got, ErrVisibility.Error())
+		}
+	})
+}
+
+func TestWithToken(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+
+	gfs := New(referenceGistID, WithToken("s3cr3t"))
+	gfs.client.BaseURL = client.BaseURL
+
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading with a token, expected no error but got %#v", err)
+	}
+
+	if got, want := fg.LastHeader().Get("Authorization"), "Bearer s3cr3t"; got != want {
+		t.Fatalf("Loading with a token, got Authorization %#v, want %#v", got, want)
+	}
+}
+
+// countingTokenSource hands out a new token every time it is called.
+type countingTokenSource struct {
+	n  int
+	mu sync.Mutex
+}
+
+func (ts *countingTokenSource) Token() (*oauth2.Token, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	ts.n++
+
+	return &oauth2.Token{
+		AccessToken: fmt.Sprintf("token-%d", ts.n),
+		Expiry:      time.Now().Add(-time.Second),
+	}, nil
+}
+
+// recordingTransport records the URL of every request going through it.
+type recordingTransport struct {
+	urls []string
+	mu   sync.Mutex
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	rt.urls = append(rt.urls, req.URL.String())
+	rt.mu.Unlock()
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestNewWithHTTPClient(t *testing.T) {
+	_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+	rt := &recordingTransport{}
+
+	gfs := NewWithHTTPClient(&http.Client{Transport: rt}, referenceGistID, WithToken("s3cr3t"))
+	gfs.client.BaseURL = client.BaseURL
+
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading with a custom http client, expected no error but got %#v", err)
+	}
+
+	if got, want := len(rt.urls), 1; got != want {
+		t.Fatalf("Loading with a custom http client, got %d requests through it, want %d", got, want)
+	}
+}
+
+func TestWithUserAgent(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+
+	gfs := New(referenceGistID, WithUserAgent("gistfs-test/1.0"))
+	gfs.client.BaseURL = client.BaseURL
+	gfs.Load(context.Background())
+
+	if got, want := fg.LastHeader().Get("User-Agent"), "gistfs-test/1.0"; got != want {
+		t.Fatalf("Loading with a user agent, got User-Agent %#v, want %#v", got, want)
+	}
+}
+
+func TestWithTokenSource(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+
+	gfs := New(referenceGistID, WithTokenSource(&countingTokenSource{}))
+	gfs.client.BaseURL = client.BaseURL
+
+	for _, want := range []string{"Bearer token-1", "Bearer token-2"} {
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading with a token source, expected no error but got %#v", err)
+		}
+
+		if got := fg.LastHeader().Get("Authorization"); got != want {
+			t.Fatalf("Loading with a rotating token, got Authorization %#v, want %#v", got, want)
+		}
+	}
 }
 
 func TestOpen(t *testing.T) {
 	t.Run("Open OK", func(t *testing.T) {
-		gfs := NewWithClient(cacheClient, referenceGistID)
+		gfs := NewWithClient(referenceClient(t), referenceGistID)
 		gfs.Load(context.Background())
 
 		tests := []struct {
@@ -76,7 +313,7 @@ func TestOpen(t *testing.T) {
 	})
 
 	t.Run("Open NOK not loaded", func(t *testing.T) {
-		gfs := NewWithClient(cacheClient, referenceGistID)
+		gfs := NewWithClient(referenceClient(t), referenceGistID)
 		_, err := gfs.Open("test1.txt")
 
 		if err == nil {
@@ -87,9 +324,38 @@ func TestOpen(t *testing.T) {
 	})
 }
 
+func TestOpenSharesContent(t *testing.T) {
+	content := strings.Repeat("a", 1<<20)
+	gfs := NewFromGist(&github.Gist{
+		ID: github.String(referenceGistID),
+		Files: map[github.GistFilename]github.GistFile{
+			"big.txt": {Filename: github.String("big.txt"), Content: &content, Size: github.Int(len(content))},
+		},
+	})
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	for i := 0; i < 100; i++ {
+		f, err := gfs.Open("big.txt")
+		if err != nil {
+			t.Fatalf("Opening, expected no error but got %#v", err)
+		}
+		f.Close()
+
+		if _, err := gfs.ReadDir("."); err != nil {
+			t.Fatalf("Listing, expected no error but got %#v", err)
+		}
+	}
+	runtime.ReadMemStats(&after)
+
+	if got := after.TotalAlloc - before.TotalAlloc; got > uint64(len(content)) {
+		t.Fatalf("Opening a file 100 times, got %d bytes allocated, want less than its size", got)
+	}
+}
+
 func TestReadFile(t *testing.T) {
 	t.Run("ReadFile OK", func(t *testing.T) {
-		gfs := NewWithClient(cacheClient, referenceGistID)
+		gfs := NewWithClient(referenceClient(t), referenceGistID)
 		gfs.Load(context.Background())
 
 		tests := []struct {
@@ -122,7 +388,7 @@ func TestReadFile(t *testing.T) {
 }
 
 func TestRead(t *testing.T) {
-	gfs := NewWithClient(cacheClient, referenceGistID)
+	gfs := NewWithClient(referenceClient(t), referenceGistID)
 	gfs.Load(context.Background())
 
 	t.Run("Read OK", func(t *testing.T) {
@@ -163,8 +429,120 @@ func TestRead(t *testing.T) {
 	})
 }
 
+func TestSeek(t *testing.T) {
+	_, client := newFakeGist(t, map[string]string{"a.txt": "0123456789"})
+
+	for name, opts := range map[string][]Option{
+		"plain":      nil,
+		"compressed": {WithCompression()},
+	} {
+		gfs := NewWithClient(client, referenceGistID, opts...)
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		t.Run("OK "+name, func(t *testing.T) {
+			f, err := gfs.Open("a.txt")
+			if err != nil {
+				t.Fatalf("Opening, expected no error but got %#v", err)
+			}
+			defer f.Close()
+
+			tests := []struct {
+				offset int64
+				whence int
+				pos    int64
+				want   string
+			}{
+				{offset: 4, whence: io.SeekStart, pos: 4, want: "45"},
+				{offset: 1, whence: io.SeekCurrent, pos: 7, want: "78"},
+				{offset: -3, whence: io.SeekEnd, pos: 7, want: "78"},
+				{offset: 0, whence: io.SeekStart, pos: 0, want: "01"},
+				{offset: 20, whence: io.SeekStart, pos: 20, want: ""},
+				{offset: -12, whence: io.SeekCurrent, pos: 8, want: "89"},
+			}
+			for _, test := range tests {
+				pos, err := f.(io.Seeker).Seek(test.offset, test.whence)
+				if err != nil {
+					t.Fatalf("Seeking, expected no error but got %#v", err)
+				}
+				if pos != test.pos {
+					t.Fatalf("Seeking %d from %d, got position %d, want %d", test.offset, test.whence, pos, test.pos)
+				}
+
+				b := make([]byte, 2)
+				n, _ := io.ReadFull(f, b)
+				if got := string(b[:n]); got != test.want {
+					t.Fatalf("Reading after seeking to %d, got %#v, want %#v", pos, got, test.want)
+				}
+			}
+		})
+
+		t.Run("NOK "+name+" negative position", func(t *testing.T) {
+			f, err := gfs.Open("a.txt")
+			if err != nil {
+				t.Fatalf("Opening, expected no error but got %#v", err)
+			}
+			defer f.Close()
+
+			if _, err := f.(io.Seeker).Seek(-1, io.SeekStart); err == nil {
+				t.Fatalf("Seeking to a negative position, expected an error but got none")
+			}
+		})
+	}
+}
+
+func TestReadAt(t *testing.T) {
+	_, client := newFakeGist(t, map[string]string{"a.txt": "0123456789"})
+
+	for name, opts := range map[string][]Option{
+		"plain":      nil,
+		"compressed": {WithCompression()},
+	} {
+		gfs := NewWithClient(client, referenceGistID, opts...)
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		t.Run("OK "+name, func(t *testing.T) {
+			f, err := gfs.Open("a.txt")
+			if err != nil {
+				t.Fatalf("Opening, expected no error but got %#v", err)
+			}
+			defer f.Close()
+
+			tests := []struct {
+				off     int64
+				want    string
+				wantErr error
+			}{
+				{off: 2, want: "234"},
+				{off: 8, want: "89", wantErr: io.EOF},
+				{off: 0, want: "012"},
+				{off: 12, want: "", wantErr: io.EOF},
+			}
+			for _, test := range tests {
+				b := make([]byte, 3)
+				n, err := f.(io.ReaderAt).ReadAt(b, test.off)
+				if err != test.wantErr {
+					t.Fatalf("Reading at %d, got error %#v, want %#v", test.off, err, test.wantErr)
+				}
+				if got := string(b[:n]); got != test.want {
+					t.Fatalf("Reading at %d, got %#v, want %#v", test.off, got, test.want)
+				}
+			}
+
+			// ReadAt leaves the offset of Read untouched.
+			b := make([]byte, 2)
+			if _, err := io.ReadFull(f, b); err != nil || string(b) != "01" {
+				t.Fatalf("Reading after ReadAt, got %#v (%v), want %#v", string(b), err, "01")
+			}
+		})
+	}
+}
+
 func TestStat(t *testing.T) {
-	gfs := NewWithClient(cacheClient, referenceGistID)
+	gfs := NewWithClient(referenceClient(t), referenceGistID)
 	gfs.Load(context.Background())
 
 	t.Run("Stat OK", func(t *testing.T) {
@@ -229,9 +607,29 @@ func TestStat(t *testing.T) {
 					t.Fatalf("got isDir %#v, want %#v", got, want)
 				}
 
-				_, ok := stat.Sys().(*github.GistFile)
+				meta, ok := stat.Sys().(*FileMetadata)
 				if got, want := ok, true; got != want {
-					t.Fatal("got Sys with type different from *github.GistFile, want it to be the case")
+					t.Fatal("got Sys with type different from *FileMetadata, want it to be the case")
+				}
+
+				if got, want := meta.Filename, test.filename; got != want {
+					t.Fatalf("got Sys filename %#v, want %#v", got, want)
+				}
+
+				if got, want := meta.Size, test.size; got != want {
+					t.Fatalf("got Sys size %#v, want %#v", got, want)
+				}
+
+				if got, want := meta.Language, "Text"; got != want {
+					t.Fatalf("got Sys language %#v, want %#v", got, want)
+				}
+
+				if got, want := meta.MIMEType, "text/plain"; got != want {
+					t.Fatalf("got Sys MIME type %#v, want %#v", got, want)
+				}
+
+				if meta.GithubFile() == nil {
+					t.Fatal("got Sys without the go-github file, want it")
 				}
 			}
 		}
@@ -253,7 +651,7 @@ func TestStat(t *testing.T) {
 }
 
 func TestReadDir(t *testing.T) {
-	gfs := NewWithClient(cacheClient, referenceGistID)
+	gfs := NewWithClient(referenceClient(t), referenceGistID)
 	gfs.Load(context.Background())
 
 	t.Run("OK", func(t *testing.T) {
@@ -412,3 +810,201 @@ func TestReadDir(t *testing.T) {
 		}
 	})
 }
+
+func TestLoad(t *testing.T) {
+	t.Run("OK concurrent loads are deduplicated", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		release := fg.Hold()
+		gfs := NewWithClient(client, referenceGistID)
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- gfs.Load(context.Background())
+			}()
+		}
+
+		eventually(t, func() bool { return fg.Requests() > 0 })
+		time.Sleep(10 * time.Millisecond)
+		release()
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			if err != nil {
+				t.Fatalf("Loading concurrently, expected no error but got %#v", err)
+			}
+		}
+
+		if got, want := fg.Requests(), 1; got != want {
+			t.Fatalf("Loading concurrently, got %d API requests, want %d", got, want)
+		}
+	})
+
+	t.Run("OK sequential loads are not deduplicated", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		gfs := NewWithClient(client, referenceGistID)
+
+		gfs.Load(context.Background())
+		gfs.Load(context.Background())
+
+		if got, want := fg.Requests(), 2; got != want {
+			t.Fatalf("Loading twice, got %d API requests, want %d", got, want)
+		}
+	})
+}
+
+func benchmarkFS(b *testing.B, opts ...Option) *FS {
+	b.Helper()
+
+	files := make(map[github.GistFilename]github.GistFile)
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("file%d.txt", i)
+		content := strings.Repeat("a", 4096)
+		files[github.GistFilename(name)] = github.GistFile{
+			Filename: github.String(name),
+			Content:  &content,
+			Size:     github.Int(len(content)),
+		}
+	}
+
+	return NewFromGist(&github.Gist{ID: github.String(referenceGistID), Files: files}, opts...)
+}
+
+func BenchmarkOpen(b *testing.B) {
+	b.Run("plain", func(b *testing.B) { benchmarkOpen(b, benchmarkFS(b)) })
+	b.Run("compressed", func(b *testing.B) { benchmarkOpen(b, benchmarkFS(b, WithCompression())) })
+}
+
+func BenchmarkReadDir(b *testing.B) {
+	gfs := benchmarkFS(b)
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := gfs.ReadDir("."); err != nil {
+				b.Fatalf("Listing the root, expected no error but got %#v", err)
+			}
+		}
+	})
+}
+
+func benchmarkOpen(b *testing.B, gfs *FS) {
+	buf := make([]byte, 1024)
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			f, err := gfs.Open("file1.txt")
+			if err != nil {
+				b.Fatalf("Opening, expected no error but got %#v", err)
+			}
+
+			for {
+				if _, err := f.Read(buf); err != nil {
+					break
+				}
+			}
+			f.Close()
+		}
+	})
+}
+
+func TestCloseRecyclesOnce(t *testing.T) {
+	content := "foobar"
+	gfs := NewFromGist(&github.Gist{
+		ID: github.String(referenceGistID),
+		Files: map[github.GistFilename]github.GistFile{
+			"a.txt": {Filename: github.String("a.txt"), Content: &content},
+		},
+	})
+
+	f, _ := gfs.Open("a.txt")
+	f.Close()
+	f.Close()
+
+	a, _ := gfs.Open("a.txt")
+	b, _ := gfs.Open("a.txt")
+	if a == b {
+		t.Fatal("Opening twice after closing a file twice, got the same file, want two")
+	}
+
+	got, _ := io.ReadAll(b)
+	if want := content; string(got) != want {
+		t.Fatalf("Reading a recycled file, got %#v, want %#v", string(got), want)
+	}
+}
+
+func TestReadAfterClose(t *testing.T) {
+	a, b := "foobar", "bazqux"
+	gfs := NewFromGist(&github.Gist{
+		ID: github.String(referenceGistID),
+		Files: map[github.GistFilename]github.GistFile{
+			"a.txt": {Filename: github.String("a.txt"), Content: &a},
+			"b.txt": {Filename: github.String("b.txt"), Content: &b},
+		},
+	})
+
+	stale, _ := gfs.Open("a.txt")
+	stale.Close()
+	stale.Close()
+
+	// Opened while the stale file is still held, possibly reusing what it
+	// was backed by.
+	f, _ := gfs.Open("b.txt")
+	defer f.Close()
+
+	buf := make([]byte, 3)
+	if _, err := stale.Read(buf); !errors.Is(err, fs.ErrClosed) {
+		t.Fatalf("Reading a closed file, got %#v, want %#v", err, fs.ErrClosed)
+	}
+	if _, err := stale.(io.Seeker).Seek(0, io.SeekStart); !errors.Is(err, fs.ErrClosed) {
+		t.Fatalf("Seeking a closed file, got %#v, want %#v", err, fs.ErrClosed)
+	}
+	if _, err := stale.(io.ReaderAt).ReadAt(buf, 0); !errors.Is(err, fs.ErrClosed) {
+		t.Fatalf("Reading a closed file at an offset, got %#v, want %#v", err, fs.ErrClosed)
+	}
+	if err := stale.Close(); err != nil {
+		t.Fatalf("Closing a closed file again, expected no error but got %#v", err)
+	}
+
+	got, _ := io.ReadAll(f)
+	if want := b; string(got) != want {
+		t.Fatalf("Reading a file opened after another was closed, got %#v, want %#v", string(got), want)
+	}
+}
+
+func TestReadsDoNotLock(t *testing.T) {
+	content := "foobar"
+	gfs := NewFromGist(&github.Gist{
+		ID: github.String(referenceGistID),
+		Files: map[github.GistFilename]github.GistFile{
+			"a.txt": {Filename: github.String("a.txt"), Content: &content},
+		},
+	})
+
+	// Hold the lock taken by refreshes.
+	gfs.mu.Lock()
+	defer gfs.mu.Unlock()
+
+	done := make(chan error)
+	go func() {
+		_, err := gfs.ReadFile("a.txt")
+		if err == nil {
+			_, err = gfs.ReadDir(".")
+		}
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Reading during a refresh, expected no error but got %#v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Reading during a refresh, got blocked, want it to proceed")
+	}
+}
@@ -0,0 +1,54 @@
+// Package gisttest provides utilities to test code depending on gistfs
+// without reaching Github.
+//
+// It runs an in-process fake of the Github Gists API, which can be seeded
+// with gists, revisions, failures and rate limit responses:
+//
+//	fsys, srv := gisttest.NewFS(t, map[string]string{"config.json": "{}"})
+//	srv.FailNext(1, http.StatusBadGateway)
+package gisttest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jhchabran/gistfs"
+	"github.com/jhchabran/gistfs/internal/gistserver"
+)
+
+// Server is a fake Gists API, serving gists from memory. Its GithubClient
+// method returns a Github client that makes requests to it, to be given to
+// gistfs.NewWithClient.
+type Server = gistserver.Server
+
+// Gist is a gist served by a Server, whose files can be changed.
+type Gist = gistserver.Gist
+
+// GistID is the ID of the gist served by the server returned by NewFS.
+const GistID = "ded2f6727d98e6b0095e62a7813aa7cf"
+
+// NewServer starts a Server, which is closed when the test ends.
+func NewServer(tb testing.TB) *Server {
+	tb.Helper()
+
+	srv := gistserver.New()
+	tb.Cleanup(srv.Close)
+
+	return srv
+}
+
+// NewFS starts a Server serving a gist made of files under GistID, and
+// returns a loaded filesystem for it along with the server.
+func NewFS(tb testing.TB, files map[string]string, opts ...gistfs.Option) (*gistfs.FS, *Server) {
+	tb.Helper()
+
+	srv := NewServer(tb)
+	srv.AddGist(GistID, files)
+
+	fsys := gistfs.NewWithClient(srv.GithubClient(), GistID, opts...)
+	if err := fsys.Load(context.Background()); err != nil {
+		tb.Fatalf("gisttest: loading gist: %v", err)
+	}
+
+	return fsys, srv
+}
@@ -0,0 +1,93 @@
+package gisttest
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"testing"
+
+	"github.com/jhchabran/gistfs"
+)
+
+func TestNewFS(t *testing.T) {
+	files := map[string]string{
+		"test1.txt": "foobar\nbarfoo",
+		"test2.txt": "olala\n12345\nabcde",
+	}
+
+	fsys, srv := NewFS(t, files)
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil || len(entries) != len(files) {
+		t.Fatalf("Listing the gist, got %d entries (%v), want %d", len(entries), err, len(files))
+	}
+
+	b, err := fsys.ReadFile("test2.txt")
+	if err != nil || string(b) != files["test2.txt"] {
+		t.Fatalf("Reading a file, got %#v (%v), want %#v", string(b), err, files["test2.txt"])
+	}
+
+	if got, want := srv.Requests(), 1; got != want {
+		t.Fatalf("Loading, got %d requests, want %d", got, want)
+	}
+}
+
+func TestServer(t *testing.T) {
+	srv := NewServer(t)
+	g := srv.AddGist("abc", map[string]string{"a.txt": "a"})
+	fsys := gistfs.NewWithClient(srv.GithubClient(), "abc")
+
+	t.Run("OK revisions", func(t *testing.T) {
+		g.SetFiles(map[string]string{"a.txt": "b"})
+
+		revs := g.Revisions()
+		if got, want := len(revs), 2; got != want {
+			t.Fatalf("Listing revisions, got %d, want %d", got, want)
+		}
+
+		pinned := gistfs.NewWithClient(srv.GithubClient(), "abc", gistfs.WithRevision(revs[1]))
+		if err := pinned.Load(context.Background()); err != nil {
+			t.Fatalf("Loading a revision, expected no error but got %v", err)
+		}
+
+		b, _ := pinned.ReadFile("a.txt")
+		if got, want := string(b), "a"; got != want {
+			t.Fatalf("Reading a revision, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK failures", func(t *testing.T) {
+		srv.FailNext(1, http.StatusBadGateway)
+
+		if err := fsys.Load(context.Background()); err == nil {
+			t.Fatal("Loading with a failing server, got no error, want one")
+		}
+
+		if err := fsys.Load(context.Background()); err != nil {
+			t.Fatalf("Loading after a failure, expected no error but got %v", err)
+		}
+	})
+
+	t.Run("OK rate limit", func(t *testing.T) {
+		srv.SetRateLimit(60, 0)
+		defer srv.SetRateLimit(60, 60)
+
+		other := gistfs.NewWithClient(srv.GithubClient(), "abc")
+		if err := other.Load(context.Background()); !errors.Is(err, gistfs.ErrRateLimited) {
+			t.Fatalf("Loading while rate limited, got %v, want %v", err, gistfs.ErrRateLimited)
+		}
+	})
+
+	t.Run("OK removed gist", func(t *testing.T) {
+		srv.RemoveGist("abc")
+
+		if err := fsys.Load(context.Background()); !errors.Is(err, gistfs.ErrGistNotFound) {
+			t.Fatalf("Loading a removed gist, got %v, want %v", err, gistfs.ErrGistNotFound)
+		}
+
+		if _, err := fsys.Open("a.txt"); err != nil {
+			t.Fatalf("Opening after a failed refresh, got %v, want the loaded content", err)
+		}
+	})
+}
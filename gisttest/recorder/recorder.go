@@ -0,0 +1,264 @@
+// Package recorder provides an http.RoundTripper recording interactions with
+// the Github API to golden files and replaying them later, so tests do not
+// depend on network access nor on live rate limits.
+//
+// Interactions are recorded once, against the real API:
+//
+//	rec, err := recorder.New("testdata/my_gist.json", recorder.ModeRecord, nil)
+//	...
+//	defer rec.Save()
+//	fsys := gistfs.NewWithHTTPClient(rec.Client(), "ded2f6727d98e6b0095e62a7813aa7cf")
+//
+// and then replayed by opening the same file with ModeReplay.
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Mode selects whether a Recorder talks to the network.
+type Mode int
+
+const (
+	// ModeReplay serves responses from the golden file only, failing requests
+	// that were not recorded.
+	ModeReplay Mode = iota
+	// ModeRecord forwards requests to the network and records them, replacing
+	// the content of the golden file once saved.
+	ModeRecord
+)
+
+// ErrNotRecorded is returned when replaying a request which does not match
+// any recorded interaction.
+var ErrNotRecorded = errors.New("recorder: no recorded interaction")
+
+// redactedHeaders are the request headers that are never written to golden
+// files.
+var redactedHeaders = []string{"Authorization", "Cookie"}
+
+// Request is the recorded part of an HTTP request.
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is the recorded part of an HTTP response.
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Interaction is a request and the response it got.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// cassette is the content of a golden file.
+type cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper recording or replaying interactions.
+//
+// When replaying, requests are matched on their method, URL and body.
+// Identical requests get the recorded responses in order, the last one being
+// served again once they have all been used.
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []*Interaction
+	replayed     map[*Interaction]bool
+}
+
+// New returns a Recorder using the golden file at path. In ModeReplay, the
+// file is read immediately. In ModeRecord, requests are sent through
+// transport, or http.DefaultTransport if nil.
+func New(path string, mode Mode, transport http.RoundTripper) (*Recorder, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: transport,
+		replayed:  make(map[*Interaction]bool),
+	}
+
+	if mode == ModeRecord {
+		return r, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: reading golden file: %w", err)
+	}
+
+	var c cassette
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("recorder: decoding golden file %s: %w", path, err)
+	}
+	r.interactions = c.Interactions
+
+	return r, nil
+}
+
+// Client returns an http.Client sending its requests through r.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Interactions returns the interactions recorded or loaded so far.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]Interaction, 0, len(r.interactions))
+	for _, in := range r.interactions {
+		out = append(out, *in)
+	}
+
+	return out
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.mode == ModeRecord {
+		return r.record(req, body)
+	}
+
+	return r.replay(req, body)
+}
+
+// readBody reads the body of req and puts it back so it can be sent again.
+func readBody(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "", nil
+	}
+
+	b, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(b))
+
+	return string(b), nil
+}
+
+func (r *Recorder) record(req *http.Request, body string) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(b))
+
+	header := req.Header.Clone()
+	for _, h := range redactedHeaders {
+		header.Del(h)
+	}
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, &Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: header,
+			Body:   body,
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header.Clone(),
+			Body:       string(b),
+		},
+	})
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, body string) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var match *Interaction
+	for _, in := range r.interactions {
+		if in.Request.Method != req.Method || in.Request.URL != req.URL.String() || in.Request.Body != body {
+			continue
+		}
+
+		match = in
+		if !r.replayed[in] {
+			break
+		}
+	}
+
+	if match == nil {
+		return nil, fmt.Errorf("%w for %s %s", ErrNotRecorded, req.Method, req.URL)
+	}
+	r.replayed[match] = true
+
+	header := match.Response.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", match.Response.StatusCode, http.StatusText(match.Response.StatusCode)),
+		StatusCode:    match.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(match.Response.Body))),
+		ContentLength: int64(len(match.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Save writes the recorded interactions to the golden file. It does nothing
+// when replaying.
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	b, err := json.MarshalIndent(cassette{Interactions: r.interactions}, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(r.path, append(b, '\n'), 0644)
+}
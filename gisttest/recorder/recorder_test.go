@@ -0,0 +1,108 @@
+package recorder
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func get(t *testing.T, c *http.Client, url string) (int, string) {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("Authorization", "token s3cr3t")
+
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("Requesting %s, expected no error but got %#v", url, err)
+	}
+	defer resp.Body.Close()
+
+	b, _ := io.ReadAll(resp.Body)
+
+	return resp.StatusCode, string(b)
+}
+
+func TestRecorder(t *testing.T) {
+	var n int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if atomic.AddInt32(&n, 1) == 1 {
+			io.WriteString(w, "first")
+			return
+		}
+		io.WriteString(w, "second")
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "testdata", "golden.json")
+
+	rec, err := New(path, ModeRecord, nil)
+	if err != nil {
+		t.Fatalf("Recording, expected no error but got %#v", err)
+	}
+
+	get(t, rec.Client(), srv.URL+"/gist")
+	get(t, rec.Client(), srv.URL+"/gist")
+	get(t, rec.Client(), srv.URL+"/missing")
+
+	if err := rec.Save(); err != nil {
+		t.Fatalf("Saving, expected no error but got %#v", err)
+	}
+
+	t.Run("OK redacted", func(t *testing.T) {
+		for _, in := range rec.Interactions() {
+			if got := in.Request.Header.Get("Authorization"); got != "" {
+				t.Fatalf("Recording, got Authorization %#v in golden file, want none", got)
+			}
+		}
+	})
+
+	srv.Close()
+
+	replay, err := New(path, ModeReplay, nil)
+	if err != nil {
+		t.Fatalf("Replaying, expected no error but got %#v", err)
+	}
+	c := replay.Client()
+
+	t.Run("OK replay in order", func(t *testing.T) {
+		for _, want := range []string{"first", "second", "second"} {
+			if _, got := get(t, c, srv.URL+"/gist"); got != want {
+				t.Fatalf("Replaying, got body %#v, want %#v", got, want)
+			}
+		}
+	})
+
+	t.Run("OK replay status", func(t *testing.T) {
+		code, body := get(t, c, srv.URL+"/missing")
+		if got, want := code, http.StatusNotFound; got != want {
+			t.Fatalf("Replaying, got status %d, want %d", got, want)
+		}
+		if !strings.Contains(body, "not found") {
+			t.Fatalf("Replaying, got body %#v, want the recorded one", body)
+		}
+	})
+
+	t.Run("NOK not recorded", func(t *testing.T) {
+		_, err := c.Get(srv.URL + "/other")
+		if !errors.Is(err, ErrNotRecorded) {
+			t.Fatalf("Replaying an unknown request, got %#v, want %#v", err, ErrNotRecorded)
+		}
+	})
+
+	t.Run("NOK missing golden file", func(t *testing.T) {
+		if _, err := New(filepath.Join(t.TempDir(), "none.json"), ModeReplay, nil); err == nil {
+			t.Fatal("Replaying without golden file, got no error, want one")
+		}
+	})
+}
@@ -0,0 +1,443 @@
+package gistfs
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// ErrGitUnsupported is returned by operations requiring the git command when
+// it can't be run, such as in browsers under GOOS=js and GOARCH=wasm.
+var ErrGitUnsupported = errors.New("git is not supported on this platform")
+
+// GitCommit describes the commit recording changes pushed to a gist with
+// WithGitWrites.
+type GitCommit struct {
+	// Message is the commit message, "Update files" if empty.
+	Message string
+	// AuthorName and AuthorEmail identify the author and committer of the
+	// commit, "gistfs" and "gistfs@users.noreply.github.com" if empty.
+	AuthorName  string
+	AuthorEmail string
+}
+
+// WithGitWrites stores changes to the files of the gist by pushing commits
+// to its git repository, rather than through the REST API. This lifts the
+// limits the API puts on the size of requests, and records the changes as
+// commits described by defaults, unless CommitFromFS is given another one.
+//
+// It requires the git command, which is not available under GOOS=js, and
+// authenticates pushes with the token set by WithToken or WithTokenSource,
+// if any, which requires git 2.31 or later. Changes to the description of
+// the gist still go through the REST API, since git does not store it.
+func WithGitWrites(defaults GitCommit) Option {
+	return func(fsys *FS) {
+		fsys.gitWrites = true
+		fsys.gitCommit = defaults
+	}
+}
+
+// WithGitRemote sets the URL of the git repository of the gist written to
+// with WithGitWrites or read with WithGitSnapshot, instead of the one Github
+// serves it at.
+func WithGitRemote(url string) Option {
+	return func(fsys *FS) {
+		fsys.gitRemote = url
+	}
+}
+
+// CommitFromFS stores the files of src into the gist as UpdateFromFS does,
+// recording the change with commit when created with WithGitWrites. Empty
+// fields of commit are taken from the defaults given to WithGitWrites.
+// Without it, commit is ignored, as the REST API doesn't take one.
+func (fsys *FS) CommitFromFS(ctx context.Context, src fs.FS, commit GitCommit) (*ImportReport, error) {
+	if !fsys.writable {
+		return nil, ErrReadOnly
+	}
+
+	files, report, err := collectFiles(src)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, fsys.commitFiles(ctx, "update", files, commit)
+}
+
+// commitFiles stores files into the gist, through the REST API or by pushing
+// commit, and refreshes the filesystem with the updated gist.
+func (fsys *FS) commitFiles(ctx context.Context, op string, files map[github.GistFilename]github.GistFile, commit GitCommit) error {
+	if !fsys.gitWrites {
+		return fsys.edit(ctx, op, &github.Gist{Files: files})
+	}
+
+	if err := fsys.gitPush(ctx, files, commit); err != nil {
+		return err
+	}
+
+	return fsys.Load(ctx)
+}
+
+// gitPush commits files to a shallow clone of the repository of the gist,
+// and pushes the commit.
+func (fsys *FS) gitPush(ctx context.Context, files map[github.GistFilename]github.GistFile, commit GitCommit) error {
+	dir, err := os.MkdirTemp("", "gistfs-")
+	if err != nil {
+		return &Error{Op: "push", ID: fsys.GetID(), Err: err}
+	}
+	defer os.RemoveAll(dir)
+
+	env, err := fsys.gitEnv(commit)
+	if err != nil {
+		return &Error{Op: "push", ID: fsys.GetID(), Err: err}
+	}
+
+	git := func(args ...string) (string, error) {
+		return fsys.runGit(ctx, "push", dir, env, args...)
+	}
+
+	if _, err := git("clone", "--quiet", "--depth", "1", fsys.gitRemoteURL(), "."); err != nil {
+		return err
+	}
+
+	for name, f := range files {
+		if err := os.WriteFile(filepath.Join(dir, string(name)), []byte(f.GetContent()), 0o644); err != nil {
+			return &Error{Op: "push", ID: fsys.GetID(), Err: err}
+		}
+	}
+
+	if _, err := git("add", "--all"); err != nil {
+		return err
+	}
+
+	status, err := git("status", "--porcelain")
+	if err != nil || status == "" {
+		return err
+	}
+
+	message := commit.Message
+	if message == "" {
+		message = fsys.gitCommit.Message
+	}
+	if message == "" {
+		message = "Update files"
+	}
+
+	if _, err := git("commit", "--quiet", "--message", message); err != nil {
+		return err
+	}
+
+	_, err = git("push", "--quiet", "origin", "HEAD")
+	return err
+}
+
+// WithGitSnapshot reads the files of the gist from a single commit of its
+// git repository, the latest one or the revision set by WithRevision, rather
+// than from the REST API, whose responses may mix files of different
+// revisions while the gist is being edited. The metadata of the gist, such
+// as its description, still comes from the REST API.
+//
+// The repository is cloned once, in a temporary directory kept until Close
+// is called or the gist is deleted, and further loads only fetch the
+// commits it lacks.
+//
+// It requires the git command, which is not available under GOOS=js, and
+// authenticates requests with the token set by WithToken or
+// WithTokenSource, if any, which requires git 2.31 or later. Metadata loaded
+// by LoadMetadata comes from the REST API only.
+func WithGitSnapshot() Option {
+	return func(fsys *FS) {
+		fsys.gitSnapshot = true
+	}
+}
+
+// gitRepo is the bare clone of the repository of the gist kept by a
+// filesystem created with WithGitSnapshot.
+type gitRepo struct {
+	mu sync.Mutex
+	// dir is the directory of the clone, empty until it is cloned.
+	dir string
+}
+
+// Close removes the clone of the repository of the gist kept by a
+// filesystem created with WithGitSnapshot. The filesystem can still be
+// used, the repository being cloned again by the next load. It does nothing
+// for other filesystems.
+func (fsys *FS) Close() error {
+	fsys.gitRepo.mu.Lock()
+	defer fsys.gitRepo.mu.Unlock()
+
+	if fsys.gitRepo.dir == "" {
+		return nil
+	}
+
+	err := os.RemoveAll(fsys.gitRepo.dir)
+	fsys.gitRepo.dir = ""
+	if err != nil {
+		return &Error{Op: "close", ID: fsys.GetID(), Err: err}
+	}
+
+	return nil
+}
+
+// readGitSnapshot returns gist with the files of the commit of its git
+// repository matching the revision the filesystem serves, read at once
+// from an archive of its tree.
+func (fsys *FS) readGitSnapshot(ctx context.Context, gist *github.Gist, extra gistExtra) (*github.Gist, gistExtra, error) {
+	fsys.gitRepo.mu.Lock()
+	defer fsys.gitRepo.mu.Unlock()
+
+	env, err := fsys.gitEnv(GitCommit{})
+	if err != nil {
+		return nil, gistExtra{}, &Error{Op: "load", ID: fsys.GetID(), Err: err}
+	}
+
+	dir, err := fsys.syncGitRepo(ctx, env)
+	if err != nil {
+		return nil, gistExtra{}, err
+	}
+
+	git := func(args ...string) (string, error) {
+		return fsys.runGit(ctx, "load", dir, env, args...)
+	}
+
+	rev := "HEAD"
+	if fsys.revision != "" {
+		rev = fsys.revision
+	}
+	sha, err := git("rev-parse", "--verify", rev+"^{commit}")
+	if err != nil {
+		return nil, gistExtra{}, err
+	}
+	sha = strings.TrimSpace(sha)
+
+	archive, err := git("archive", "--format=tar", sha)
+	if err != nil {
+		return nil, gistExtra{}, err
+	}
+
+	files := make(map[github.GistFilename]github.GistFile)
+	tr := tar.NewReader(strings.NewReader(archive))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, gistExtra{}, &Error{Op: "load", ID: fsys.GetID(), Err: err}
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		b, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, gistExtra{}, &Error{Op: "load", ID: fsys.GetID(), Err: err}
+		}
+
+		name := github.GistFilename(hdr.Name)
+		f := gist.Files[name]
+		f.Filename = github.String(hdr.Name)
+		f.Content = github.String(string(b))
+		f.Size = github.Int(len(b))
+		if sha, ok := rawURLSHA(f.GetRawURL()); ok && sha != blobSHA(string(b)) {
+			// The raw URL is the one of the file at another revision.
+			f.RawURL = nil
+		}
+		files[name] = f
+	}
+
+	snapshot := *gist
+	snapshot.Files = files
+	if err := fsys.checkSize(fromGithubGist(&snapshot)); err != nil {
+		return nil, gistExtra{}, err
+	}
+
+	extra.sha = sha
+	extra.deferContent = false
+
+	return &snapshot, extra, nil
+}
+
+// syncGitRepo clones the repository of the gist into gitRepo, or fetches
+// the commits it lacks if it was cloned before, and returns its directory.
+// It must be called with gitRepo locked.
+func (fsys *FS) syncGitRepo(ctx context.Context, env []string) (string, error) {
+	repo := &fsys.gitRepo
+	if repo.dir != "" {
+		_, err := fsys.runGit(ctx, "load", repo.dir, env, "fetch", "--quiet", "--prune", "origin", "+refs/heads/*:refs/heads/*")
+		if err == nil {
+			return repo.dir, nil
+		}
+
+		// The clone may be broken or gone, so the next load clones again.
+		os.RemoveAll(repo.dir)
+		repo.dir = ""
+		return "", err
+	}
+
+	dir, err := os.MkdirTemp("", "gistfs-")
+	if err != nil {
+		return "", &Error{Op: "load", ID: fsys.GetID(), Err: err}
+	}
+
+	if _, err := fsys.runGit(ctx, "load", dir, env, "clone", "--quiet", "--bare", fsys.gitRemoteURL(), "."); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	repo.dir = dir
+
+	return dir, nil
+}
+
+// runGit runs the git command with args in dir, and returns its output.
+// Errors are wrapped in an *Error describing op.
+func (fsys *FS) runGit(ctx context.Context, op, dir string, env []string, args ...string) (string, error) {
+	if !gitSupported {
+		return "", &Error{Op: op, ID: fsys.GetID(), Err: ErrGitUnsupported}
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = env
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", &Error{Op: op, ID: fsys.GetID(), Err: fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))}
+	}
+
+	return stdout.String(), nil
+}
+
+// gitRemoteURL returns the URL of the git repository of the gist.
+func (fsys *FS) gitRemoteURL() string {
+	if fsys.gitRemote != "" {
+		return fsys.gitRemote
+	}
+
+	return "https://gist.github.com/" + fsys.GetID() + ".git"
+}
+
+// gitEnv returns the environment of the git commands pushing commit, which
+// identifies its author and holds the token authenticating requests, so
+// that it doesn't show up in their arguments.
+func (fsys *FS) gitEnv(commit GitCommit) ([]string, error) {
+	name := firstNonEmpty(commit.AuthorName, fsys.gitCommit.AuthorName, "gistfs")
+	email := firstNonEmpty(commit.AuthorEmail, fsys.gitCommit.AuthorEmail, "gistfs@users.noreply.github.com")
+
+	env := append(os.Environ(),
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_AUTHOR_NAME="+name,
+		"GIT_AUTHOR_EMAIL="+email,
+		"GIT_COMMITTER_NAME="+name,
+		"GIT_COMMITTER_EMAIL="+email,
+	)
+
+	if fsys.tokenSource != nil {
+		if err := checkGitVersion(); err != nil {
+			return nil, err
+		}
+
+		tok, err := fsys.tokenSource.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		auth := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + tok.AccessToken))
+		env = append(env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+auth,
+		)
+	}
+
+	return env, nil
+}
+
+// minGitVersion is the first version of git reading its configuration from
+// GIT_CONFIG_COUNT and related variables, which gitEnv relies on.
+var minGitVersion = [2]int{2, 31}
+
+// installedGitVersion is the version of the installed git command, read
+// once.
+var installedGitVersion struct {
+	once    sync.Once
+	version [2]int
+	err     error
+}
+
+// checkGitVersion returns an error if the installed git command is older
+// than minGitVersion, as tokens would then not be sent.
+func checkGitVersion() error {
+	if !gitSupported {
+		// runGit reports it.
+		return nil
+	}
+
+	v := &installedGitVersion
+	v.once.Do(func() {
+		out, err := exec.Command("git", "version").Output()
+		if err != nil {
+			v.err = fmt.Errorf("git version: %w", err)
+			return
+		}
+		v.version, v.err = parseGitVersion(string(out))
+	})
+	if v.err != nil {
+		return v.err
+	}
+
+	if v.version[0] < minGitVersion[0] || (v.version[0] == minGitVersion[0] && v.version[1] < minGitVersion[1]) {
+		return fmt.Errorf("git %d.%d can't authenticate requests, %d.%d or later is required", v.version[0], v.version[1], minGitVersion[0], minGitVersion[1])
+	}
+
+	return nil
+}
+
+// parseGitVersion returns the major and minor version printed by
+// "git version", such as "git version 2.39.5".
+func parseGitVersion(out string) ([2]int, error) {
+	fields := strings.Fields(out)
+	if len(fields) < 3 {
+		return [2]int{}, fmt.Errorf("unexpected git version %q", strings.TrimSpace(out))
+	}
+
+	var version [2]int
+	parts := strings.SplitN(fields[2], ".", 3)
+	for i := range version {
+		if i >= len(parts) {
+			return [2]int{}, fmt.Errorf("unexpected git version %q", fields[2])
+		}
+
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return [2]int{}, fmt.Errorf("unexpected git version %q", fields[2])
+		}
+		version[i] = n
+	}
+
+	return version, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,4 @@
+package gistfs
+
+// gitSupported is false in browsers, where the git command can't be run.
+const gitSupported = false
@@ -0,0 +1,23 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGitUnsupported(t *testing.T) {
+	_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+
+	gfs := NewWithClient(client, referenceGistID, WithGitSnapshot())
+	if err := gfs.Load(context.Background()); !errors.Is(err, ErrGitUnsupported) {
+		t.Fatalf("Loading, got %#v, want ErrGitUnsupported", err)
+	}
+
+	gfs = NewWithClient(client, referenceGistID, WithWritable(), WithGitWrites(GitCommit{}))
+	src := fstest.MapFS{"a.txt": {Data: []byte("b")}}
+	if _, err := gfs.UpdateFromFS(context.Background(), src); !errors.Is(err, ErrGitUnsupported) {
+		t.Fatalf("Updating, got %#v, want ErrGitUnsupported", err)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !js
+// +build !js
+
+package gistfs
+
+// gitSupported tells whether the git command can be run.
+const gitSupported = true
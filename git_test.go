@@ -0,0 +1,234 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// newGitRemote returns the path of a bare git repository holding a commit
+// of files.
+func newGitRemote(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	gitRun(t, "", "init", "--quiet", "--bare", remote)
+	commitToRemote(t, remote, files)
+
+	return remote
+}
+
+// commitToRemote pushes a commit of files to remote, and returns its SHA.
+func commitToRemote(t *testing.T, remote string, files map[string]string) string {
+	t.Helper()
+
+	work := t.TempDir()
+	gitRun(t, work, "clone", "--quiet", remote, ".")
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(work, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitRun(t, work, "add", "--all")
+	gitRun(t, work, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "--message", "Commit")
+	gitRun(t, work, "push", "--quiet", "origin", "HEAD")
+
+	return gitRun(t, work, "rev-parse", "HEAD")
+}
+
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+func TestWithGitWrites(t *testing.T) {
+	remote := newGitRemote(t, map[string]string{"a.txt": "a"})
+	_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+
+	gfs := NewWithClient(client, referenceGistID,
+		WithWritable(),
+		WithGitWrites(GitCommit{AuthorName: "Jane", AuthorEmail: "jane@example.com"}),
+		WithGitRemote(remote),
+	)
+
+	t.Run("OK commit", func(t *testing.T) {
+		src := fstest.MapFS{"b.txt": {Data: []byte("b")}}
+		if _, err := gfs.CommitFromFS(context.Background(), src, GitCommit{Message: "Add b"}); err != nil {
+			t.Fatalf("Committing, expected no error but got %#v", err)
+		}
+
+		if got, want := gitRun(t, remote, "log", "-1", "--format=%an <%ae> %s"), "Jane <jane@example.com> Add b"; got != want {
+			t.Fatalf("Committing, got commit %#v, want %#v", got, want)
+		}
+		if got := gitRun(t, remote, "show", "HEAD:b.txt"); got != "b" {
+			t.Fatalf("Committing, got b.txt %#v, want %#v", got, "b")
+		}
+		if got := gitRun(t, remote, "show", "HEAD:a.txt"); got != "a" {
+			t.Fatalf("Committing, got a.txt %#v, want it left untouched", got)
+		}
+	})
+
+	t.Run("OK update", func(t *testing.T) {
+		src := fstest.MapFS{"a.txt": {Data: []byte("updated")}}
+		if _, err := gfs.UpdateFromFS(context.Background(), src); err != nil {
+			t.Fatalf("Updating, expected no error but got %#v", err)
+		}
+
+		if got, want := gitRun(t, remote, "log", "-1", "--format=%s"), "Update files"; got != want {
+			t.Fatalf("Updating, got commit %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK unchanged", func(t *testing.T) {
+		before := gitRun(t, remote, "rev-parse", "HEAD")
+
+		src := fstest.MapFS{"a.txt": {Data: []byte("updated")}}
+		if _, err := gfs.UpdateFromFS(context.Background(), src); err != nil {
+			t.Fatalf("Updating, expected no error but got %#v", err)
+		}
+
+		if after := gitRun(t, remote, "rev-parse", "HEAD"); after != before {
+			t.Fatal("Updating with unchanged files, got a new commit, want none")
+		}
+	})
+
+	t.Run("NOK remote", func(t *testing.T) {
+		gfs := NewWithClient(client, referenceGistID, WithWritable(), WithGitWrites(GitCommit{}), WithGitRemote(filepath.Join(t.TempDir(), "missing.git")))
+
+		src := fstest.MapFS{"a.txt": {Data: []byte("a")}}
+		if _, err := gfs.UpdateFromFS(context.Background(), src); err == nil {
+			t.Fatal("Updating, expected an error but got none")
+		}
+	})
+}
+
+func TestWithGitSnapshot(t *testing.T) {
+	remote := newGitRemote(t, map[string]string{"a.txt": "a"})
+	sha := commitToRemote(t, remote, map[string]string{"a.txt": "git a", "b.txt": "git b"})
+	_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+
+	t.Run("OK", func(t *testing.T) {
+		gfs := NewWithClient(client, referenceGistID, WithGitSnapshot(), WithGitRemote(remote), WithIntegrityCheck())
+		t.Cleanup(func() { gfs.Close() })
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		for name, want := range map[string]string{"a.txt": "git a", "b.txt": "git b"} {
+			if b, err := gfs.ReadFile(name); err != nil || string(b) != want {
+				t.Fatalf("Reading %s, got %#v (%v), want %#v", name, string(b), err, want)
+			}
+		}
+		if got := gfs.DebugState().Revision; got != sha {
+			t.Fatalf("Loading, got revision %#v, want the git commit %#v", got, sha)
+		}
+	})
+
+	t.Run("OK fetch", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		gfs := NewWithClient(client, referenceGistID, WithGitSnapshot(), WithGitRemote(remote))
+		t.Cleanup(func() { gfs.Close() })
+
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+		dir := gfs.gitRepo.dir
+
+		sha := commitToRemote(t, remote, map[string]string{"a.txt": "git a2"})
+		fg.SetFiles(map[string]string{"a.txt": "a2"})
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading again, expected no error but got %#v", err)
+		}
+
+		if b, _ := gfs.ReadFile("a.txt"); string(b) != "git a2" {
+			t.Fatalf("Reading after a new commit, got %#v, want %#v", string(b), "git a2")
+		}
+		if got := gfs.DebugState().Revision; got != sha {
+			t.Fatalf("Loading again, got revision %#v, want the new commit %#v", got, sha)
+		}
+		if gfs.gitRepo.dir != dir {
+			t.Fatalf("Loading again, got clone %#v, want the first one %#v fetched into", gfs.gitRepo.dir, dir)
+		}
+	})
+
+	t.Run("OK close", func(t *testing.T) {
+		gfs := NewWithClient(client, referenceGistID, WithGitSnapshot(), WithGitRemote(remote))
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+		dir := gfs.gitRepo.dir
+
+		if err := gfs.Close(); err != nil {
+			t.Fatalf("Closing, expected no error but got %#v", err)
+		}
+		if _, err := os.Stat(dir); !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("Closing, got %#v stating the clone, want %#v", err, fs.ErrNotExist)
+		}
+
+		// The repository is cloned again.
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading after closing, expected no error but got %#v", err)
+		}
+		if b, _ := gfs.ReadFile("b.txt"); string(b) != "git b" {
+			t.Fatalf("Reading after closing, got %#v, want %#v", string(b), "git b")
+		}
+		if err := gfs.Close(); err != nil {
+			t.Fatalf("Closing again, expected no error but got %#v", err)
+		}
+	})
+
+	t.Run("OK metadata", func(t *testing.T) {
+		gfs := NewWithClient(client, referenceGistID, WithGitSnapshot(), WithGitRemote(filepath.Join(t.TempDir(), "missing.git")))
+		if err := gfs.LoadMetadata(context.Background()); err != nil {
+			t.Fatalf("Loading metadata, expected no error but got %#v", err)
+		}
+	})
+
+	t.Run("NOK remote", func(t *testing.T) {
+		gfs := NewWithClient(client, referenceGistID, WithGitSnapshot(), WithGitRemote(filepath.Join(t.TempDir(), "missing.git")))
+
+		var gistErr *Error
+		if err := gfs.Load(context.Background()); !errors.As(err, &gistErr) || gistErr.Op != "load" {
+			t.Fatalf("Loading, got %#v, want a load *Error", err)
+		}
+		if gfs.IsLoaded() {
+			t.Fatal("Loading, got a loaded filesystem, want none")
+		}
+	})
+}
+
+func TestParseGitVersion(t *testing.T) {
+	tests := map[string][2]int{
+		"git version 2.39.5\n":                 {2, 39},
+		"git version 2.30.1 (Apple Git-130)\n": {2, 30},
+		"git version 2.31.0.windows.1\n":       {2, 31},
+	}
+	for out, want := range tests {
+		got, err := parseGitVersion(out)
+		if err != nil || got != want {
+			t.Fatalf("Parsing %q, got %v (%v), want %v", out, got, err, want)
+		}
+	}
+
+	if _, err := parseGitVersion("git version unknown"); err == nil {
+		t.Fatal("Parsing an unknown version, got no error, want one")
+	}
+}
@@ -3,6 +3,18 @@ module github.com/jhchabran/gistfs
 go 1.16
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/google/go-github/v33 v33.0.0
 	github.com/gregjones/httpcache v0.0.0-20190611155906-901d90724c79
+	github.com/hanwen/go-fuse/v2 v2.1.0
+	github.com/prometheus/client_golang v1.11.0
+	github.com/spf13/afero v1.6.0
+	github.com/yuin/goldmark v1.4.13
+	go.opentelemetry.io/otel v1.0.0
+	go.opentelemetry.io/otel/sdk v1.0.0
+	go.opentelemetry.io/otel/trace v1.0.0
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
+	golang.org/x/net v0.0.0-20200625001655-4c5254603344
+	golang.org/x/oauth2 v0.0.0-20190226205417-e64efc72b421
+	gopkg.in/yaml.v3 v3.0.1
 )
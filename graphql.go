@@ -0,0 +1,162 @@
+package gistfs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// WithGraphQL fetches the gist through the Github GraphQL API instead of the
+// REST one, retrieving its metadata and the text of all its files in a single
+// query. Because the GraphQL API looks gists up by owner, the login of the
+// gist owner must be given.
+//
+// The GraphQL API only accepts authenticated requests, see WithToken.
+func WithGraphQL(owner string) Option {
+	return func(fsys *FS) {
+		fsys.graphQLOwner = owner
+	}
+}
+
+// graphQLMaxFiles is the maximum number of files the GraphQL API returns
+// for a single gist.
+const graphQLMaxFiles = 300
+
+const gistQuery = `query($owner: String!, $name: String!, $limit: Int!) {
+  user(login: $owner) {
+    gist(name: $name) {
+      name
+      description
+      isPublic
+      url
+      createdAt
+      updatedAt
+      owner { login }
+      files(limit: $limit) {
+        name
+        size
+        text
+        language { name }
+      }
+    }
+  }
+}`
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+type graphQLGist struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	IsPublic    bool      `json:"isPublic"`
+	URL         string    `json:"url"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	Owner       struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+	Files []graphQLGistFile `json:"files"`
+}
+
+type graphQLGistFile struct {
+	Name     string `json:"name"`
+	Size     int    `json:"size"`
+	Text     string `json:"text"`
+	Language *struct {
+		Name string `json:"name"`
+	} `json:"language"`
+}
+
+type graphQLUser struct {
+	Gist *graphQLGist `json:"gist"`
+}
+
+type graphQLResponse struct {
+	Data struct {
+		User *graphQLUser `json:"user"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// fetchGraphQL retrieves the gist through the GraphQL API, and converts it
+// to the same shape the REST API returns.
+func (fsys *FS) fetchGraphQL(ctx context.Context) (*github.Gist, *github.Response, error) {
+	req, err := fsys.client.NewRequest(http.MethodPost, graphQLEndpoint(fsys.client), &graphQLRequest{
+		Query: gistQuery,
+		Variables: map[string]interface{}{
+			"owner": fsys.graphQLOwner,
+			"name":  fsys.GetID(),
+			"limit": graphQLMaxFiles,
+		},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var data graphQLResponse
+	resp, err := fsys.client.Do(ctx, req, &data)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	if len(data.Errors) > 0 {
+		msgs := make([]string, len(data.Errors))
+		for i, e := range data.Errors {
+			msgs[i] = e.Message
+		}
+		return nil, resp, fmt.Errorf("graphql: %s", strings.Join(msgs, "; "))
+	}
+
+	if data.Data.User == nil || data.Data.User.Gist == nil {
+		return nil, resp, fmt.Errorf("graphql: %w", ErrGistNotFound)
+	}
+
+	return data.Data.User.Gist.toGist(), resp, nil
+}
+
+// graphQLEndpoint returns the URL of the GraphQL API matching the REST API
+// the client targets, which differs on Github Enterprise.
+func graphQLEndpoint(client *github.Client) string {
+	if strings.HasSuffix(client.BaseURL.Path, "/api/v3/") {
+		u := *client.BaseURL
+		u.Path = strings.TrimSuffix(u.Path, "v3/") + "graphql"
+		return u.String()
+	}
+
+	return "graphql"
+}
+
+func (g *graphQLGist) toGist() *github.Gist {
+	files := make(map[github.GistFilename]github.GistFile, len(g.Files))
+	for _, f := range g.Files {
+		f := f
+		gf := github.GistFile{
+			Filename: &f.Name,
+			Size:     &f.Size,
+			Content:  &f.Text,
+		}
+		if f.Language != nil {
+			gf.Language = &f.Language.Name
+		}
+		files[github.GistFilename(f.Name)] = gf
+	}
+
+	return &github.Gist{
+		ID:          &g.Name,
+		Description: &g.Description,
+		Public:      &g.IsPublic,
+		HTMLURL:     &g.URL,
+		CreatedAt:   &g.CreatedAt,
+		UpdatedAt:   &g.UpdatedAt,
+		Owner:       &github.User{Login: &g.Owner.Login},
+		Files:       files,
+	}
+}
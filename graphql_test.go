@@ -0,0 +1,64 @@
+package gistfs
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v33/github"
+)
+
+func TestWithGraphQL(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{
+			"a.txt": "a",
+			"b.txt": "bb",
+		})
+
+		gfs := NewWithClient(client, referenceGistID, WithGraphQL("jhchabran"))
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading through GraphQL, expected no error but got %#v", err)
+		}
+
+		b, err := gfs.ReadFile("b.txt")
+		if err != nil {
+			t.Fatalf("Reading a file loaded through GraphQL, expected no error but got %#v", err)
+		}
+
+		if got, want := string(b), "bb"; got != want {
+			t.Fatalf("Reading a file loaded through GraphQL, got %#v, want %#v", got, want)
+		}
+
+		if got, want := fg.Requests(), 1; got != want {
+			t.Fatalf("Loading through GraphQL, got %d API requests, want %d", got, want)
+		}
+	})
+
+	t.Run("NOK unknown gist", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+
+		gfs := NewWithClient(client, "unknown", WithGraphQL("jhchabran"))
+		if err := gfs.Load(context.Background()); err == nil {
+			t.Fatal("Loading an unknown gist through GraphQL, got no error, want one")
+		}
+	})
+}
+
+func TestGraphQLEndpoint(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		want    string
+	}{
+		{"https://api.github.com/", "graphql"},
+		{"https://github.example.com/api/v3/", "https://github.example.com/api/graphql"},
+	}
+
+	for _, test := range tests {
+		client := github.NewClient(nil)
+		client.BaseURL, _ = url.Parse(test.baseURL)
+
+		if got := graphQLEndpoint(client); got != test.want {
+			t.Fatalf("GraphQL endpoint for %#v, got %#v, want %#v", test.baseURL, got, test.want)
+		}
+	}
+}
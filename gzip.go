@@ -0,0 +1,83 @@
+package gistfs
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// GzipNames tells which names the files decompressed by WithGunzip are
+// served at.
+type GzipNames int
+
+const (
+	// GzipLogical serves the decompressed content of a file named
+	// data.csv.gz at data.csv only.
+	GzipLogical GzipNames = iota + 1
+	// GzipBoth serves the decompressed content of a file named data.csv.gz
+	// at data.csv, and its compressed content at data.csv.gz.
+	GzipBoth
+)
+
+// gzipExt is the extension of the files decompressed by WithGunzip.
+const gzipExt = ".gz"
+
+// WithGunzip decompresses the files of the gist with a .gz extension when
+// they are loaded, serving their content at their name without it, and
+// also at their name as is if names is GzipBoth. Gists only store text, but
+// compressed files can still be pushed to their git repository, to fit
+// large datasets in them.
+//
+// A file of the gist already named like the decompressed one takes
+// precedence over it. Loads fail if a file is not valid gzip data, or if
+// its decompressed content exceeds the limit set with WithMaxFileSize.
+// Files whose content was deferred by LoadMetadata are only decompressed
+// once loaded with Load.
+func WithGunzip(names GzipNames) Option {
+	return func(fsys *FS) {
+		fsys.gunzipNames = names
+	}
+}
+
+// gunzipFile returns the decompressed version of f, served as name, if f is
+// a gzipped file that must be decompressed.
+func (fsys *FS) gunzipFile(gist *github.Gist, f github.GistFile) (github.GistFile, bool, error) {
+	name := f.GetFilename()
+	if fsys.gunzipNames == 0 || len(name) <= len(gzipExt) || !strings.EqualFold(name[len(name)-len(gzipExt):], gzipExt) {
+		return f, false, nil
+	}
+
+	logical := name[:len(name)-len(gzipExt)]
+	if _, ok := gist.Files[github.GistFilename(logical)]; ok {
+		return f, false, nil
+	}
+
+	zr, err := gzip.NewReader(strings.NewReader(f.GetContent()))
+	if err != nil {
+		return f, false, fmt.Errorf("decompressing %s: %w", name, err)
+	}
+
+	var r io.Reader = zr
+	if fsys.maxFileSize > 0 {
+		r = io.LimitReader(zr, fsys.maxFileSize+1)
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return f, false, fmt.Errorf("decompressing %s: %w", name, err)
+	}
+	if err := fsys.checkFileSize(logical, int64(len(b))); err != nil {
+		return f, false, err
+	}
+
+	// The type, language and raw URL Github reports are the ones of the
+	// compressed file.
+	return github.GistFile{
+		Filename: github.String(logical),
+		Size:     github.Int(len(b)),
+		Content:  github.String(string(b)),
+	}, true, nil
+}
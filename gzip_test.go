@@ -0,0 +1,114 @@
+package gistfs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+// gzipped returns content compressed with gzip.
+func gzipped(t *testing.T, content string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(content)); err != nil {
+		t.Fatalf("Compressing, expected no error but got %#v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Compressing, expected no error but got %#v", err)
+	}
+
+	return buf.String()
+}
+
+func TestWithGunzip(t *testing.T) {
+	compressed := gzipped(t, "a,b\r\n1,2\r\n")
+	newGetter := func(files map[string]string) *stubGetter {
+		gist := &Gist{ID: referenceGistID, Files: map[string]GistFile{}}
+		for name, content := range files {
+			gist.Files[name] = GistFile{Filename: name, Content: content, Size: len(content)}
+		}
+		return &stubGetter{gist: gist}
+	}
+
+	t.Run("OK logical", func(t *testing.T) {
+		gfs := NewWithGetter(newGetter(map[string]string{"data.csv.gz": compressed}), referenceGistID, WithGunzip(GzipLogical))
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		b, err := gfs.ReadFile("data.csv")
+		if err != nil {
+			t.Fatalf("Reading, expected no error but got %#v", err)
+		}
+		if got, want := string(b), "a,b\r\n1,2\r\n"; got != want {
+			t.Fatalf("Reading, got %#v, want %#v", got, want)
+		}
+
+		info, err := fs.Stat(gfs, "data.csv")
+		if err != nil {
+			t.Fatalf("Stating, expected no error but got %#v", err)
+		}
+		if got, want := info.Size(), int64(len(b)); got != want {
+			t.Fatalf("Stating, got size %d, want %d", got, want)
+		}
+		if got, want := info.Sys().(*FileMetadata).MIMEType, "text/csv"; got != want {
+			t.Fatalf("Stating, got MIME type %#v, want %#v", got, want)
+		}
+
+		if _, err := gfs.ReadFile("data.csv.gz"); !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("Reading the compressed file, got %#v, want a not exist error", err)
+		}
+	})
+
+	t.Run("OK both with transforms", func(t *testing.T) {
+		gfs := NewWithGetter(newGetter(map[string]string{"data.csv.gz": compressed}), referenceGistID, WithGunzip(GzipBoth), WithNormalizeNewlines())
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		if b, _ := gfs.ReadFile("data.csv"); string(b) != "a,b\n1,2\n" {
+			t.Fatalf("Reading, got %#v, want %#v", string(b), "a,b\n1,2\n")
+		}
+		if b, _ := gfs.ReadFile("data.csv.gz"); string(b) != compressed {
+			t.Fatalf("Reading the compressed file, got %#v, want the compressed content", string(b))
+		}
+	})
+
+	t.Run("OK existing file", func(t *testing.T) {
+		gfs := NewWithGetter(newGetter(map[string]string{"data.csv.gz": compressed, "data.csv": "real"}), referenceGistID, WithGunzip(GzipLogical))
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		if b, _ := gfs.ReadFile("data.csv"); string(b) != "real" {
+			t.Fatalf("Reading, got %#v, want %#v", string(b), "real")
+		}
+		if _, err := gfs.ReadFile("data.csv.gz"); err != nil {
+			t.Fatalf("Reading the compressed file, expected no error but got %#v", err)
+		}
+	})
+
+	t.Run("NOK invalid", func(t *testing.T) {
+		gfs := NewWithGetter(newGetter(map[string]string{"data.csv.gz": "not gzip"}), referenceGistID, WithGunzip(GzipLogical))
+
+		var gistErr *Error
+		if err := gfs.Load(context.Background()); !errors.As(err, &gistErr) {
+			t.Fatalf("Loading invalid gzip data, got %#v, want an *Error", err)
+		}
+	})
+
+	t.Run("NOK too large", func(t *testing.T) {
+		bomb := gzipped(t, strings.Repeat("a", 1<<20))
+		gfs := NewWithGetter(newGetter(map[string]string{"data.csv.gz": bomb}), referenceGistID, WithGunzip(GzipLogical), WithMaxFileSize(int64(len(bomb))))
+
+		if err := gfs.Load(context.Background()); !errors.Is(err, ErrTooLarge) {
+			t.Fatalf("Loading a gzip bomb, got %#v, want a too large error", err)
+		}
+	})
+}
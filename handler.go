@@ -0,0 +1,381 @@
+package gistfs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"html/template"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// handler is the http.Handler returned by Handler.
+type handler struct {
+	fsys     *FS
+	listing  bool
+	archive  string
+	markdown MarkdownRenderer
+}
+
+// HandlerOption configures the http.Handler returned by Handler.
+type HandlerOption func(*handler)
+
+// WithListing makes the handler list the content of directories that have
+// no index.html file, in an HTML page linking to the gist on Github.
+func WithListing() HandlerOption {
+	return func(h *handler) {
+		h.listing = true
+	}
+}
+
+// WithArchive makes the handler serve a zip archive of all the files of the
+// gist at the given route, such as "/archive.zip", which shadows any file of
+// the same name.
+func WithArchive(route string) HandlerOption {
+	return func(h *handler) {
+		h.archive = path.Clean("/" + route)
+	}
+}
+
+// MarkdownRenderer renders Markdown documents to HTML.
+type MarkdownRenderer interface {
+	// Render writes the HTML rendering of the Markdown source to w.
+	Render(w io.Writer, source []byte) error
+}
+
+// MarkdownRendererFunc is an adapter to use an ordinary function as a
+// MarkdownRenderer.
+type MarkdownRendererFunc func(w io.Writer, source []byte) error
+
+// Render calls f(w, source).
+func (f MarkdownRendererFunc) Render(w io.Writer, source []byte) error {
+	return f(w, source)
+}
+
+// goldmarkRenderer is the default MarkdownRenderer, rendering Github
+// flavored Markdown.
+var goldmarkRenderer = func() MarkdownRenderer {
+	md := goldmark.New(goldmark.WithExtensions(extension.GFM))
+	return MarkdownRendererFunc(func(w io.Writer, source []byte) error {
+		return md.Convert(source, w)
+	})
+}()
+
+// WithMarkdown makes the handler serve Markdown files, named *.md or
+// *.markdown, as HTML pages rendered by r instead of raw text. A nil r
+// renders Github flavored Markdown with goldmark.
+func WithMarkdown(r MarkdownRenderer) HandlerOption {
+	return func(h *handler) {
+		if r == nil {
+			r = goldmarkRenderer
+		}
+		h.markdown = r
+	}
+}
+
+// Handler returns an http.Handler serving the files of fsys, which must be
+// loaded beforehand. Directories are served by their index.html file, if
+// any, and are otherwise not found unless WithListing is given.
+//
+// Unlike http.FileServer, it sets the ETag of each response to a hash of its
+// content, along with its Last-Modified date and a Content-Type guessed from
+// its name or content, so that browsers and caches can revalidate gist
+// content cheaply with If-None-Match or If-Modified-Since. Range requests
+// are supported as well.
+func Handler(fsys *FS, opts ...HandlerOption) http.Handler {
+	h := &handler{fsys: fsys}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.archive != "" && path.Clean("/"+r.URL.Path) == h.archive {
+		h.serveArchive(w, r)
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" {
+		name = "."
+	}
+
+	info, err := h.stat(r.Context(), name)
+	if err == nil && info.IsDir() {
+		dir := name
+		name = path.Join(dir, indexPage)
+		info, err = h.stat(r.Context(), name)
+
+		if h.listing && errors.Is(err, fs.ErrNotExist) {
+			h.serveListing(w, r, dir)
+			return
+		}
+	}
+
+	var content []byte
+	if err == nil {
+		content, err = h.fsys.ReadFileContext(r.Context(), name)
+	}
+
+	if err != nil {
+		serveError(w, err)
+		return
+	}
+
+	ctype := contentType(name, info, content)
+	if h.markdown != nil && isMarkdown(name) {
+		if content, err = h.renderMarkdown(name, content); err != nil {
+			http.Error(w, "cannot render markdown", http.StatusInternalServerError)
+			return
+		}
+		ctype = "text/html; charset=utf-8"
+	}
+
+	sum := sha256.Sum256(content)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+	w.Header().Set("Content-Type", ctype)
+
+	http.ServeContent(w, r, name, info.ModTime(), bytes.NewReader(content))
+}
+
+// markdownTemplate wraps the rendering of a Markdown file in a page.
+var markdownTemplate = template.Must(template.New("markdown").Parse(`<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body>
+{{.Body}}</body>
+</html>
+`))
+
+// isMarkdown reports whether the named file is a Markdown document.
+func isMarkdown(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".md", ".markdown":
+		return true
+	}
+
+	return false
+}
+
+// stat returns the fs.FileInfo of the named file, making the requests it
+// needs with ctx.
+func (h *handler) stat(ctx context.Context, name string) (fs.FileInfo, error) {
+	f, err := h.fsys.OpenContext(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return f.Stat()
+}
+
+// renderMarkdown renders the Markdown content of the named file to an HTML
+// page.
+func (h *handler) renderMarkdown(name string, content []byte) ([]byte, error) {
+	return renderMarkdownPage(h.markdown, name, content)
+}
+
+// renderMarkdownPage renders the Markdown content of the named file to an
+// HTML page with r.
+func renderMarkdownPage(r MarkdownRenderer, name string, content []byte) ([]byte, error) {
+	var body bytes.Buffer
+	if err := r.Render(&body, content); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err := markdownTemplate.Execute(&buf, struct {
+		Title string
+		Body  template.HTML
+	}{
+		Title: path.Base(name),
+		Body:  template.HTML(body.String()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// serveArchive replies with a zip archive of the gist, streamed as it is
+// built.
+func (h *handler) serveArchive(w http.ResponseWriter, r *http.Request) {
+	if h.fsys.state().gist == nil {
+		serveError(w, ErrNotLoaded)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": h.fsys.GetID() + ".zip",
+	}))
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	// Headers are sent along with the first bytes of the archive, so a
+	// failure past that point can only cut the response short.
+	_ = h.fsys.WriteZip(w)
+}
+
+// contentType returns the media type of the named file, guessed from its
+// extension, or else the one Github reports for it, or else guessed from its
+// content.
+func contentType(name string, info fs.FileInfo, content []byte) string {
+	if ctype := mime.TypeByExtension(path.Ext(name)); ctype != "" {
+		return ctype
+	}
+
+	if meta, ok := info.Sys().(*FileMetadata); ok && meta.MIMEType != "" {
+		// Gist content is always UTF-8.
+		if strings.HasPrefix(meta.MIMEType, "text/") {
+			return meta.MIMEType + "; charset=utf-8"
+		}
+		return meta.MIMEType
+	}
+
+	return http.DetectContentType(content)
+}
+
+// serveError replies to a request that failed with err, with the matching
+// HTTP status.
+func serveError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrStale):
+		http.Error(w, "gist is stale", http.StatusServiceUnavailable)
+	case errors.Is(err, fs.ErrNotExist):
+		http.Error(w, "404 page not found", http.StatusNotFound)
+	case errors.Is(err, ErrNotLoaded):
+		http.Error(w, "gist not loaded", http.StatusServiceUnavailable)
+	default:
+		http.Error(w, "cannot read gist", http.StatusBadGateway)
+	}
+}
+
+// listingTemplate renders the listing of a directory.
+var listingTemplate = template.Must(template.New("listing").Parse(`<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{with .HTMLURL}}<p><a href="{{.}}">View on Github</a></p>
+{{end}}{{with .Archive}}<p><a href="{{.}}">Download ZIP</a></p>
+{{end}}<table>
+<thead><tr><th>Name</th><th>Size</th><th>Modified</th><th>Language</th></tr></thead>
+<tbody>
+{{range .Entries}}<tr><td><a href="{{.Link}}">{{.Name}}</a></td><td>{{.Size}}</td><td>{{.ModTime}}</td><td>{{.Language}}</td></tr>
+{{end}}</tbody>
+</table>
+</body>
+</html>
+`))
+
+// listingEntry is an entry of a directory listing.
+type listingEntry struct {
+	Name     string
+	Link     string
+	Size     string
+	ModTime  string
+	Language string
+}
+
+// serveListing replies with an HTML page listing the entries of dir.
+func (h *handler) serveListing(w http.ResponseWriter, r *http.Request, dir string) {
+	// Entries are linked relatively, which requires the URL of directories
+	// to end with a slash. As with http.FileServer, the redirect is relative
+	// too, so that it works under a prefix.
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		w.Header().Set("Location", path.Base(r.URL.Path)+"/")
+		w.WriteHeader(http.StatusMovedPermanently)
+		return
+	}
+
+	entries, err := h.fsys.ReadDirContext(r.Context(), dir)
+	if err != nil {
+		serveError(w, err)
+		return
+	}
+
+	data := struct {
+		Title   string
+		HTMLURL string
+		Archive string
+		Entries []listingEntry
+	}{
+		Title:   h.fsys.Description(),
+		HTMLURL: h.fsys.HTMLURL(),
+	}
+	if h.archive != "" {
+		// Links are relative, so that the handler can be served under a
+		// prefix, as with http.StripPrefix.
+		up := ""
+		if dir != "." {
+			up = strings.Repeat("../", strings.Count(dir, "/")+1)
+		}
+		data.Archive = up + strings.TrimPrefix(h.archive, "/")
+	}
+	if data.Title == "" {
+		data.Title = "Gist " + h.fsys.GetID()
+	}
+
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			serveError(w, err)
+			return
+		}
+
+		e := listingEntry{
+			Name:    entry.Name(),
+			Link:    (&url.URL{Path: entry.Name()}).String(),
+			ModTime: info.ModTime().UTC().Format(time.RFC3339),
+		}
+		if entry.IsDir() {
+			e.Name += "/"
+			e.Link += "/"
+		} else {
+			e.Size = strconv.FormatInt(info.Size(), 10)
+		}
+		if meta, ok := info.Sys().(*FileMetadata); ok {
+			e.Language = meta.Language
+		}
+
+		data.Entries = append(data.Entries, e)
+	}
+
+	var buf bytes.Buffer
+	if err := listingTemplate.Execute(&buf, data); err != nil {
+		serveError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	http.ServeContent(w, r, "", h.fsys.UpdatedAt(), bytes.NewReader(buf.Bytes()))
+}
@@ -0,0 +1,315 @@
+package gistfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	_, client := newFakeGist(t, map[string]string{
+		"a.txt":      "a",
+		"index.html": "<p>hello</p>",
+		"notes.zzz":  "package main",
+		"blob.zzz":   "\x00\x01\x02",
+	})
+	gfs := NewWithClient(client, referenceGistID)
+
+	serve := func(method, target string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+
+		rec := httptest.NewRecorder()
+		Handler(gfs).ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("NOK not loaded", func(t *testing.T) {
+		if got, want := serve(http.MethodGet, "/a.txt", nil).Code, http.StatusServiceUnavailable; got != want {
+			t.Fatalf("Serving before loading, got status %d, want %d", got, want)
+		}
+	})
+
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		rec := serve(http.MethodGet, "/a.txt", nil)
+		if got, want := rec.Code, http.StatusOK; got != want {
+			t.Fatalf("Serving a file, got status %d, want %d", got, want)
+		}
+		if got, want := rec.Body.String(), "a"; got != want {
+			t.Fatalf("Serving a file, got body %#v, want %#v", got, want)
+		}
+
+		tests := map[string]string{
+			"Content-Type":  "text/plain; charset=utf-8",
+			"ETag":          `"ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb"`,
+			"Last-Modified": fakeUpdatedAt.UTC().Format(http.TimeFormat),
+		}
+		for header, want := range tests {
+			if got := rec.Header().Get(header); got != want {
+				t.Fatalf("Serving a file, got %s %#v, want %#v", header, got, want)
+			}
+		}
+	})
+
+	t.Run("OK content type", func(t *testing.T) {
+		for target, want := range map[string]string{
+			"/":          "text/html; charset=utf-8",
+			"/notes.zzz": "text/plain; charset=utf-8",
+			// The type Github reports is preferred to sniffing.
+			"/blob.zzz": "text/plain; charset=utf-8",
+		} {
+			if got := serve(http.MethodGet, target, nil).Header().Get("Content-Type"); got != want {
+				t.Fatalf("Serving %s, got Content-Type %#v, want %#v", target, got, want)
+			}
+		}
+	})
+
+	t.Run("OK not modified", func(t *testing.T) {
+		etag := serve(http.MethodGet, "/a.txt", nil).Header().Get("ETag")
+
+		rec := serve(http.MethodGet, "/a.txt", http.Header{"If-None-Match": {etag}})
+		if got, want := rec.Code, http.StatusNotModified; got != want {
+			t.Fatalf("Serving with a matching If-None-Match, got status %d, want %d", got, want)
+		}
+
+		since := fakeUpdatedAt.Add(time.Hour).UTC().Format(http.TimeFormat)
+		rec = serve(http.MethodGet, "/a.txt", http.Header{"If-Modified-Since": {since}})
+		if got, want := rec.Code, http.StatusNotModified; got != want {
+			t.Fatalf("Serving with a later If-Modified-Since, got status %d, want %d", got, want)
+		}
+	})
+
+	t.Run("NOK", func(t *testing.T) {
+		tests := []struct {
+			method, target string
+			want           int
+		}{
+			{http.MethodGet, "/unknown.txt", http.StatusNotFound},
+			{http.MethodPost, "/a.txt", http.StatusMethodNotAllowed},
+		}
+
+		for _, test := range tests {
+			if got := serve(test.method, test.target, nil).Code; got != test.want {
+				t.Fatalf("%s %s, got status %d, want %d", test.method, test.target, got, test.want)
+			}
+		}
+	})
+}
+
+func TestHandlerListing(t *testing.T) {
+	_, client := newFakeGist(t, map[string]string{
+		"a.txt":     "a",
+		"<b>.zzz":   "bb",
+		"sub/c.txt": "c",
+	})
+	gfs := NewWithClient(client, referenceGistID)
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+
+	serve := func(h http.Handler, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	t.Run("NOK disabled", func(t *testing.T) {
+		if got, want := serve(Handler(gfs), "/").Code, http.StatusNotFound; got != want {
+			t.Fatalf("Serving the root without listing, got status %d, want %d", got, want)
+		}
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		rec := serve(Handler(gfs, WithListing()), "/")
+		if got, want := rec.Code, http.StatusOK; got != want {
+			t.Fatalf("Serving the root, got status %d, want %d", got, want)
+		}
+		if got, want := rec.Header().Get("Content-Type"), "text/html; charset=utf-8"; got != want {
+			t.Fatalf("Serving the root, got Content-Type %#v, want %#v", got, want)
+		}
+
+		body := rec.Body.String()
+		for _, want := range []string{
+			`<a href="a.txt">a.txt</a>`,
+			`<a href="sub/">sub/</a>`,
+			`&lt;b&gt;.zzz`,
+			`<a href="` + gfs.HTMLURL() + `">`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Fatalf("Serving the root, expected body to contain %#v but got %s", want, body)
+			}
+		}
+	})
+
+	t.Run("OK redirect", func(t *testing.T) {
+		rec := serve(Handler(gfs, WithListing()), "/sub")
+		if got, want := rec.Code, http.StatusMovedPermanently; got != want {
+			t.Fatalf("Serving a directory without a trailing slash, got status %d, want %d", got, want)
+		}
+		if got, want := rec.Header().Get("Location"), "sub/"; got != want {
+			t.Fatalf("Serving a directory without a trailing slash, got Location %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK subdirectory", func(t *testing.T) {
+		rec := serve(Handler(gfs, WithListing()), "/sub/")
+		if got, want := rec.Code, http.StatusOK; got != want {
+			t.Fatalf("Serving a subdirectory, got status %d, want %d", got, want)
+		}
+		if body := rec.Body.String(); !strings.Contains(body, `<a href="c.txt">c.txt</a>`) {
+			t.Fatalf("Serving a subdirectory, expected body to list c.txt but got %s", body)
+		}
+	})
+}
+
+func TestHandlerArchive(t *testing.T) {
+	_, client := newFakeGist(t, map[string]string{
+		"a.txt": "a",
+		"b.txt": "bb",
+	})
+	gfs := NewWithClient(client, referenceGistID)
+	h := Handler(gfs, WithListing(), WithArchive("archive.zip"))
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	t.Run("NOK not loaded", func(t *testing.T) {
+		if got, want := serve(http.MethodGet, "/archive.zip").Code, http.StatusServiceUnavailable; got != want {
+			t.Fatalf("Serving the archive before loading, got status %d, want %d", got, want)
+		}
+	})
+
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		rec := serve(http.MethodGet, "/archive.zip")
+		if got, want := rec.Code, http.StatusOK; got != want {
+			t.Fatalf("Serving the archive, got status %d, want %d", got, want)
+		}
+		if got, want := rec.Header().Get("Content-Type"), "application/zip"; got != want {
+			t.Fatalf("Serving the archive, got Content-Type %#v, want %#v", got, want)
+		}
+		if got, want := rec.Header().Get("Content-Disposition"), "attachment; filename="+referenceGistID+".zip"; got != want {
+			t.Fatalf("Serving the archive, got Content-Disposition %#v, want %#v", got, want)
+		}
+
+		zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+		if err != nil {
+			t.Fatalf("Reading the archive, expected no error but got %#v", err)
+		}
+
+		var names []string
+		for _, f := range zr.File {
+			names = append(names, f.Name)
+		}
+		if got, want := strings.Join(names, ","), "a.txt,b.txt"; got != want {
+			t.Fatalf("Reading the archive, got files %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK head", func(t *testing.T) {
+		rec := serve(http.MethodHead, "/archive.zip")
+		if got, want := rec.Code, http.StatusOK; got != want {
+			t.Fatalf("Serving the archive, got status %d, want %d", got, want)
+		}
+		if got := rec.Body.Len(); got != 0 {
+			t.Fatalf("Serving the archive to a HEAD request, got %d bytes of body, want none", got)
+		}
+	})
+
+	t.Run("OK listing", func(t *testing.T) {
+		if body := serve(http.MethodGet, "/").Body.String(); !strings.Contains(body, `<a href="archive.zip">`) {
+			t.Fatalf("Serving the root, expected body to link to the archive but got %s", body)
+		}
+	})
+}
+
+func TestHandlerMarkdown(t *testing.T) {
+	_, client := newFakeGist(t, map[string]string{
+		"notes.md": "# Title\n\n<b>~~done~~</b>",
+		"a.txt":    "# a",
+	})
+	gfs := NewWithClient(client, referenceGistID)
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+
+	serve := func(h http.Handler, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	t.Run("OK default", func(t *testing.T) {
+		rec := serve(Handler(gfs, WithMarkdown(nil)), "/notes.md")
+		if got, want := rec.Code, http.StatusOK; got != want {
+			t.Fatalf("Serving a Markdown file, got status %d, want %d", got, want)
+		}
+		if got, want := rec.Header().Get("Content-Type"), "text/html; charset=utf-8"; got != want {
+			t.Fatalf("Serving a Markdown file, got Content-Type %#v, want %#v", got, want)
+		}
+
+		body := rec.Body.String()
+		for _, want := range []string{"<title>notes.md</title>", "<h1>Title</h1>", "<del>done</del>"} {
+			if !strings.Contains(body, want) {
+				t.Fatalf("Serving a Markdown file, expected body to contain %#v but got %s", want, body)
+			}
+		}
+		if strings.Contains(body, "<b>") {
+			t.Fatalf("Serving a Markdown file, expected raw HTML to be omitted but got %s", body)
+		}
+	})
+
+	t.Run("OK custom", func(t *testing.T) {
+		r := MarkdownRendererFunc(func(w io.Writer, source []byte) error {
+			_, err := io.WriteString(w, "<pre>"+strings.ToUpper(string(source))+"</pre>")
+			return err
+		})
+
+		if body := serve(Handler(gfs, WithMarkdown(r)), "/notes.md").Body.String(); !strings.Contains(body, "<pre># TITLE") {
+			t.Fatalf("Serving a Markdown file, expected the custom rendering but got %s", body)
+		}
+	})
+
+	t.Run("OK other files", func(t *testing.T) {
+		rec := serve(Handler(gfs, WithMarkdown(nil)), "/a.txt")
+		if got, want := rec.Body.String(), "# a"; got != want {
+			t.Fatalf("Serving a text file, got body %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK disabled", func(t *testing.T) {
+		rec := serve(Handler(gfs), "/notes.md")
+		if got, want := rec.Body.String(), "# Title\n\n<b>~~done~~</b>"; got != want {
+			t.Fatalf("Serving a Markdown file without rendering, got body %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("NOK render error", func(t *testing.T) {
+		r := MarkdownRendererFunc(func(w io.Writer, source []byte) error {
+			return errors.New("boom")
+		})
+
+		if got, want := serve(Handler(gfs, WithMarkdown(r)), "/notes.md").Code, http.StatusInternalServerError; got != want {
+			t.Fatalf("Serving a Markdown file that fails to render, got status %d, want %d", got, want)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package gistfs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// Hashes returns the SHA-256 of the content of the files of the gist, in
+// hexadecimal, by the path they are served at. It returns nil if the
+// filesystem is not loaded.
+//
+// Virtual files are left out, along with files whose content was deferred
+// by LoadMetadata and not fetched yet.
+func (fsys *FS) Hashes() map[string]string {
+	s := fsys.state()
+	if s.gist == nil {
+		return nil
+	}
+
+	sums := fsys.hashes(s)
+	hashes := make(map[string]string, len(sums))
+	for p, sum := range sums {
+		hashes[p] = sum
+	}
+
+	return hashes
+}
+
+// fileHashes are the hashes of the files of a gist.
+type fileHashes struct {
+	gist *github.Gist
+	sums map[string]string
+}
+
+// hashes returns the hashes of the files of the gist of s, computing them
+// only once per loaded gist, as virtual files depending on them are
+// generated on every lookup. The returned map must not be modified.
+func (fsys *FS) hashes(s *state) map[string]string {
+	if h, ok := fsys.fileHashes.Load().(*fileHashes); ok && h.gist == s.gist {
+		return h.sums
+	}
+
+	sums := make(map[string]string, len(s.gist.Files))
+	for _, f := range s.gist.Files {
+		if isTruncated(f) {
+			continue
+		}
+
+		content, err := fsys.content(s, &f)
+		if err != nil {
+			continue
+		}
+
+		sum := sha256.Sum256([]byte(content))
+		sums[fsys.filePath(f.GetFilename())] = hex.EncodeToString(sum[:])
+	}
+
+	fsys.fileHashes.Store(&fileHashes{gist: s.gist, sums: sums})
+
+	return sums
+}
+
+// ChecksumsFile is the path of the virtual file added by WithChecksumsFile.
+const ChecksumsFile = ".gist/checksums.txt"
+
+// WithChecksumsFile exposes the hashes returned by Hashes in a virtual file
+// at ChecksumsFile, in the format of the sha256sum command, so that the
+// files of the gist can be checked with sha256sum -c once copied.
+func WithChecksumsFile() Option {
+	return func(fsys *FS) {
+		fsys.virtuals = append(fsys.virtuals, checksumsFile)
+	}
+}
+
+func checksumsFile(fsys *FS, s *state) map[string]github.GistFile {
+	sums := fsys.hashes(s)
+
+	paths := make([]string, 0, len(sums))
+	for p := range sums {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	for _, p := range paths {
+		fmt.Fprintf(&b, "%s  %s\n", sums[p], p)
+	}
+
+	return map[string]github.GistFile{
+		ChecksumsFile: virtualFile(ChecksumsFile, []byte(b.String()), "Text"),
+	}
+}
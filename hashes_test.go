@@ -0,0 +1,57 @@
+package gistfs
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// Hashes of the contents used in tests, as given by sha256sum.
+const (
+	sha256A  = "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb"
+	sha256BC = "1e0bbd6c686ba050b8eb03ffeedc64fdc9d80947fce821abbe5d6dc8d252c5ac"
+)
+
+func TestHashes(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "a", "dir--b.txt": "bc"})
+
+	gfs := NewWithClient(client, referenceGistID, WithPathSeparator("--"), WithCompression(), WithChecksumsFile())
+	if got := gfs.Hashes(); got != nil {
+		t.Fatalf("Hashes before loading, got %#v, want nil", got)
+	}
+
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		want := map[string]string{"a.txt": sha256A, "dir/b.txt": sha256BC}
+		if got := gfs.Hashes(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Hashes, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK checksums file", func(t *testing.T) {
+		b, err := gfs.ReadFile(ChecksumsFile)
+		if err != nil {
+			t.Fatalf("Reading the checksums file, expected no error but got %#v", err)
+		}
+
+		want := sha256A + "  a.txt\n" + sha256BC + "  dir/b.txt\n"
+		if got := string(b); got != want {
+			t.Fatalf("Reading the checksums file, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK refreshed", func(t *testing.T) {
+		fg.SetFiles(map[string]string{"a.txt": "bc"})
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		want := map[string]string{"a.txt": sha256BC}
+		if got := gfs.Hashes(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Hashes after a refresh, got %#v, want %#v", got, want)
+		}
+	})
+}
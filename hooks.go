@@ -0,0 +1,119 @@
+package gistfs
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Request describes a request made to Github by a filesystem, as given to
+// the hooks set by WithRequestHooks.
+type Request struct {
+	// Method and URL are the ones of the request.
+	Method string
+	URL    string
+	// StatusCode is the status of the response, or 0 if none was received.
+	StatusCode int
+	// Duration is how long it took to receive the response headers.
+	Duration time.Duration
+	// RateLimit is the rate limit state reported by the response, and is
+	// the zero value if it reported none, such as for raw content.
+	RateLimit RateLimit
+	// Err is the error the request failed with, without a response, if any.
+	// Error statuses are reported by StatusCode only.
+	Err error
+}
+
+// RequestHooks are functions called around every request made to Github by
+// a filesystem, whether to the API or to download raw content. Before is
+// called before the request is sent, with a Request holding its method and
+// URL only, and After once its response is received, or once it failed.
+// Either can be nil.
+//
+// Hooks are called concurrently, and with the context of the request, so
+// they must be safe for concurrent use and return quickly.
+type RequestHooks struct {
+	Before func(ctx context.Context, req Request)
+	After  func(ctx context.Context, req Request)
+}
+
+// WithRequestHooks calls hooks around every request made to Github, to audit
+// them without replacing the transport of the filesystem. Retried requests
+// are reported once per attempt.
+//
+// Like WithUserAgent, it has no effect on a Github client given to
+// NewWithClient, nor on a getter given to NewWithGetter. Pushes made with
+// WithGitWrites run git, and are not reported either.
+func WithRequestHooks(hooks RequestHooks) Option {
+	return func(fsys *FS) {
+		fsys.requestHooks = &hooks
+	}
+}
+
+// withRequestHooks returns a copy of c, or of http.DefaultClient if nil,
+// whose requests are reported to the hooks of the filesystem.
+func (fsys *FS) withRequestHooks(c *http.Client) *http.Client {
+	if c == nil {
+		c = http.DefaultClient
+	}
+
+	base := c.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	hooked := *c
+	hooked.Transport = &hookTransport{fsys: fsys, base: base}
+
+	return &hooked
+}
+
+// hookTransport is an http.RoundTripper calling the request hooks of fsys
+// around the requests made with base.
+type hookTransport struct {
+	fsys *FS
+	base http.RoundTripper
+}
+
+func (t *hookTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	hooks := t.fsys.requestHooks
+	ctx := req.Context()
+	r := Request{Method: req.Method, URL: req.URL.String()}
+
+	if hooks.Before != nil {
+		hooks.Before(ctx, r)
+	}
+
+	start := t.fsys.now()
+	resp, err := t.base.RoundTrip(req)
+	r.Duration = t.fsys.now().Sub(start)
+	r.Err = err
+	if resp != nil {
+		r.StatusCode = resp.StatusCode
+		r.RateLimit = parseRateLimit(resp.Header)
+	}
+
+	if hooks.After != nil {
+		hooks.After(ctx, r)
+	}
+
+	return resp, err
+}
+
+// parseRateLimit returns the rate limit state reported by the headers of a
+// response from the Github API, or the zero value if they hold none.
+func parseRateLimit(h http.Header) RateLimit {
+	limit, err := strconv.Atoi(h.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return RateLimit{}
+	}
+
+	rate := RateLimit{Limit: limit}
+	rate.Remaining, _ = strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rate.Reset = time.Unix(reset, 0)
+	}
+
+	return rate
+}
@@ -0,0 +1,58 @@
+package gistfs
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jhchabran/gistfs/internal/gistserver"
+)
+
+func TestWithRequestHooks(t *testing.T) {
+	_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+
+	var (
+		mu            sync.Mutex
+		before, after []Request
+	)
+	hooks := RequestHooks{
+		Before: func(ctx context.Context, req Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			before = append(before, req)
+		},
+		After: func(ctx context.Context, req Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			after = append(after, req)
+		},
+	}
+
+	gfs := New(referenceGistID, WithRequestHooks(hooks), WithToken("s3cr3t"))
+	gfs.client.BaseURL = client.BaseURL
+
+	for i := 0; i < 2; i++ {
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading with request hooks, expected no error but got %#v", err)
+		}
+	}
+
+	if len(before) != 2 || len(after) != 2 {
+		t.Fatalf("Loading twice, got %d calls to Before and %d to After, want 2 each", len(before), len(after))
+	}
+
+	for i, want := range []int{http.StatusOK, http.StatusNotModified} {
+		req := after[i]
+		if req.Method != http.MethodGet || !strings.HasSuffix(req.URL, "/gists/"+referenceGistID) || req.URL != before[i].URL {
+			t.Fatalf("Loading, got request %s %s, want GET of the gist", req.Method, req.URL)
+		}
+		if req.StatusCode != want || req.Err != nil {
+			t.Fatalf("Loading, got status %d (%v), want %d", req.StatusCode, req.Err, want)
+		}
+		if req.RateLimit.Limit != gistserver.DefaultRateLimit || !req.RateLimit.Reset.Equal(gistserver.RateReset) {
+			t.Fatalf("Loading, got rate limit %+v, want the one reported by the server", req.RateLimit)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package gistfs
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"testing/fstest"
+	"unicode/utf8"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// ErrReadOnly is an error that signals that the filesystem is being modified
+// while not created with WithWritable.
+var ErrReadOnly = fmt.Errorf("gist is read-only: %w", fs.ErrPermission)
+
+// WithWritable allows operations that modify the gist on Github, such as
+// UpdateFromFS. Without it, they fail with ErrReadOnly.
+func WithWritable() Option {
+	return func(fsys *FS) {
+		fsys.writable = true
+	}
+}
+
+// ImportReport describes the outcome of importing files into a gist.
+type ImportReport struct {
+	// Imported lists the names of the files stored in the gist.
+	Imported []string
+	// Skipped lists the files that could not be stored in the gist.
+	Skipped []SkippedFile
+}
+
+// SkippedFile is a file that could not be stored in a gist.
+type SkippedFile struct {
+	Name   string
+	Reason string
+}
+
+// CreateFromFS creates a new gist on Github from the files of src, and
+// returns a filesystem loaded with it. The created gist is owned by the user
+// client is authenticated as.
+//
+// Because gists are flat, only files at the root of src are imported, and
+// because gists only store text, empty and binary files are skipped. These
+// are listed in the returned ImportReport.
+//
+// Archives can be imported by passing a *zip.Reader, or the result of TarFS.
+func CreateFromFS(ctx context.Context, client *github.Client, src fs.FS, description string, public bool, opts ...Option) (*FS, *ImportReport, error) {
+	files, report, err := collectFiles(src)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fsys := NewWithClient(client, "", opts...)
+	var created *github.Gist
+	err = fsys.call(ctx, "create", func() (resp *github.Response, err error) {
+		created, resp, err = client.Gists.Create(ctx, &github.Gist{
+			Description: &description,
+			Public:      &public,
+			Files:       files,
+		})
+		return resp, err
+	})
+	if err != nil {
+		return nil, report, err
+	}
+
+	fsys.update(func(s *state) { s.id = created.GetID() })
+	if err := fsys.storeEdited(ctx, "create", fromGithubGist(created)); err != nil {
+		return nil, report, err
+	}
+
+	return fsys, report, nil
+}
+
+// UpdateFromFS stores the files of src into the gist, adding new files and
+// replacing existing ones, leaving the other files of the gist untouched.
+// The filesystem is then refreshed with the updated gist.
+//
+// It requires the filesystem to be created with WithWritable, and the same
+// restrictions as CreateFromFS apply to the files of src.
+func (fsys *FS) UpdateFromFS(ctx context.Context, src fs.FS) (*ImportReport, error) {
+	return fsys.CommitFromFS(ctx, src, GitCommit{})
+}
+
+// collectFiles gathers the files of src that can be stored in a gist.
+func collectFiles(src fs.FS) (map[github.GistFilename]github.GistFile, *ImportReport, error) {
+	files := map[github.GistFilename]github.GistFile{}
+	report := &ImportReport{}
+
+	err := fs.WalkDir(src, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		skip := func(reason string) error {
+			report.Skipped = append(report.Skipped, SkippedFile{Name: name, Reason: reason})
+			return nil
+		}
+
+		if path.Dir(name) != "." {
+			return skip("gists cannot have directories")
+		}
+
+		if !d.Type().IsRegular() {
+			return skip("not a regular file")
+		}
+
+		b, err := fs.ReadFile(src, name)
+		if err != nil {
+			return err
+		}
+
+		if len(b) == 0 {
+			return skip("gists cannot store empty files")
+		}
+
+		if !utf8.Valid(b) {
+			return skip("gists cannot store binary files")
+		}
+
+		content := string(b)
+		files[github.GistFilename(name)] = github.GistFile{Content: &content}
+		report.Imported = append(report.Imported, name)
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, report, errors.New("no file can be stored in a gist")
+	}
+
+	sort.Strings(report.Imported)
+
+	return files, report, nil
+}
+
+// TarFS reads a tar archive into memory and returns it as a fs.FS, suitable
+// for CreateFromFS and UpdateFromFS.
+func TarFS(r io.Reader) (fs.FS, error) {
+	mfs := fstest.MapFS{}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return mfs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := path.Clean(hdr.Name)
+		if !fs.ValidPath(name) {
+			return nil, fmt.Errorf("invalid path %q in tar archive", hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			mfs[name] = &fstest.MapFile{Mode: fs.ModeDir | 0755, ModTime: hdr.ModTime}
+		case tar.TypeReg:
+			b, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			mfs[name] = &fstest.MapFile{Data: b, Mode: fs.FileMode(hdr.Mode).Perm(), ModTime: hdr.ModTime}
+		default:
+			mfs[name] = &fstest.MapFile{Mode: fs.ModeIrregular}
+		}
+	}
+}
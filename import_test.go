@@ -0,0 +1,138 @@
+package gistfs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCreateFromFS(t *testing.T) {
+	_, client := newFakeGist(t, map[string]string{})
+
+	src := fstest.MapFS{
+		"a.txt":     {Data: []byte("a")},
+		"b.txt":     {Data: []byte("b")},
+		"empty.txt": {Data: []byte{}},
+		"bin.dat":   {Data: []byte{0xff, 0xfe}},
+		"dir/c.txt": {Data: []byte("c")},
+	}
+
+	gfs, report, err := CreateFromFS(context.Background(), client, src, "test", false)
+	if err != nil {
+		t.Fatalf("Creating from a FS, expected no error but got %#v", err)
+	}
+
+	if gfs.GetID() == "" || gfs.GetID() == referenceGistID {
+		t.Fatalf("Creating from a FS, got ID %#v, want the one of a new gist", gfs.GetID())
+	}
+
+	if got, want := report.Imported, []string{"a.txt", "b.txt"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Creating from a FS, got imported %v, want %v", got, want)
+	}
+
+	if got, want := len(report.Skipped), 3; got != want {
+		t.Fatalf("Creating from a FS, got %d skipped files (%v), want %d", got, report.Skipped, want)
+	}
+
+	b, err := gfs.ReadFile("b.txt")
+	if err != nil || string(b) != "b" {
+		t.Fatalf("Reading a created file, got %#v (%v), want %#v", string(b), err, "b")
+	}
+
+	t.Run("OK truncated by the API", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{})
+		fg.SetTruncateSize(4)
+
+		src := fstest.MapFS{"large.txt": {Data: []byte("0123456789")}}
+		gfs, _, err := CreateFromFS(context.Background(), client, src, "test", false)
+		if err != nil {
+			t.Fatalf("Creating from a FS, expected no error but got %#v", err)
+		}
+
+		b, _ := gfs.ReadFile("large.txt")
+		if got, want := string(b), "0123456789"; got != want {
+			t.Fatalf("Reading a created file truncated by the API, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("NOK nothing to import", func(t *testing.T) {
+		src := fstest.MapFS{"empty.txt": {Data: []byte{}}}
+		if _, _, err := CreateFromFS(context.Background(), client, src, "test", false); err == nil {
+			t.Fatal("Creating from a FS without importable files, got no error, want one")
+		}
+	})
+}
+
+func TestUpdateFromFS(t *testing.T) {
+	t.Run("OK zip archive", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a", "b.txt": "b"})
+		gfs := NewWithClient(client, referenceGistID, WithWritable())
+		gfs.Load(context.Background())
+
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		w, _ := zw.Create("b.txt")
+		w.Write([]byte("updated"))
+		zw.Close()
+
+		zr, _ := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if _, err := gfs.UpdateFromFS(context.Background(), zr); err != nil {
+			t.Fatalf("Updating from a zip archive, expected no error but got %#v", err)
+		}
+
+		for name, want := range map[string]string{"a.txt": "a", "b.txt": "updated"} {
+			b, _ := gfs.ReadFile(name)
+			if got := string(b); got != want {
+				t.Fatalf("Reading %#v after update, got %#v, want %#v", name, got, want)
+			}
+		}
+	})
+
+	t.Run("OK tar archive", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		gfs := NewWithClient(client, referenceGistID, WithWritable())
+		gfs.Load(context.Background())
+
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		tw.WriteHeader(&tar.Header{Name: "c.txt", Mode: 0644, Size: 1, Typeflag: tar.TypeReg})
+		tw.Write([]byte("c"))
+		tw.WriteHeader(&tar.Header{Name: "link", Linkname: "c.txt", Typeflag: tar.TypeSymlink})
+		tw.Close()
+
+		src, err := TarFS(&buf)
+		if err != nil {
+			t.Fatalf("Reading a tar archive, expected no error but got %#v", err)
+		}
+
+		report, err := gfs.UpdateFromFS(context.Background(), src)
+		if err != nil {
+			t.Fatalf("Updating from a tar archive, expected no error but got %#v", err)
+		}
+
+		if got, want := report.Skipped, []SkippedFile{{"link", "not a regular file"}}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("Updating from a tar archive, got skipped %v, want %v", got, want)
+		}
+
+		b, _ := gfs.ReadFile("c.txt")
+		if got, want := string(b), "c"; got != want {
+			t.Fatalf("Reading an imported file, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("NOK read-only", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		gfs := NewWithClient(client, referenceGistID)
+
+		_, err := gfs.UpdateFromFS(context.Background(), fstest.MapFS{"b.txt": {Data: []byte("b")}})
+		if !errors.Is(err, ErrReadOnly) || !errors.Is(err, fs.ErrPermission) {
+			t.Fatalf("Updating a read-only FS, got %#v, want %#v", err, ErrReadOnly)
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package gistfs
+
+import (
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// fileIndex holds the files served by the filesystem for a loaded gist,
+// virtual ones included, sorted by path, along with the entries of every
+// directory. It is built once per loaded gist, so that looking up a file is
+// a binary search and listing a directory does not go through all the
+// files. Like the state holding it, it is never modified once built.
+type fileIndex struct {
+	// paths are the paths of the files, sorted, and files the files served
+	// at each of them.
+	paths []string
+	files []github.GistFile
+	// dirs holds the entries of every directory by path, sorted by name.
+	dirs map[string][]fs.DirEntry
+	// folded holds the paths of files and directories by their lower case
+	// form, sorted by it, for case insensitive filesystems only.
+	folded []foldedPath
+}
+
+// foldedPath is a path of a case insensitive filesystem, along with the
+// lower case form names referring to it are matched against.
+type foldedPath struct {
+	key  string
+	path string
+}
+
+// newFileIndex returns the index of the files served for s.
+func (fsys *FS) newFileIndex(s *state) *fileIndex {
+	files := fsys.files(s)
+	idx := &fileIndex{
+		paths: make([]string, 0, len(files)),
+		files: make([]github.GistFile, len(files)),
+		dirs:  map[string][]fs.DirEntry{".": nil},
+	}
+
+	for p := range files {
+		idx.paths = append(idx.paths, p)
+	}
+	sort.Strings(idx.paths)
+
+	modtime := s.gist.GetUpdatedAt()
+	for i, p := range idx.paths {
+		f := files[p]
+		idx.files[i] = f
+
+		// Directories are added to their parent the first time they are
+		// seen, and their own parents were seen before them.
+		for d := path.Dir(p); d != "."; d = path.Dir(d) {
+			if _, ok := idx.dirs[d]; ok {
+				break
+			}
+			idx.dirs[d] = nil
+
+			parent := path.Dir(d)
+			idx.dirs[parent] = append(idx.dirs[parent], &dir{name: d, modtime: modtime})
+		}
+
+		d := path.Dir(p)
+		idx.dirs[d] = append(idx.dirs[d], fsys.fileInfo(s, p, f))
+	}
+
+	for _, entries := range idx.dirs {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	}
+
+	if fsys.caseInsensitive {
+		idx.folded = foldPaths(idx)
+	}
+
+	return idx
+}
+
+// foldPaths returns the paths of the files and directories of idx by their
+// lower case form, sorted by it. The first path of a given form wins.
+func foldPaths(idx *fileIndex) []foldedPath {
+	folded := make([]foldedPath, 0, len(idx.paths)+len(idx.dirs))
+	for _, p := range idx.paths {
+		folded = append(folded, foldedPath{key: strings.ToLower(p), path: p})
+	}
+	for d := range idx.dirs {
+		if d != "." {
+			folded = append(folded, foldedPath{key: strings.ToLower(d), path: d})
+		}
+	}
+
+	sort.SliceStable(folded, func(i, j int) bool {
+		if folded[i].key != folded[j].key {
+			return folded[i].key < folded[j].key
+		}
+		return folded[i].path < folded[j].path
+	})
+
+	return folded
+}
+
+// file returns the file served at p.
+func (idx *fileIndex) file(p string) (github.GistFile, bool) {
+	i := sort.SearchStrings(idx.paths, p)
+	if i < len(idx.paths) && idx.paths[i] == p {
+		return idx.files[i], true
+	}
+
+	return github.GistFile{}, false
+}
+
+// fold returns the path of the file or directory name refers to, ignoring
+// case, or name if it refers to nothing.
+func (idx *fileIndex) fold(name string) string {
+	if _, ok := idx.file(name); ok {
+		return name
+	}
+	if _, ok := idx.dirs[name]; ok {
+		return name
+	}
+
+	key := strings.ToLower(name)
+	i := sort.Search(len(idx.folded), func(i int) bool { return idx.folded[i].key >= key })
+	if i < len(idx.folded) && idx.folded[i].key == key {
+		return idx.folded[i].path
+	}
+
+	return name
+}
@@ -0,0 +1,70 @@
+package gistfs
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFileIndex(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{
+		"a-b.txt":        "ab",
+		"a__c.txt":       "c",
+		"a__d__e.txt":    "e",
+		"Docs__Intro.md": "intro",
+	})
+
+	gfs := NewWithClient(client, referenceGistID, WithPathSeparator("__"), WithCaseInsensitive())
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+
+	t.Run("OK sorted entries", func(t *testing.T) {
+		tests := map[string][]string{
+			".":   {"Docs", "a", "a-b.txt"},
+			"a":   {"c.txt", "d"},
+			"a/d": {"e.txt"},
+		}
+		for name, want := range tests {
+			entries, err := gfs.ReadDir(name)
+			if err != nil {
+				t.Fatalf("Listing %s, expected no error but got %#v", name, err)
+			}
+
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.Name())
+			}
+			if len(got) != len(want) {
+				t.Fatalf("Listing %s, got %v, want %v", name, got, want)
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Fatalf("Listing %s, got %v, want %v", name, got, want)
+				}
+			}
+		}
+	})
+
+	t.Run("OK folded lookups", func(t *testing.T) {
+		if b, err := gfs.ReadFile("docs/intro.MD"); err != nil || string(b) != "intro" {
+			t.Fatalf("Reading docs/intro.MD, got %q (%v), want %q", b, err, "intro")
+		}
+		if entries, err := gfs.ReadDir("DOCS"); err != nil || len(entries) != 1 {
+			t.Fatalf("Listing DOCS, got %v (%v), want the intro", entries, err)
+		}
+	})
+
+	t.Run("OK indexed again on load", func(t *testing.T) {
+		fg.SetFiles(map[string]string{"b__f.txt": "f"})
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		if _, err := gfs.ReadDir("a"); err == nil {
+			t.Fatal("Listing a removed directory, expected an error but got none")
+		}
+		if b, err := gfs.ReadFile("B/F.txt"); err != nil || string(b) != "f" {
+			t.Fatalf("Reading B/F.txt, got %q (%v), want %q", b, err, "f")
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package gistfs
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// ErrIntegrity is matched by the errors returned when the content of a file
+// does not match the SHA Github gave to it.
+var ErrIntegrity = errors.New("content does not match its blob SHA")
+
+// IntegrityError is returned when WithIntegrityCheck is set and the content
+// received for a file does not match the SHA of the git blob Github reports
+// for it, such as when a proxy truncated or altered it. It matches
+// ErrIntegrity with errors.Is.
+type IntegrityError struct {
+	// File is the name of the file.
+	File string
+	// Want is the SHA of the blob reported by Github.
+	Want string
+	// Got is the SHA of the blob made of the content received.
+	Got string
+}
+
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("%v: file %q has SHA %s, want %s", ErrIntegrity, e.File, e.Got, e.Want)
+}
+
+// Is makes IntegrityError match ErrIntegrity.
+func (e *IntegrityError) Is(target error) bool { return target == ErrIntegrity }
+
+// WithIntegrityCheck verifies that the content of the files fetched through
+// the REST API, including the raw content of large files, matches the SHA
+// of their git blob, which Github puts in their raw URL. Loads and reads
+// receiving mismatching content fail with an *IntegrityError instead of
+// serving it.
+//
+// Files whose raw URL holds no SHA, such as the ones loaded with
+// WithGraphQL, are not verified.
+func WithIntegrityCheck() Option {
+	return func(fsys *FS) {
+		fsys.verifyIntegrity = true
+	}
+}
+
+// verifyGist checks the content of the files of gist, skipping the ones
+// that are truncated or stored compressed, as they were checked on load.
+func (fsys *FS) verifyGist(gist *github.Gist) error {
+	for _, f := range gist.Files {
+		if f.Content == nil || isTruncated(f) {
+			continue
+		}
+
+		if err := verifyContent(f.GetFilename(), f.GetRawURL(), f.GetContent()); err != nil {
+			return &Error{Op: "load", ID: fsys.GetID(), Err: err}
+		}
+	}
+
+	return nil
+}
+
+// verifyContent checks that content matches the blob SHA in rawURL, if any.
+func verifyContent(name, rawURL, content string) error {
+	want, ok := rawURLSHA(rawURL)
+	if !ok {
+		return nil
+	}
+
+	if got := blobSHA(content); got != want {
+		return &IntegrityError{File: name, Want: want, Got: got}
+	}
+
+	return nil
+}
+
+// rawURLSHA returns the blob SHA in a raw URL, which is in the segment
+// before the file name:
+//
+//	https://gist.githubusercontent.com/<owner>/<id>/raw/<sha>/<name>
+func rawURLSHA(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+
+	segments := strings.Split(u.Path, "/")
+	if len(segments) < 2 {
+		return "", false
+	}
+
+	sha := segments[len(segments)-2]
+	if len(sha) != sha1.Size*2 {
+		return "", false
+	}
+	if _, err := hex.DecodeString(sha); err != nil {
+		return "", false
+	}
+
+	return sha, true
+}
+
+// blobSHA returns the SHA git gives to a blob of content.
+func blobSHA(content string) string {
+	h := sha1.New()
+	h.Write([]byte("blob " + strconv.Itoa(len(content)) + "\x00"))
+	h.Write([]byte(content))
+	return hex.EncodeToString(h.Sum(nil))
+}
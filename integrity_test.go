@@ -0,0 +1,90 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRawURLSHA(t *testing.T) {
+	tests := []struct {
+		rawURL string
+		want   string
+		ok     bool
+	}{
+		{"https://gist.githubusercontent.com/jhchabran/aa5a315d61ae9438b18d/raw/0a5b6ef93b8c3c5f5e49d4aa9a0c3d30f7e0a4c2/a.txt", "0a5b6ef93b8c3c5f5e49d4aa9a0c3d30f7e0a4c2", true},
+		{"https://gist.githubusercontent.com/jhchabran/aa5a315d61ae9438b18d/raw/a.txt", "", false},
+		{"https://raw/notasha0000000000000000000000000000000000/a.txt", "", false},
+		{"", "", false},
+	}
+
+	for _, test := range tests {
+		got, ok := rawURLSHA(test.rawURL)
+		if got != test.want || ok != test.ok {
+			t.Fatalf("rawURLSHA(%#v), got %#v, %v, want %#v, %v", test.rawURL, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestWithIntegrityCheck(t *testing.T) {
+	rawURL := func(content, name string) string {
+		return "https://gist.githubusercontent.com/jhchabran/" + referenceGistID + "/raw/" + blobSHA(content) + "/" + name
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "abc", "b.txt": ""})
+
+		gfs := NewWithClient(client, referenceGistID, WithIntegrityCheck())
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+	})
+
+	t.Run("NOK altered content", func(t *testing.T) {
+		getter := &stubGetter{gist: &Gist{
+			ID: referenceGistID,
+			Files: map[string]GistFile{
+				"a.txt": {Filename: "a.txt", Content: "abd", Size: 3, RawURL: rawURL("abc", "a.txt")},
+			},
+		}}
+
+		err := NewWithGetter(getter, referenceGistID, WithIntegrityCheck()).Load(context.Background())
+		if !errors.Is(err, ErrIntegrity) {
+			t.Fatalf("Loading altered content, got %#v, want an integrity error", err)
+		}
+
+		var integrityErr *IntegrityError
+		if !errors.As(err, &integrityErr) || integrityErr.File != "a.txt" || integrityErr.Want != blobSHA("abc") {
+			t.Fatalf("Loading altered content, got %#v, want an *IntegrityError for a.txt", err)
+		}
+
+		if err := NewWithGetter(getter, referenceGistID).Load(context.Background()); err != nil {
+			t.Fatalf("Loading altered content without checks, expected no error but got %#v", err)
+		}
+	})
+
+	t.Run("NOK truncated raw content", func(t *testing.T) {
+		getter := &stubGetter{
+			gist: &Gist{
+				ID: referenceGistID,
+				Files: map[string]GistFile{
+					"big.txt": {Filename: "big.txt", Content: "big", Size: 9, Truncated: true, RawURL: rawURL("big file!", "big.txt")},
+				},
+			},
+			raw: map[string]string{rawURL("big file!", "big.txt"): "big fi"},
+		}
+
+		err := NewWithGetter(getter, referenceGistID, WithIntegrityCheck()).Load(context.Background())
+		if !errors.Is(err, ErrIntegrity) {
+			t.Fatalf("Loading truncated raw content, got %#v, want an integrity error", err)
+		}
+
+		gfs := NewWithGetter(getter, referenceGistID, WithIntegrityCheck())
+		if err := gfs.LoadMetadata(context.Background()); err != nil {
+			t.Fatalf("Loading metadata, expected no error but got %#v", err)
+		}
+		if _, err := gfs.ReadFile("big.txt"); !errors.Is(err, ErrIntegrity) {
+			t.Fatalf("Reading truncated deferred content, got %#v, want an integrity error", err)
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package gistfs
+
+import (
+	"sync"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// Interner deduplicates the content of files held in memory, so that files
+// with the same content share a single copy, whether they belong to
+// successive refreshes of a gist or to filesystems serving different
+// revisions of it. Content no longer held by any filesystem is forgotten.
+//
+// Each filesystem interns the content it holds with its own Interner, unless
+// one is shared between filesystems with WithInterner.
+type Interner struct {
+	mu       sync.Mutex
+	contents map[string]*internedContent
+}
+
+// internedContent is a content held by filesystems, along with the number
+// of times it is held.
+type internedContent struct {
+	s    string
+	refs int
+}
+
+// NewInterner returns an Interner that can be shared between filesystems
+// with WithInterner.
+func NewInterner() *Interner {
+	return &Interner{contents: make(map[string]*internedContent)}
+}
+
+// WithInterner makes the filesystem intern the content of its files with in,
+// to share identical contents with the other filesystems using it.
+//
+// A filesystem releases contents when it replaces them, on refresh, so the
+// contents of a filesystem that is no longer used remain held by in.
+func WithInterner(in *Interner) Option {
+	return func(fsys *FS) {
+		fsys.interner = in
+	}
+}
+
+// Len returns the number of distinct contents currently held.
+func (in *Interner) Len() int {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	return len(in.contents)
+}
+
+// intern returns the copy of s held already, if any, and records that it is
+// held once more. It must be called with in.mu held.
+func (in *Interner) intern(s string) string {
+	if s == "" {
+		return s
+	}
+
+	c, ok := in.contents[s]
+	if !ok {
+		c = &internedContent{s: s}
+		in.contents[s] = c
+	}
+	c.refs++
+
+	return c.s
+}
+
+// release records that s is held once less. It must be called with in.mu
+// held.
+func (in *Interner) release(s string) {
+	c, ok := in.contents[s]
+	if !ok {
+		return
+	}
+
+	if c.refs--; c.refs == 0 {
+		delete(in.contents, s)
+	}
+}
+
+// internGist returns a copy of gist and extra whose contents are interned.
+// Each call must be balanced by a call to releaseGist once they are no longer
+// held.
+func (in *Interner) internGist(gist *github.Gist, extra gistExtra) (*github.Gist, gistExtra) {
+	if gist == nil {
+		return nil, extra
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	interned := *gist
+	interned.Files = make(map[github.GistFilename]github.GistFile, len(gist.Files))
+	for name, f := range gist.Files {
+		if f.Content != nil {
+			f.Content = github.String(in.intern(*f.Content))
+		}
+		interned.Files[name] = f
+	}
+
+	if extra.compressed != nil {
+		compressed := make(map[github.GistFilename]string, len(extra.compressed))
+		for name, z := range extra.compressed {
+			compressed[name] = in.intern(z)
+		}
+		extra.compressed = compressed
+	}
+
+	return &interned, extra
+}
+
+// releaseGist releases the contents of gist and extra, interned by
+// internGist.
+func (in *Interner) releaseGist(gist *github.Gist, extra gistExtra) {
+	if gist == nil {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	for _, f := range gist.Files {
+		if f.Content != nil {
+			in.release(*f.Content)
+		}
+	}
+
+	for _, z := range extra.compressed {
+		in.release(z)
+	}
+}
@@ -0,0 +1,64 @@
+package gistfs
+
+import (
+	"context"
+	"testing"
+)
+
+func TestInterner(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{
+		"a.txt": "same",
+		"b.txt": "same",
+		"c.txt": "other",
+	})
+
+	in := NewInterner()
+	gfs := NewWithClient(client, referenceGistID, WithInterner(in))
+	mirror := NewWithClient(client, referenceGistID, WithInterner(in))
+
+	if err := LoadAll(context.Background(), gfs, mirror); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+
+	t.Run("OK deduplicated", func(t *testing.T) {
+		if got, want := in.Len(), 2; got != want {
+			t.Fatalf("Loading two filesystems, got %d contents held, want %d", got, want)
+		}
+
+		b, _ := mirror.ReadFile("b.txt")
+		if got, want := string(b), "same"; got != want {
+			t.Fatalf("Reading an interned file, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK released on refresh", func(t *testing.T) {
+		fg.SetFiles(map[string]string{"a.txt": "new"})
+
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Refreshing, expected no error but got %#v", err)
+		}
+		if got, want := in.Len(), 3; got != want {
+			t.Fatalf("Refreshing one filesystem, got %d contents held, want %d", got, want)
+		}
+
+		if err := mirror.Load(context.Background()); err != nil {
+			t.Fatalf("Refreshing, expected no error but got %#v", err)
+		}
+		if got, want := in.Len(), 1; got != want {
+			t.Fatalf("Refreshing both filesystems, got %d contents held, want %d", got, want)
+		}
+	})
+
+	t.Run("OK compressed", func(t *testing.T) {
+		in := NewInterner()
+		for i := 0; i < 2; i++ {
+			if err := NewWithClient(client, referenceGistID, WithInterner(in), WithCompression()).Load(context.Background()); err != nil {
+				t.Fatalf("Loading, expected no error but got %#v", err)
+			}
+		}
+
+		if got, want := in.Len(), 1; got != want {
+			t.Fatalf("Loading compressed filesystems, got %d contents held, want %d", got, want)
+		}
+	})
+}
@@ -0,0 +1,265 @@
+package gistserver
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Repo is a repository served by a Server, through the part of the git data
+// API used to commit files: references, commits and trees.
+type Repo struct {
+	Owner string
+	Name  string
+
+	srv     *Server
+	refs    map[string]string
+	commits map[string]repoCommit
+	trees   map[string]map[string]string
+}
+
+// repoCommit is a commit of a repository.
+type repoCommit struct {
+	message string
+	tree    string
+	parents []string
+}
+
+// AddRepo adds a repository to the server, whose branch holds files in
+// a single commit.
+func (s *Server) AddRepo(owner, name, branch string, files map[string]string) *Repo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := &Repo{
+		Owner:   owner,
+		Name:    name,
+		srv:     s,
+		refs:    map[string]string{},
+		commits: map[string]repoCommit{},
+		trees:   map[string]map[string]string{},
+	}
+	r.refs[branch] = r.commit("Initial commit", r.tree(files), nil)
+	s.repos[owner+"/"+name] = r
+
+	return r
+}
+
+// Files returns the files of the last commit of branch, by path.
+func (r *Repo) Files(branch string) map[string]string {
+	r.srv.mu.Lock()
+	defer r.srv.mu.Unlock()
+
+	files := map[string]string{}
+	for p, content := range r.trees[r.commits[r.refs[branch]].tree] {
+		files[p] = content
+	}
+
+	return files
+}
+
+// Message returns the message of the last commit of branch.
+func (r *Repo) Message(branch string) string {
+	r.srv.mu.Lock()
+	defer r.srv.mu.Unlock()
+
+	return r.commits[r.refs[branch]].message
+}
+
+// tree stores a tree made of files and returns its SHA.
+func (r *Repo) tree(files map[string]string) string {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	h := sha1.New()
+	for _, p := range paths {
+		h.Write([]byte(p + "\x00" + files[p] + "\x00"))
+	}
+	sha := hex.EncodeToString(h.Sum(nil))
+	r.trees[sha] = files
+
+	return sha
+}
+
+// commit stores a commit and returns its SHA.
+func (r *Repo) commit(message, tree string, parents []string) string {
+	h := sha1.New()
+	h.Write([]byte(message + "\x00" + tree + "\x00" + strings.Join(parents, ",")))
+	sha := hex.EncodeToString(h.Sum(nil))
+	r.commits[sha] = repoCommit{message: message, tree: tree, parents: parents}
+
+	return sha
+}
+
+type gitRefPayload struct {
+	Ref    string `json:"ref"`
+	Object struct {
+		Type string `json:"type"`
+		SHA  string `json:"sha"`
+	} `json:"object"`
+}
+
+type shaPayload struct {
+	SHA string `json:"sha"`
+}
+
+type commitPayload struct {
+	SHA     string       `json:"sha"`
+	Message string       `json:"message"`
+	Tree    shaPayload   `json:"tree"`
+	Parents []shaPayload `json:"parents"`
+}
+
+type treeEntryPayload struct {
+	Path    string  `json:"path"`
+	Mode    string  `json:"mode"`
+	Type    string  `json:"type"`
+	SHA     *string `json:"sha"`
+	Content *string `json:"content,omitempty"`
+}
+
+type treePayload struct {
+	SHA       string             `json:"sha"`
+	Entries   []treeEntryPayload `json:"tree"`
+	Truncated bool               `json:"truncated"`
+}
+
+// serveGit serves the git data API of the repository named owner/name, the
+// path of the request being the parts following git.
+func (s *Server) serveGit(w http.ResponseWriter, r *http.Request, name string, parts []string) {
+	repo, ok := s.repos[name]
+	if !ok || len(parts) == 0 {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	switch {
+	case parts[0] == "ref" && len(parts) == 3 && parts[1] == "heads" && r.Method == http.MethodGet:
+		sha, ok := repo.refs[parts[2]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		_ = json.NewEncoder(w).Encode(gitRef(parts[2], sha))
+
+	case parts[0] == "refs" && len(parts) == 3 && parts[1] == "heads" && r.Method == http.MethodPatch:
+		var update struct {
+			SHA   string `json:"sha"`
+			Force bool   `json:"force"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if _, ok := repo.commits[update.SHA]; !ok {
+			writeError(w, http.StatusUnprocessableEntity, "Object does not exist")
+			return
+		}
+		repo.refs[parts[2]] = update.SHA
+		_ = json.NewEncoder(w).Encode(gitRef(parts[2], update.SHA))
+
+	case parts[0] == "commits" && len(parts) == 2 && r.Method == http.MethodGet:
+		c, ok := repo.commits[parts[1]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		_ = json.NewEncoder(w).Encode(commitJSON(parts[1], c))
+
+	case parts[0] == "commits" && len(parts) == 1 && r.Method == http.MethodPost:
+		var create struct {
+			Message string   `json:"message"`
+			Tree    string   `json:"tree"`
+			Parents []string `json:"parents"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&create); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if _, ok := repo.trees[create.Tree]; !ok {
+			writeError(w, http.StatusUnprocessableEntity, "Tree SHA does not exist")
+			return
+		}
+		sha := repo.commit(create.Message, create.Tree, create.Parents)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(commitJSON(sha, repo.commits[sha]))
+
+	case parts[0] == "trees" && len(parts) == 2 && r.Method == http.MethodGet:
+		files, ok := repo.trees[parts[1]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		_ = json.NewEncoder(w).Encode(treeJSON(parts[1], files))
+
+	case parts[0] == "trees" && len(parts) == 1 && r.Method == http.MethodPost:
+		var create struct {
+			BaseTree string             `json:"base_tree"`
+			Entries  []treeEntryPayload `json:"tree"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&create); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		files := map[string]string{}
+		for p, content := range repo.trees[create.BaseTree] {
+			files[p] = content
+		}
+		for _, e := range create.Entries {
+			switch {
+			case e.Content != nil:
+				files[e.Path] = *e.Content
+			case e.SHA == nil:
+				delete(files, e.Path)
+			default:
+				writeError(w, http.StatusUnprocessableEntity, "only inline content is supported")
+				return
+			}
+		}
+
+		sha := repo.tree(files)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(treeJSON(sha, files))
+
+	default:
+		writeError(w, http.StatusNotFound, "Not Found")
+	}
+}
+
+func gitRef(branch, sha string) gitRefPayload {
+	var p gitRefPayload
+	p.Ref = "refs/heads/" + branch
+	p.Object.Type = "commit"
+	p.Object.SHA = sha
+
+	return p
+}
+
+func commitJSON(sha string, c repoCommit) commitPayload {
+	p := commitPayload{SHA: sha, Message: c.message, Tree: shaPayload{SHA: c.tree}}
+	for _, parent := range c.parents {
+		p.Parents = append(p.Parents, shaPayload{SHA: parent})
+	}
+
+	return p
+}
+
+// treeJSON lists the files of a tree recursively, as blobs.
+func treeJSON(sha string, files map[string]string) treePayload {
+	p := treePayload{SHA: sha, Entries: []treeEntryPayload{}}
+	for path, content := range files {
+		sum := sha1.Sum([]byte(content))
+		blob := hex.EncodeToString(sum[:])
+		p.Entries = append(p.Entries, treeEntryPayload{Path: path, Mode: "100644", Type: "blob", SHA: &blob})
+	}
+	sort.Slice(p.Entries, func(i, j int) bool { return p.Entries[i].Path < p.Entries[j].Path })
+
+	return p
+}
@@ -0,0 +1,837 @@
+// Package gistserver implements an in-process fake of the Github Gists API,
+// shared by the tests of gistfs and the gisttest package.
+package gistserver
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// UpdatedAt is when the gists served by a new Server were last updated.
+var UpdatedAt = time.Date(2021, 1, 2, 3, 4, 6, 0, time.UTC)
+
+// RateReset is when the rate limit reported by a Server resets.
+var RateReset = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// DefaultRateLimit is the number of requests a new Server accepts before
+// rejecting them for exceeding the rate limit.
+const DefaultRateLimit = 5000
+
+// Server is a fake Gists API, serving gists from memory.
+type Server struct {
+	*httptest.Server
+
+	gists     map[string]*Gist
+	repos     map[string]*Repo
+	requests  int
+	header    http.Header
+	status    int
+	failures  int
+	limit     int
+	remaining int
+	hold      chan struct{}
+	nextID    int
+	truncate  int
+	mu        sync.Mutex
+}
+
+// Gist is a gist served by a Server.
+type Gist struct {
+	ID          string
+	Description string
+	Owner       string
+	Public      bool
+	// ForkOf is the ID of the gist this one was forked from, if any.
+	ForkOf string
+	// Starred is set when the gist is starred by the user.
+	Starred bool
+
+	srv       *Server
+	files     map[string]string
+	history   []revision
+	comments  []comment
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+// revision is a past state of a gist.
+type revision struct {
+	sha         string
+	files       map[string]string
+	committedAt time.Time
+}
+
+// comment is a comment on a gist.
+type comment struct {
+	ID        int64     `json:"id"`
+	Body      string    `json:"body"`
+	User      user      `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type user struct {
+	Login string `json:"login"`
+}
+
+// New starts a Server, which must be closed after use.
+func New() *Server {
+	s := &Server{
+		gists:     map[string]*Gist{},
+		repos:     map[string]*Repo{},
+		limit:     DefaultRateLimit,
+		remaining: DefaultRateLimit,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// GithubClient returns a Github client making its requests to the server.
+func (s *Server) GithubClient() *github.Client {
+	client := github.NewClient(s.Client())
+	client.BaseURL, _ = url.Parse(s.URL + "/")
+
+	return client
+}
+
+// AddGist adds a gist made of files to the server.
+func (s *Server) AddGist(id string, files map[string]string) *Gist {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addGist(id, files)
+}
+
+func (s *Server) addGist(id string, files map[string]string) *Gist {
+	g := &Gist{
+		ID:        id,
+		Owner:     "gistfs",
+		srv:       s,
+		createdAt: UpdatedAt,
+		updatedAt: UpdatedAt,
+	}
+	g.commit(files, UpdatedAt)
+	s.gists[id] = g
+
+	return g
+}
+
+// RemoveGist removes a gist from the server, as if it was deleted.
+func (s *Server) RemoveGist(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.gists, id)
+}
+
+// SetStatus makes the server fail every request with the given HTTP status,
+// or serve requests normally again if status is zero.
+func (s *Server) SetStatus(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status = status
+	s.failures = 0
+}
+
+// FailNext makes the server fail the next n requests with the given HTTP
+// status, before serving requests normally again.
+func (s *Server) FailNext(n int, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status = status
+	s.failures = n
+}
+
+// SetRateLimit sets the rate limit reported by the server, and how many
+// requests it accepts before rejecting them for exceeding it.
+func (s *Server) SetRateLimit(limit, remaining int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.limit = limit
+	s.remaining = remaining
+}
+
+// SetTruncateSize makes the server truncate the content of files larger
+// than n bytes in the gists it serves, as the API does for files over 1 MB,
+// their full content being served at their raw URL. A size of zero serves
+// content whole.
+func (s *Server) SetTruncateSize(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.truncate = n
+}
+
+// Hold delays responses until the returned function is called.
+func (s *Server) Hold() (release func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hold := make(chan struct{})
+	s.hold = hold
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			s.hold = nil
+			s.mu.Unlock()
+			close(hold)
+		})
+	}
+}
+
+// Requests returns how many requests reached the server.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+// LastHeader returns the headers of the last request that reached the server.
+func (s *Server) LastHeader() http.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.header
+}
+
+// SetFiles replaces the files of the gist, creating a new revision.
+func (g *Gist) SetFiles(files map[string]string) {
+	g.srv.mu.Lock()
+	defer g.srv.mu.Unlock()
+
+	g.commit(files, g.updatedAt.Add(time.Minute))
+}
+
+// SetPublic sets whether the gist is public.
+func (g *Gist) SetPublic(public bool) {
+	g.srv.mu.Lock()
+	defer g.srv.mu.Unlock()
+
+	g.Public = public
+}
+
+// AddRevision makes the server serve files as the given revision of the gist,
+// without changing its latest content.
+func (g *Gist) AddRevision(sha string, files map[string]string) {
+	g.srv.mu.Lock()
+	defer g.srv.mu.Unlock()
+
+	g.history = append([]revision{{sha: sha, files: files, committedAt: g.createdAt}}, g.history...)
+}
+
+// AddComment adds a comment written by author to the gist.
+func (g *Gist) AddComment(author, body string) {
+	g.srv.mu.Lock()
+	defer g.srv.mu.Unlock()
+
+	g.comments = append(g.comments, comment{
+		ID:        int64(1000 + len(g.comments)),
+		Body:      body,
+		User:      user{Login: author},
+		CreatedAt: g.createdAt.Add(time.Duration(len(g.comments)+1) * time.Hour),
+	})
+}
+
+// Revisions returns the SHA of the revisions of the gist, latest first.
+func (g *Gist) Revisions() []string {
+	g.srv.mu.Lock()
+	defer g.srv.mu.Unlock()
+
+	shas := make([]string, len(g.history))
+	for i, rev := range g.history {
+		shas[len(shas)-1-i] = rev.sha
+	}
+
+	return shas
+}
+
+// blobSHA returns the SHA git gives to a blob of content, which Github puts
+// in the raw URLs of files.
+func blobSHA(content string) string {
+	h := sha1.New()
+	h.Write([]byte("blob " + strconv.Itoa(len(content)) + "\x00"))
+	h.Write([]byte(content))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// commit records files as the latest revision of the gist. It must be called
+// with the server lock held.
+func (g *Gist) commit(files map[string]string, at time.Time) {
+	h := sha1.New()
+	h.Write([]byte(g.ID))
+	h.Write([]byte(at.String()))
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		h.Write([]byte(name))
+		h.Write([]byte(files[name]))
+	}
+
+	g.files = files
+	g.updatedAt = at
+	g.history = append(g.history, revision{
+		sha:         hex.EncodeToString(h.Sum(nil)),
+		files:       files,
+		committedAt: at,
+	})
+}
+
+func (g *Gist) etag() string {
+	return `W/"` + strconv.FormatInt(g.updatedAt.UnixNano(), 16) + `"`
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	s.header = r.Header.Clone()
+	hold := s.hold
+	s.mu.Unlock()
+
+	if hold != nil {
+		<-hold
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-GitHub-Request-Id", "FAKE:"+strconv.Itoa(s.requests))
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(s.limit))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(RateReset.Unix(), 10))
+	if s.remaining <= 0 {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		writeError(w, http.StatusForbidden, "API rate limit exceeded for 127.0.0.1.")
+		return
+	}
+	s.remaining--
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(s.remaining))
+
+	if s.status != 0 {
+		status := s.status
+		if s.failures > 0 {
+			s.failures--
+			if s.failures == 0 {
+				s.status = 0
+			}
+		}
+
+		writeError(w, status, http.StatusText(status))
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.URL.Path == "/graphql" && r.Method == http.MethodPost:
+		s.serveGraphQL(w, r)
+	case parts[0] == "raw" && len(parts) == 4:
+		s.serveRaw(w, parts[1], parts[2], parts[3])
+	case len(parts) == 1 && strings.HasSuffix(parts[0], ".json"):
+		s.serveEmbed(w, r, strings.TrimSuffix(parts[0], ".json"))
+	case len(parts) == 3 && parts[1] == "raw":
+		s.serveLatestRaw(w, parts[0], parts[2])
+	case r.URL.Path == "/gists" && r.Method == http.MethodPost:
+		s.serveCreate(w, r)
+	case parts[0] == "gists" && len(parts) == 2:
+		s.serveGist(w, r, parts[1])
+	case parts[0] == "gists" && len(parts) == 3 && parts[2] == "comments" && r.Method == http.MethodGet:
+		s.serveComments(w, r, parts[1])
+	case parts[0] == "gists" && len(parts) == 3 && parts[2] == "forks" && r.Method == http.MethodGet:
+		s.serveForks(w, parts[1])
+	case parts[0] == "gists" && len(parts) == 3 && parts[2] == "forks" && r.Method == http.MethodPost:
+		s.serveFork(w, parts[1])
+	case parts[0] == "gists" && len(parts) == 3 && parts[2] == "star":
+		s.serveStar(w, r, parts[1])
+	case parts[0] == "gists" && len(parts) == 3 && r.Method == http.MethodGet:
+		s.serveRevision(w, parts[1], parts[2])
+	case parts[0] == "users" && len(parts) == 3 && parts[2] == "gists" && r.Method == http.MethodGet:
+		s.serveUserGists(w, r, parts[1])
+	case parts[0] == "repos" && len(parts) > 3 && parts[3] == "git":
+		s.serveGit(w, r, parts[1]+"/"+parts[2], parts[4:])
+	default:
+		writeError(w, http.StatusNotFound, "Not Found")
+	}
+}
+
+// serveComments lists the comments of a gist, paginated like the Github API.
+func (s *Server) serveComments(w http.ResponseWriter, r *http.Request, id string) {
+	g, ok := s.gists[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	start, end := paginate(w, r, len(g.comments))
+	_ = json.NewEncoder(w).Encode(append([]comment{}, g.comments[start:end]...))
+}
+
+// serveUserGists lists the gists of a user, most recently updated first,
+// without the content of their files.
+func (s *Server) serveUserGists(w http.ResponseWriter, r *http.Request, login string) {
+	var gists []*Gist
+	for _, g := range s.gists {
+		if g.Owner == login {
+			gists = append(gists, g)
+		}
+	}
+	sort.Slice(gists, func(i, j int) bool {
+		if !gists[i].updatedAt.Equal(gists[j].updatedAt) {
+			return gists[i].updatedAt.After(gists[j].updatedAt)
+		}
+		return gists[i].ID < gists[j].ID
+	})
+
+	start, end := paginate(w, r, len(gists))
+	payloads := []*gistPayload{}
+	for _, g := range gists[start:end] {
+		p := s.payload(g, g.files)
+		for name, f := range p.Files {
+			f.Content = ""
+			p.Files[name] = f
+		}
+		p.History = nil
+		payloads = append(payloads, p)
+	}
+
+	_ = json.NewEncoder(w).Encode(payloads)
+}
+
+// paginate returns the bounds of the page of n items requested by r, and
+// links the next page, if any, like the Github API.
+func paginate(w http.ResponseWriter, r *http.Request, n int) (start, end int) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if perPage < 1 {
+		perPage = 30
+	}
+
+	start = (page - 1) * perPage
+	if start > n {
+		start = n
+	}
+	end = start + perPage
+	if end >= n {
+		end = n
+	} else {
+		next := *r.URL
+		next.Scheme, next.Host = "http", r.Host
+		next.RawQuery = url.Values{"page": {strconv.Itoa(page + 1)}, "per_page": {strconv.Itoa(perPage)}}.Encode()
+		w.Header().Set("Link", `<`+next.String()+`>; rel="next"`)
+	}
+
+	return start, end
+}
+
+// serveForks lists the forks of a gist.
+func (s *Server) serveForks(w http.ResponseWriter, id string) {
+	if _, ok := s.gists[id]; !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	forks := []*refPayload{}
+	for _, g := range s.gists {
+		if g.ForkOf == id {
+			forks = append(forks, newRefPayload(g))
+		}
+	}
+	sort.Slice(forks, func(i, j int) bool { return forks[i].ID < forks[j].ID })
+
+	_ = json.NewEncoder(w).Encode(forks)
+}
+
+// serveFork forks a gist.
+func (s *Server) serveFork(w http.ResponseWriter, id string) {
+	parent, ok := s.gists[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	s.nextID++
+	g := s.addGist("fork"+strconv.Itoa(s.nextID), parent.files)
+	g.Description = parent.Description
+	g.Public = parent.Public
+	g.ForkOf = id
+
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(s.payload(g, g.files))
+}
+
+// serveStar stars, unstars or tells if a gist is starred.
+func (s *Server) serveStar(w http.ResponseWriter, r *http.Request, id string) {
+	g, ok := s.gists[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		g.Starred = true
+	case http.MethodDelete:
+		g.Starred = false
+	case http.MethodGet:
+		if !g.Starred {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) serveGist(w http.ResponseWriter, r *http.Request, id string) {
+	g, ok := s.gists[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if r.Header.Get("If-None-Match") == g.etag() {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	case http.MethodPatch:
+		var edit editRequest
+		if err := json.NewDecoder(r.Body).Decode(&edit); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		files := map[string]string{}
+		for name, content := range g.files {
+			files[name] = content
+		}
+		for name, f := range edit.Files {
+			if f == nil {
+				delete(files, name)
+				continue
+			}
+			if f.Filename != nil && *f.Filename != name {
+				delete(files, name)
+				name = *f.Filename
+			}
+			if f.Content != nil {
+				files[name] = *f.Content
+			}
+		}
+		if edit.Description != nil {
+			g.Description = *edit.Description
+		}
+
+		g.commit(files, g.updatedAt.Add(time.Minute))
+	case http.MethodDelete:
+		delete(s.gists, id)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	w.Header().Set("ETag", g.etag())
+	_ = json.NewEncoder(w).Encode(s.payload(g, g.files))
+}
+
+func (s *Server) serveRevision(w http.ResponseWriter, id, sha string) {
+	g, ok := s.gists[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	for _, rev := range g.history {
+		if rev.sha == sha {
+			_ = json.NewEncoder(w).Encode(s.payload(g, rev.files))
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "Not Found")
+}
+
+func (s *Server) serveCreate(w http.ResponseWriter, r *http.Request) {
+	var create editRequest
+	if err := json.NewDecoder(r.Body).Decode(&create); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	files := map[string]string{}
+	for name, f := range create.Files {
+		if f != nil && f.Content != nil {
+			files[name] = *f.Content
+		}
+	}
+
+	s.nextID++
+	g := s.addGist("created"+strconv.Itoa(s.nextID), files)
+	if create.Description != nil {
+		g.Description = *create.Description
+	}
+	if create.Public != nil {
+		g.Public = *create.Public
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(s.payload(g, g.files))
+}
+
+func (s *Server) serveRaw(w http.ResponseWriter, id, sha, name string) {
+	g, ok := s.gists[id]
+	if !ok {
+		http.NotFound(w, nil)
+		return
+	}
+
+	for _, rev := range g.history {
+		if content, ok := rev.files[name]; ok && (rev.sha == sha || blobSHA(content) == sha) {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			_, _ = w.Write([]byte(content))
+			return
+		}
+	}
+
+	http.NotFound(w, nil)
+}
+
+// serveEmbed serves the document gist.github.com serves at <id>.json to
+// embed a gist, which lists the names of its files.
+func (s *Server) serveEmbed(w http.ResponseWriter, r *http.Request, id string) {
+	g, ok := s.gists[id]
+	if !ok {
+		http.NotFound(w, nil)
+		return
+	}
+
+	if r.Header.Get("If-None-Match") == g.etag() {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	names := make([]string, 0, len(g.files))
+	for name := range g.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w.Header().Set("ETag", g.etag())
+	_ = json.NewEncoder(w).Encode(embedPayload{
+		Description: g.Description,
+		Public:      g.Public,
+		Owner:       g.Owner,
+		CreatedAt:   g.createdAt,
+		Files:       names,
+	})
+}
+
+type embedPayload struct {
+	Description string    `json:"description"`
+	Public      bool      `json:"public"`
+	Owner       string    `json:"owner"`
+	CreatedAt   time.Time `json:"created_at"`
+	Files       []string  `json:"files"`
+}
+
+// serveLatestRaw serves the latest content of a file, as gist.github.com
+// does at <id>/raw/<name>.
+func (s *Server) serveLatestRaw(w http.ResponseWriter, id, name string) {
+	g, ok := s.gists[id]
+	if !ok {
+		http.NotFound(w, nil)
+		return
+	}
+
+	content, ok := g.files[name]
+	if !ok {
+		http.NotFound(w, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(content))
+}
+
+type editRequest struct {
+	Description *string `json:"description"`
+	Public      *bool   `json:"public"`
+	Files       map[string]*struct {
+		Filename *string `json:"filename"`
+		Content  *string `json:"content"`
+	} `json:"files"`
+}
+
+type filePayload struct {
+	Filename  string `json:"filename"`
+	Type      string `json:"type"`
+	Language  string `json:"language,omitempty"`
+	RawURL    string `json:"raw_url"`
+	Size      int    `json:"size"`
+	Truncated bool   `json:"truncated"`
+	Content   string `json:"content"`
+}
+
+type historyPayload struct {
+	Version     string    `json:"version"`
+	CommittedAt time.Time `json:"committed_at"`
+}
+
+// refPayload is the short representation of a gist, used for forks.
+type refPayload struct {
+	ID        string            `json:"id"`
+	Owner     map[string]string `json:"owner"`
+	HTMLURL   string            `json:"html_url"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+func newRefPayload(g *Gist) *refPayload {
+	return &refPayload{
+		ID:        g.ID,
+		Owner:     map[string]string{"login": g.Owner},
+		HTMLURL:   "https://gist.github.com/" + g.ID,
+		CreatedAt: g.createdAt,
+		UpdatedAt: g.updatedAt,
+	}
+}
+
+type gistPayload struct {
+	ID          string                 `json:"id"`
+	Description string                 `json:"description"`
+	Public      bool                   `json:"public"`
+	Owner       map[string]string      `json:"owner"`
+	HTMLURL     string                 `json:"html_url"`
+	GitPullURL  string                 `json:"git_pull_url"`
+	Files       map[string]filePayload `json:"files"`
+	History     []historyPayload       `json:"history"`
+	Comments    int                    `json:"comments"`
+	ForkOf      *refPayload            `json:"fork_of,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+// payload returns the API representation of g with the given files.
+func (s *Server) payload(g *Gist, files map[string]string) *gistPayload {
+	p := &gistPayload{
+		ID:          g.ID,
+		Description: g.Description,
+		Public:      g.Public,
+		Owner:       map[string]string{"login": g.Owner},
+		HTMLURL:     "https://gist.github.com/" + g.ID,
+		GitPullURL:  "https://gist.github.com/" + g.ID + ".git",
+		Files:       map[string]filePayload{},
+		Comments:    len(g.comments),
+		CreatedAt:   g.createdAt,
+		UpdatedAt:   g.updatedAt,
+	}
+
+	for name, content := range files {
+		f := filePayload{
+			Filename: name,
+			Type:     "text/plain",
+			RawURL:   s.URL + "/raw/" + g.ID + "/" + blobSHA(content) + "/" + name,
+			Size:     len(content),
+			Content:  content,
+		}
+		if s.truncate > 0 && len(content) > s.truncate {
+			f.Content, f.Truncated = content[:s.truncate], true
+		}
+		p.Files[name] = f
+	}
+
+	if parent, ok := s.gists[g.ForkOf]; ok {
+		p.ForkOf = newRefPayload(parent)
+	}
+
+	for i := len(g.history) - 1; i >= 0; i-- {
+		p.History = append(p.History, historyPayload{
+			Version:     g.history[i].sha,
+			CommittedAt: g.history[i].committedAt,
+		})
+	}
+
+	return p
+}
+
+type graphQLRequest struct {
+	Variables struct {
+		Owner string `json:"owner"`
+		Name  string `json:"name"`
+	} `json:"variables"`
+}
+
+type graphQLFile struct {
+	Name string `json:"name"`
+	Size int    `json:"size"`
+	Text string `json:"text"`
+}
+
+// serveGraphQL answers the gist queries made through the GraphQL API.
+func (s *Server) serveGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphQLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g, ok := s.gists[req.Variables.Name]
+	if !ok {
+		_, _ = w.Write([]byte(`{"data":{"user":{"gist":null}}}`))
+		return
+	}
+
+	files := []graphQLFile{}
+	for name, content := range g.files {
+		files = append(files, graphQLFile{Name: name, Size: len(content), Text: content})
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": map[string]interface{}{
+			"user": map[string]interface{}{
+				"gist": map[string]interface{}{
+					"name":        g.ID,
+					"description": g.Description,
+					"isPublic":    g.Public,
+					"url":         "https://gist.github.com/" + g.ID,
+					"createdAt":   g.createdAt,
+					"updatedAt":   g.updatedAt,
+					"owner":       map[string]string{"login": req.Variables.Owner},
+					"files":       files,
+				},
+			},
+		},
+	})
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
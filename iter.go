@@ -0,0 +1,58 @@
+//go:build go1.23
+// +build go1.23
+
+package gistfs
+
+import (
+	"context"
+	"io/fs"
+	"iter"
+)
+
+// All returns an iterator over the files of the gist, including the ones
+// under directories, yielding their path along with their entry, sorted by
+// path. Directories themselves are not yielded.
+//
+// It only reads the metadata of files, so that iterating over a gist loaded
+// with LoadMetadata does not download their content. It yields nothing if
+// the gist can't be served, which ReadDir reports as an error.
+//
+// It requires Go 1.23 or later.
+func (fsys *FS) All() iter.Seq2[string, fs.DirEntry] {
+	return func(yield func(string, fs.DirEntry) bool) {
+		_ = fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			if !yield(p, d) {
+				return fs.SkipAll
+			}
+			return nil
+		})
+	}
+}
+
+// Contents returns an iterator over the files of the gist, yielding their
+// path along with their content, sorted by path, as All does. Content
+// deferred by LoadMetadata is fetched with ctx as iteration reaches it, so
+// that stopping early leaves the remaining files alone.
+//
+// Iteration stops at the first file that can't be read. The returned
+// function reports its error, once iteration is over.
+//
+// It requires Go 1.23 or later.
+func (fsys *FS) Contents(ctx context.Context) (iter.Seq2[string, []byte], func() error) {
+	var err error
+
+	seq := func(yield func(string, []byte) bool) {
+		for p := range fsys.All() {
+			var b []byte
+			b, err = fsys.ReadFileContext(ctx, p)
+			if err != nil || !yield(p, b) {
+				return
+			}
+		}
+	}
+
+	return seq, func() error { return err }
+}
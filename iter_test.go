@@ -0,0 +1,98 @@
+//go:build go1.23
+// +build go1.23
+
+package gistfs
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestIterators(t *testing.T) {
+	newFS := func() (*FS, *stubGetter) {
+		getter := &stubGetter{
+			gist: &Gist{
+				ID: referenceGistID,
+				Files: map[string]GistFile{
+					"b.txt":         {Filename: "b.txt", Content: "b", Size: 1},
+					"docs__big.txt": {Filename: "docs__big.txt", Content: "big", Size: 9, Truncated: true, RawURL: "https://raw/big.txt"},
+					"a.txt":         {Filename: "a.txt", Content: "a", Size: 1},
+				},
+			},
+			raw: map[string]string{"https://raw/big.txt": "big file!"},
+		}
+
+		gfs := NewWithGetter(getter, referenceGistID, WithPathSeparator("__"))
+		if err := gfs.LoadMetadata(context.Background()); err != nil {
+			t.Fatalf("Loading metadata, expected no error but got %#v", err)
+		}
+		return gfs, getter
+	}
+
+	t.Run("OK All", func(t *testing.T) {
+		gfs, getter := newFS()
+
+		var paths []string
+		for p, d := range gfs.All() {
+			if d.Name() == "" || d.IsDir() {
+				t.Errorf("Iterating, got entry %+v for %s", d, p)
+			}
+			paths = append(paths, p)
+		}
+
+		if want := []string{"a.txt", "b.txt", "docs/big.txt"}; !reflect.DeepEqual(paths, want) {
+			t.Errorf("Iterating, got %v, want %v", paths, want)
+		}
+		if getter.rawCalls != 0 {
+			t.Errorf("Iterating, got %d raw fetches, want none", getter.rawCalls)
+		}
+	})
+
+	t.Run("OK Contents", func(t *testing.T) {
+		gfs, getter := newFS()
+
+		seq, errFn := gfs.Contents(context.Background())
+		got := map[string]string{}
+		for p, b := range seq {
+			got[p] = string(b)
+		}
+		if err := errFn(); err != nil {
+			t.Fatalf("Iterating, expected no error but got %#v", err)
+		}
+
+		want := map[string]string{"a.txt": "a", "b.txt": "b", "docs/big.txt": "big file!"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Iterating, got %v, want %v", got, want)
+		}
+		if getter.rawCalls != 1 {
+			t.Errorf("Iterating, got %d raw fetches, want 1", getter.rawCalls)
+		}
+	})
+
+	t.Run("OK Contents stopped early", func(t *testing.T) {
+		gfs, getter := newFS()
+
+		seq, _ := gfs.Contents(context.Background())
+		for range seq {
+			break
+		}
+		if getter.rawCalls != 0 {
+			t.Errorf("Stopping early, got %d raw fetches, want none", getter.rawCalls)
+		}
+	})
+
+	t.Run("NOK Contents error", func(t *testing.T) {
+		gfs, getter := newFS()
+		getter.raw = nil
+
+		seq, errFn := gfs.Contents(context.Background())
+		var n int
+		for range seq {
+			n++
+		}
+		if n != 2 || errFn() == nil {
+			t.Errorf("Iterating, got %d files and error %#v, want 2 files and an error", n, errFn())
+		}
+	})
+}
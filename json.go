@@ -0,0 +1,100 @@
+package gistfs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// LoadFromJSON populates the filesystem from a gist serialized in JSON, as
+// returned by the Github API or written by WriteJSON, instead of fetching it
+// from Github. It makes it possible to use the filesystem offline, such as in
+// tests or during development.
+//
+// If the filesystem has an ID, it must match the one of the serialized gist.
+// The gist is checked and transformed as Load does, unless it was written by
+// WriteJSON from a filesystem transforming content, in which case it is
+// served as is, without verifying integrity or signature against content
+// that was transformed. Such a gist is rejected when WithSignedManifest is
+// set, as it was never verified.
+func (fsys *FS) LoadFromJSON(r io.Reader) error {
+	var doc jsonGist
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return fmt.Errorf("decoding gist: %w", err)
+	}
+	if doc.Gist == nil {
+		doc.Gist = new(github.Gist)
+	}
+
+	if id := fsys.GetID(); id != "" && doc.GetID() != id {
+		return fmt.Errorf("decoding gist: got gist %q, want %q", doc.GetID(), id)
+	}
+
+	return fsys.restore(doc.Gist, "", gistExtra{}, fsys.now(), doc.Transformed)
+}
+
+// jsonGist is a gist as written by WriteJSON, in the format of the Github
+// API.
+type jsonGist struct {
+	*github.Gist
+	// Transformed is set when the content of the gist was transformed by
+	// the filesystem it was written from.
+	Transformed bool `json:"gistfs_transformed,omitempty"`
+}
+
+// restore serves gist, decoded from a serialized state rather than fetched,
+// once it passed the checks of load, transforming it unless transformed is
+// set. Filesystems created without an ID take the one of gist.
+func (fsys *FS) restore(gist *github.Gist, etag string, extra gistExtra, loadedAt time.Time, transformed bool) error {
+	if err := fsys.checkSize(fromGithubGist(gist)); err != nil {
+		return &Error{Op: "load", ID: gist.GetID(), Err: err}
+	}
+
+	// Transformed content no longer matches the hashes it is verified with,
+	// so it can only be served if it was verified before being transformed.
+	if err := fsys.checkGist(gist, &extra, !transformed); err != nil {
+		return err
+	}
+	if transformed && fsys.verifySignature != nil && extra.signed == nil {
+		err := &SignatureError{File: fsys.signedManifest, Err: errors.New("content was transformed without being verified")}
+		return &Error{Op: "load", ID: gist.GetID(), Err: err}
+	}
+	if !transformed {
+		var err error
+		if gist, err = fsys.transformGist(gist); err != nil {
+			return err
+		}
+	}
+
+	fsys.update(func(s *state) {
+		if s.id == "" {
+			s.id = gist.GetID()
+		}
+	})
+	fsys.setGist(gist, etag, extra, loadedAt)
+
+	return nil
+}
+
+// WriteJSON serializes the loaded gist in JSON, in the format LoadFromJSON
+// expects. Combined with Load, it dumps a live gist for later offline use.
+func (fsys *FS) WriteJSON(w io.Writer) error {
+	s := fsys.state()
+	if s.gist == nil {
+		return ErrNotLoaded
+	}
+
+	gist, err := fsys.plainGist(s)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(&jsonGist{Gist: gist, Transformed: fsys.transformsContent()})
+}
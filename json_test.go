@@ -0,0 +1,100 @@
+package gistfs
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	t.Run("OK round trip", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a", "b.txt": "b"})
+		live := NewWithClient(client, referenceGistID)
+		live.Load(context.Background())
+
+		var buf bytes.Buffer
+		if err := live.WriteJSON(&buf); err != nil {
+			t.Fatalf("Writing JSON, expected no error but got %#v", err)
+		}
+
+		gfs := New(referenceGistID)
+		if err := gfs.LoadFromJSON(&buf); err != nil {
+			t.Fatalf("Loading from JSON, expected no error but got %#v", err)
+		}
+
+		b, err := gfs.ReadFile("b.txt")
+		if err != nil || string(b) != "b" {
+			t.Fatalf("Reading a file loaded from JSON, got %#v (%v), want %#v", string(b), err, "b")
+		}
+	})
+
+	t.Run("OK round trip with a transform", func(t *testing.T) {
+		exclaim := WithTransform(func(name string, data []byte) ([]byte, error) {
+			return append(data, '!'), nil
+		})
+
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		live := NewWithClient(client, referenceGistID, exclaim)
+		live.Load(context.Background())
+
+		var buf bytes.Buffer
+		if err := live.WriteJSON(&buf); err != nil {
+			t.Fatalf("Writing JSON, expected no error but got %#v", err)
+		}
+
+		gfs := New(referenceGistID, exclaim, WithIntegrityCheck())
+		if err := gfs.LoadFromJSON(&buf); err != nil {
+			t.Fatalf("Loading from JSON, expected no error but got %#v", err)
+		}
+
+		if b, _ := gfs.ReadFile("a.txt"); string(b) != "a!" {
+			t.Fatalf("Reading a file loaded from JSON, got %#v, want %#v", string(b), "a!")
+		}
+	})
+
+	t.Run("OK without ID", func(t *testing.T) {
+		gfs := New("")
+		if err := gfs.LoadFromJSON(strings.NewReader(`{"id":"abc","files":{}}`)); err != nil {
+			t.Fatalf("Loading from JSON, expected no error but got %#v", err)
+		}
+
+		if got, want := gfs.GetID(), "abc"; got != want {
+			t.Fatalf("Loading from JSON, got ID %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("NOK mismatching ID", func(t *testing.T) {
+		gfs := New(referenceGistID)
+		if err := gfs.LoadFromJSON(strings.NewReader(`{"id":"abc","files":{}}`)); err == nil {
+			t.Fatal("Loading another gist from JSON, got no error, want one")
+		}
+	})
+
+	t.Run("NOK checks", func(t *testing.T) {
+		doc := `{"id":"` + referenceGistID + `","public":true,"files":{"a.txt":{"filename":"a.txt","content":"aaaa","size":4}}}`
+
+		for name, opt := range map[string]Option{
+			"too large": WithMaxTotalSize(3),
+			"public":    WithSecretOnly(),
+		} {
+			gfs := New(referenceGistID, opt)
+			if err := gfs.LoadFromJSON(strings.NewReader(doc)); err == nil || gfs.IsLoaded() {
+				t.Fatalf("Loading a gist failing the checks of Load (%s) from JSON, got %#v, want an error", name, err)
+			}
+		}
+	})
+
+	t.Run("NOK invalid JSON", func(t *testing.T) {
+		gfs := New(referenceGistID)
+		if err := gfs.LoadFromJSON(strings.NewReader(`{`)); err == nil {
+			t.Fatal("Loading invalid JSON, got no error, want one")
+		}
+	})
+
+	t.Run("NOK write not loaded", func(t *testing.T) {
+		if err := New(referenceGistID).WriteJSON(&bytes.Buffer{}); err != ErrNotLoaded {
+			t.Fatalf("Writing JSON without loading, got %#v, want %#v", err, ErrNotLoaded)
+		}
+	})
+}
@@ -0,0 +1,181 @@
+// Package kv uses a gist as a small key-value store, holding its entries in
+// a JSON document stored in a file of the gist:
+//
+//	gfs := gistfs.New(id, gistfs.WithToken(token), gistfs.WithWritable(), gistfs.WithTTL(time.Minute))
+//	store := kv.New(gfs)
+//	err := store.Set(ctx, "last-run", time.Now())
+//	...
+//	var lastRun time.Time
+//	ok, err := store.Get("last-run", &lastRun)
+//
+// Reads are served from the loaded gist, refreshed as set by the options of
+// the filesystem, while writes update the gist on Github.
+package kv
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"sync"
+	"testing/fstest"
+
+	"github.com/jhchabran/gistfs"
+)
+
+// DefaultFile is the file of the gist holding the entries of a Store, unless
+// set otherwise with WithFile.
+const DefaultFile = "kv.json"
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithFile stores the entries in the named file of the gist, instead of
+// DefaultFile.
+func WithFile(name string) Option {
+	return func(s *Store) {
+		s.name = name
+	}
+}
+
+// Store is a key-value store backed by a gist. Values are encoded as JSON.
+// It is safe for concurrent use.
+//
+// Writes are serialized within a Store, and each one loads the gist again
+// before updating it, but as gists can't be updated conditionally, writes
+// made concurrently by other processes may overwrite each other.
+type Store struct {
+	fsys *gistfs.FS
+	name string
+
+	// mu serializes writes.
+	mu sync.Mutex
+}
+
+// New returns a Store keeping its entries in the gist of fsys, which must be
+// created with gistfs.WithWritable for the store to be written to. The file
+// holding the entries is created by the first write, if needed.
+func New(fsys *gistfs.FS, opts ...Option) *Store {
+	s := &Store{fsys: fsys, name: DefaultFile}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Get decodes the value of key into the value v points to, and reports
+// whether key was found.
+func (s *Store) Get(key string, v interface{}) (bool, error) {
+	entries, err := s.entries()
+	if err != nil {
+		return false, err
+	}
+
+	raw, ok := entries[key]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return true, fmt.Errorf("kv: decoding %q: %w", key, err)
+	}
+
+	return true, nil
+}
+
+// Keys returns the keys starting with prefix, sorted.
+func (s *Store) Keys(prefix string) ([]string, error) {
+	entries, err := s.entries()
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for key := range entries {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+// Set sets the value of key to v, encoded as JSON, and stores it in the
+// gist.
+func (s *Store) Set(ctx context.Context, key string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("kv: encoding %q: %w", key, err)
+	}
+
+	return s.write(ctx, func(entries map[string]json.RawMessage) bool {
+		if bytes.Equal(entries[key], raw) {
+			return false
+		}
+		entries[key] = raw
+		return true
+	})
+}
+
+// Delete removes key from the gist. Deleting a missing key is not an error.
+func (s *Store) Delete(ctx context.Context, key string) error {
+	return s.write(ctx, func(entries map[string]json.RawMessage) bool {
+		if _, ok := entries[key]; !ok {
+			return false
+		}
+		delete(entries, key)
+		return true
+	})
+}
+
+// write loads the gist again, applies change to its entries, and stores
+// them in the gist if change reports they changed.
+func (s *Store) write(ctx context.Context, change func(map[string]json.RawMessage) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.fsys.Load(ctx); err != nil {
+		return err
+	}
+
+	entries, err := s.entries()
+	if err != nil {
+		return err
+	}
+	if !change(entries) {
+		return nil
+	}
+
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Gists can't hold empty files, so an empty store is still "{}".
+	_, err = s.fsys.UpdateFromFS(ctx, fstest.MapFS{s.name: {Data: append(b, '\n')}})
+	return err
+}
+
+// entries returns the entries held by the gist, none if the file holding
+// them doesn't exist yet.
+func (s *Store) entries() (map[string]json.RawMessage, error) {
+	b, err := s.fsys.ReadFile(s.name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]json.RawMessage{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, fmt.Errorf("kv: decoding %s: %w", s.name, err)
+	}
+
+	return entries, nil
+}
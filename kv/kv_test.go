@@ -0,0 +1,108 @@
+package kv
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jhchabran/gistfs"
+	"github.com/jhchabran/gistfs/gisttest"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK set, get and delete", func(t *testing.T) {
+		gfs, srv := gisttest.NewFS(t, map[string]string{"README.md": "store"}, gistfs.WithWritable())
+		store := New(gfs)
+
+		if err := store.Set(ctx, "port", 8080); err != nil {
+			t.Fatalf("Setting, expected no error but got %#v", err)
+		}
+		if err := store.Set(ctx, "name", "api"); err != nil {
+			t.Fatalf("Setting, expected no error but got %#v", err)
+		}
+		if err := store.Delete(ctx, "name"); err != nil {
+			t.Fatalf("Deleting, expected no error but got %#v", err)
+		}
+
+		// The entries must be read back from Github by another filesystem.
+		other := gistfs.NewWithClient(srv.GithubClient(), gisttest.GistID)
+		if err := other.Load(ctx); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		var port int
+		if ok, err := New(other).Get("port", &port); err != nil || !ok || port != 8080 {
+			t.Fatalf("Getting, got %v, %v, %#v, want 8080", port, ok, err)
+		}
+		var name string
+		if ok, err := New(other).Get("name", &name); err != nil || ok {
+			t.Fatalf("Getting deleted key, got %v, %#v, want not found", ok, err)
+		}
+	})
+
+	t.Run("OK keys", func(t *testing.T) {
+		gfs, _ := gisttest.NewFS(t, map[string]string{
+			DefaultFile: `{"user/2": "b", "user/1": "a", "team/1": "c"}`,
+		})
+
+		keys, err := New(gfs).Keys("user/")
+		if err != nil {
+			t.Fatalf("Listing keys, expected no error but got %#v", err)
+		}
+		if want := []string{"user/1", "user/2"}; !reflect.DeepEqual(keys, want) {
+			t.Fatalf("Listing keys, got %v, want %v", keys, want)
+		}
+	})
+
+	t.Run("OK keeps entries written elsewhere", func(t *testing.T) {
+		srv := gisttest.NewServer(t)
+		g := srv.AddGist(gisttest.GistID, map[string]string{"store.json": `{"a": 1}`})
+		gfs := gistfs.NewWithClient(srv.GithubClient(), gisttest.GistID, gistfs.WithWritable())
+		if err := gfs.Load(ctx); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+		store := New(gfs, WithFile("store.json"))
+
+		g.SetFiles(map[string]string{"store.json": `{"a": 1, "b": 2}`})
+		if err := store.Set(ctx, "c", 3); err != nil {
+			t.Fatalf("Setting, expected no error but got %#v", err)
+		}
+
+		keys, err := store.Keys("")
+		if err != nil {
+			t.Fatalf("Listing keys, expected no error but got %#v", err)
+		}
+		if want := []string{"a", "b", "c"}; !reflect.DeepEqual(keys, want) {
+			t.Fatalf("Listing keys, got %v, want %v", keys, want)
+		}
+	})
+
+	t.Run("OK empty", func(t *testing.T) {
+		gfs, _ := gisttest.NewFS(t, map[string]string{"README.md": "store"})
+
+		var v string
+		if ok, err := New(gfs).Get("missing", &v); err != nil || ok {
+			t.Fatalf("Getting, got %v, %#v, want not found", ok, err)
+		}
+	})
+
+	t.Run("NOK read-only", func(t *testing.T) {
+		gfs, _ := gisttest.NewFS(t, map[string]string{"README.md": "store"})
+
+		if err := New(gfs).Set(ctx, "a", 1); !errors.Is(err, gistfs.ErrReadOnly) {
+			t.Fatalf("Setting, got %#v, want ErrReadOnly", err)
+		}
+	})
+
+	t.Run("NOK invalid document", func(t *testing.T) {
+		gfs, _ := gisttest.NewFS(t, map[string]string{DefaultFile: "["})
+
+		var v string
+		if _, err := New(gfs).Get("a", &v); err == nil {
+			t.Fatal("Getting, expected an error but got none")
+		}
+	})
+}
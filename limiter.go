@@ -0,0 +1,98 @@
+package gistfs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter throttles requests made to Github with a token bucket: requests
+// consume tokens, which are replenished at a steady rate up to a burst size.
+//
+// A Limiter is safe for concurrent use, and can be shared by several
+// filesystems through WithLimiter to throttle their requests as a whole.
+type Limiter struct {
+	qps    float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	mu     sync.Mutex
+}
+
+// NewLimiter returns a Limiter allowing qps requests per second on average,
+// with bursts of up to burst requests. It panics if qps is not positive.
+func NewLimiter(qps float64, burst int) *Limiter {
+	if !(qps > 0) {
+		panic("gistfs: non-positive qps for NewLimiter")
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &Limiter{
+		qps:    qps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// Wait blocks until a request is allowed, or ctx is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.qps
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+
+	// reserve a token, possibly going in debt, which tells how long to wait.
+	l.tokens--
+	debt := -l.tokens
+	l.mu.Unlock()
+
+	if debt <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(time.Duration(debt / l.qps * float64(time.Second)))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+
+		return ctx.Err()
+	}
+}
+
+// WithRateLimit throttles the requests the filesystem makes to Github to qps
+// requests per second on average, with bursts of up to burst requests. It
+// panics if qps is not positive, as NewLimiter does.
+func WithRateLimit(qps float64, burst int) Option {
+	return WithLimiter(NewLimiter(qps, burst))
+}
+
+// WithLimiter throttles the requests the filesystem makes to Github with l,
+// which can be shared with other filesystems.
+func WithLimiter(l *Limiter) Option {
+	return func(fsys *FS) {
+		fsys.limiter = l
+	}
+}
+
+// wait blocks until the limiter of the filesystem, if any, allows a request,
+// or ctx is done.
+func (fsys *FS) wait(ctx context.Context) error {
+	if fsys.limiter == nil {
+		return nil
+	}
+
+	return fsys.limiter.Wait(ctx)
+}
@@ -0,0 +1,104 @@
+package gistfs
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	t.Run("OK burst", func(t *testing.T) {
+		l := NewLimiter(1, 3)
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			if err := l.Wait(context.Background()); err != nil {
+				t.Fatalf("Waiting within burst, expected no error but got %#v", err)
+			}
+		}
+
+		if d := time.Since(start); d > 100*time.Millisecond {
+			t.Fatalf("Waiting within burst, took %v, want no wait", d)
+		}
+	})
+
+	t.Run("OK throttled", func(t *testing.T) {
+		l := NewLimiter(50, 1)
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			l.Wait(context.Background())
+		}
+
+		if d := time.Since(start); d < 35*time.Millisecond {
+			t.Fatalf("Waiting beyond burst, took %v, want about 40ms", d)
+		}
+	})
+
+	t.Run("NOK non-positive qps", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("Creating a limiter with a qps of 0, got no panic, want one")
+			}
+		}()
+		NewLimiter(0, 1)
+	})
+
+	t.Run("NOK canceled", func(t *testing.T) {
+		l := NewLimiter(0.001, 1)
+		l.Wait(context.Background())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		if err := l.Wait(ctx); err != context.DeadlineExceeded {
+			t.Fatalf("Waiting with an expiring context, got %#v, want %#v", err, context.DeadlineExceeded)
+		}
+	})
+}
+
+func TestWithLimiter(t *testing.T) {
+	fg1, client1 := newFakeGist(t, map[string]string{"a.txt": "a"})
+	fg2, client2 := newFakeGist(t, map[string]string{"b.txt": "b"})
+
+	l := NewLimiter(0.001, 1)
+	gfs1 := NewWithClient(client1, referenceGistID, WithLimiter(l))
+	gfs2 := NewWithClient(client2, referenceGistID, WithLimiter(l))
+
+	if err := gfs1.Load(context.Background()); err != nil {
+		t.Fatalf("Loading within the limit, expected no error but got %#v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := gfs2.Load(ctx); err == nil {
+		t.Fatal("Loading beyond a shared limit, got no error, want one")
+	}
+
+	if got, want := fg1.Requests()+fg2.Requests(), 1; got != want {
+		t.Fatalf("Loading with a shared limiter, got %d API requests, want %d", got, want)
+	}
+}
+
+func TestLimiterRawContent(t *testing.T) {
+	fg, _ := newFakeGist(t, map[string]string{"a.txt": "a", "b.txt": "b"})
+
+	getter, err := NewRawGetter(fg.Client(), fg.URL)
+	if err != nil {
+		t.Fatalf("Creating a raw getter, expected no error but got %#v", err)
+	}
+
+	// Allows the request of the gist, but none of its files.
+	gfs := NewWithGetter(getter, referenceGistID, WithLimiter(NewLimiter(0.001, 1)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := gfs.Load(ctx); err == nil {
+		t.Fatal("Loading files beyond the limit, got no error, want one")
+	}
+	if got, want := fg.Requests(), 1; got != want {
+		t.Fatalf("Loading files beyond the limit, got %d requests, want %d", got, want)
+	}
+}
@@ -0,0 +1,149 @@
+package gistfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrTooLarge is an error that signals that a gist exceeds the limits set
+// with WithMaxFileSize or WithMaxTotalSize. Errors matching it with errors.Is
+// can be inspected with errors.As to find which limit was exceeded.
+var ErrTooLarge = errors.New("gist too large")
+
+// SizeError is returned when a gist exceeds the limits set with
+// WithMaxFileSize or WithMaxTotalSize. It matches ErrTooLarge with errors.Is.
+type SizeError struct {
+	// File is the name of the file exceeding the limit set with
+	// WithMaxFileSize, or empty when the whole gist exceeds the limit set
+	// with WithMaxTotalSize.
+	File string
+	// Size is the size of the file or of the gist, in bytes.
+	Size int64
+	// Limit is the limit that was exceeded, in bytes.
+	Limit int64
+}
+
+func (e *SizeError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("%v: %d bytes exceed the limit of %d", ErrTooLarge, e.Size, e.Limit)
+	}
+
+	return fmt.Sprintf("%v: file %s of %d bytes exceeds the limit of %d", ErrTooLarge, e.File, e.Size, e.Limit)
+}
+
+// Is makes SizeError match ErrTooLarge.
+func (e *SizeError) Is(target error) bool { return target == ErrTooLarge }
+
+// WithMaxFileSize makes Load fail with a *SizeError if a file of the gist is
+// larger than n bytes, before its content is downloaded. Files turning out
+// larger once downloaded fail the same way, when they are read if their
+// content was deferred by LoadMetadata, and their download stops right past
+// the limit.
+//
+// It protects services serving gists they do not control from absurdly large
+// ones.
+func WithMaxFileSize(n int64) Option {
+	return func(fsys *FS) {
+		fsys.maxFileSize = n
+	}
+}
+
+// WithMaxTotalSize makes Load fail with a *SizeError if the files of the
+// gist add up to more than n bytes, before their content is downloaded. When
+// their sizes are unknown, as with NewAnonymous, Load fails as soon as the
+// content downloaded so far adds up to more than n bytes.
+func WithMaxTotalSize(n int64) Option {
+	return func(fsys *FS) {
+		fsys.maxTotalSize = n
+	}
+}
+
+// checkSize returns a *SizeError if gist exceeds the configured limits,
+// according to the sizes reported by Github.
+func (fsys *FS) checkSize(gist *Gist) error {
+	var total int64
+	for name, f := range gist.Files {
+		if err := fsys.checkFileSize(name, int64(f.Size)); err != nil {
+			return err
+		}
+		total += int64(f.Size)
+	}
+
+	return fsys.checkTotalSize(total)
+}
+
+// checkTotalSize returns a *SizeError if a gist whose files add up to total
+// bytes exceeds the configured limit.
+func (fsys *FS) checkTotalSize(total int64) error {
+	if fsys.maxTotalSize > 0 && total > fsys.maxTotalSize {
+		return &SizeError{Size: total, Limit: fsys.maxTotalSize}
+	}
+
+	return nil
+}
+
+// checkFileSize returns a *SizeError if a file of size bytes exceeds the
+// configured limit. Downloaded content is checked as well, as it may not
+// match the size Github reported.
+func (fsys *FS) checkFileSize(name string, size int64) error {
+	if fsys.maxFileSize > 0 && size > fsys.maxFileSize {
+		return &SizeError{File: name, Size: size, Limit: fsys.maxFileSize}
+	}
+
+	return nil
+}
+
+// rawLimitKey is the key of the context value holding the limit set with
+// WithMaxFileSize, for the getters of the package to stop downloading
+// content once it is exceeded.
+type rawLimitKey struct{}
+
+// getRaw returns the raw content at rawURL, as the getter of the filesystem
+// does. Getters of the package download at most one byte more than the
+// limit set with WithMaxFileSize, which checkFileSize then rejects.
+func (fsys *FS) getRaw(ctx context.Context, rawURL string) ([]byte, error) {
+	if fsys.maxFileSize > 0 {
+		ctx = context.WithValue(ctx, rawLimitKey{}, fsys.maxFileSize+1)
+	}
+
+	return fsys.getter.GetRaw(ctx, rawURL)
+}
+
+// rawLimit returns how many bytes of raw content can be read with ctx, or 0
+// if there is no limit.
+func rawLimit(ctx context.Context) int64 {
+	n, _ := ctx.Value(rawLimitKey{}).(int64)
+	return n
+}
+
+// readRaw reads raw content from r, up to the limit set in ctx.
+func readRaw(ctx context.Context, r io.Reader) ([]byte, error) {
+	if n := rawLimit(ctx); n > 0 {
+		r = io.LimitReader(r, n)
+	}
+
+	return io.ReadAll(r)
+}
+
+// errRawLimit stops copying raw content into a full rawBuffer.
+var errRawLimit = errors.New("raw content limit reached")
+
+// rawBuffer holds raw content, up to max bytes if max is positive. Writes
+// past it fail, which stops go-github from copying the rest of a response.
+// It does not embed its buffer, whose ReadFrom method io.Copy would use.
+type rawBuffer struct {
+	buf bytes.Buffer
+	max int64
+}
+
+func (b *rawBuffer) Write(p []byte) (int, error) {
+	if left := b.max - int64(b.buf.Len()); b.max > 0 && int64(len(p)) > left {
+		n, _ := b.buf.Write(p[:left])
+		return n, errRawLimit
+	}
+
+	return b.buf.Write(p)
+}
@@ -0,0 +1,94 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSizeLimits(t *testing.T) {
+	getter := &stubGetter{
+		gist: &Gist{
+			ID: referenceGistID,
+			Files: map[string]GistFile{
+				"a.txt":   {Filename: "a.txt", Content: "aaaa", Size: 4},
+				"big.txt": {Filename: "big.txt", Content: "big", Size: 6, Truncated: true, RawURL: "https://raw/big.txt"},
+			},
+		},
+		raw: map[string]string{"https://raw/big.txt": "big file!"},
+	}
+
+	t.Run("OK within limits", func(t *testing.T) {
+		gfs := NewWithGetter(getter, referenceGistID, WithMaxFileSize(10), WithMaxTotalSize(20))
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading a gist within limits, expected no error but got %#v", err)
+		}
+	})
+
+	t.Run("NOK file too large", func(t *testing.T) {
+		rawCalls := getter.rawCalls
+		gfs := NewWithGetter(getter, referenceGistID, WithMaxFileSize(5))
+		err := gfs.Load(context.Background())
+		if !errors.Is(err, ErrTooLarge) {
+			t.Fatalf("Loading a gist with a large file, got %#v, want %#v", err, ErrTooLarge)
+		}
+
+		var sizeErr *SizeError
+		if !errors.As(err, &sizeErr) {
+			t.Fatalf("Loading a gist with a large file, got %#v, want a *SizeError", err)
+		}
+		if got, want := *sizeErr, (SizeError{File: "big.txt", Size: 6, Limit: 5}); got != want {
+			t.Fatalf("Loading a gist with a large file, got %#v, want %#v", got, want)
+		}
+		if got := getter.rawCalls - rawCalls; got != 0 {
+			t.Fatalf("Loading a gist with a large file, got %d raw fetches, want none", got)
+		}
+	})
+
+	t.Run("NOK gist too large", func(t *testing.T) {
+		gfs := NewWithGetter(getter, referenceGistID, WithMaxTotalSize(8))
+
+		var sizeErr *SizeError
+		if err := gfs.Load(context.Background()); !errors.As(err, &sizeErr) || sizeErr.File != "" {
+			t.Fatalf("Loading a large gist, got %#v, want a *SizeError for the whole gist", err)
+		}
+	})
+
+	t.Run("NOK downloaded content too large", func(t *testing.T) {
+		gfs := NewWithGetter(getter, referenceGistID, WithMaxFileSize(8))
+		if err := gfs.LoadMetadata(context.Background()); err != nil {
+			t.Fatalf("Loading metadata, expected no error but got %#v", err)
+		}
+
+		if _, err := gfs.ReadFile("big.txt"); !errors.Is(err, ErrTooLarge) {
+			t.Fatalf("Reading a file larger than reported, got %#v, want %#v", err, ErrTooLarge)
+		}
+	})
+}
+
+func TestGetRawLimit(t *testing.T) {
+	big := strings.Repeat("a", 1000)
+	fg, client := newFakeGist(t, map[string]string{"big.txt": big})
+	rawURL := fg.URL + "/raw/" + referenceGistID + "/" + blobSHA(big) + "/big.txt"
+
+	httpGetter, err := NewHTTPGetter(fg.Client(), fg.URL)
+	if err != nil {
+		t.Fatalf("Creating an HTTP getter, expected no error but got %#v", err)
+	}
+
+	for name, gfs := range map[string]*FS{
+		"github": NewWithClient(client, referenceGistID, WithMaxFileSize(10)),
+		"http":   NewWithGetter(httpGetter, referenceGistID, WithMaxFileSize(10)),
+	} {
+		t.Run("OK "+name, func(t *testing.T) {
+			b, err := gfs.getRaw(context.Background(), rawURL)
+			if err != nil {
+				t.Fatalf("Getting raw content, expected no error but got %#v", err)
+			}
+			if got, want := len(b), 11; got != want {
+				t.Fatalf("Getting raw content larger than the limit, got %d bytes, want %d", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package gistfs
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// logLevel is the severity of a logged event. Its values are the ones of the
+// log/slog levels.
+type logLevel int
+
+const (
+	levelDebug logLevel = -4
+	levelInfo  logLevel = 0
+	levelWarn  logLevel = 4
+)
+
+// logger receives the events logged by a filesystem, with their attributes
+// given as alternating keys and values. It keeps the filesystem independent
+// of log/slog, which WithLogger adapts.
+type logger interface {
+	log(ctx context.Context, level logLevel, msg string, args ...interface{})
+}
+
+// log logs an event with the logger of the filesystem, if any, adding the
+// ID of the gist to its attributes.
+func (fsys *FS) log(ctx context.Context, level logLevel, msg string, args ...interface{}) {
+	if fsys.logger == nil {
+		return
+	}
+
+	fsys.logger.log(ctx, level, msg, append([]interface{}{"gist", fsys.GetID()}, args...)...)
+}
+
+// logLoad logs the outcome of a load of the gist that started at start.
+func (fsys *FS) logLoad(ctx context.Context, start time.Time, gist *github.Gist, notModified bool, err error) {
+	if fsys.logger == nil {
+		return
+	}
+
+	duration := fsys.now().Sub(start)
+	remaining := fsys.RateLimit().Remaining
+	if err != nil {
+		fsys.log(ctx, levelWarn, "gist load failed", "duration", duration, "rate_limit_remaining", remaining, "error", err)
+		return
+	}
+
+	level := levelInfo
+	if notModified {
+		level = levelDebug
+	}
+	fsys.log(ctx, level, "gist loaded", "duration", duration, "files", len(gist.Files), "bytes", gistSize(gist), "not_modified", notModified, "rate_limit_remaining", remaining)
+}
+
+// logRaw logs the outcome of a fetch of the raw content of the file name
+// that started at start.
+func (fsys *FS) logRaw(ctx context.Context, name string, start time.Time, size int, err error) {
+	if fsys.logger == nil {
+		return
+	}
+
+	duration := fsys.now().Sub(start)
+	if err != nil {
+		fsys.log(ctx, levelWarn, "raw content fetch failed", "file", name, "duration", duration, "error", err)
+		return
+	}
+
+	fsys.log(ctx, levelDebug, "raw content fetched", "file", name, "duration", duration, "bytes", size)
+}
+
+// gistSize returns the total size of the files of gist.
+func gistSize(gist *github.Gist) int {
+	var size int
+	for _, f := range gist.Files {
+		size += f.GetSize()
+	}
+
+	return size
+}
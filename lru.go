@@ -0,0 +1,221 @@
+package gistfs
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"sort"
+	"sync"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// LRU bounds the number of gists held in memory by the filesystems sharing
+// it, and their total size, evicting the gists of the filesystems least
+// recently used beyond them.
+//
+// Eviction is transparent: the next read of an evicted filesystem loads its
+// gist again, from its cache if it has one, or from Github.
+type LRU struct {
+	maxGists int
+	maxBytes int64
+
+	mu sync.Mutex
+	// order holds *lruEntry, the most recently used first.
+	order *list.List
+	items map[*FS]*list.Element
+	bytes int64
+}
+
+// lruEntry is a filesystem tracked by an LRU.
+type lruEntry struct {
+	fsys *FS
+	size int64
+}
+
+// NewLRU returns an LRU holding up to maxGists gists of maxBytes in total,
+// zero meaning no bound. The most recently used gist is always held, even
+// if it is larger than maxBytes.
+func NewLRU(maxGists int, maxBytes int64) *LRU {
+	return &LRU{
+		maxGists: maxGists,
+		maxBytes: maxBytes,
+		order:    list.New(),
+		items:    make(map[*FS]*list.Element),
+	}
+}
+
+// WithLRU makes the loaded gist subject to eviction by l, which is meant to
+// be shared by many filesystems. Evicted filesystems report being loaded,
+// but their methods other than Open, ReadFile and ReadDir, such as Hashes,
+// behave as if they are not until they are read again.
+func WithLRU(l *LRU) Option {
+	return func(fsys *FS) {
+		fsys.lru = l
+	}
+}
+
+// Len returns the number of gists held by the filesystems tracked by l.
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.order.Len()
+}
+
+// Size returns the total size of the gists held by the filesystems tracked
+// by l.
+func (l *LRU) Size() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.bytes
+}
+
+// touch marks fsys as the most recently used, holding a gist of size bytes
+// or of the size last given if negative, and evicts the gists of the least
+// recently used filesystems beyond the bounds of l.
+func (l *LRU) touch(fsys *FS, size int64) {
+	l.mu.Lock()
+
+	if e, ok := l.items[fsys]; ok {
+		l.order.MoveToFront(e)
+		if entry := e.Value.(*lruEntry); size >= 0 {
+			l.bytes += size - entry.size
+			entry.size = size
+		}
+	} else {
+		if size < 0 {
+			size = 0
+		}
+		l.items[fsys] = l.order.PushFront(&lruEntry{fsys: fsys, size: size})
+		l.bytes += size
+	}
+
+	var evicted []*FS
+	for l.order.Len() > 1 && l.over() {
+		entry := l.order.Remove(l.order.Back()).(*lruEntry)
+		delete(l.items, entry.fsys)
+		l.bytes -= entry.size
+		evicted = append(evicted, entry.fsys)
+	}
+
+	l.mu.Unlock()
+
+	for _, fsys := range evicted {
+		fsys.evict()
+	}
+}
+
+// remove stops tracking fsys, whose gist is dropped by other means than
+// eviction.
+func (l *LRU) remove(fsys *FS) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, ok := l.items[fsys]; ok {
+		l.order.Remove(e)
+		delete(l.items, fsys)
+		l.bytes -= e.Value.(*lruEntry).size
+	}
+}
+
+// over reports whether l holds more than its bounds allow.
+func (l *LRU) over() bool {
+	return (l.maxGists > 0 && l.order.Len() > l.maxGists) || (l.maxBytes > 0 && l.bytes > l.maxBytes)
+}
+
+// evictedGist is what the filesystem keeps of a gist evicted by its LRU.
+type evictedGist struct {
+	// digests identify the content of the files of the gist, to notify
+	// watchers of the changes found once it is loaded again.
+	digests map[github.GistFilename][sha256.Size]byte
+	// deferContent is the one the gist was loaded with.
+	deferContent bool
+}
+
+// evict drops the gist held by the filesystem, to be loaded again on its
+// next read.
+func (fsys *FS) evict() {
+	old := fsys.update(func(s *state) {
+		if s.gist == nil {
+			return
+		}
+
+		s.evicted = &evictedGist{
+			digests:      digestGist(s.gist, s.extra),
+			deferContent: s.extra.deferContent,
+		}
+		s.gist, s.etag, s.extra, s.comments = nil, "", gistExtra{}, nil
+	})
+
+	fsys.interner.releaseGist(old.gist, old.extra)
+	fsys.fileHashes.Store(&fileHashes{})
+}
+
+// reloadEvicted loads the gist again if it was evicted.
+func (fsys *FS) reloadEvicted(ctx context.Context) error {
+	s := fsys.state()
+	if s.gist != nil || s.evicted == nil {
+		return nil
+	}
+
+	return fsys.loadShared(ctx, s.evicted.deferContent)
+}
+
+// used marks the filesystem as used by its LRU, if any, and as not idle.
+func (fsys *FS) used() {
+	if fsys.lru != nil {
+		fsys.lru.touch(fsys, -1)
+	}
+	fsys.markUsed()
+}
+
+// digestGist returns digests of the content of the files of gist.
+func digestGist(gist *github.Gist, extra gistExtra) map[github.GistFilename][sha256.Size]byte {
+	digests := make(map[github.GistFilename][sha256.Size]byte, len(gist.Files))
+	for name, f := range gist.Files {
+		key := extra.contentKey(name, f)
+
+		h := sha256.New()
+		if key.compressed {
+			h.Write([]byte{1})
+		} else {
+			h.Write([]byte{0})
+		}
+		h.Write([]byte(key.content))
+
+		var sum [sha256.Size]byte
+		copy(sum[:], h.Sum(nil))
+		digests[name] = sum
+	}
+
+	return digests
+}
+
+// changes returns the changes needed to go from the evicted gist to gist,
+// as diffGists does.
+func (e *evictedGist) changes(gist *github.Gist, extra gistExtra) []ChangeEvent {
+	digests := digestGist(gist, extra)
+
+	var events []ChangeEvent
+	for name, sum := range digests {
+		prev, ok := e.digests[name]
+		switch {
+		case !ok:
+			events = append(events, ChangeEvent{Op: Added, Name: string(name)})
+		case prev != sum:
+			events = append(events, ChangeEvent{Op: Modified, Name: string(name)})
+		}
+	}
+
+	for name := range e.digests {
+		if _, ok := digests[name]; !ok {
+			events = append(events, ChangeEvent{Op: Removed, Name: string(name)})
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].Name < events[j].Name })
+
+	return events
+}
@@ -0,0 +1,139 @@
+package gistfs
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWithLRU(t *testing.T) {
+	type gist struct {
+		fg  *fakeGist
+		gfs *FS
+	}
+
+	newGists := func(t *testing.T, lru *LRU, contents ...string) []gist {
+		t.Helper()
+
+		var gists []gist
+		for _, content := range contents {
+			fg, client := newFakeGist(t, map[string]string{"a.txt": content})
+			gfs := NewWithClient(client, referenceGistID, WithLRU(lru), WithCache(NewMemoryCache()))
+			if err := gfs.Load(context.Background()); err != nil {
+				t.Fatalf("Loading, expected no error but got %#v", err)
+			}
+			gists = append(gists, gist{fg, gfs})
+		}
+
+		return gists
+	}
+
+	held := func(gists []gist) []bool {
+		var loaded []bool
+		for _, g := range gists {
+			loaded = append(loaded, g.gfs.state().gist != nil)
+		}
+		return loaded
+	}
+
+	t.Run("OK count", func(t *testing.T) {
+		lru := NewLRU(2, 0)
+		gists := newGists(t, lru, "a", "b", "c")
+
+		if got := held(gists); got[0] || !got[1] || !got[2] || lru.Len() != 2 {
+			t.Fatalf("Loading, got gists held %v, want the first one evicted", got)
+		}
+		if !gists[0].gfs.IsLoaded() {
+			t.Fatal("Checking an evicted gist, got not loaded, want loaded")
+		}
+
+		b, err := gists[0].gfs.ReadFile("a.txt")
+		if err != nil || string(b) != "a" {
+			t.Fatalf("Reading an evicted gist, got %#v (%v), want %#v", string(b), err, "a")
+		}
+		if gists[0].fg.LastHeader().Get("If-None-Match") == "" {
+			t.Fatal("Reading an evicted gist, got an unconditional request, want one revalidating the cached gist")
+		}
+
+		if got := held(gists); !got[0] || got[1] || !got[2] {
+			t.Fatalf("Reading an evicted gist, got gists held %v, want the second one evicted", got)
+		}
+	})
+
+	t.Run("OK bytes", func(t *testing.T) {
+		lru := NewLRU(0, 5)
+		gists := newGists(t, lru, "aa", "bb", "cc")
+
+		if got := held(gists); got[0] || !got[1] || !got[2] || lru.Size() != 4 {
+			t.Fatalf("Loading, got gists held %v (%d bytes), want the first one evicted", got, lru.Size())
+		}
+	})
+
+	t.Run("OK watch", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		lru := NewLRU(1, 0)
+		gists := newGists(t, lru, "a")
+		events, _ := gists[0].gfs.Watch(ctx)
+
+		newGists(t, lru, "b")
+		gists[0].fg.SetFiles(map[string]string{"a.txt": "a", "b.txt": "b"})
+		if _, err := gists[0].gfs.ReadDir("."); err != nil {
+			t.Fatalf("Reading an evicted gist, expected no error but got %#v", err)
+		}
+
+		select {
+		case ev := <-events:
+			if ev != (ChangeEvent{Op: Added, Name: "b.txt"}) {
+				t.Fatalf("Watching an evicted gist, got %v, want b.txt added", ev)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Watching an evicted gist, timed out")
+		}
+
+		select {
+		case ev := <-events:
+			t.Fatalf("Watching an evicted gist, got %v, want no other event", ev)
+		default:
+		}
+	})
+}
+
+func TestDeleteWithLRU(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK LRU", func(t *testing.T) {
+		l := NewLRU(2, 0)
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		gfs := NewWithClient(client, referenceGistID, WithWritable(), WithLRU(l))
+		if err := gfs.Load(ctx); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		if err := gfs.Delete(ctx); err != nil {
+			t.Fatalf("Deleting, expected no error but got %#v", err)
+		}
+		if got, want := l.Len(), 0; got != want {
+			t.Fatalf("Deleting, got %d gists held by the LRU, want %d", got, want)
+		}
+	})
+
+	t.Run("OK idle timeout", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		gfs := NewWithClient(client, referenceGistID, WithWritable(), WithIdleTimeout(time.Hour))
+		if err := gfs.Load(ctx); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		if err := gfs.Delete(ctx); err != nil {
+			t.Fatalf("Deleting, expected no error but got %#v", err)
+		}
+		gfs.idle.mu.Lock()
+		timer := gfs.idle.timer
+		gfs.idle.mu.Unlock()
+		if timer != nil {
+			t.Fatal("Deleting, the idle timer is still running")
+		}
+	})
+}
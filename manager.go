@@ -0,0 +1,141 @@
+package gistfs
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// Manager hands out filesystems for many gists, such as one per user of
+// a service, making their requests with a single Github client.
+//
+// The options given to NewManager apply to all the filesystems it creates,
+// so that the instances they hold are shared among them: a Limiter given
+// with WithRateLimit throttles their requests as a whole, a Cache given
+// with WithCache stores all their gists and an LRU given with WithLRU
+// bounds the memory they use.
+type Manager struct {
+	client *github.Client
+	opts   []Option
+
+	mu  sync.Mutex
+	fss map[string]*FS
+}
+
+// NewManager returns a Manager creating filesystems with client, or an
+// unauthenticated one if nil, configured with opts.
+func NewManager(client *github.Client, opts ...Option) *Manager {
+	if client == nil {
+		client = github.NewClient(nil)
+	}
+
+	return &Manager{
+		client: client,
+		opts:   opts,
+		fss:    make(map[string]*FS),
+	}
+}
+
+// Get returns the filesystem of the gist id, loading it the first time it
+// is requested. Concurrent calls for the same gist share the filesystem and
+// its load. If loading it fails, the error is returned and the next call
+// tries again.
+func (m *Manager) Get(ctx context.Context, id string) (*FS, error) {
+	m.mu.Lock()
+	fsys, ok := m.fss[id]
+	if !ok {
+		fsys = NewWithClient(m.client, id, m.opts...)
+		m.fss[id] = fsys
+	}
+	m.mu.Unlock()
+
+	if fsys.IsLoaded() {
+		return fsys, nil
+	}
+
+	if err := fsys.Load(ctx); err != nil {
+		m.mu.Lock()
+		if m.fss[id] == fsys && !fsys.IsLoaded() {
+			delete(m.fss, id)
+		}
+		m.mu.Unlock()
+
+		return nil, err
+	}
+
+	return fsys, nil
+}
+
+// Remove stops managing the filesystem of the gist id, if any. The next
+// call to Get for it creates and loads a new one.
+func (m *Manager) Remove(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.fss, id)
+}
+
+// IDs returns the IDs of the gists of the managed filesystems, sorted.
+func (m *Manager) IDs() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := make([]string, 0, len(m.fss))
+	for id := range m.fss {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+// Refresh loads the gists of the managed filesystems again, one at a time.
+// Gists evicted by an LRU are left alone, as they are loaded again when
+// read. All filesystems are refreshed even if some fail, and the first
+// error is returned.
+func (m *Manager) Refresh(ctx context.Context) error {
+	m.mu.Lock()
+	fss := make([]*FS, 0, len(m.fss))
+	for _, fsys := range m.fss {
+		fss = append(fss, fsys)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(fss, func(i, j int) bool { return fss[i].GetID() < fss[j].GetID() })
+
+	var firstErr error
+	for _, fsys := range fss {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if fsys.state().gist == nil {
+			continue
+		}
+
+		if err := fsys.Load(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// RefreshEvery calls Refresh every d until ctx is done, which it returns.
+// It is meant to be run in its own goroutine. Failed refreshes are reported
+// like other loads, through the logger and metrics of the filesystems.
+func (m *Manager) RefreshEvery(ctx context.Context, d time.Duration) error {
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.Refresh(ctx)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package gistfs
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jhchabran/gistfs/internal/gistserver"
+)
+
+func TestManager(t *testing.T) {
+	srv := gistserver.New()
+	t.Cleanup(srv.Close)
+
+	first := srv.AddGist("first", map[string]string{"a.txt": "first"})
+	srv.AddGist("second", map[string]string{"a.txt": "second"})
+
+	ctx := context.Background()
+	m := NewManager(srv.GithubClient(), WithLRU(NewLRU(1, 0)))
+
+	t.Run("OK get", func(t *testing.T) {
+		var wg sync.WaitGroup
+		fss := make([]*FS, 10)
+		for i := range fss {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				fss[i], _ = m.Get(ctx, "first")
+			}(i)
+		}
+		wg.Wait()
+
+		for _, fsys := range fss {
+			if fsys == nil || fsys != fss[0] {
+				t.Fatal("Getting a gist concurrently, got different filesystems, want a single one")
+			}
+		}
+		if got, want := srv.Requests(), 1; got != want {
+			t.Fatalf("Getting a gist concurrently, got %d requests, want %d", got, want)
+		}
+	})
+
+	t.Run("NOK get", func(t *testing.T) {
+		if _, err := m.Get(ctx, "missing"); err == nil {
+			t.Fatal("Getting a missing gist, expected an error but got none")
+		}
+
+		if got := m.IDs(); len(got) != 1 || got[0] != "first" {
+			t.Fatalf("Listing gists, got %v, want [first]", got)
+		}
+	})
+
+	t.Run("OK shared options", func(t *testing.T) {
+		second, err := m.Get(ctx, "second")
+		if err != nil {
+			t.Fatalf("Getting a gist, expected no error but got %#v", err)
+		}
+		fsys, _ := m.Get(ctx, "first")
+
+		if fsys.state().gist != nil {
+			t.Fatal("Getting another gist, got the first one held, want it evicted by the shared LRU")
+		}
+		if b, err := second.ReadFile("a.txt"); err != nil || string(b) != "second" {
+			t.Fatalf("Reading, got %#v (%v), want %#v", string(b), err, "second")
+		}
+	})
+
+	t.Run("OK refresh", func(t *testing.T) {
+		first.SetFiles(map[string]string{"a.txt": "updated"})
+		fsys, _ := m.Get(ctx, "first")
+		if _, err := fsys.ReadFile("a.txt"); err != nil {
+			t.Fatalf("Reading, expected no error but got %#v", err)
+		}
+		first.SetFiles(map[string]string{"a.txt": "refreshed"})
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go m.RefreshEvery(ctx, time.Millisecond)
+
+		eventually(t, func() bool {
+			b, _ := fsys.ReadFile("a.txt")
+			return string(b) == "refreshed"
+		})
+	})
+
+	t.Run("OK remove", func(t *testing.T) {
+		m.Remove("first")
+
+		if got := m.IDs(); len(got) != 1 || got[0] != "second" {
+			t.Fatalf("Listing gists, got %v, want [second]", got)
+		}
+	})
+}
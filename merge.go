@@ -0,0 +1,197 @@
+package gistfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"sync"
+)
+
+// ConflictPolicy tells which file a MergeFS serves when several of its
+// layers hold one at the same path.
+type ConflictPolicy int
+
+const (
+	// FirstWins serves the file of the first layer holding one.
+	FirstWins ConflictPolicy = iota
+	// LastWins serves the file of the last layer holding one.
+	LastWins
+	// ErrorOnConflict fails with ErrConflict.
+	ErrorOnConflict
+)
+
+// ErrConflict is returned by a MergeFS using ErrorOnConflict when several
+// of its layers hold a file at the same path.
+var ErrConflict = errors.New("file exists in several layers")
+
+// MergeFS is a filesystem layering several ones, such as gists overriding
+// a base one or a local directory. Directories found at the same path in
+// several layers are merged, while files are picked according to the
+// conflict policy, a file hiding directories of layers it wins over.
+type MergeFS struct {
+	// layers are ordered by precedence.
+	layers []fs.FS
+	policy ConflictPolicy
+}
+
+// Merge returns a MergeFS layering layers, given from the bottom one to the
+// top one, resolving conflicts according to policy.
+//
+// For example, Merge(LastWins, base, overrides) serves the files of the
+// overrides gist along with the files of the base one it doesn't override.
+func Merge(policy ConflictPolicy, layers ...fs.FS) *MergeFS {
+	ordered := make([]fs.FS, len(layers))
+	copy(ordered, layers)
+	if policy == LastWins {
+		for i, j := 0, len(ordered)-1; i < j; i, j = i+1, j-1 {
+			ordered[i], ordered[j] = ordered[j], ordered[i]
+		}
+	}
+
+	return &MergeFS{layers: ordered, policy: policy}
+}
+
+// Open opens the named file of the layer it is served from, or the merged
+// directory of the layers holding one at name.
+func (m *MergeFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	var (
+		file  fs.FS
+		files int
+		dirs  []fs.FS
+		info  fs.FileInfo
+	)
+	for _, l := range m.layers {
+		hidden, err := hiddenByFile(l, name)
+		if err != nil {
+			return nil, err
+		}
+		if hidden {
+			break
+		}
+
+		fi, err := fs.Stat(l, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case !fi.IsDir():
+			if files == 0 && len(dirs) == 0 {
+				file = l
+			}
+			files++
+		case file == nil:
+			if len(dirs) == 0 {
+				info = fi
+			}
+			dirs = append(dirs, l)
+		}
+	}
+
+	if m.policy == ErrorOnConflict && files > 0 && files+len(dirs) > 1 {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: ErrConflict}
+	}
+
+	if file != nil {
+		return file.Open(name)
+	}
+	if len(dirs) == 0 {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return m.openDir(name, dirs, info)
+}
+
+// hiddenByFile reports whether l holds a file at one of the parent
+// directories of name, hiding what lower layers hold under it.
+func hiddenByFile(l fs.FS, name string) (bool, error) {
+	for d := path.Dir(name); d != "."; d = path.Dir(d) {
+		fi, err := fs.Stat(l, d)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if !fi.IsDir() {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// openDir returns the directory at name merging the ones of dirs, ordered
+// by precedence, described by info.
+func (m *MergeFS) openDir(name string, dirs []fs.FS, info fs.FileInfo) (*mergedDir, error) {
+	d := &mergedDir{name: name, info: info}
+
+	seen := make(map[string]fs.DirEntry)
+	for _, l := range dirs {
+		entries, err := fs.ReadDir(l, name)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range entries {
+			prev, ok := seen[e.Name()]
+			if !ok {
+				seen[e.Name()] = e
+				d.entries = append(d.entries, e)
+				continue
+			}
+
+			if m.policy == ErrorOnConflict && !(prev.IsDir() && e.IsDir()) {
+				return nil, &fs.PathError{Op: "open", Path: name, Err: ErrConflict}
+			}
+		}
+	}
+
+	sort.Slice(d.entries, func(i, j int) bool { return d.entries[i].Name() < d.entries[j].Name() })
+
+	return d, nil
+}
+
+// mergedDir is a directory of a MergeFS and implements fs.ReadDirFile.
+type mergedDir struct {
+	name    string
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	offset  int
+	mu      sync.Mutex
+}
+
+func (d *mergedDir) Close() error               { return nil }
+func (d *mergedDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+
+func (d *mergedDir) Read(b []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("is a directory")}
+}
+
+func (d *mergedDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	n := len(d.entries) - d.offset
+	if n == 0 && count > 0 {
+		return nil, io.EOF
+	}
+	if count > 0 && n > count {
+		n = count
+	}
+
+	entries := make([]fs.DirEntry, n)
+	copy(entries, d.entries[d.offset:])
+	d.offset += n
+
+	return entries, nil
+}
@@ -0,0 +1,87 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMerge(t *testing.T) {
+	_, client := newFakeGist(t, map[string]string{"style.css": "gist style", "logo.svg": "gist logo"})
+
+	gfs := NewWithClient(client, referenceGistID)
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+
+	base := fstest.MapFS{
+		"style.css":          {Data: []byte("base style")},
+		"index.html":         {Data: []byte("base index")},
+		"partials/head.html": {Data: []byte("base head")},
+	}
+	overrides := fstest.MapFS{
+		"partials/foot.html": {Data: []byte("override foot")},
+	}
+
+	tests := []struct {
+		name   string
+		policy ConflictPolicy
+		want   map[string]string
+	}{
+		{"OK first wins", FirstWins, map[string]string{"style.css": "base style", "logo.svg": "gist logo"}},
+		{"OK last wins", LastWins, map[string]string{"style.css": "gist style", "logo.svg": "gist logo"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := Merge(test.policy, base, gfs, overrides)
+
+			for name, want := range test.want {
+				b, err := fs.ReadFile(m, name)
+				if err != nil || string(b) != want {
+					t.Fatalf("Reading %s, got %#v (%v), want %#v", name, string(b), err, want)
+				}
+			}
+
+			entries, err := fs.ReadDir(m, "partials")
+			if err != nil || len(entries) != 2 || entries[0].Name() != "foot.html" || entries[1].Name() != "head.html" {
+				t.Fatalf("Reading a merged directory, got %v (%v), want foot.html and head.html", entries, err)
+			}
+
+			if err := fstest.TestFS(m, "index.html", "logo.svg", "style.css", "partials/foot.html", "partials/head.html"); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	t.Run("NOK error on conflict", func(t *testing.T) {
+		m := Merge(ErrorOnConflict, base, gfs, overrides)
+
+		if _, err := fs.ReadFile(m, "style.css"); !errors.Is(err, ErrConflict) {
+			t.Fatalf("Reading a conflicting file, got %#v, want ErrConflict", err)
+		}
+		if _, err := fs.ReadDir(m, "."); !errors.Is(err, ErrConflict) {
+			t.Fatalf("Reading a directory holding conflicting files, got %#v, want ErrConflict", err)
+		}
+
+		if b, err := fs.ReadFile(m, "logo.svg"); err != nil || string(b) != "gist logo" {
+			t.Fatalf("Reading a file without conflict, got %#v (%v), want %#v", string(b), err, "gist logo")
+		}
+		if _, err := fs.ReadDir(m, "partials"); err != nil {
+			t.Fatalf("Reading a merged directory, expected no error but got %#v", err)
+		}
+	})
+
+	t.Run("OK file over directory", func(t *testing.T) {
+		m := Merge(LastWins, base, fstest.MapFS{"partials": {Data: []byte("file")}})
+
+		if b, err := fs.ReadFile(m, "partials"); err != nil || string(b) != "file" {
+			t.Fatalf("Reading a file hiding a directory, got %#v (%v), want %#v", string(b), err, "file")
+		}
+		if _, err := fs.Stat(m, "partials/head.html"); !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("Stating a file of a hidden directory, got %#v, want fs.ErrNotExist", err)
+		}
+	})
+}
@@ -0,0 +1,340 @@
+package gistfs
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"mime"
+	"path"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// FileMetadata describes a file of a gist. It is what the Sys method of the
+// fs.FileInfo of a gist file returns.
+type FileMetadata struct {
+	Filename string
+	Language string
+	// MIMEType is the media type Github reports for the file, or the one
+	// matching its extension for virtual files.
+	MIMEType string
+	RawURL   string
+	// Truncated is set when the content served for the file only holds its
+	// beginning, because the rest could not be fetched or was deferred by
+	// LoadMetadata.
+	Truncated bool
+	Size      int64
+
+	gistFile *github.GistFile
+}
+
+// GithubFile returns the file as returned by go-github, for callers needing
+// fields not exposed by FileMetadata.
+func (m *FileMetadata) GithubFile() *github.GistFile {
+	return m.gistFile
+}
+
+// GistMetadata describes a gist. The Sys method of the FileInfo of the root
+// directory of a loaded filesystem returns its *GistMetadata, so that code
+// only given a fs.FS can learn about the gist by stating ".":
+//
+//	info, err := fs.Stat(fsys, ".")
+//	if meta, ok := info.Sys().(*gistfs.GistMetadata); ok {
+//		fmt.Println(meta.ID, meta.Description)
+//	}
+type GistMetadata struct {
+	ID          string    `json:"id"`
+	Description string    `json:"description"`
+	Owner       string    `json:"owner,omitempty"`
+	Public      bool      `json:"public"`
+	HTMLURL     string    `json:"html_url,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// Revision is the SHA of the revision being served, when known.
+	Revision string `json:"revision,omitempty"`
+}
+
+func newGistMetadata(s *state) *GistMetadata {
+	return &GistMetadata{
+		ID:          s.gist.GetID(),
+		Description: s.gist.GetDescription(),
+		Owner:       s.gist.GetOwner().GetLogin(),
+		Public:      s.gist.GetPublic(),
+		HTMLURL:     s.gist.GetHTMLURL(),
+		CreatedAt:   s.gist.GetCreatedAt(),
+		UpdatedAt:   s.gist.GetUpdatedAt(),
+		Revision:    s.extra.sha,
+	}
+}
+
+func newFileMetadata(f *github.GistFile) *FileMetadata {
+	return &FileMetadata{
+		Filename:  f.GetFilename(),
+		Language:  f.GetLanguage(),
+		MIMEType:  mimeType(f),
+		RawURL:    f.GetRawURL(),
+		Truncated: isTruncated(*f),
+		Size:      int64(f.GetSize()),
+		gistFile:  f,
+	}
+}
+
+// mimeType returns the media type of f, as reported by Github or else
+// guessed from its extension.
+func mimeType(f *github.GistFile) string {
+	if t := f.GetType(); t != "" {
+		return t
+	}
+
+	t := mime.TypeByExtension(path.Ext(f.GetFilename()))
+	if mediaType, _, err := mime.ParseMediaType(t); err == nil {
+		return mediaType
+	}
+
+	return ""
+}
+
+// LoadMetadata is like Load, except that the content of truncated files is
+// not fetched until they are first opened or read. It saves downloading large
+// files when only their names, sizes, languages and raw URLs are needed, for
+// example to list the gist.
+//
+// The Github API returns the content of small files along with the gist, in
+// which case it is kept. Only truncated files, whose content exceeds what the
+// API returns, are deferred: until then, their FileMetadata reports them as
+// Truncated.
+//
+// Refreshes of a filesystem loaded with LoadMetadata defer content as well,
+// until Load is called.
+func (fsys *FS) LoadMetadata(ctx context.Context) error {
+	return fsys.loadShared(ctx, true)
+}
+
+// fetchDeferred fetches the content of the named file if LoadMetadata
+// deferred it, and stores it in the loaded gist. The file is reported to p,
+// or to a "read" operation of its own if nil and it had to be fetched.
+func (fsys *FS) fetchDeferred(ctx context.Context, name string, p *progress) error {
+	var f github.GistFile
+	var ok bool
+	var signed map[github.GistFilename]string
+
+	if s := fsys.state(); s.gist != nil && s.extra.deferContent {
+		_, f, ok = fsys.lookup(s, name)
+		signed = s.extra.signed
+	}
+
+	if !ok || !isTruncated(f) || f.GetRawURL() == "" {
+		if p != nil {
+			// The content is already there.
+			p.add(name, 1, fsys.sizeOf([]string{name}))
+		}
+		return nil
+	}
+	if p == nil {
+		p = fsys.startProgress("read", 1, int64(f.GetSize()))
+	}
+
+	ctx, cancel := fsys.withLoadTimeout(ctx)
+	defer cancel()
+
+	ctx, span := fsys.startSpan(ctx, "gistfs.GetRaw", f.GetFilename())
+	var b []byte
+	err := fsys.call(ctx, "read", func() (*github.Response, error) {
+		var err error
+		start := fsys.now()
+		b, err = fsys.getRaw(ctx, f.GetRawURL())
+		fsys.logRaw(ctx, f.GetFilename(), start, len(b), err)
+		if err == nil {
+			err = fsys.checkFileSize(f.GetFilename(), int64(len(b)))
+		}
+		if err == nil && fsys.verifyIntegrity {
+			err = verifyContent(f.GetFilename(), f.GetRawURL(), string(b))
+		}
+		if err == nil && fsys.verifySignature != nil {
+			err = verifySigned(signed, f.GetFilename(), string(b))
+		}
+		return nil, err
+	})
+	endSpan(span, len(b), err)
+	if err != nil {
+		return err
+	}
+
+	content, err := fsys.transformContent(f.GetFilename(), string(b))
+	if err != nil {
+		return &Error{Op: "read", ID: fsys.GetID(), Err: err}
+	}
+
+	var filled bool
+	old := fsys.update(func(s *state) {
+		// The gist may have been refreshed or filled meanwhile. Raw URLs
+		// identify the revision of a file, so the content can still be
+		// stored as long as it is the same one.
+		if s.gist == nil {
+			return
+		}
+
+		key := github.GistFilename(f.GetFilename())
+		if current, ok := s.gist.Files[key]; !ok || !isTruncated(current) || current.GetRawURL() != f.GetRawURL() {
+			return
+		}
+
+		// The gist may be in use by readers, so a copy holding the content
+		// replaces it.
+		gist := *s.gist
+		gist.Files = make(map[github.GistFilename]github.GistFile, len(s.gist.Files))
+		for name, gf := range s.gist.Files {
+			gist.Files[name] = gf
+		}
+
+		f = gist.Files[key]
+		f.Content = github.String(content)
+		f.Size = github.Int(len(content))
+		gist.Files[key] = f
+
+		stored, extra := &gist, s.extra
+		if fsys.compress {
+			stored, extra.compressed = compressGist(stored, extra.compressed)
+		}
+
+		s.gist, s.extra = fsys.interner.internGist(stored, extra)
+		filled = true
+	})
+
+	if filled {
+		fsys.interner.releaseGist(old.gist, old.extra)
+	}
+	p.add(name, 1, int64(len(b)))
+
+	return nil
+}
+
+// Prefetch fetches the content of the named files, if it was deferred by
+// LoadMetadata, so that opening them later does not wait for the network.
+// Files are fetched concurrently, as allowed by WithFetchConcurrency, and the
+// first error encountered is returned. Naming a file that does not exist is
+// an error.
+func (fsys *FS) Prefetch(ctx context.Context, names ...string) error {
+	if err := fsys.checkExist(names); err != nil {
+		return err
+	}
+
+	p := fsys.startProgress("prefetch", len(names), fsys.sizeOf(names))
+	return fsys.fetchAll(ctx, len(names), func(ctx context.Context, i int) error {
+		return fsys.fetchDeferred(ctx, names[i], p)
+	})
+}
+
+// checkExist returns an error if one of the named files does not exist.
+func (fsys *FS) checkExist(names []string) error {
+	s := fsys.state()
+	if s.gist == nil {
+		return ErrNotLoaded
+	}
+
+	for _, name := range names {
+		if _, _, ok := fsys.lookup(s, name); !ok {
+			return &fs.PathError{Op: "prefetch", Path: name, Err: fs.ErrNotExist}
+		}
+	}
+
+	return nil
+}
+
+// sizeOf returns the total size of the named files, as reported by Github.
+func (fsys *FS) sizeOf(names []string) int64 {
+	s := fsys.state()
+	if s.gist == nil {
+		return 0
+	}
+
+	var size int64
+	for _, name := range names {
+		if _, f, ok := fsys.lookup(s, name); ok {
+			size += int64(f.GetSize())
+		}
+	}
+
+	return size
+}
+
+// loadedGist returns the loaded gist, or nil if the filesystem is not loaded.
+func (fsys *FS) loadedGist() *github.Gist {
+	return fsys.state().gist
+}
+
+// Gist returns a copy of the loaded gist, as returned by the Github API, or
+// nil if the filesystem is not loaded. It gives access to the metadata the
+// filesystem does not expose, and can be modified freely.
+//
+// Files whose content was deferred by LoadMetadata and not fetched yet are
+// still truncated.
+func (fsys *FS) Gist() *github.Gist {
+	s := fsys.state()
+	if s.gist == nil {
+		return nil
+	}
+
+	gist, err := fsys.plainGist(s)
+	if err != nil {
+		return nil
+	}
+
+	// A JSON round trip copies the values all the fields point to, so that
+	// the copy shares nothing with the gist being served.
+	b, err := json.Marshal(gist)
+	if err != nil {
+		return nil
+	}
+
+	var copied github.Gist
+	if err := json.Unmarshal(b, &copied); err != nil {
+		return nil
+	}
+
+	return &copied
+}
+
+// Description returns the description of the gist, or an empty string if the
+// filesystem is not loaded.
+func (fsys *FS) Description() string {
+	return fsys.loadedGist().GetDescription()
+}
+
+// Owner returns the login of the owner of the gist, or an empty string if the
+// filesystem is not loaded or the gist is anonymous.
+func (fsys *FS) Owner() string {
+	return fsys.loadedGist().GetOwner().GetLogin()
+}
+
+// HTMLURL returns the address of the gist on gist.github.com, or an empty
+// string if the filesystem is not loaded.
+func (fsys *FS) HTMLURL() string {
+	return fsys.loadedGist().GetHTMLURL()
+}
+
+// CreatedAt returns when the gist was created, or the zero time if the
+// filesystem is not loaded.
+func (fsys *FS) CreatedAt() time.Time {
+	return fsys.loadedGist().GetCreatedAt()
+}
+
+// UpdatedAt returns when the gist was last updated, or the zero time if the
+// filesystem is not loaded.
+func (fsys *FS) UpdatedAt() time.Time {
+	return fsys.loadedGist().GetUpdatedAt()
+}
+
+// Public reports whether the gist is public. It returns false if the
+// filesystem is not loaded.
+func (fsys *FS) Public() bool {
+	return fsys.loadedGist().GetPublic()
+}
+
+// IsSecret reports whether the gist is secret. It returns false if the
+// filesystem is not loaded.
+func (fsys *FS) IsSecret() bool {
+	gist := fsys.loadedGist()
+	return gist != nil && !gist.GetPublic()
+}
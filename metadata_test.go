@@ -0,0 +1,333 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+	"time"
+)
+
+func TestMetadata(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+	fg.Description = "my gist"
+	fg.Owner = "jhchabran"
+
+	gfs := NewWithClient(client, referenceGistID)
+
+	t.Run("OK not loaded", func(t *testing.T) {
+		if got := gfs.Description(); got != "" {
+			t.Fatalf("Description before loading, got %#v, want none", got)
+		}
+
+		if got := gfs.UpdatedAt(); !got.IsZero() {
+			t.Fatalf("UpdatedAt before loading, got %v, want the zero time", got)
+		}
+	})
+
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			got, want string
+		}{
+			{"Description", gfs.Description(), "my gist"},
+			{"Owner", gfs.Owner(), "jhchabran"},
+			{"HTMLURL", gfs.HTMLURL(), "https://gist.github.com/" + referenceGistID},
+		}
+
+		for _, test := range tests {
+			if test.got != test.want {
+				t.Fatalf("%s, got %#v, want %#v", test.name, test.got, test.want)
+			}
+		}
+
+		if got, want := gfs.UpdatedAt(), fakeUpdatedAt; !got.Equal(want) {
+			t.Fatalf("UpdatedAt, got %v, want %v", got, want)
+		}
+
+		if got := gfs.CreatedAt(); got.IsZero() || got.After(time.Now()) {
+			t.Fatalf("CreatedAt, got %v, want a time in the past", got)
+		}
+	})
+
+	t.Run("OK root Sys", func(t *testing.T) {
+		info, err := fs.Stat(gfs, ".")
+		if err != nil {
+			t.Fatalf("Stating the root, expected no error but got %#v", err)
+		}
+
+		meta, ok := info.Sys().(*GistMetadata)
+		if !ok {
+			t.Fatalf("Stating the root, got Sys %#v, want a *GistMetadata", info.Sys())
+		}
+		if meta.ID != referenceGistID || meta.Description != "my gist" || meta.Owner != "jhchabran" {
+			t.Fatalf("Stating the root, got %+v, want the metadata of the gist", meta)
+		}
+
+		entries, _ := gfs.ReadDir(".")
+		if info, _ := entries[0].Info(); info.Sys() == nil {
+			t.Fatal("Stating a file, got no Sys, want its *FileMetadata")
+		}
+	})
+}
+
+func TestGist(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "abc"})
+	fg.Description = "my gist"
+
+	for _, compress := range []bool{false, true} {
+		var opts []Option
+		if compress {
+			opts = append(opts, WithCompression())
+		}
+		gfs := NewWithClient(client, referenceGistID, opts...)
+
+		if got := gfs.Gist(); got != nil {
+			t.Fatalf("Gist before loading, got %#v, want nil", got)
+		}
+
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		gist := gfs.Gist()
+		if got, want := gist.GetDescription(), "my gist"; got != want {
+			t.Fatalf("Gist description, got %#v, want %#v", got, want)
+		}
+		f := gist.Files["a.txt"]
+		if got, want := f.GetContent(), "abc"; got != want {
+			t.Fatalf("Gist content with compression %v, got %#v, want %#v", compress, got, want)
+		}
+
+		*gist.Description = "changed"
+		gist.Files["a.txt"] = gist.Files["b.txt"]
+		if got, want := gfs.Description(), "my gist"; got != want {
+			t.Fatalf("Description after changing the gist copy, got %#v, want %#v", got, want)
+		}
+		if b, _ := gfs.ReadFile("a.txt"); string(b) != "abc" {
+			t.Fatalf("Reading after changing the gist copy, got %#v, want %#v", string(b), "abc")
+		}
+	}
+}
+
+func TestLoadMetadata(t *testing.T) {
+	getter := &stubGetter{
+		gist: &Gist{
+			ID: referenceGistID,
+			Files: map[string]GistFile{
+				"a.txt":   {Filename: "a.txt", Content: "a", Size: 1},
+				"big.txt": {Filename: "big.txt", Content: "big", Size: 9, Truncated: true, RawURL: "https://raw/big.txt"},
+			},
+		},
+		etag: `"v1"`,
+		raw:  map[string]string{"https://raw/big.txt": "big file!"},
+	}
+
+	gfs := NewWithGetter(getter, referenceGistID)
+	if err := gfs.LoadMetadata(context.Background()); err != nil {
+		t.Fatalf("Loading metadata, expected no error but got %#v", err)
+	}
+
+	t.Run("OK content deferred", func(t *testing.T) {
+		if got := getter.rawCalls; got != 0 {
+			t.Fatalf("Loading metadata, got %d raw fetches, want none", got)
+		}
+
+		entries, err := gfs.ReadDir(".")
+		if err != nil {
+			t.Fatalf("Listing, expected no error but got %#v", err)
+		}
+
+		info, _ := entries[1].Info()
+		if got, want := info.Size(), int64(9); got != want {
+			t.Fatalf("Size of a deferred file, got %d, want %d", got, want)
+		}
+		if !info.Sys().(*FileMetadata).Truncated {
+			t.Fatal("Metadata of a deferred file, got not truncated, want truncated")
+		}
+	})
+
+	t.Run("OK fetched on read", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			b, err := gfs.ReadFile("big.txt")
+			if err != nil {
+				t.Fatalf("Reading a deferred file, expected no error but got %#v", err)
+			}
+			if got, want := string(b), "big file!"; got != want {
+				t.Fatalf("Reading a deferred file, got %#v, want %#v", got, want)
+			}
+		}
+
+		if got, want := getter.rawCalls, 1; got != want {
+			t.Fatalf("Reading twice, got %d raw fetches, want %d", got, want)
+		}
+	})
+
+	t.Run("OK full load", func(t *testing.T) {
+		deferred := NewWithGetter(getter, referenceGistID)
+		if err := deferred.LoadMetadata(context.Background()); err != nil {
+			t.Fatalf("Loading metadata, expected no error but got %#v", err)
+		}
+		if err := deferred.Load(context.Background()); err != nil {
+			t.Fatalf("Loading after metadata, expected no error but got %#v", err)
+		}
+
+		if got, want := getter.etags[len(getter.etags)-1], ""; got != want {
+			t.Fatalf("Loading after metadata, got etag %#v, want %#v", got, want)
+		}
+
+		f, _ := deferred.Open("big.txt")
+		info, _ := f.Stat()
+		if info.Sys().(*FileMetadata).Truncated {
+			t.Fatal("Metadata after a full load, got truncated, want not truncated")
+		}
+	})
+
+	t.Run("NOK raw fetch failure", func(t *testing.T) {
+		getter.gist.Files["big.txt"] = GistFile{Filename: "big.txt", Content: "big", Size: 9, Truncated: true, RawURL: "https://raw/gone.txt"}
+		failing := NewWithGetter(getter, referenceGistID)
+		if err := failing.LoadMetadata(context.Background()); err != nil {
+			t.Fatalf("Loading metadata, expected no error but got %#v", err)
+		}
+
+		var gistErr *Error
+		if _, err := failing.Open("big.txt"); !errors.As(err, &gistErr) {
+			t.Fatalf("Opening a file that can't be fetched, got %#v, want an *Error", err)
+		}
+	})
+}
+
+func TestPrefetch(t *testing.T) {
+	getter := &stubGetter{
+		gist: &Gist{
+			ID: referenceGistID,
+			Files: map[string]GistFile{
+				"a.txt": {Filename: "a.txt", Content: "a", Size: 3, Truncated: true, RawURL: "https://raw/a.txt"},
+				"b.txt": {Filename: "b.txt", Content: "b", Size: 3, Truncated: true, RawURL: "https://raw/b.txt"},
+			},
+		},
+		raw: map[string]string{"https://raw/a.txt": "aaa", "https://raw/b.txt": "bbb"},
+	}
+
+	gfs := NewWithGetter(getter, referenceGistID)
+
+	t.Run("NOK not loaded", func(t *testing.T) {
+		if err := gfs.Prefetch(context.Background(), "a.txt"); !errors.Is(err, ErrNotLoaded) {
+			t.Fatalf("Prefetching before loading, got %#v, want %#v", err, ErrNotLoaded)
+		}
+	})
+
+	if err := gfs.LoadMetadata(context.Background()); err != nil {
+		t.Fatalf("Loading metadata, expected no error but got %#v", err)
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		if err := gfs.Prefetch(context.Background(), "a.txt", "b.txt"); err != nil {
+			t.Fatalf("Prefetching, expected no error but got %#v", err)
+		}
+
+		if got, want := getter.rawCalls, 2; got != want {
+			t.Fatalf("Prefetching, got %d raw fetches, want %d", got, want)
+		}
+
+		for name, want := range map[string]string{"a.txt": "aaa", "b.txt": "bbb"} {
+			b, _ := gfs.ReadFile(name)
+			if got := string(b); got != want {
+				t.Fatalf("Reading %s after prefetching, got %#v, want %#v", name, got, want)
+			}
+		}
+
+		if got, want := getter.rawCalls, 2; got != want {
+			t.Fatalf("Reading prefetched files, got %d raw fetches, want %d", got, want)
+		}
+	})
+
+	t.Run("NOK unknown file", func(t *testing.T) {
+		if err := gfs.Prefetch(context.Background(), "unknown.txt"); !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("Prefetching an unknown file, got %#v, want %#v", err, fs.ErrNotExist)
+		}
+	})
+}
+
+// blockingRawGetter is a stubGetter whose raw fetches block until their
+// context is done.
+type blockingRawGetter struct {
+	*stubGetter
+}
+
+func (g blockingRawGetter) GetRaw(ctx context.Context, rawURL string) ([]byte, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestReadContext(t *testing.T) {
+	getter := blockingRawGetter{&stubGetter{
+		gist: &Gist{
+			ID: referenceGistID,
+			Files: map[string]GistFile{
+				"a.txt":   {Filename: "a.txt", Content: "a", Size: 1},
+				"big.txt": {Filename: "big.txt", Content: "big", Size: 9, Truncated: true, RawURL: "https://raw/big.txt"},
+			},
+		},
+	}}
+
+	gfs := NewWithGetter(getter, referenceGistID)
+	if err := gfs.LoadMetadata(context.Background()); err != nil {
+		t.Fatalf("Loading metadata, expected no error but got %#v", err)
+	}
+
+	t.Run("OK deferred fetch canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		errc := make(chan error, 1)
+		go func() {
+			_, err := gfs.ReadFileContext(ctx, "big.txt")
+			errc <- err
+		}()
+
+		select {
+		case err := <-errc:
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("Reading a deferred file, got %#v, want context.Canceled", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Reading a deferred file, expected it to abort once canceled")
+		}
+	})
+
+	t.Run("OK read of an open file canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		f, err := gfs.OpenContext(ctx, "a.txt")
+		if err != nil {
+			t.Fatalf("Opening a.txt, expected no error but got %#v", err)
+		}
+		defer f.Close()
+
+		cancel()
+		if _, err := f.Read(make([]byte, 1)); !errors.Is(err, context.Canceled) {
+			t.Fatalf("Reading once canceled, got %#v, want context.Canceled", err)
+		}
+	})
+
+	t.Run("OK WithReadContext", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		gfs := NewWithGetter(getter, referenceGistID, WithReadContext(ctx))
+		if err := gfs.LoadMetadata(context.Background()); err != nil {
+			t.Fatalf("Loading metadata, expected no error but got %#v", err)
+		}
+
+		if _, err := gfs.ReadFile("big.txt"); !errors.Is(err, context.Canceled) {
+			t.Fatalf("Reading a deferred file, got %#v, want context.Canceled", err)
+		}
+		if _, err := gfs.ReadFileContext(context.Background(), "a.txt"); err != nil {
+			t.Fatalf("Reading with another context, expected no error but got %#v", err)
+		}
+	})
+}
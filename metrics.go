@@ -0,0 +1,37 @@
+package gistfs
+
+import "time"
+
+// MetricsRecorder receives measurements of the work of filesystems, to be
+// exported to a monitoring system. Filesystems call it concurrently, so its
+// methods must be safe for concurrent use, and return quickly. The
+// prometheus subpackage provides one exporting Prometheus metrics.
+type MetricsRecorder interface {
+	// ObserveLoad is called when a load of the gist identified by id ends,
+	// whether it was started by Load or by a background refresh, with how
+	// long it took and the error it ended with, if any.
+	ObserveLoad(id string, d time.Duration, err error)
+	// ObserveRefresh is called when a background refresh of the gist
+	// identified by id ends, with the error it ended with, if any.
+	ObserveRefresh(id string, err error)
+	// ObserveAPIError is called when a request made to Github for the gist
+	// identified by id fails, once retries are exhausted. op is the
+	// operation the request was made for, such as "load" or "read".
+	ObserveAPIError(id, op string)
+	// SetRateLimitRemaining is called with the number of requests left
+	// before reaching the rate limit, whenever Github reports it.
+	SetRateLimitRemaining(remaining int)
+	// AddBytesServed is called with the number of bytes read from the files
+	// of the gist identified by id.
+	AddBytesServed(id string, n int)
+	// AddOpenFiles is called with 1 when a file of the gist identified by
+	// id is opened, and -1 when it is closed.
+	AddOpenFiles(id string, delta int)
+}
+
+// WithMetrics reports measurements of the filesystem to r.
+func WithMetrics(r MetricsRecorder) Option {
+	return func(fsys *FS) {
+		fsys.metrics = r
+	}
+}
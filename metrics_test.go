@@ -0,0 +1,153 @@
+package gistfs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingRecorder is a MetricsRecorder keeping the measurements it gets.
+type countingRecorder struct {
+	mu        sync.Mutex
+	loads     int
+	loadErrs  int
+	refreshes int
+	apiErrors map[string]int
+	remaining int
+	bytes     int
+	openFiles int
+}
+
+func (r *countingRecorder) ObserveLoad(id string, d time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.loads++
+	if err != nil {
+		r.loadErrs++
+	}
+}
+
+func (r *countingRecorder) ObserveRefresh(id string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.refreshes++
+}
+
+func (r *countingRecorder) ObserveAPIError(id, op string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.apiErrors == nil {
+		r.apiErrors = map[string]int{}
+	}
+	r.apiErrors[op]++
+}
+
+func (r *countingRecorder) SetRateLimitRemaining(remaining int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.remaining = remaining
+}
+
+func (r *countingRecorder) AddBytesServed(id string, n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.bytes += n
+}
+
+func (r *countingRecorder) AddOpenFiles(id string, delta int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.openFiles += delta
+}
+
+func TestWithMetrics(t *testing.T) {
+	t.Run("OK loads and reads", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "abc"})
+		r := &countingRecorder{}
+
+		gfs := NewWithClient(client, referenceGistID, WithMetrics(r))
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Load, expected no error but got %#v", err)
+		}
+
+		if _, err := gfs.ReadFile("a.txt"); err != nil {
+			t.Fatalf("ReadFile, expected no error but got %#v", err)
+		}
+
+		f, err := gfs.Open("a.txt")
+		if err != nil {
+			t.Fatalf("Open, expected no error but got %#v", err)
+		}
+		if got, want := r.openFiles, 1; got != want {
+			t.Fatalf("Opening a file, got %d open files, want %d", got, want)
+		}
+		if _, err := io.ReadAll(f); err != nil {
+			t.Fatalf("Reading a file, expected no error but got %#v", err)
+		}
+		f.Close()
+		f.Close()
+
+		if got, want := r.loads, 1; got != want {
+			t.Fatalf("Got %d loads, want %d", got, want)
+		}
+		if got, want := r.bytes, 6; got != want {
+			t.Fatalf("Got %d bytes served, want %d", got, want)
+		}
+		if got, want := r.openFiles, 0; got != want {
+			t.Fatalf("Closing a file, got %d open files, want %d", got, want)
+		}
+		if r.remaining == 0 {
+			t.Fatal("Got no remaining rate limit, want the one reported by Github")
+		}
+	})
+
+	t.Run("NOK load", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "abc"})
+		fg.FailNext(1, http.StatusNotFound)
+		r := &countingRecorder{}
+
+		gfs := NewWithClient(client, referenceGistID, WithMetrics(r))
+		if err := gfs.Load(context.Background()); err == nil {
+			t.Fatal("Load, expected an error but got none")
+		}
+
+		if r.loads != 1 || r.loadErrs != 1 {
+			t.Fatalf("Got %d loads and %d failed, want 1 failed load", r.loads, r.loadErrs)
+		}
+		if got, want := r.apiErrors["load"], 1; got != want {
+			t.Fatalf("Got %d API errors for loads, want %d", got, want)
+		}
+	})
+	t.Run("OK refresh", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "abc"})
+		clock := newFakeClock()
+		r := &countingRecorder{}
+
+		gfs := NewWithClient(client, referenceGistID, WithTTL(time.Minute), WithMetrics(r))
+		gfs.now = clock.Now
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Load, expected no error but got %#v", err)
+		}
+
+		clock.Advance(2 * time.Minute)
+		if _, err := gfs.ReadFile("a.txt"); err != nil {
+			t.Fatalf("ReadFile, expected no error but got %#v", err)
+		}
+
+		eventually(t, func() bool {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			return r.refreshes == 1 && r.loads == 2
+		})
+	})
+}
@@ -0,0 +1,252 @@
+package gistfs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MirrorManifest is the file Mirror stores in its destination to record the
+// SHA-256 of the files it copied there, in the format of sha256sum.
+const MirrorManifest = ".gistfs-mirror.sha256"
+
+// WriteFS is a destination Mirror copies files to, such as a local directory
+// returned by DirWriteFS, or a bucket of an object storage.
+type WriteFS interface {
+	// ReadFile returns the content of the named file, or an error matching
+	// fs.ErrNotExist if it doesn't exist.
+	ReadFile(ctx context.Context, name string) ([]byte, error)
+	// WriteFile stores data as the content of the named file, replacing it
+	// if it exists. Names are slash-separated paths, as in fs.FS.
+	WriteFile(ctx context.Context, name string, data []byte) error
+	// Remove removes the named file. Removing a missing file is not an
+	// error.
+	Remove(ctx context.Context, name string) error
+}
+
+// MirrorReport describes what Mirror did to its destination.
+type MirrorReport struct {
+	// Written lists the files that were copied, because they were added or
+	// modified since the last mirror.
+	Written []string
+	// Removed lists the files that were removed, because they are no longer
+	// served.
+	Removed []string
+	// Unchanged lists the files that were left as they were.
+	Unchanged []string
+}
+
+// Mirror copies the files served by the filesystem to dst, so that it holds
+// the same files, such as to publish the assets of a gist to a CDN bucket.
+//
+// Mirror only writes the files that changed since it last mirrored the
+// filesystem to dst, and removes those it copied then that are no longer
+// served, telling them apart with the SHA-256 recorded in MirrorManifest.
+// Other files of dst are left untouched. When copying fails, the files
+// copied so far are recorded, so that the next call resumes from there.
+func (fsys *FS) Mirror(ctx context.Context, dst WriteFS) (*MirrorReport, error) {
+	if s := fsys.state(); s.gist == nil {
+		return nil, ErrNotLoaded
+	}
+
+	fail := func(err error) error {
+		return &Error{Op: "mirror", ID: fsys.GetID(), Err: err}
+	}
+
+	mirrored, err := readMirrorManifest(ctx, dst)
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	files := map[string][]byte{}
+	err = fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		files[name], err = fsys.ReadFileContext(ctx, name)
+		return err
+	})
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	report := &MirrorReport{}
+	err = mirrorFiles(ctx, dst, files, mirrored, report)
+	if werr := writeMirrorManifest(ctx, dst, mirrored); err == nil {
+		err = werr
+	}
+	if err != nil {
+		return report, fail(err)
+	}
+
+	return report, nil
+}
+
+// mirrorFiles copies files to dst and removes from it the files of
+// mirrored that are not in files, keeping mirrored up to date along the way.
+func mirrorFiles(ctx context.Context, dst WriteFS, files map[string][]byte, mirrored map[string]string, report *MirrorReport) error {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if name == MirrorManifest {
+			continue
+		}
+
+		sum := sha256.Sum256(files[name])
+		hash := hex.EncodeToString(sum[:])
+		if mirrored[name] == hash {
+			report.Unchanged = append(report.Unchanged, name)
+			continue
+		}
+
+		if err := dst.WriteFile(ctx, name, files[name]); err != nil {
+			return fmt.Errorf("writing %s: %w", name, err)
+		}
+		mirrored[name] = hash
+		report.Written = append(report.Written, name)
+	}
+
+	var removed []string
+	for name := range mirrored {
+		if _, ok := files[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+
+	for _, name := range removed {
+		if err := dst.Remove(ctx, name); err != nil {
+			return fmt.Errorf("removing %s: %w", name, err)
+		}
+		delete(mirrored, name)
+		report.Removed = append(report.Removed, name)
+	}
+
+	return nil
+}
+
+// readMirrorManifest returns the SHA-256 of the files mirrored to dst, by
+// name, none if it was never mirrored to.
+func readMirrorManifest(ctx context.Context, dst WriteFS) (map[string]string, error) {
+	mirrored := map[string]string{}
+
+	b, err := dst.ReadFile(ctx, MirrorManifest)
+	if errors.Is(err, fs.ErrNotExist) {
+		return mirrored, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", MirrorManifest, err)
+	}
+
+	for _, line := range strings.Split(string(b), "\n") {
+		fields := strings.SplitN(line, "  ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		mirrored[fields[1]] = fields[0]
+	}
+
+	return mirrored, nil
+}
+
+// writeMirrorManifest records the SHA-256 of the files mirrored to dst.
+func writeMirrorManifest(ctx context.Context, dst WriteFS, mirrored map[string]string) error {
+	names := make([]string, 0, len(mirrored))
+	for name := range mirrored {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s  %s\n", mirrored[name], name)
+	}
+
+	if err := dst.WriteFile(ctx, MirrorManifest, b.Bytes()); err != nil {
+		return fmt.Errorf("writing %s: %w", MirrorManifest, err)
+	}
+
+	return nil
+}
+
+// DirWriteFS returns a WriteFS storing files in the directory dir, creating
+// the directories they are in as needed. Files are replaced atomically, so
+// that a server reading dir never sees them partially written.
+func DirWriteFS(dir string) WriteFS {
+	return dirWriteFS(dir)
+}
+
+type dirWriteFS string
+
+func (dir dirWriteFS) path(name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+
+	return filepath.Join(string(dir), filepath.FromSlash(name)), nil
+}
+
+func (dir dirWriteFS) ReadFile(ctx context.Context, name string) ([]byte, error) {
+	p, err := dir.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(p)
+}
+
+func (dir dirWriteFS) WriteFile(ctx context.Context, name string, data []byte) error {
+	p, err := dir.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), "."+filepath.Base(p)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), p)
+}
+
+func (dir dirWriteFS) Remove(ctx context.Context, name string) error {
+	p, err := dir.path(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// failingWriteFS fails writes of the file named fail.
+type failingWriteFS struct {
+	WriteFS
+	fail string
+}
+
+func (f failingWriteFS) WriteFile(ctx context.Context, name string, data []byte) error {
+	if name == f.fail {
+		return errors.New("boom")
+	}
+	return f.WriteFS.WriteFile(ctx, name, data)
+}
+
+func TestMirror(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK incremental", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "a", "b.txt": "b", "c.txt": "c"})
+		gfs := NewWithClient(client, referenceGistID)
+		gfs.Load(ctx)
+
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, "other.txt"), []byte("other"), 0o644)
+
+		report, err := gfs.Mirror(ctx, DirWriteFS(dir))
+		if err != nil {
+			t.Fatalf("Mirroring, expected no error but got %#v", err)
+		}
+		if want := []string{"a.txt", "b.txt", "c.txt"}; !reflect.DeepEqual(report.Written, want) {
+			t.Fatalf("Mirroring, wrote %v, want %v", report.Written, want)
+		}
+
+		fg.SetFiles(map[string]string{"a.txt": "a", "b.txt": "B", "d.txt": "d"})
+		gfs.Load(ctx)
+
+		report, err = gfs.Mirror(ctx, DirWriteFS(dir))
+		if err != nil {
+			t.Fatalf("Mirroring again, expected no error but got %#v", err)
+		}
+		want := &MirrorReport{Written: []string{"b.txt", "d.txt"}, Removed: []string{"c.txt"}, Unchanged: []string{"a.txt"}}
+		if !reflect.DeepEqual(report, want) {
+			t.Fatalf("Mirroring again, got %+v, want %+v", report, want)
+		}
+
+		for name, content := range map[string]string{"a.txt": "a", "b.txt": "B", "d.txt": "d", "other.txt": "other"} {
+			b, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil || string(b) != content {
+				t.Fatalf("Reading mirrored %s, got %q (%v), want %q", name, b, err, content)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dir, "c.txt")); !os.IsNotExist(err) {
+			t.Fatalf("Stating removed file, got %v, want it not to exist", err)
+		}
+	})
+
+	t.Run("OK resumes", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a", "b.txt": "b"})
+		gfs := NewWithClient(client, referenceGistID)
+		gfs.Load(ctx)
+
+		dir := DirWriteFS(t.TempDir())
+		if _, err := gfs.Mirror(ctx, failingWriteFS{WriteFS: dir, fail: "b.txt"}); err == nil {
+			t.Fatal("Mirroring, expected an error but got none")
+		}
+
+		report, err := gfs.Mirror(ctx, dir)
+		if err != nil {
+			t.Fatalf("Mirroring again, expected no error but got %#v", err)
+		}
+		if want := []string{"b.txt"}; !reflect.DeepEqual(report.Written, want) {
+			t.Fatalf("Mirroring again, wrote %v, want %v", report.Written, want)
+		}
+	})
+
+	t.Run("NOK not loaded", func(t *testing.T) {
+		gfs := New(referenceGistID)
+
+		if _, err := gfs.Mirror(ctx, DirWriteFS(t.TempDir())); !errors.Is(err, ErrNotLoaded) {
+			t.Fatalf("Mirroring, got %#v, want ErrNotLoaded", err)
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package gistfs
+
+import (
+	"context"
+	"sync"
+)
+
+// Notifier carries notifications that gists changed between the instances
+// of a service, so that an instance refreshing a gist can have the others
+// refresh it too, rather than each of them polling Github on its own.
+// MemoryNotifier connects filesystems of a single process, and the redis
+// package provides one connecting processes through Redis pub/sub.
+type Notifier interface {
+	// Publish notifies the subscribers that the gist id changed.
+	Publish(ctx context.Context, id string) error
+	// Subscribe calls fn with the ID of each gist published, including by
+	// the caller, until ctx is done or the subscription fails, and returns
+	// the error that ended it. Calls to fn are never concurrent.
+	Subscribe(ctx context.Context, fn func(id string)) error
+}
+
+// WithNotifier publishes the ID of the gist to n when a load finds it
+// changed, and when the filesystem modifies it. Refreshes triggered by the
+// notifications received by Listen don't publish it again.
+//
+// Publishing failures don't fail loads, but are logged.
+func WithNotifier(n Notifier) Option {
+	return func(fsys *FS) {
+		fsys.notifier = n
+	}
+}
+
+// notifiedKey marks the context of loads triggered by a notification.
+type notifiedKey struct{}
+
+// publish publishes the ID of the gist to the notifier of the filesystem,
+// unless ctx is the one of a load triggered by a notification.
+func (fsys *FS) publish(ctx context.Context) {
+	if fsys.notifier == nil || ctx.Value(notifiedKey{}) != nil {
+		return
+	}
+
+	if err := fsys.notifier.Publish(ctx, fsys.GetID()); err != nil {
+		fsys.log(ctx, levelWarn, "publishing gist change failed", "error", err)
+	}
+}
+
+// Listen subscribes to n, and loads the filesystems of the gists it is
+// notified of again, until ctx is done or the subscription fails, whose
+// error it returns. It is meant to be run in its own goroutine.
+//
+// Failed loads are reported like other loads, through the logger and
+// metrics of the filesystems.
+func Listen(ctx context.Context, n Notifier, fss ...*FS) error {
+	byID := make(map[string][]*FS)
+	for _, fsys := range fss {
+		byID[fsys.GetID()] = append(byID[fsys.GetID()], fsys)
+	}
+
+	return n.Subscribe(ctx, func(id string) {
+		refreshNotified(ctx, byID[id])
+	})
+}
+
+// Listen subscribes to n, and loads the managed filesystem of the gists it
+// is notified of again, as the function Listen does.
+func (m *Manager) Listen(ctx context.Context, n Notifier) error {
+	return n.Subscribe(ctx, func(id string) {
+		m.mu.Lock()
+		fsys, ok := m.fss[id]
+		m.mu.Unlock()
+
+		if ok {
+			refreshNotified(ctx, []*FS{fsys})
+		}
+	})
+}
+
+// refreshNotified loads fss again, if they were loaded, after a notification
+// that their gist changed.
+func refreshNotified(ctx context.Context, fss []*FS) {
+	ctx = context.WithValue(ctx, notifiedKey{}, true)
+
+	for _, fsys := range fss {
+		if fsys.state().gist == nil {
+			continue
+		}
+		_ = fsys.Load(ctx)
+	}
+}
+
+// MemoryNotifier is a Notifier connecting the filesystems of a single
+// process, such as the ones of several Managers.
+type MemoryNotifier struct {
+	mu          sync.Mutex
+	subscribers map[*memorySubscriber]struct{}
+}
+
+// memorySubscriber receives the notifications of a MemoryNotifier.
+type memorySubscriber struct {
+	ctx context.Context
+	ch  chan string
+}
+
+// NewMemoryNotifier returns a MemoryNotifier without subscribers.
+func NewMemoryNotifier() *MemoryNotifier {
+	return &MemoryNotifier{subscribers: make(map[*memorySubscriber]struct{})}
+}
+
+// Publish implements Notifier. It waits for the subscribers to receive the
+// notification, or for ctx or the ones of their subscriptions to be done.
+func (n *MemoryNotifier) Publish(ctx context.Context, id string) error {
+	n.mu.Lock()
+	subs := make([]*memorySubscriber, 0, len(n.subscribers))
+	for sub := range n.subscribers {
+		subs = append(subs, sub)
+	}
+	n.mu.Unlock()
+
+	for _, sub := range subs {
+		select {
+		case sub.ch <- id:
+		case <-sub.ctx.Done():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// Subscribe implements Notifier.
+func (n *MemoryNotifier) Subscribe(ctx context.Context, fn func(id string)) error {
+	sub := &memorySubscriber{ctx: ctx, ch: make(chan string, watchBufferSize)}
+
+	n.mu.Lock()
+	n.subscribers[sub] = struct{}{}
+	n.mu.Unlock()
+
+	defer func() {
+		n.mu.Lock()
+		delete(n.subscribers, sub)
+		n.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case id := <-sub.ch:
+			fn(id)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// subscribed waits for n to have count subscribers.
+func subscribed(t *testing.T, n *MemoryNotifier, count int) {
+	t.Helper()
+
+	eventually(t, func() bool {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+
+		return len(n.subscribers) == count
+	})
+}
+
+func TestNotifier(t *testing.T) {
+	t.Run("OK refresh", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		n := NewMemoryNotifier()
+
+		a := NewWithClient(client, referenceGistID, WithNotifier(n))
+		b := NewWithClient(client, referenceGistID, WithNotifier(n))
+		a.Load(context.Background())
+		b.Load(context.Background())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- Listen(ctx, n, a, b) }()
+		subscribed(t, n, 1)
+
+		fg.SetFiles(map[string]string{"a.txt": "b"})
+		if err := a.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		eventually(t, func() bool {
+			got, _ := b.ReadFile("a.txt")
+			return string(got) == "b"
+		})
+
+		cancel()
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Fatalf("Listening, got %#v, want context.Canceled", err)
+		}
+	})
+
+	t.Run("OK manager", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		n := NewMemoryNotifier()
+
+		m := NewManager(client)
+		b, _ := m.Get(context.Background(), referenceGistID)
+		a := NewWithClient(client, referenceGistID, WithNotifier(n), WithWritable())
+		a.Load(context.Background())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go m.Listen(ctx, n)
+		subscribed(t, n, 1)
+
+		if err := a.WriteFile(context.Background(), "a.txt", []byte("written")); err != nil {
+			t.Fatalf("Writing, expected no error but got %#v", err)
+		}
+
+		eventually(t, func() bool {
+			got, _ := b.ReadFile("a.txt")
+			return string(got) == "written"
+		})
+	})
+
+	t.Run("OK no publication when unchanged", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		n := NewMemoryNotifier()
+
+		a := NewWithClient(client, referenceGistID, WithNotifier(n))
+		a.Load(context.Background())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		notified := make(chan string, 1)
+		go n.Subscribe(ctx, func(id string) { notified <- id })
+		subscribed(t, n, 1)
+
+		a.Load(context.Background())
+		n.Publish(context.Background(), "other")
+
+		if got := <-notified; got != "other" {
+			t.Fatalf("Subscribing, got notified of %s, want only other", got)
+		}
+	})
+}
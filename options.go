@@ -0,0 +1,260 @@
+package gistfs
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+	"golang.org/x/oauth2"
+)
+
+// Option configures a FS when it is created.
+type Option func(*FS)
+
+// WithTTL makes the loaded gist content fresh for d. Reads happening after
+// that delay still serve the loaded content, but trigger a refresh in the
+// background, so subsequent reads eventually see the updated gist.
+//
+// The filesystem must still be loaded once with Load before being used.
+func WithTTL(d time.Duration) Option {
+	return func(fsys *FS) {
+		fsys.freshness.ttl = d
+	}
+}
+
+// WithStaleIfError keeps serving the last loaded content for d after it
+// expired when refreshing it fails, instead of failing reads with the
+// refresh error. It only makes sense combined with WithTTL.
+//
+// Stale reports whether the filesystem is currently serving such content.
+func WithStaleIfError(d time.Duration) Option {
+	return func(fsys *FS) {
+		fsys.freshness.staleIfError = d
+	}
+}
+
+// WithMaxAge fails reads with a *StaleError once the loaded gist is older
+// than d, for callers that must never serve outdated content. Unlike
+// WithTTL, which keeps serving the loaded gist while it is refreshed in the
+// background, the gist must be loaded again, by Load or a refresh, for reads
+// to succeed again. Reads are served by the filesystem given to WithFallback
+// instead, if any.
+func WithMaxAge(d time.Duration) Option {
+	return func(fsys *FS) {
+		fsys.maxAge = d
+		fsys.maxAgeRefresh = false
+	}
+}
+
+// WithMaxAgeRefresh makes reads load the gist again, before serving it,
+// once it is older than d. Reads fail with a *StaleError wrapping the error
+// of the load if it fails, as with WithMaxAge.
+func WithMaxAgeRefresh(d time.Duration) Option {
+	return func(fsys *FS) {
+		fsys.maxAge = d
+		fsys.maxAgeRefresh = true
+	}
+}
+
+// WithFallback serves reads from fallback, such as an embed.FS or the
+// directory returned by os.DirFS, while the gist can't be served: before it
+// is loaded, or once a failed refresh leaves it expired beyond the window of
+// WithStaleIfError. Reads go back to the gist as soon as it loads again.
+func WithFallback(fallback fs.FS) Option {
+	return func(fsys *FS) {
+		fsys.fallback = fallback
+	}
+}
+
+// WithLoadTimeout bounds loads, background refreshes and downloads of
+// deferred content to d, when the context they are given has no deadline.
+// It keeps a stalled connection to Github from blocking callers passing
+// context.Background(), or reads of files whose content was deferred.
+func WithLoadTimeout(d time.Duration) Option {
+	return func(fsys *FS) {
+		fsys.loadTimeout = d
+	}
+}
+
+// WithReadContext makes the requests needed by Open, ReadFile and ReadDir,
+// such as downloading content deferred by LoadMetadata, with ctx rather than
+// context.Background(), and makes reads of the files returned by Open fail
+// once it is done. It ties reads to the lifetime of a server, for instance,
+// while OpenContext, ReadFileContext and ReadDirContext tie them to a single
+// request.
+func WithReadContext(ctx context.Context) Option {
+	return func(fsys *FS) {
+		fsys.readCtx = ctx
+	}
+}
+
+// readContext returns the context of reads made through the methods of
+// fs.FS.
+func (fsys *FS) readContext() context.Context {
+	if fsys.readCtx != nil {
+		return fsys.readCtx
+	}
+
+	return context.Background()
+}
+
+// WithFileMode sets the mode reported for files, instead of 0444. Only its
+// permission bits are kept. Directories keep reporting fs.ModeDir|0444.
+func WithFileMode(mode fs.FileMode) Option {
+	return func(fsys *FS) {
+		fsys.defaultMode = mode.Perm()
+	}
+}
+
+// WithFileModes sets the modes reported for files by extension, such as
+// {".sh": 0555}, overriding the one set by WithFileMode. Extensions include
+// the leading dot and are matched regardless of case. Only the permission
+// bits of the modes are kept.
+func WithFileModes(modes map[string]fs.FileMode) Option {
+	return func(fsys *FS) {
+		fsys.extModes = make(map[string]fs.FileMode, len(modes))
+		for ext, mode := range modes {
+			fsys.extModes[strings.ToLower(ext)] = mode.Perm()
+		}
+	}
+}
+
+// fileMode returns the mode reported for the file at path p.
+func (fsys *FS) fileMode(p string) fs.FileMode {
+	if mode, ok := fsys.extModes[strings.ToLower(path.Ext(p))]; ok {
+		return mode
+	}
+
+	return fsys.defaultMode
+}
+
+// WithToken authenticates requests to the Github API with the given personal
+// access token, granting access to secret gists and to a higher rate limit.
+func WithToken(token string) Option {
+	return WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))
+}
+
+// WithTokenSource authenticates requests to the Github API with tokens
+// obtained from ts. Tokens are reused until they expire, at which point
+// a new one is requested, making it suitable for short-lived credentials
+// such as Github App installation tokens.
+func WithTokenSource(ts oauth2.TokenSource) Option {
+	return func(fsys *FS) {
+		fsys.tokenSource = ts
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with requests made by the
+// filesystem, so its traffic can be told apart from other Github API clients.
+// It is sent by filesystems created by NewAnonymous too. It has no effect on
+// a Github client given to NewWithClient.
+func WithUserAgent(ua string) Option {
+	return func(fsys *FS) {
+		fsys.userAgent = ua
+	}
+}
+
+// WithRevision pins the filesystem to the given revision of the gist, so
+// that Load always fetches the content as it was at that revision.
+// Revisions are always fetched through the REST API.
+func WithRevision(sha string) Option {
+	return func(fsys *FS) {
+		fsys.revision = sha
+	}
+}
+
+// withBaseHTTPClient sets the http.Client that the Github client is built
+// with, before any authentication is added.
+func withBaseHTTPClient(c *http.Client) Option {
+	return func(fsys *FS) {
+		fsys.baseHTTPClient = c
+	}
+}
+
+// httpClient returns the http.Client to build the Github client with,
+// according to the options, or nil to use the default one.
+func (fsys *FS) httpClient() *http.Client {
+	c := fsys.baseHTTPClient
+	if fsys.tokenSource != nil {
+		ctx := context.Background()
+		if fsys.baseHTTPClient != nil {
+			ctx = context.WithValue(ctx, oauth2.HTTPClient, fsys.baseHTTPClient)
+		}
+		c = oauth2.NewClient(ctx, fsys.tokenSource)
+	}
+
+	if fsys.requestHooks != nil {
+		c = fsys.withRequestHooks(c)
+	}
+
+	return c
+}
+
+// WithPathSeparator serves the files of the gist whose name contains sep in
+// directories, as if sep was a slash. With "__" as separator, a file named
+// "templates__header.tmpl" is served as "header.tmpl" in the "templates"
+// directory, which fs.ReadDir, fs.Sub, fs.Glob and fs.WalkDir all see.
+//
+// Names that would not make a valid path once converted, such as ones
+// starting with sep, are served as is.
+func WithPathSeparator(sep string) Option {
+	return func(fsys *FS) {
+		fsys.pathSeparator = sep
+	}
+}
+
+// WithCaseInsensitive makes Open, ReadFile and ReadDir ignore the case of
+// the names they are given, so that opening "README.md" finds "readme.md".
+// Names are still listed with their original case.
+//
+// Load fails with ErrCaseCollision if the gist holds files whose names only
+// differ by their case, keeping the previously loaded content.
+func WithCaseInsensitive() Option {
+	return func(fsys *FS) {
+		fsys.caseInsensitive = true
+	}
+}
+
+// WithAliases exposes files of the gist under additional names, given as
+// a map from each alias to the path of the file it stands for, as served by
+// the filesystem. Aliases of missing files are not served, and files of the
+// gist take precedence over aliases with the same name.
+//
+// For example, WithAliases(map[string]string{"index.html": "home.html"})
+// serves the content of "home.html" as "index.html" as well.
+func WithAliases(aliases map[string]string) Option {
+	copied := make(map[string]string, len(aliases))
+	for alias, target := range aliases {
+		copied[alias] = target
+	}
+
+	return func(fsys *FS) {
+		fsys.virtuals = append(fsys.virtuals, func(fsys *FS, s *state) map[string]github.GistFile {
+			return fsys.aliasFiles(s, copied)
+		})
+	}
+}
+
+// WithIndex makes directories holding one of the given files, such as
+// "README.md", serve it as their index: the first one found in a directory
+// is also served as "index.html" in that directory, unless it already holds
+// such a file. Because http.FileServer answers requests for a directory with
+// its "index.html" file, serving the filesystem with it then behaves like a
+// static site.
+//
+// Only HTML files, served as is, and Markdown files, named *.md or
+// *.markdown and rendered to an HTML page as Github flavored Markdown, can
+// be served as an index. Other files are skipped, as they would be served as
+// HTML. So are Markdown files whose content is deferred by LoadMetadata,
+// until it is downloaded.
+func WithIndex(names ...string) Option {
+	return func(fsys *FS) {
+		fsys.virtuals = append(fsys.virtuals, func(fsys *FS, s *state) map[string]github.GistFile {
+			return fsys.indexFiles(s, names)
+		})
+	}
+}
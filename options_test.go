@@ -0,0 +1,403 @@
+package gistfs
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithPathSeparator(t *testing.T) {
+	_, client := newFakeGist(t, map[string]string{
+		"README.md":                "readme",
+		"templates__header.tmpl":   "header",
+		"templates__footer.tmpl":   "footer",
+		"templates__partials__nav": "nav",
+		"__odd":                    "odd",
+	})
+
+	gfs := NewWithClient(client, referenceGistID, WithPathSeparator("__"))
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+
+	t.Run("OK WalkDir", func(t *testing.T) {
+		var got []string
+		err := fs.WalkDir(gfs, ".", func(p string, d fs.DirEntry, err error) error {
+			got = append(got, p)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("Walking, expected no error but got %#v", err)
+		}
+
+		want := []string{".", "README.md", "__odd", "templates", "templates/footer.tmpl", "templates/header.tmpl", "templates/partials", "templates/partials/nav"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Walking, got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("OK Sub and Glob", func(t *testing.T) {
+		sub, err := fs.Sub(gfs, "templates")
+		if err != nil {
+			t.Fatalf("Sub, expected no error but got %#v", err)
+		}
+
+		b, err := fs.ReadFile(sub, "partials/nav")
+		if err != nil || string(b) != "nav" {
+			t.Fatalf("Reading through Sub, got %#v (%v), want %#v", string(b), err, "nav")
+		}
+
+		matches, _ := fs.Glob(gfs, "templates/*.tmpl")
+		if want := []string{"templates/footer.tmpl", "templates/header.tmpl"}; !reflect.DeepEqual(matches, want) {
+			t.Fatalf("Globbing, got %v, want %v", matches, want)
+		}
+	})
+
+	t.Run("OK Sys keeps the gist filename", func(t *testing.T) {
+		stat, err := fs.Stat(gfs, "templates/header.tmpl")
+		if err != nil {
+			t.Fatalf("Stat, expected no error but got %#v", err)
+		}
+
+		if got, want := stat.Name(), "header.tmpl"; got != want {
+			t.Fatalf("Stat, got name %#v, want %#v", got, want)
+		}
+
+		if got, want := stat.Sys().(*FileMetadata).Filename, "templates__header.tmpl"; got != want {
+			t.Fatalf("Stat, got Sys filename %#v, want %#v", got, want)
+		}
+	})
+}
+
+func TestWithCaseInsensitive(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{
+		"readme.md":           "readme",
+		"Templates__Nav.tmpl": "nav",
+	})
+
+	gfs := NewWithClient(client, referenceGistID, WithCaseInsensitive(), WithPathSeparator("__"))
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		for _, name := range []string{"README.md", "readme.md", "templates/nav.TMPL"} {
+			if _, err := gfs.ReadFile(name); err != nil {
+				t.Fatalf("Reading %#v, expected no error but got %#v", name, err)
+			}
+
+			f, err := gfs.Open(name)
+			if err != nil {
+				t.Fatalf("Opening %#v, expected no error but got %#v", name, err)
+			}
+			f.Close()
+		}
+
+		entries, err := gfs.ReadDir("TEMPLATES")
+		if err != nil || len(entries) != 1 || entries[0].Name() != "Nav.tmpl" {
+			t.Fatalf("Reading a directory with another case, got %v (%v), want [Nav.tmpl]", entries, err)
+		}
+	})
+
+	t.Run("NOK not found", func(t *testing.T) {
+		if _, err := gfs.ReadFile("missing.md"); !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("Reading a missing file, got %#v, want %#v", err, fs.ErrNotExist)
+		}
+	})
+
+	t.Run("NOK collision", func(t *testing.T) {
+		fg.SetFiles(map[string]string{"readme.md": "a", "README.md": "b"})
+
+		err := gfs.Load(context.Background())
+		if !errors.Is(err, ErrCaseCollision) {
+			t.Fatalf("Loading colliding names, got %#v, want %#v", err, ErrCaseCollision)
+		}
+
+		b, _ := gfs.ReadFile("README.md")
+		if got, want := string(b), "readme"; got != want {
+			t.Fatalf("Reading after a collision, got %#v, want the previous content %#v", got, want)
+		}
+	})
+
+	t.Run("OK case sensitive by default", func(t *testing.T) {
+		plain := NewWithClient(client, referenceGistID)
+		if err := plain.Load(context.Background()); err != nil {
+			t.Fatalf("Loading colliding names without the option, expected no error but got %#v", err)
+		}
+	})
+}
+
+func TestWithAliases(t *testing.T) {
+	_, client := newFakeGist(t, map[string]string{
+		"home.html":  "home",
+		"about.html": "about",
+	})
+
+	aliases := map[string]string{
+		"index.html":      "home.html",
+		"about.html":      "home.html",
+		"pages/team.html": "about.html",
+		"missing.html":    "nowhere.html",
+	}
+	gfs := NewWithClient(client, referenceGistID, WithAliases(aliases))
+	aliases["late.html"] = "home.html"
+
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		err     error
+	}{
+		{"index.html", "home", nil},
+		{"about.html", "about", nil},
+		{"pages/team.html", "about", nil},
+		{"missing.html", "", fs.ErrNotExist},
+		{"late.html", "", fs.ErrNotExist},
+	}
+
+	for _, test := range tests {
+		b, err := fs.ReadFile(gfs, test.name)
+		if test.err != nil && !errors.Is(err, test.err) {
+			t.Fatalf("Reading %#v, got %#v, want %#v", test.name, err, test.err)
+		}
+
+		if got, want := string(b), test.content; got != want {
+			t.Fatalf("Reading %#v, got %#v, want %#v", test.name, got, want)
+		}
+	}
+
+	stat, err := fs.Stat(gfs, "index.html")
+	if err != nil {
+		t.Fatalf("Stat of an alias, expected no error but got %#v", err)
+	}
+
+	if got, want := stat.Name(), "index.html"; got != want {
+		t.Fatalf("Stat of an alias, got name %#v, want %#v", got, want)
+	}
+}
+
+func TestWithIndex(t *testing.T) {
+	_, client := newFakeGist(t, map[string]string{
+		"README.md":        "# readme",
+		"docs__intro.md":   "intro",
+		"docs__README.md":  "docs readme",
+		"site__index.html": "<h1>site</h1>",
+		"site__README.md":  "site readme",
+		"other__notes.txt": "notes",
+		"text__README.txt": "<script>alert(1)</script>",
+		"text__intro.md":   "text intro",
+	})
+
+	gfs := NewWithClient(client, referenceGistID, WithPathSeparator("__"), WithIndex("README.txt", "README.md", "intro.md"))
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+
+	srv := httptest.NewServer(http.FileServer(http.FS(gfs)))
+	defer srv.Close()
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/", http.StatusOK, "<h1>readme</h1>"},
+		{"/docs/", http.StatusOK, "<p>docs readme</p>"},
+		{"/site/", http.StatusOK, "<h1>site</h1>"},
+		{"/other/", http.StatusOK, "notes.txt"},
+		{"/text/", http.StatusOK, "<p>text intro</p>"},
+	}
+
+	for _, test := range tests {
+		resp, err := http.Get(srv.URL + test.path)
+		if err != nil {
+			t.Fatalf("Requesting %s, expected no error but got %#v", test.path, err)
+		}
+
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if got, want := resp.StatusCode, test.status; got != want {
+			t.Fatalf("Requesting %s, got status %d, want %d", test.path, got, want)
+		}
+
+		if got := string(b); !strings.Contains(got, test.body) {
+			t.Fatalf("Requesting %s, got %#v, want it to contain %#v", test.path, got, test.body)
+		}
+	}
+}
+
+// hangingGetter is a stubGetter whose requests hang until their context is
+// done, for the ones hang is set for.
+type hangingGetter struct {
+	*stubGetter
+	hangGist, hangRaw bool
+	deadline          time.Time
+}
+
+func (g *hangingGetter) GetGist(ctx context.Context, id, etag string) (*Gist, string, error) {
+	g.deadline, _ = ctx.Deadline()
+	if !g.hangGist {
+		return g.stubGetter.GetGist(ctx, id, etag)
+	}
+
+	<-ctx.Done()
+	return nil, "", ctx.Err()
+}
+
+func (g *hangingGetter) GetRaw(ctx context.Context, rawURL string) ([]byte, error) {
+	if !g.hangRaw {
+		return g.stubGetter.GetRaw(ctx, rawURL)
+	}
+
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestWithLoadTimeout(t *testing.T) {
+	newGetter := func() *hangingGetter {
+		return &hangingGetter{stubGetter: &stubGetter{
+			gist: &Gist{
+				ID: referenceGistID,
+				Files: map[string]GistFile{
+					"big.txt": {Filename: "big.txt", Content: "big", Size: 9, Truncated: true, RawURL: "https://raw/big.txt"},
+				},
+			},
+			raw: map[string]string{"https://raw/big.txt": "big file!"},
+		}}
+	}
+
+	t.Run("NOK hanging load", func(t *testing.T) {
+		getter := newGetter()
+		getter.hangGist = true
+
+		gfs := NewWithGetter(getter, referenceGistID, WithLoadTimeout(10*time.Millisecond))
+		err := gfs.Load(context.Background())
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Load, got %#v, want a deadline exceeded error", err)
+		}
+	})
+
+	t.Run("NOK hanging deferred content", func(t *testing.T) {
+		getter := newGetter()
+		getter.hangRaw = true
+
+		gfs := NewWithGetter(getter, referenceGistID, WithLoadTimeout(10*time.Millisecond))
+		if err := gfs.LoadMetadata(context.Background()); err != nil {
+			t.Fatalf("LoadMetadata, expected no error but got %#v", err)
+		}
+
+		_, err := gfs.ReadFile("big.txt")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Reading deferred content, got %#v, want a deadline exceeded error", err)
+		}
+	})
+
+	t.Run("OK caller deadline", func(t *testing.T) {
+		getter := newGetter()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+		defer cancel()
+		want, _ := ctx.Deadline()
+
+		gfs := NewWithGetter(getter, referenceGistID, WithLoadTimeout(10*time.Millisecond))
+		if err := gfs.Load(ctx); err != nil {
+			t.Fatalf("Load, expected no error but got %#v", err)
+		}
+
+		if !getter.deadline.Equal(want) {
+			t.Fatalf("Load, got deadline %v, want the one of the caller %v", getter.deadline, want)
+		}
+	})
+}
+
+func TestWithFileMode(t *testing.T) {
+	_, client := newFakeGist(t, map[string]string{"a.txt": "a", "run.sh": "echo", "RUN2.SH": "echo"})
+
+	gfs := NewWithClient(client, referenceGistID, WithFileMode(0640|fs.ModeSetuid), WithFileModes(map[string]fs.FileMode{".sh": 0755}))
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+
+	want := map[string]fs.FileMode{"a.txt": 0640, "run.sh": 0755, "RUN2.SH": 0755}
+
+	t.Run("OK stat", func(t *testing.T) {
+		for name, mode := range want {
+			info, err := fs.Stat(gfs, name)
+			if err != nil {
+				t.Fatalf("Stating %s, expected no error but got %#v", name, err)
+			}
+			if got := info.Mode(); got != mode {
+				t.Fatalf("Stating %s, got mode %v, want %v", name, got, mode)
+			}
+		}
+
+		entries, err := gfs.ReadDir(".")
+		if err != nil {
+			t.Fatalf("Reading root directory, expected no error but got %#v", err)
+		}
+		for _, e := range entries {
+			info, _ := e.Info()
+			if got := info.Mode(); got != want[e.Name()] {
+				t.Fatalf("Listing %s, got mode %v, want %v", e.Name(), got, want[e.Name()])
+			}
+		}
+	})
+
+	t.Run("OK exports", func(t *testing.T) {
+		mfs, err := gfs.ToMapFS()
+		if err != nil {
+			t.Fatalf("Exporting, expected no error but got %#v", err)
+		}
+		if got, want := mfs["run.sh"].Mode, fs.FileMode(0755); got != want {
+			t.Fatalf("Exporting, got mode %v, want %v", got, want)
+		}
+
+		var buf bytes.Buffer
+		if err := gfs.WriteTar(&buf); err != nil {
+			t.Fatalf("Writing a tar archive, expected no error but got %#v", err)
+		}
+
+		tr := tar.NewReader(&buf)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Reading the tar archive, expected no error but got %#v", err)
+			}
+
+			if got := fs.FileMode(hdr.Mode); got != want[hdr.Name] {
+				t.Fatalf("Reading the tar archive, got mode %v for %s, want %v", got, hdr.Name, want[hdr.Name])
+			}
+		}
+	})
+
+	t.Run("OK default", func(t *testing.T) {
+		gfs := NewWithClient(client, referenceGistID)
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		info, err := fs.Stat(gfs, "run.sh")
+		if err != nil {
+			t.Fatalf("Stating, expected no error but got %#v", err)
+		}
+		if got, want := info.Mode(), fs.FileMode(0444); got != want {
+			t.Fatalf("Stating, got mode %v, want %v", got, want)
+		}
+	})
+}
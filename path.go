@@ -0,0 +1,75 @@
+package gistfs
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// Errors wrapped by the *fs.PathError returned for paths that can't be
+// served, which match fs.ErrInvalid with errors.Is.
+var (
+	errRootedPath    = fmt.Errorf("%w: paths are relative to the root of the gist and can't start with a slash", fs.ErrInvalid)
+	errDrivePath     = fmt.Errorf("%w: paths are relative to the root of the gist and can't start with a drive letter", fs.ErrInvalid)
+	errBackslashPath = fmt.Errorf("%w: paths are separated by slashes, not backslashes, unless WithWindowsPaths is set", fs.ErrInvalid)
+)
+
+// WithWindowsPaths makes reads accept paths separated by backslashes, such
+// as the ones written in configuration files on Windows, by turning them
+// into slashes. Without it, paths holding a backslash are rejected.
+func WithWindowsPaths() Option {
+	return func(fsys *FS) {
+		fsys.windowsPaths = true
+	}
+}
+
+// cleanPath returns the path name refers to, as given to the operation op:
+// leading "./" elements are trimmed, and backslashes are turned into slashes
+// with WithWindowsPaths. Paths that can't be served, such as rooted ones,
+// are reported with a *fs.PathError matching fs.ErrInvalid.
+func (fsys *FS) cleanPath(op, name string) (string, error) {
+	p := name
+	if fsys.windowsPaths {
+		p = strings.ReplaceAll(p, `\`, "/")
+		if len(p) >= 2 && p[1] == ':' && ('a' <= p[0]|0x20 && p[0]|0x20 <= 'z') {
+			return "", &fs.PathError{Op: op, Path: name, Err: errDrivePath}
+		}
+	} else if strings.Contains(p, `\`) {
+		return "", &fs.PathError{Op: op, Path: name, Err: errBackslashPath}
+	}
+
+	if strings.HasPrefix(p, "/") {
+		return "", &fs.PathError{Op: op, Path: name, Err: errRootedPath}
+	}
+
+	for strings.HasPrefix(p, "./") {
+		p = p[len("./"):]
+	}
+	if p == "" {
+		p = "."
+	}
+
+	if !fs.ValidPath(p) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	return p, nil
+}
+
+// Stat returns a FileInfo describing the named file.
+func (fsys *FS) Stat(name string) (fs.FileInfo, error) {
+	return fsys.StatContext(fsys.readContext(), name)
+}
+
+// StatContext returns a FileInfo describing the named file as Stat does,
+// making the requests it needs with ctx.
+func (fsys *FS) StatContext(ctx context.Context, name string) (fs.FileInfo, error) {
+	f, err := fsys.OpenContext(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return f.Stat()
+}
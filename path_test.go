@@ -0,0 +1,71 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+func TestCleanPath(t *testing.T) {
+	_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+
+	t.Run("OK leading dot", func(t *testing.T) {
+		gfs := NewWithClient(client, referenceGistID, WithMetaFile())
+		gfs.Load(context.Background())
+
+		for _, name := range []string{"a.txt", "./a.txt", "././a.txt"} {
+			if b, err := gfs.ReadFile(name); err != nil || string(b) != "a" {
+				t.Fatalf("Reading %s, got %q (%v), want %q", name, b, err, "a")
+			}
+		}
+
+		if info, err := gfs.Stat("./a.txt"); err != nil || info.Name() != "a.txt" {
+			t.Fatalf("Stating ./a.txt, got %v (%v), want a.txt", info, err)
+		}
+		if entries, err := gfs.ReadDir("./.gist"); err != nil || len(entries) != 1 {
+			t.Fatalf("Listing ./.gist, got %v (%v), want the meta file", entries, err)
+		}
+		if info, err := gfs.Stat("./"); err != nil || !info.IsDir() {
+			t.Fatalf("Stating ./, got %v (%v), want the root directory", info, err)
+		}
+	})
+
+	t.Run("NOK invalid", func(t *testing.T) {
+		gfs := NewWithClient(client, referenceGistID)
+		gfs.Load(context.Background())
+
+		tests := map[string]error{
+			"/a.txt":     errRootedPath,
+			`.\a.txt`:    errBackslashPath,
+			"../a.txt":   fs.ErrInvalid,
+			"a.txt/":     fs.ErrInvalid,
+			"dir//a.txt": fs.ErrInvalid,
+		}
+		for name, want := range tests {
+			_, err := gfs.Open(name)
+
+			var pathErr *fs.PathError
+			if !errors.As(err, &pathErr) || pathErr.Path != name || !errors.Is(err, want) || !errors.Is(err, fs.ErrInvalid) {
+				t.Fatalf("Opening %s, got %#v, want a *fs.PathError wrapping %v", name, err, want)
+			}
+		}
+	})
+
+	t.Run("OK windows paths", func(t *testing.T) {
+		gfs := NewWithClient(client, referenceGistID, WithWindowsPaths(), WithMetaFile())
+		gfs.Load(context.Background())
+
+		for _, name := range []string{`.\a.txt`, `.gist\meta.json`} {
+			if _, err := gfs.ReadFile(name); err != nil {
+				t.Fatalf("Reading %s, expected no error but got %#v", name, err)
+			}
+		}
+
+		for _, name := range []string{`C:\a.txt`, `\a.txt`} {
+			if _, err := gfs.ReadFile(name); !errors.Is(err, fs.ErrInvalid) {
+				t.Fatalf("Reading %s, got %#v, want fs.ErrInvalid", name, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// Ping checks that the gist exists and can be read with the credentials of
+// the filesystem, without loading it, so that it can back readiness probes.
+// It returns nil on success, or an *Error matching ErrGistNotFound,
+// ErrUnauthorized or ErrRateLimited with errors.Is.
+//
+// Once a gist is loaded, the request is conditional, which Github answers
+// without content and without counting it against the rate limit as long
+// as the gist did not change. Before that, it fetches the gist like Load,
+// but discards it.
+func (fsys *FS) Ping(ctx context.Context) error {
+	ctx, cancel := fsys.withLoadTimeout(ctx)
+	defer cancel()
+
+	ctx, span := fsys.startSpan(ctx, "gistfs.Ping", "")
+	etag := fsys.state().etag
+	err := fsys.call(ctx, "ping", func() (*github.Response, error) {
+		_, _, err := fsys.getter.GetGist(ctx, fsys.GetID(), etag)
+		if errors.Is(err, ErrNotModified) {
+			return nil, nil
+		}
+		return nil, err
+	})
+	endSpan(span, -1, err)
+
+	return err
+}
@@ -0,0 +1,51 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPing(t *testing.T) {
+	getter := &stubGetter{
+		gist: &Gist{ID: referenceGistID, Files: map[string]GistFile{"a.txt": {Filename: "a.txt", Content: "a", Size: 1}}},
+		etag: `"v1"`,
+	}
+
+	t.Run("OK not loaded", func(t *testing.T) {
+		gfs := NewWithGetter(getter, referenceGistID)
+		if err := gfs.Ping(context.Background()); err != nil {
+			t.Fatalf("Pinging, expected no error but got %#v", err)
+		}
+		if gfs.IsLoaded() {
+			t.Errorf("Pinging, expected the gist not to be loaded")
+		}
+	})
+
+	t.Run("OK conditional once loaded", func(t *testing.T) {
+		gfs := NewWithGetter(getter, referenceGistID)
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+		if err := gfs.Ping(context.Background()); err != nil {
+			t.Fatalf("Pinging, expected no error but got %#v", err)
+		}
+
+		getter.mu.Lock()
+		last := getter.etags[len(getter.etags)-1]
+		getter.mu.Unlock()
+		if last != `"v1"` {
+			t.Errorf("Pinging, got ETag %q, want the one of the loaded gist", last)
+		}
+	})
+
+	t.Run("NOK not found", func(t *testing.T) {
+		gfs := NewWithGetter(getter, "missing")
+		err := gfs.Ping(context.Background())
+
+		var e *Error
+		if !errors.Is(err, ErrGistNotFound) || !errors.As(err, &e) || e.Op != "ping" {
+			t.Fatalf("Pinging a missing gist, got %#v, want a ping *Error matching ErrGistNotFound", err)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package gistfs
+
+import (
+	"context"
+	"sync"
+)
+
+// defaultFetchConcurrency is the number of raw contents fetched at once,
+// unless set with WithFetchConcurrency.
+const defaultFetchConcurrency = 4
+
+// WithFetchConcurrency sets how many files are downloaded at once when the
+// content of truncated files is fetched, on Load or by Prefetch. It defaults
+// to 4, and values below 1 download files one at a time.
+func WithFetchConcurrency(n int) Option {
+	return func(fsys *FS) {
+		if n < 1 {
+			n = 1
+		}
+		fsys.fetchConcurrency = n
+	}
+}
+
+// fetchAll calls fetch for i from 0 to n-1, running as many calls at once as
+// the fetch concurrency allows.
+func (fsys *FS) fetchAll(ctx context.Context, n int, fetch func(ctx context.Context, i int) error) error {
+	limit := fsys.fetchConcurrency
+	if limit == 0 {
+		limit = defaultFetchConcurrency
+	}
+
+	return runAll(ctx, limit, n, fetch)
+}
+
+// DefaultLoadAllConcurrency is the number of filesystems LoadAll loads at
+// once.
+const DefaultLoadAllConcurrency = 8
+
+// LoadAll loads the given filesystems concurrently, at most
+// DefaultLoadAllConcurrency at once, and returns the first error encountered,
+// cancelling the loads still running. As errors returned by Load are *Error
+// values, it tells which gist failed.
+func LoadAll(ctx context.Context, fss ...*FS) error {
+	return LoadAllN(ctx, DefaultLoadAllConcurrency, fss...)
+}
+
+// LoadAllN loads the given filesystems as LoadAll does, at most n at once.
+// Values of n below 1 load them one at a time.
+func LoadAllN(ctx context.Context, n int, fss ...*FS) error {
+	if n < 1 {
+		n = 1
+	}
+
+	return runAll(ctx, n, len(fss), func(ctx context.Context, i int) error {
+		return fss[i].Load(ctx)
+	})
+}
+
+// runAll calls fn for i from 0 to n-1, running up to limit calls at once.
+// It stops starting new calls once one fails, cancelling the context of the
+// ones running, and returns the first error.
+func runAll(ctx context.Context, limit, n int, fn func(ctx context.Context, i int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+
+	sem := make(chan struct{}, limit)
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if err := fn(ctx, i); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return ctx.Err()
+}
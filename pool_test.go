@@ -0,0 +1,167 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestFetchAll(t *testing.T) {
+	t.Run("OK bounded", func(t *testing.T) {
+		fsys := newFS(nil, referenceGistID, []Option{WithFetchConcurrency(2)})
+
+		var mu sync.Mutex
+		running, max := 0, 0
+		started := make(chan struct{})
+		release := make(chan struct{})
+		done := make(chan error)
+
+		go func() {
+			done <- fsys.fetchAll(context.Background(), 6, func(ctx context.Context, i int) error {
+				mu.Lock()
+				running++
+				if running > max {
+					max = running
+				}
+				mu.Unlock()
+
+				started <- struct{}{}
+				<-release
+
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return nil
+			})
+		}()
+
+		// Let the fetches go in pairs, once both are running.
+		for i := 0; i < 3; i++ {
+			<-started
+			<-started
+			release <- struct{}{}
+			release <- struct{}{}
+		}
+		if err := <-done; err != nil {
+			t.Fatalf("Fetching, expected no error but got %#v", err)
+		}
+
+		if got, want := max, 2; got != want {
+			t.Fatalf("Fetching with a concurrency of 2, got %d concurrent fetches, want %d", got, want)
+		}
+	})
+
+	t.Run("NOK stops on error", func(t *testing.T) {
+		fsys := newFS(nil, referenceGistID, []Option{WithFetchConcurrency(1)})
+		fail := errors.New("fail")
+
+		var calls int
+		err := fsys.fetchAll(context.Background(), 5, func(ctx context.Context, i int) error {
+			calls++
+			if i == 1 {
+				return fail
+			}
+			return nil
+		})
+
+		if !errors.Is(err, fail) {
+			t.Fatalf("Fetching, got %#v, want %#v", err, fail)
+		}
+		if got, want := calls, 2; got != want {
+			t.Fatalf("Fetching after an error, got %d calls, want %d", got, want)
+		}
+	})
+}
+
+func TestLoadAll(t *testing.T) {
+	getter := &stubGetter{
+		gist: &Gist{ID: referenceGistID, Files: map[string]GistFile{"a.txt": {Filename: "a.txt", Content: "a", Size: 1}}},
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		var fss []*FS
+		for i := 0; i < 20; i++ {
+			fss = append(fss, NewWithGetter(getter, referenceGistID))
+		}
+
+		if err := LoadAll(context.Background(), fss...); err != nil {
+			t.Fatalf("Loading all, expected no error but got %#v", err)
+		}
+
+		for _, fsys := range fss {
+			if _, err := fsys.ReadFile("a.txt"); err != nil {
+				t.Fatalf("Reading after loading all, expected no error but got %#v", err)
+			}
+		}
+	})
+
+	t.Run("OK bounded", func(t *testing.T) {
+		gated := &gatedGetter{
+			GistGetter: getter,
+			started:    make(chan struct{}),
+			release:    make(chan struct{}),
+		}
+		var fss []*FS
+		for i := 0; i < 4; i++ {
+			fss = append(fss, NewWithGetter(gated, referenceGistID))
+		}
+
+		done := make(chan error)
+		go func() {
+			done <- LoadAllN(context.Background(), 2, fss...)
+		}()
+
+		// Let the loads go in pairs, once both are running.
+		for i := 0; i < 2; i++ {
+			<-gated.started
+			<-gated.started
+			gated.release <- struct{}{}
+			gated.release <- struct{}{}
+		}
+		if err := <-done; err != nil {
+			t.Fatalf("Loading all, expected no error but got %#v", err)
+		}
+
+		if got, want := gated.max, 2; got != want {
+			t.Fatalf("Loading all with a concurrency of 2, got %d concurrent loads, want %d", got, want)
+		}
+	})
+
+	t.Run("NOK", func(t *testing.T) {
+		err := LoadAll(context.Background(), NewWithGetter(getter, referenceGistID), NewWithGetter(getter, "unknown"))
+
+		var gistErr *Error
+		if !errors.As(err, &gistErr) || gistErr.ID != "unknown" {
+			t.Fatalf("Loading all with an unknown gist, got %#v, want an *Error for it", err)
+		}
+	})
+}
+
+// gatedGetter is a GistGetter holding each GetGist call until released,
+// recording how many of them run at once.
+type gatedGetter struct {
+	GistGetter
+	started, release chan struct{}
+
+	mu           sync.Mutex
+	running, max int
+}
+
+func (g *gatedGetter) GetGist(ctx context.Context, id, etag string) (*Gist, string, error) {
+	g.mu.Lock()
+	g.running++
+	if g.running > g.max {
+		g.max = g.running
+	}
+	g.mu.Unlock()
+
+	g.started <- struct{}{}
+	<-g.release
+
+	g.mu.Lock()
+	g.running--
+	g.mu.Unlock()
+
+	return g.GistGetter.GetGist(ctx, id, etag)
+}
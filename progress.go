@@ -0,0 +1,101 @@
+package gistfs
+
+import (
+	"sync"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// ProgressEvent reports the progress of an operation fetching the content of
+// files of a gist.
+type ProgressEvent struct {
+	// Op is the operation in progress: "load" for Load and refreshes,
+	// "prefetch" for Prefetch, and "read" when reading a file whose content
+	// was deferred by LoadMetadata.
+	Op string
+	// Name is the file whose content was just fetched. It is empty for the
+	// first event of a load, sent once the gist itself was fetched.
+	Name string
+	// Files and Bytes are the number of files fetched so far by the
+	// operation, and their size.
+	Files int
+	Bytes int64
+	// TotalFiles and TotalBytes are the number of files the operation
+	// fetches, and their size as reported by Github.
+	TotalFiles int
+	TotalBytes int64
+}
+
+// Done reports whether the operation fetched all its files.
+func (e ProgressEvent) Done() bool {
+	return e.Files >= e.TotalFiles
+}
+
+// WithProgress calls fn as the content of files is fetched, so that loading
+// a large gist can be reported rather than stalling silently.
+//
+// A load calls fn once the gist is fetched, counting the files whose content
+// it held, then once per file whose content was truncated and had to be
+// downloaded. Loads reading the gist at once, with WithGraphQL or
+// WithGitSnapshot, call fn a single time. Conditional refreshes finding the
+// gist did not change don't call fn at all.
+//
+// Calls for an operation are never concurrent, but calls for different ones
+// may be, such as a Prefetch running along with a refresh.
+func WithProgress(fn func(ProgressEvent)) Option {
+	return func(fsys *FS) {
+		fsys.progress = fn
+	}
+}
+
+// progress tracks the progress of an operation, and is nil when the
+// filesystem reports none.
+type progress struct {
+	fn func(ProgressEvent)
+
+	mu sync.Mutex
+	ev ProgressEvent
+}
+
+// startProgress returns the tracker of op, which fetches files making up
+// bytes in total.
+func (fsys *FS) startProgress(op string, files int, bytes int64) *progress {
+	if fsys.progress == nil {
+		return nil
+	}
+
+	return &progress{
+		fn: fsys.progress,
+		ev: ProgressEvent{Op: op, TotalFiles: files, TotalBytes: bytes},
+	}
+}
+
+// add reports that files making up bytes were fetched, the last one being
+// name.
+func (p *progress) add(name string, files int, bytes int64) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.ev.Name = name
+	p.ev.Files += files
+	p.ev.Bytes += bytes
+	p.fn(p.ev)
+}
+
+// reportFetched reports a load fetching all the files of gist at once.
+func (fsys *FS) reportFetched(gist *github.Gist) {
+	if fsys.progress == nil {
+		return
+	}
+
+	var bytes int64
+	for _, f := range gist.Files {
+		bytes += int64(f.GetSize())
+	}
+
+	fsys.startProgress("load", len(gist.Files), bytes).add("", len(gist.Files), bytes)
+}
@@ -0,0 +1,95 @@
+package gistfs
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestWithProgress(t *testing.T) {
+	newGetter := func() *stubGetter {
+		return &stubGetter{
+			gist: &Gist{
+				ID: referenceGistID,
+				Files: map[string]GistFile{
+					"a.txt":    {Filename: "a.txt", Content: "a", Size: 1},
+					"big.txt":  {Filename: "big.txt", Content: "big", Size: 9, Truncated: true, RawURL: "https://raw/big.txt"},
+					"huge.txt": {Filename: "huge.txt", Content: "huge", Size: 10, Truncated: true, RawURL: "https://raw/huge.txt"},
+				},
+			},
+			etag: `"v1"`,
+			raw: map[string]string{
+				"https://raw/big.txt":  "big file!",
+				"https://raw/huge.txt": "huge file!",
+			},
+		}
+	}
+
+	var (
+		mu     sync.Mutex
+		events []ProgressEvent
+	)
+	record := WithProgress(func(e ProgressEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	})
+	take := func() []ProgressEvent {
+		mu.Lock()
+		defer mu.Unlock()
+		got := events
+		events = nil
+		return got
+	}
+
+	t.Run("OK load", func(t *testing.T) {
+		gfs := NewWithGetter(newGetter(), referenceGistID, record)
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		got := take()
+		if len(got) != 3 {
+			t.Fatalf("Loading, got %d events, want 3: %+v", len(got), got)
+		}
+		if first := got[0]; first.Op != "load" || first.Name != "" || first.Files != 1 || first.Bytes != 1 || first.TotalFiles != 3 || first.TotalBytes != 20 {
+			t.Errorf("Loading, got first event %+v", first)
+		}
+		if last := got[2]; !last.Done() || last.Bytes != last.TotalBytes {
+			t.Errorf("Loading, got last event %+v, want all files fetched", last)
+		}
+
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Refreshing, expected no error but got %#v", err)
+		}
+		if got := take(); len(got) != 0 {
+			t.Errorf("Refreshing an unchanged gist, got events %+v, want none", got)
+		}
+	})
+
+	t.Run("OK prefetch and read", func(t *testing.T) {
+		gfs := NewWithGetter(newGetter(), referenceGistID, record)
+		if err := gfs.LoadMetadata(context.Background()); err != nil {
+			t.Fatalf("Loading metadata, expected no error but got %#v", err)
+		}
+		if got := take(); len(got) != 0 {
+			t.Errorf("Loading metadata, got events %+v, want none", got)
+		}
+
+		if err := gfs.Prefetch(context.Background(), "a.txt", "big.txt"); err != nil {
+			t.Fatalf("Prefetching, expected no error but got %#v", err)
+		}
+		got := take()
+		if len(got) != 2 || got[1].Op != "prefetch" || !got[1].Done() || got[1].Bytes != 10 || got[1].TotalBytes != 10 {
+			t.Errorf("Prefetching, got events %+v", got)
+		}
+
+		if _, err := gfs.ReadFile("huge.txt"); err != nil {
+			t.Fatalf("Reading huge.txt, expected no error but got %#v", err)
+		}
+		want := ProgressEvent{Op: "read", Name: "huge.txt", Files: 1, Bytes: 10, TotalFiles: 1, TotalBytes: 10}
+		if got := take(); len(got) != 1 || got[0] != want {
+			t.Errorf("Reading a deferred file, got events %+v, want %+v", got, want)
+		}
+	})
+}
@@ -0,0 +1,143 @@
+// Package prometheus exports the metrics of gistfs filesystems to
+// Prometheus. A Recorder is both a gistfs.MetricsRecorder and a
+// prometheus.Collector:
+//
+//	r := prometheus.NewRecorder()
+//	registry.MustRegister(r)
+//	gfs := gistfs.New(id, gistfs.WithMetrics(r))
+//
+// Metrics are labelled with the ID of the gist, so a single Recorder can be
+// shared by several filesystems.
+package prometheus
+
+import (
+	"time"
+
+	"github.com/jhchabran/gistfs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// namespace prefixes the names of the exported metrics.
+const namespace = "gistfs"
+
+// Ensure Recorder implements the interfaces it is used through.
+var (
+	_ gistfs.MetricsRecorder = (*Recorder)(nil)
+	_ prometheus.Collector   = (*Recorder)(nil)
+)
+
+// Recorder records the measurements of gistfs filesystems as Prometheus
+// metrics.
+type Recorder struct {
+	loads         *prometheus.CounterVec
+	loadDurations *prometheus.HistogramVec
+	refreshes     *prometheus.CounterVec
+	apiErrors     *prometheus.CounterVec
+	rateRemaining prometheus.Gauge
+	bytesServed   *prometheus.CounterVec
+	openFiles     *prometheus.GaugeVec
+}
+
+// NewRecorder returns a Recorder, to be registered with a Prometheus
+// registry.
+func NewRecorder() *Recorder {
+	return &Recorder{
+		loads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "loads_total",
+			Help:      "Loads of gists, including background refreshes, by result.",
+		}, []string{"gist", "result"}),
+		loadDurations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "load_duration_seconds",
+			Help:      "Duration of the loads of gists.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"gist"}),
+		refreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "refreshes_total",
+			Help:      "Background refreshes of expired gists, by result.",
+		}, []string{"gist", "result"}),
+		apiErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "api_errors_total",
+			Help:      "Failed requests to the Github API, by operation.",
+		}, []string{"gist", "op"}),
+		rateRemaining: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "rate_limit_remaining",
+			Help:      "Requests left before reaching the Github API rate limit.",
+		}),
+		bytesServed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "served_bytes_total",
+			Help:      "Bytes read from the files of gists.",
+		}, []string{"gist"}),
+		openFiles: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "open_files",
+			Help:      "Files of gists currently open.",
+		}, []string{"gist"}),
+	}
+}
+
+// result returns the value of the result label for err.
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+
+	return "success"
+}
+
+func (r *Recorder) ObserveLoad(id string, d time.Duration, err error) {
+	r.loads.WithLabelValues(id, result(err)).Inc()
+	r.loadDurations.WithLabelValues(id).Observe(d.Seconds())
+}
+
+func (r *Recorder) ObserveRefresh(id string, err error) {
+	r.refreshes.WithLabelValues(id, result(err)).Inc()
+}
+
+func (r *Recorder) ObserveAPIError(id, op string) {
+	r.apiErrors.WithLabelValues(id, op).Inc()
+}
+
+func (r *Recorder) SetRateLimitRemaining(remaining int) {
+	r.rateRemaining.Set(float64(remaining))
+}
+
+func (r *Recorder) AddBytesServed(id string, n int) {
+	r.bytesServed.WithLabelValues(id).Add(float64(n))
+}
+
+func (r *Recorder) AddOpenFiles(id string, delta int) {
+	r.openFiles.WithLabelValues(id).Add(float64(delta))
+}
+
+// collectors returns the metrics of r.
+func (r *Recorder) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		r.loads,
+		r.loadDurations,
+		r.refreshes,
+		r.apiErrors,
+		r.rateRemaining,
+		r.bytesServed,
+		r.openFiles,
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (r *Recorder) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range r.collectors() {
+		c.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (r *Recorder) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range r.collectors() {
+		c.Collect(ch)
+	}
+}
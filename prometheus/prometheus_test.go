@@ -0,0 +1,59 @@
+package prometheus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jhchabran/gistfs"
+	"github.com/jhchabran/gistfs/gisttest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder()
+
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(r); err != nil {
+		t.Fatalf("Registering, expected no error but got %#v", err)
+	}
+
+	gfs, _ := gisttest.NewFS(t, map[string]string{"a.txt": "abc"}, gistfs.WithMetrics(r))
+
+	f, err := gfs.Open("a.txt")
+	if err != nil {
+		t.Fatalf("Opening, expected no error but got %#v", err)
+	}
+	if got, want := testutil.ToFloat64(r.openFiles.WithLabelValues(gisttest.GistID)), 1.0; got != want {
+		t.Fatalf("Opening, got %v open files, want %v", got, want)
+	}
+	f.Close()
+
+	if _, err := gfs.ReadFile("a.txt"); err != nil {
+		t.Fatalf("Reading, expected no error but got %#v", err)
+	}
+
+	want := `
+# HELP gistfs_loads_total Loads of gists, including background refreshes, by result.
+# TYPE gistfs_loads_total counter
+gistfs_loads_total{gist="` + gisttest.GistID + `",result="success"} 1
+# HELP gistfs_open_files Files of gists currently open.
+# TYPE gistfs_open_files gauge
+gistfs_open_files{gist="` + gisttest.GistID + `"} 0
+# HELP gistfs_served_bytes_total Bytes read from the files of gists.
+# TYPE gistfs_served_bytes_total counter
+gistfs_served_bytes_total{gist="` + gisttest.GistID + `"} 3
+`
+	err = testutil.GatherAndCompare(registry, strings.NewReader(want),
+		"gistfs_loads_total", "gistfs_open_files", "gistfs_served_bytes_total")
+	if err != nil {
+		t.Fatalf("Gathering, expected matching metrics but got %v", err)
+	}
+
+	if got := testutil.ToFloat64(r.rateRemaining); got == 0 {
+		t.Fatal("Got no remaining rate limit, want the one reported by Github")
+	}
+	if got, want := testutil.CollectAndCount(r, "gistfs_load_duration_seconds"), 1; got != want {
+		t.Fatalf("Got %d load duration series, want %d", got, want)
+	}
+}
@@ -0,0 +1,130 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// gistWebURL is the address of gist.github.com, which serves public gists
+// without going through the Github API.
+const gistWebURL = "https://gist.github.com/"
+
+// ErrRevisionsUnsupported is returned when loading a revision of a gist with
+// a getter that only knows the latest one, such as the one of NewAnonymous.
+var ErrRevisionsUnsupported = errors.New("revisions are not supported")
+
+// NewAnonymous returns a FS based on a given Gist ID, loading it from
+// gist.github.com rather than from the Github API: its files are listed from
+// the document gist.github.com serves to embed the gist, and their content
+// is downloaded from their raw URLs. Neither needs a token, nor counts
+// against the rate limit of the API, which suits reading public gists at
+// scale.
+//
+// Less is known about gists loaded this way. The sizes of files are only
+// known once their content is downloaded, so LoadMetadata downloads it as
+// Load does. The time of the last update, the revision and the history of
+// the gist are unknown, so files report a zero modification time and
+// WithRevision makes Load fail with ErrRevisionsUnsupported. Options
+// authenticating requests, such as WithToken, have no effect.
+func NewAnonymous(id string, opts ...Option) *FS {
+	return newFS(nil, id, append([]Option{withAnonymous()}, opts...))
+}
+
+func withAnonymous() Option {
+	return func(fsys *FS) {
+		fsys.anonymous = true
+	}
+}
+
+// anonymousHTTPClient returns the http.Client anonymous requests are made
+// with, according to the options.
+func (fsys *FS) anonymousHTTPClient() *http.Client {
+	c := fsys.baseHTTPClient
+	if c == nil {
+		c = http.DefaultClient
+	}
+
+	if fsys.requestHooks != nil {
+		c = fsys.withRequestHooks(c)
+	}
+
+	return c
+}
+
+// NewRawGetter returns a GistGetter loading gists from baseURL as
+// gist.github.com serves them, with httpClient, as done by NewAnonymous. An
+// empty baseURL targets gist.github.com.
+func NewRawGetter(httpClient *http.Client, baseURL string) (GistGetter, error) {
+	if baseURL == "" {
+		baseURL = gistWebURL
+	}
+
+	g, err := NewHTTPGetter(httpClient, baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rawGetter{httpGetter: g.(*httpGetter)}, nil
+}
+
+// rawGetter is a GistGetter loading gists as gist.github.com serves them,
+// outside of the Github API. Raw content is fetched like httpGetter does.
+type rawGetter struct {
+	*httpGetter
+}
+
+// embedDocument is the document gist.github.com serves at <id>.json to
+// embed a gist.
+type embedDocument struct {
+	Description string    `json:"description"`
+	Public      bool      `json:"public"`
+	Owner       string    `json:"owner"`
+	CreatedAt   time.Time `json:"created_at"`
+	Files       []string  `json:"files"`
+}
+
+func (g *rawGetter) GetGist(ctx context.Context, id, etag string) (*Gist, string, error) {
+	header := http.Header{}
+	if etag != "" {
+		header.Set("If-None-Match", etag)
+	}
+
+	doc := new(embedDocument)
+	resp, err := g.do(ctx, url.PathEscape(id)+".json", header, doc)
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, etag, ErrNotModified
+	}
+
+	htmlURL := g.baseURL.String() + url.PathEscape(id)
+	gist := &Gist{
+		ID:          id,
+		Description: doc.Description,
+		Public:      doc.Public,
+		Owner:       doc.Owner,
+		HTMLURL:     htmlURL,
+		CreatedAt:   doc.CreatedAt,
+		Files:       make(map[string]GistFile, len(doc.Files)),
+	}
+
+	// Sizes are unknown, which makes the files truncated until their
+	// content is downloaded.
+	for _, name := range doc.Files {
+		gist.Files[name] = GistFile{
+			Filename:  name,
+			RawURL:    htmlURL + "/raw/" + url.PathEscape(name),
+			Truncated: true,
+		}
+	}
+
+	return gist, resp.Header.Get("ETag"), nil
+}
+
+func (g *rawGetter) GetGistRevision(ctx context.Context, id, sha string) (*Gist, error) {
+	return nil, ErrRevisionsUnsupported
+}
@@ -0,0 +1,119 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestNewAnonymous(t *testing.T) {
+	gfs := NewAnonymous(referenceGistID, WithToken("s3cr3t"))
+	if _, ok := gfs.getter.(*rawGetter); !ok {
+		t.Fatalf("NewAnonymous returned a FS with getter %T, want a *rawGetter", gfs.getter)
+	}
+}
+
+func TestNewAnonymousUserAgent(t *testing.T) {
+	fg, _ := newFakeGist(t, map[string]string{"a.txt": "a"})
+
+	gfs := NewAnonymous(referenceGistID, WithUserAgent("gistfs-test/1.0"), withBaseHTTPClient(fg.Client()))
+	getter := gfs.getter.(*rawGetter)
+	getter.baseURL, _ = url.Parse(fg.URL + "/")
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading anonymously, expected no error but got %#v", err)
+	}
+
+	if got, want := fg.LastHeader().Get("User-Agent"), "gistfs-test/1.0"; got != want {
+		t.Fatalf("Loading anonymously with a user agent, got User-Agent %#v, want %#v", got, want)
+	}
+}
+
+func TestNewAnonymousMaxTotalSize(t *testing.T) {
+	fg, _ := newFakeGist(t, map[string]string{"a.txt": "aaaa", "b.txt": "bbbb"})
+
+	newFS := func(n int64) *FS {
+		gfs := NewAnonymous(referenceGistID, WithMaxTotalSize(n), withBaseHTTPClient(fg.Client()))
+		gfs.getter.(*rawGetter).baseURL, _ = url.Parse(fg.URL + "/")
+		return gfs
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		if err := newFS(8).Load(context.Background()); err != nil {
+			t.Fatalf("Loading anonymously within the limit, expected no error but got %#v", err)
+		}
+	})
+
+	t.Run("NOK too large", func(t *testing.T) {
+		var sizeErr *SizeError
+		err := newFS(7).Load(context.Background())
+		if !errors.As(err, &sizeErr) || sizeErr.File != "" || sizeErr.Limit != 7 {
+			t.Fatalf("Loading anonymously over the limit, got %#v, want a *SizeError for the whole gist", err)
+		}
+	})
+}
+
+func TestRawGetter(t *testing.T) {
+	fg, _ := newFakeGist(t, map[string]string{"a.txt": "a", "b c.txt": "bc"})
+	fg.Description = "anonymous"
+
+	getter, err := NewRawGetter(fg.Client(), fg.URL)
+	if err != nil {
+		t.Fatalf("Creating a raw getter, expected no error but got %#v", err)
+	}
+
+	t.Run("OK load", func(t *testing.T) {
+		gfs := NewWithGetter(getter, referenceGistID)
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading anonymously, expected no error but got %#v", err)
+		}
+
+		for name, want := range map[string]string{"a.txt": "a", "b c.txt": "bc"} {
+			b, err := gfs.ReadFile(name)
+			if err != nil || string(b) != want {
+				t.Fatalf("Reading %s, got %q (%v), want %q", name, b, err, want)
+			}
+		}
+
+		info, _ := gfs.Stat("b c.txt")
+		if got, want := info.Size(), int64(2); got != want {
+			t.Fatalf("Stating a loaded file, got size %d, want %d", got, want)
+		}
+		if got, want := gfs.Description(), "anonymous"; got != want {
+			t.Fatalf("Loading anonymously, got description %q, want %q", got, want)
+		}
+
+		requests := fg.Requests()
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading again, expected no error but got %#v", err)
+		}
+		if got, want := fg.Requests()-requests, 1; got != want {
+			t.Fatalf("Loading an unchanged gist again, got %d requests, want %d", got, want)
+		}
+	})
+
+	t.Run("OK load metadata", func(t *testing.T) {
+		gfs := NewWithGetter(getter, referenceGistID)
+		if err := gfs.LoadMetadata(context.Background()); err != nil {
+			t.Fatalf("Loading metadata anonymously, expected no error but got %#v", err)
+		}
+
+		if b, err := gfs.ReadFile("a.txt"); err != nil || string(b) != "a" {
+			t.Fatalf("Reading a.txt, got %q (%v), want %q", b, err, "a")
+		}
+	})
+
+	t.Run("NOK revision", func(t *testing.T) {
+		gfs := NewWithGetter(getter, referenceGistID, WithRevision("abc"))
+		if err := gfs.Load(context.Background()); !errors.Is(err, ErrRevisionsUnsupported) {
+			t.Fatalf("Loading a revision anonymously, got %#v, want %#v", err, ErrRevisionsUnsupported)
+		}
+	})
+
+	t.Run("NOK missing gist", func(t *testing.T) {
+		gfs := NewWithGetter(getter, "missing")
+		if err := gfs.Load(context.Background()); !errors.Is(err, ErrGistNotFound) {
+			t.Fatalf("Loading a missing gist anonymously, got %#v, want %#v", err, ErrGistNotFound)
+		}
+	})
+}
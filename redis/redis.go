@@ -0,0 +1,274 @@
+// Package redis provides a gistfs.Notifier publishing the changes of gists
+// over Redis pub/sub, so that the instances of a service refresh them
+// together rather than each polling Github:
+//
+//	n := redis.New("localhost:6379")
+//	gfs := gistfs.New(id, gistfs.WithNotifier(n), gistfs.WithTTL(time.Hour))
+//	...
+//	go gistfs.Listen(ctx, n, gfs)
+//
+// It speaks the Redis protocol itself, needing no client library.
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultChannel is the channel notifications are published on, unless set
+// otherwise with WithChannel.
+const DefaultChannel = "gistfs"
+
+// Option configures a Notifier.
+type Option func(*Notifier)
+
+// WithChannel publishes and subscribes to notifications on the named
+// channel, instead of DefaultChannel, such as to keep the notifications of
+// several services apart.
+func WithChannel(name string) Option {
+	return func(n *Notifier) {
+		n.channel = name
+	}
+}
+
+// WithPassword authenticates the connections to Redis with password.
+func WithPassword(password string) Option {
+	return func(n *Notifier) {
+		n.password = password
+	}
+}
+
+// WithDialer opens the connections to Redis with dial, such as to connect
+// over TLS, rather than with a net.Dialer.
+func WithDialer(dial func(ctx context.Context, network, addr string) (net.Conn, error)) Option {
+	return func(n *Notifier) {
+		n.dial = dial
+	}
+}
+
+// Error is an error replied by Redis.
+type Error string
+
+func (e Error) Error() string { return "redis: " + string(e) }
+
+// Notifier is a gistfs.Notifier publishing notifications on a Redis
+// channel. Publish reuses a single connection, while each call to Subscribe
+// opens its own.
+type Notifier struct {
+	addr     string
+	channel  string
+	password string
+	dial     func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// mu guards conn, the connection publications are made on.
+	mu   sync.Mutex
+	conn *conn
+}
+
+// New returns a Notifier connecting to the Redis server at addr, as
+// host:port.
+func New(addr string, opts ...Option) *Notifier {
+	var d net.Dialer
+	n := &Notifier{addr: addr, channel: DefaultChannel, dial: d.DialContext}
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	return n
+}
+
+// Publish implements gistfs.Notifier.
+func (n *Notifier) Publish(ctx context.Context, id string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	// A connection left idle may have been closed by the server, in which
+	// case the publication is tried again on a new one.
+	for retry := n.conn != nil; ; retry = false {
+		if n.conn == nil {
+			c, err := n.open(ctx)
+			if err != nil {
+				return err
+			}
+			n.conn = c
+		}
+
+		_, err := n.conn.do(ctx, "PUBLISH", n.channel, id)
+		var replyErr Error
+		if err == nil || errors.As(err, &replyErr) {
+			return err
+		}
+
+		n.conn.Close()
+		n.conn = nil
+		if !retry || ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+// Subscribe implements gistfs.Notifier. It returns the error of its
+// connection once it is lost, and can be called again to subscribe on
+// a new one.
+func (n *Notifier) Subscribe(ctx context.Context, fn func(id string)) error {
+	c, err := n.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if _, err := c.do(ctx, "SUBSCRIBE", n.channel); err != nil {
+		return err
+	}
+
+	// Closing the connection unblocks the read of the next message.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		reply, err := c.read()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		msg, ok := reply.([]interface{})
+		if !ok || len(msg) != 3 || msg[0] != "message" {
+			continue
+		}
+		if id, ok := msg[2].(string); ok {
+			fn(id)
+		}
+	}
+}
+
+// Close closes the connection used to publish notifications, if any.
+func (n *Notifier) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn == nil {
+		return nil
+	}
+
+	err := n.conn.Close()
+	n.conn = nil
+
+	return err
+}
+
+// open opens an authenticated connection to Redis.
+func (n *Notifier) open(ctx context.Context) (*conn, error) {
+	nc, err := n.dial(ctx, "tcp", n.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &conn{Conn: nc, r: bufio.NewReader(nc)}
+	if n.password != "" {
+		if _, err := c.do(ctx, "AUTH", n.password); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// conn is a connection to Redis.
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// do sends a command and returns its reply, giving up once ctx is done.
+func (c *conn) do(ctx context.Context, args ...string) (interface{}, error) {
+	deadline, _ := ctx.Deadline()
+	if err := c.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	defer c.SetDeadline(time.Time{})
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.SetDeadline(time.Unix(1, 0))
+		case <-stop:
+		}
+	}()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c, b.String()); err != nil {
+		return nil, err
+	}
+
+	return c.read()
+}
+
+// read reads a reply, returning replied errors as an Error.
+func (c *conn) read() (interface{}, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, Error(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:size]), nil
+	case '*':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 {
+			return nil, err
+		}
+		elems := make([]interface{}, size)
+		for i := range elems {
+			if elems[i], err = c.read(); err != nil {
+				return nil, err
+			}
+		}
+		return elems, nil
+	default:
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+}
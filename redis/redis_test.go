@@ -0,0 +1,332 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jhchabran/gistfs"
+	"github.com/jhchabran/gistfs/gisttest"
+)
+
+// fakeRedis is a Redis server implementing AUTH, PUBLISH and SUBSCRIBE.
+type fakeRedis struct {
+	net.Listener
+	password string
+
+	mu          sync.Mutex
+	subscribers map[string][]net.Conn
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listening, expected no error but got %#v", err)
+	}
+
+	s := &fakeRedis{Listener: l, password: password, subscribers: map[string][]net.Conn{}}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(c)
+		}
+	}()
+
+	return s
+}
+
+// countSubscribers returns the number of subscribers of channel.
+func (s *fakeRedis) countSubscribers(channel string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.subscribers[channel])
+}
+
+// dropSubscribers closes the connections of the subscribers of channel, as
+// a restarting server would.
+func (s *fakeRedis) dropSubscribers(channel string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.subscribers[channel] {
+		c.Close()
+	}
+	delete(s.subscribers, channel)
+}
+
+func (s *fakeRedis) serve(c net.Conn) {
+	defer c.Close()
+
+	r := bufio.NewReader(c)
+	authenticated := s.password == ""
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+
+		switch strings.ToUpper(args[0]) {
+		case "AUTH":
+			if args[1] != s.password {
+				fmt.Fprint(c, "-WRONGPASS invalid password\r\n")
+				continue
+			}
+			authenticated = true
+			fmt.Fprint(c, "+OK\r\n")
+		case "PUBLISH":
+			if !authenticated {
+				fmt.Fprint(c, "-NOAUTH Authentication required.\r\n")
+				continue
+			}
+			s.mu.Lock()
+			subs := s.subscribers[args[1]]
+			for _, sub := range subs {
+				fmt.Fprintf(sub, "*3\r\n$7\r\nmessage\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n", len(args[1]), args[1], len(args[2]), args[2])
+			}
+			s.mu.Unlock()
+			fmt.Fprintf(c, ":%d\r\n", len(subs))
+		case "SUBSCRIBE":
+			if !authenticated {
+				fmt.Fprint(c, "-NOAUTH Authentication required.\r\n")
+				continue
+			}
+			s.mu.Lock()
+			fmt.Fprintf(c, "*3\r\n$9\r\nsubscribe\r\n$%d\r\n%s\r\n:1\r\n", len(args[1]), args[1])
+			s.subscribers[args[1]] = append(s.subscribers[args[1]], c)
+			s.mu.Unlock()
+		default:
+			fmt.Fprintf(c, "-ERR unknown command '%s'\r\n", args[0])
+		}
+	}
+}
+
+// readCommand reads a command sent as an array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+
+	return args, nil
+}
+
+// waitFor fails the test if cond does not become true within a second.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met after 1s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestNotifier(t *testing.T) {
+	t.Run("OK publish and subscribe", func(t *testing.T) {
+		srv := newFakeRedis(t, "secret")
+		pub := New(srv.Addr().String(), WithPassword("secret"), WithChannel("gists"))
+		defer pub.Close()
+		sub := New(srv.Addr().String(), WithPassword("secret"), WithChannel("gists"))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		ids := make(chan string, 2)
+		done := make(chan error)
+		go func() { done <- sub.Subscribe(ctx, func(id string) { ids <- id }) }()
+		waitFor(t, func() bool { return srv.countSubscribers("gists") == 1 })
+
+		for _, id := range []string{"a", "b"} {
+			if err := pub.Publish(context.Background(), id); err != nil {
+				t.Fatalf("Publishing, expected no error but got %#v", err)
+			}
+			if got := <-ids; got != id {
+				t.Fatalf("Subscribing, got %q, want %q", got, id)
+			}
+		}
+
+		cancel()
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Fatalf("Subscribing, got %#v, want context.Canceled", err)
+		}
+	})
+
+	t.Run("OK reconnects", func(t *testing.T) {
+		srv := newFakeRedis(t, "")
+		pub := New(srv.Addr().String())
+
+		if err := pub.Publish(context.Background(), "a"); err != nil {
+			t.Fatalf("Publishing, expected no error but got %#v", err)
+		}
+		pub.conn.Conn.Close()
+
+		if err := pub.Publish(context.Background(), "b"); err != nil {
+			t.Fatalf("Publishing on a closed connection, expected no error but got %#v", err)
+		}
+	})
+
+	t.Run("OK subscribe again", func(t *testing.T) {
+		srv := newFakeRedis(t, "")
+		pub := New(srv.Addr().String())
+		defer pub.Close()
+		sub := New(srv.Addr().String())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ids := make(chan string, 1)
+		done := make(chan error)
+		go func() { done <- sub.Subscribe(ctx, func(id string) { ids <- id }) }()
+		waitFor(t, func() bool { return srv.countSubscribers(DefaultChannel) == 1 })
+
+		srv.dropSubscribers(DefaultChannel)
+		if err := <-done; err == nil || errors.Is(err, context.Canceled) {
+			t.Fatalf("Subscribing on a dropped connection, got %#v, want the error of the connection", err)
+		}
+
+		go func() { done <- sub.Subscribe(ctx, func(id string) { ids <- id }) }()
+		waitFor(t, func() bool { return srv.countSubscribers(DefaultChannel) == 1 })
+
+		if err := pub.Publish(context.Background(), "a"); err != nil {
+			t.Fatalf("Publishing, expected no error but got %#v", err)
+		}
+		if got := <-ids; got != "a" {
+			t.Fatalf("Subscribing again, got %q, want %q", got, "a")
+		}
+
+		cancel()
+		<-done
+	})
+
+	t.Run("NOK subscribe unauthenticated", func(t *testing.T) {
+		srv := newFakeRedis(t, "secret")
+		sub := New(srv.Addr().String())
+
+		var replyErr Error
+		if err := sub.Subscribe(context.Background(), func(string) {}); !errors.As(err, &replyErr) || !strings.HasPrefix(string(replyErr), "NOAUTH") {
+			t.Fatalf("Subscribing without a password, got %#v, want a NOAUTH Error", err)
+		}
+	})
+
+	t.Run("NOK wrong password", func(t *testing.T) {
+		srv := newFakeRedis(t, "secret")
+		pub := New(srv.Addr().String(), WithPassword("wrong"))
+
+		var replyErr Error
+		if err := pub.Publish(context.Background(), "a"); !errors.As(err, &replyErr) {
+			t.Fatalf("Publishing, got %#v, want an Error", err)
+		}
+	})
+
+	t.Run("OK refreshes gists", func(t *testing.T) {
+		srv := newFakeRedis(t, "")
+		gists := gisttest.NewServer(t)
+		g := gists.AddGist(gisttest.GistID, map[string]string{"a.txt": "a"})
+
+		// Each filesystem stands for another instance of a service, with its
+		// own connection to Redis.
+		a := gistfs.NewWithClient(gists.GithubClient(), gisttest.GistID, gistfs.WithNotifier(New(srv.Addr().String())))
+		b := gistfs.NewWithClient(gists.GithubClient(), gisttest.GistID)
+		a.Load(context.Background())
+		b.Load(context.Background())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go gistfs.Listen(ctx, New(srv.Addr().String()), b)
+		waitFor(t, func() bool { return srv.countSubscribers(DefaultChannel) == 1 })
+
+		g.SetFiles(map[string]string{"a.txt": "b"})
+		if err := a.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		waitFor(t, func() bool {
+			got, _ := b.ReadFile("a.txt")
+			return string(got) == "b"
+		})
+	})
+}
+
+func TestRead(t *testing.T) {
+	tests := map[string]struct {
+		reply string
+		want  interface{}
+	}{
+		"simple string": {"+OK\r\n", "OK"},
+		"integer":       {":42\r\n", int64(42)},
+		"bulk string":   {"$8\r\na\r\nb c d\r\n", "a\r\nb c d"},
+		"empty bulk":    {"$0\r\n\r\n", ""},
+		"nil bulk":      {"$-1\r\n", nil},
+		"nil array":     {"*-1\r\n", nil},
+		"array":         {"*3\r\n$1\r\na\r\n$-1\r\n:1\r\n", []interface{}{"a", nil, int64(1)}},
+	}
+	for name, tt := range tests {
+		t.Run("OK "+name, func(t *testing.T) {
+			c := &conn{r: bufio.NewReader(strings.NewReader(tt.reply))}
+
+			got, err := c.read()
+			if err != nil {
+				t.Fatalf("Reading %q, expected no error but got %#v", tt.reply, err)
+			}
+			if fmt.Sprintf("%#v", got) != fmt.Sprintf("%#v", tt.want) {
+				t.Fatalf("Reading %q, got %#v, want %#v", tt.reply, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("NOK error reply", func(t *testing.T) {
+		c := &conn{r: bufio.NewReader(strings.NewReader("-ERR unknown command 'FOO'\r\n+OK\r\n"))}
+
+		if _, err := c.read(); err != Error("ERR unknown command 'FOO'") {
+			t.Fatalf("Reading an error reply, got %#v, want an Error", err)
+		}
+		if got, err := c.read(); err != nil || got != "OK" {
+			t.Fatalf("Reading the reply following an error, got %#v (%v), want %#v", got, err, "OK")
+		}
+	})
+
+	for name, reply := range map[string]string{
+		"malformed":       "?\r\n",
+		"empty":           "\r\n",
+		"truncated bulk":  "$5\r\nab",
+		"truncated array": "*2\r\n+a\r\n",
+		"bad size":        "$x\r\n",
+	} {
+		t.Run("NOK "+name, func(t *testing.T) {
+			c := &conn{r: bufio.NewReader(strings.NewReader(reply))}
+			if _, err := c.read(); err == nil {
+				t.Fatalf("Reading %q, got no error, want one", reply)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package gistfs
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// freshness tells how long loaded content is served before being refreshed,
+// and revalidates it in the background once it expired. It is shared by FS
+// and CacheFS.
+type freshness struct {
+	// ttl is how long loaded content is considered fresh, zero meaning
+	// forever.
+	ttl time.Duration
+	// staleIfError is how long expired content is still served when
+	// refreshing it fails.
+	staleIfError time.Duration
+	// refreshing is set while a background refresh is running.
+	refreshing int32
+}
+
+// revalidate runs refresh in the background if content loaded at loadedAt
+// expired at now, and no refresh is already running.
+func (f *freshness) revalidate(now, loadedAt time.Time, refresh func()) {
+	if f.ttl <= 0 || now.Sub(loadedAt) <= f.ttl {
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&f.refreshing, 0, 1) {
+		return
+	}
+
+	go func() {
+		defer atomic.StoreInt32(&f.refreshing, 0)
+		refresh()
+	}()
+}
+
+// expiredErr returns refreshErr, the error of the last refresh, if content
+// loaded at loadedAt expired at now and can no longer be served as stale.
+func (f *freshness) expiredErr(now, loadedAt time.Time, refreshErr error) error {
+	if f.ttl <= 0 || refreshErr == nil {
+		return nil
+	}
+
+	if now.Sub(loadedAt) > f.ttl+f.staleIfError {
+		return refreshErr
+	}
+
+	return nil
+}
+
+// revalidate starts a background refresh if the loaded gist is older than
+// the configured TTL and no refresh is already running.
+func (fsys *FS) revalidate() {
+	s := fsys.state()
+	if s.gist == nil {
+		return
+	}
+
+	deferContent := s.extra.deferContent
+	fsys.freshness.revalidate(fsys.now(), s.loadedAt, func() {
+		ctx, span := fsys.startSpan(context.Background(), "gistfs.Refresh", "")
+		fsys.log(ctx, levelDebug, "refreshing expired gist", "age", fsys.now().Sub(s.loadedAt))
+		err := fsys.loadShared(ctx, deferContent)
+		endSpan(span, -1, err)
+		if fsys.metrics != nil {
+			fsys.metrics.ObserveRefresh(fsys.GetID(), err)
+		}
+	})
+}
+
+// unavailableErr returns ErrNotLoaded if no gist was loaded, the error of
+// expiredErr, or a *StaleError if the gist is older than WithMaxAge allows.
+func (fsys *FS) unavailableErr(s *state) error {
+	if s.gist == nil {
+		return ErrNotLoaded
+	}
+
+	if err := fsys.expiredErr(s); err != nil {
+		return err
+	}
+
+	if age := fsys.now().Sub(s.loadedAt); fsys.maxAge > 0 && age > fsys.maxAge {
+		return &StaleError{Age: age, MaxAge: fsys.maxAge, Err: s.refreshErr}
+	}
+
+	return nil
+}
+
+// refreshTooOld loads the gist again if it is older than allowed by
+// WithMaxAgeRefresh. A failed load is recorded as the refresh error of the
+// state, which unavailableErr reports.
+func (fsys *FS) refreshTooOld(ctx context.Context) {
+	if !fsys.maxAgeRefresh {
+		return
+	}
+
+	s := fsys.state()
+	if s.gist == nil || fsys.now().Sub(s.loadedAt) <= fsys.maxAge {
+		return
+	}
+
+	_ = fsys.loadShared(ctx, s.extra.deferContent)
+}
+
+// expiredErr returns the error of the last refresh if the gist of s expired
+// and can no longer be served as stale content.
+func (fsys *FS) expiredErr(s *state) error {
+	return fsys.freshness.expiredErr(fsys.now(), s.loadedAt, s.refreshErr)
+}
+
+// IsLoaded reports whether the filesystem serves a gist, that is whether a
+// load succeeded, whatever happened to refreshes since.
+func (fsys *FS) IsLoaded() bool {
+	s := fsys.state()
+	return s.gist != nil || s.evicted != nil
+}
+
+// LastLoadedAt returns when the gist being served was last loaded
+// successfully, or the zero time if the filesystem is not loaded.
+func (fsys *FS) LastLoadedAt() time.Time {
+	s := fsys.state()
+	if s.gist == nil && s.evicted == nil {
+		return time.Time{}
+	}
+
+	return s.loadedAt
+}
+
+// Stale reports whether the last refresh failed, meaning that the content
+// being served is older than the gist it was loaded from.
+func (fsys *FS) Stale() bool {
+	s := fsys.state()
+	return s.gist != nil && s.refreshErr != nil
+}
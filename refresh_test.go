@@ -0,0 +1,314 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for time dependent tests.
+type fakeClock struct {
+	t  time.Time
+	mu sync.Mutex
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{t: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.t
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.t = c.t.Add(d)
+}
+
+// eventually fails the test if cond does not become true within a second.
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met after 1s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+	clock := newFakeClock()
+
+	gfs := NewWithClient(client, referenceGistID, WithTTL(time.Minute))
+	gfs.now = clock.Now
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+
+	fg.SetFiles(map[string]string{"a.txt": "b"})
+
+	t.Run("OK fresh", func(t *testing.T) {
+		clock.Advance(30 * time.Second)
+
+		b, _ := gfs.ReadFile("a.txt")
+		if got, want := string(b), "a"; got != want {
+			t.Fatalf("Reading within TTL, got %#v, want %#v", got, want)
+		}
+
+		if got, want := fg.Requests(), 1; got != want {
+			t.Fatalf("Reading within TTL, got %d API requests, want %d", got, want)
+		}
+	})
+
+	t.Run("OK stale while revalidate", func(t *testing.T) {
+		clock.Advance(time.Minute)
+
+		b, _ := gfs.ReadFile("a.txt")
+		if got, want := string(b), "a"; got != want {
+			t.Fatalf("Reading after TTL, got %#v, want the stale %#v", got, want)
+		}
+
+		eventually(t, func() bool {
+			b, _ := gfs.ReadFile("a.txt")
+			return string(b) == "b"
+		})
+
+		if got, want := fg.Requests(), 2; got != want {
+			t.Fatalf("Reading after TTL, got %d API requests, want %d", got, want)
+		}
+	})
+}
+
+func TestStaleIfError(t *testing.T) {
+	tests := []struct {
+		name         string
+		staleIfError time.Duration
+		wantErr      bool
+	}{
+		{"OK within grace period", time.Hour, false},
+		{"NOK without grace period", 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+			clock := newFakeClock()
+
+			gfs := NewWithClient(client, referenceGistID, WithTTL(time.Minute), WithStaleIfError(test.staleIfError))
+			gfs.now = clock.Now
+			gfs.Load(context.Background())
+
+			if gfs.Stale() {
+				t.Fatal("Checking staleness after a successful load, got true, want false")
+			}
+
+			fg.SetStatus(http.StatusBadGateway)
+			clock.Advance(2 * time.Minute)
+
+			gfs.ReadFile("a.txt")
+			eventually(t, gfs.Stale)
+
+			b, err := gfs.ReadFile("a.txt")
+			if test.wantErr && err == nil {
+				t.Fatal("Reading expired content after a failed refresh, got no error, want one")
+			}
+
+			if !test.wantErr && string(b) != "a" {
+				t.Fatalf("Reading stale content after a failed refresh, got %#v (%v), want %#v", string(b), err, "a")
+			}
+
+			fg.SetStatus(0)
+			eventually(t, func() bool {
+				b, _ := gfs.ReadFile("a.txt")
+				return !gfs.Stale() && string(b) == "a"
+			})
+		})
+	}
+}
+
+func TestWithFallback(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+	clock := newFakeClock()
+
+	fallback := fstest.MapFS{"a.txt": {Data: []byte("offline a")}, "b.txt": {Data: []byte("offline b")}}
+	gfs := NewWithClient(client, referenceGistID, WithTTL(time.Minute), WithFallback(fallback))
+	gfs.now = clock.Now
+
+	read := func(name string) string {
+		t.Helper()
+
+		b, err := gfs.ReadFile(name)
+		if err != nil {
+			t.Fatalf("Reading %s, expected no error but got %#v", name, err)
+		}
+
+		return string(b)
+	}
+
+	t.Run("OK not loaded", func(t *testing.T) {
+		if got, want := read("a.txt"), "offline a"; got != want {
+			t.Fatalf("Reading, got %#v, want %#v", got, want)
+		}
+
+		if _, err := fs.Stat(gfs, "b.txt"); err != nil {
+			t.Fatalf("Stating, expected no error but got %#v", err)
+		}
+
+		entries, err := gfs.ReadDir(".")
+		if err != nil || len(entries) != 2 {
+			t.Fatalf("Reading the root directory, got %v (%v), want the fallback entries", entries, err)
+		}
+	})
+
+	t.Run("OK loaded", func(t *testing.T) {
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		if got, want := read("a.txt"), "a"; got != want {
+			t.Fatalf("Reading, got %#v, want %#v", got, want)
+		}
+		if _, err := gfs.ReadFile("b.txt"); err == nil {
+			t.Fatal("Reading a file only in the fallback, expected an error but got none")
+		}
+	})
+
+	t.Run("OK expired", func(t *testing.T) {
+		fg.SetStatus(http.StatusBadGateway)
+		clock.Advance(2 * time.Minute)
+
+		gfs.ReadFile("a.txt")
+		eventually(t, gfs.Stale)
+
+		if got, want := read("a.txt"), "offline a"; got != want {
+			t.Fatalf("Reading, got %#v, want %#v", got, want)
+		}
+
+		fg.SetStatus(0)
+		eventually(t, func() bool {
+			b, _ := gfs.ReadFile("a.txt")
+			return string(b) == "a"
+		})
+	})
+}
+
+func TestIsLoaded(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+	clock := newFakeClock()
+
+	gfs := NewWithClient(client, referenceGistID)
+	gfs.now = clock.Now
+
+	if gfs.IsLoaded() || !gfs.LastLoadedAt().IsZero() {
+		t.Fatalf("Before loading, got loaded %v at %v, want not loaded", gfs.IsLoaded(), gfs.LastLoadedAt())
+	}
+
+	fg.FailNext(1, http.StatusBadGateway)
+	if err := gfs.Load(context.Background()); err == nil {
+		t.Fatal("Loading, expected an error but got none")
+	}
+	if gfs.IsLoaded() || !gfs.LastLoadedAt().IsZero() {
+		t.Fatalf("After a failed load, got loaded %v at %v, want not loaded", gfs.IsLoaded(), gfs.LastLoadedAt())
+	}
+
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+	loadedAt := clock.Now()
+
+	clock.Advance(time.Minute)
+	fg.FailNext(1, http.StatusBadGateway)
+	gfs.Load(context.Background())
+
+	if !gfs.IsLoaded() {
+		t.Fatal("After a failed refresh, got not loaded, want loaded")
+	}
+	if got := gfs.LastLoadedAt(); !got.Equal(loadedAt) {
+		t.Fatalf("After a failed refresh, got last loaded at %v, want %v", got, loadedAt)
+	}
+}
+
+func TestMaxAge(t *testing.T) {
+	t.Run("NOK stale", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		clock := newFakeClock()
+
+		gfs := NewWithClient(client, referenceGistID, WithMaxAge(time.Minute))
+		gfs.now = clock.Now
+		gfs.Load(context.Background())
+
+		clock.Advance(30 * time.Second)
+		if _, err := gfs.ReadFile("a.txt"); err != nil {
+			t.Fatalf("Reading within max age, expected no error but got %#v", err)
+		}
+
+		clock.Advance(time.Minute)
+		_, err := gfs.ReadFile("a.txt")
+		var staleErr *StaleError
+		if !errors.Is(err, ErrStale) || !errors.As(err, &staleErr) {
+			t.Fatalf("Reading after max age, got %#v, want a *StaleError", err)
+		}
+		if got, want := staleErr.Age, 90*time.Second; got != want {
+			t.Fatalf("Reading after max age, got age %v, want %v", got, want)
+		}
+		if _, err := gfs.ReadDir("."); !errors.Is(err, ErrStale) {
+			t.Fatalf("Listing after max age, got %#v, want ErrStale", err)
+		}
+		if got, want := fg.Requests(), 1; got != want {
+			t.Fatalf("Reading after max age, got %d API requests, want %d", got, want)
+		}
+
+		gfs.Load(context.Background())
+		if _, err := gfs.ReadFile("a.txt"); err != nil {
+			t.Fatalf("Reading once loaded again, expected no error but got %#v", err)
+		}
+	})
+
+	t.Run("OK fallback", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		clock := newFakeClock()
+
+		gfs := NewWithClient(client, referenceGistID, WithMaxAge(time.Minute), WithFallback(fstest.MapFS{"a.txt": {Data: []byte("fallback")}}))
+		gfs.now = clock.Now
+		gfs.Load(context.Background())
+
+		clock.Advance(2 * time.Minute)
+		if b, _ := gfs.ReadFile("a.txt"); string(b) != "fallback" {
+			t.Fatalf("Reading after max age, got %q, want %q", b, "fallback")
+		}
+	})
+
+	t.Run("OK refresh", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		clock := newFakeClock()
+
+		gfs := NewWithClient(client, referenceGistID, WithMaxAgeRefresh(time.Minute))
+		gfs.now = clock.Now
+		gfs.Load(context.Background())
+
+		fg.SetFiles(map[string]string{"a.txt": "b"})
+		clock.Advance(2 * time.Minute)
+		if b, err := gfs.ReadFile("a.txt"); err != nil || string(b) != "b" {
+			t.Fatalf("Reading after max age, got %q (%v), want %q", b, err, "b")
+		}
+
+		fg.SetStatus(http.StatusNotFound)
+		clock.Advance(2 * time.Minute)
+		if _, err := gfs.ReadFile("a.txt"); !errors.Is(err, ErrStale) || !errors.Is(err, ErrGistNotFound) {
+			t.Fatalf("Reading after a failed refresh, got %#v, want ErrStale wrapping ErrGistNotFound", err)
+		}
+	})
+}
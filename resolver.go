@@ -0,0 +1,125 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// ResolverScheme is the scheme of the URLs opened by a Resolver.
+const ResolverScheme = "gist://"
+
+// Resolver opens files across gists, given URLs such as
+// "gist://<id>/<file>" naming both the gist and the file, for code that
+// references files of several gists, such as templates including each
+// other.
+//
+// The filesystems of the gists are created and loaded by a Manager the first
+// time one of their files is opened, and reused afterwards. They are
+// refreshed as configured by the options of the Manager, such as WithTTL,
+// or by Manager.RefreshEvery.
+type Resolver struct {
+	m *Manager
+}
+
+// NewResolver returns a Resolver opening files with the filesystems handed
+// out by m.
+func NewResolver(m *Manager) *Resolver {
+	return &Resolver{m: m}
+}
+
+// Manager returns the Manager of the filesystems of r.
+func (r *Resolver) Manager() *Manager {
+	return r.m
+}
+
+// Open opens the file at rawurl, as "gist://<id>/<file>", loading the gist
+// first if needed. URLs without a file, such as "gist://<id>", open the root
+// directory of the gist.
+func (r *Resolver) Open(rawurl string) (fs.File, error) {
+	return r.OpenContext(context.Background(), rawurl)
+}
+
+// OpenContext opens the file at rawurl as Open does, loading the gist and
+// opening the file with ctx.
+func (r *Resolver) OpenContext(ctx context.Context, rawurl string) (fs.File, error) {
+	fsys, name, err := r.resolve(ctx, "open", rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := fsys.OpenContext(ctx, name)
+	return f, withURL(err, rawurl)
+}
+
+// ReadFile reads and returns the content of the file at rawurl, as
+// "gist://<id>/<file>", loading the gist first if needed.
+func (r *Resolver) ReadFile(rawurl string) ([]byte, error) {
+	return r.ReadFileContext(context.Background(), rawurl)
+}
+
+// ReadFileContext reads the file at rawurl as ReadFile does, loading the
+// gist and reading the file with ctx.
+func (r *Resolver) ReadFileContext(ctx context.Context, rawurl string) ([]byte, error) {
+	fsys, name, err := r.resolve(ctx, "read", rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := fsys.ReadFileContext(ctx, name)
+	return b, withURL(err, rawurl)
+}
+
+// resolve returns the filesystem of the gist rawurl points to, loaded, and
+// the name of the file it points to in it. URLs that can't be parsed are
+// reported with a *fs.PathError describing op.
+func (r *Resolver) resolve(ctx context.Context, op, rawurl string) (*FS, string, error) {
+	id, name, err := ParseResolverURL(rawurl)
+	if err != nil {
+		return nil, "", &fs.PathError{Op: op, Path: rawurl, Err: err}
+	}
+
+	fsys, err := r.m.Get(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return fsys, name, nil
+}
+
+// ParseResolverURL returns the gist ID and the name of the file a
+// "gist://<id>/<file>" URL points to. The name is "." for URLs without a
+// file. Errors match fs.ErrInvalid with errors.Is.
+func ParseResolverURL(rawurl string) (id, name string, err error) {
+	if !strings.HasPrefix(rawurl, ResolverScheme) {
+		return "", "", fmt.Errorf("%w: %q does not start with %s", fs.ErrInvalid, rawurl, ResolverScheme)
+	}
+
+	rest := rawurl[len(ResolverScheme):]
+	id, name = rest, "."
+	if i := strings.Index(rest, "/"); i >= 0 {
+		id = rest[:i]
+		if rest[i+1:] != "" {
+			name = rest[i+1:]
+		}
+	}
+
+	if id == "" {
+		return "", "", fmt.Errorf("%w: %q does not name a gist", fs.ErrInvalid, rawurl)
+	}
+
+	return id, name, nil
+}
+
+// withURL returns err with the path of the file it reports, if any, replaced
+// by rawurl, so that errors tell which gist the file was looked up in.
+func withURL(err error, rawurl string) error {
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) || err != error(pathErr) {
+		return err
+	}
+
+	return &fs.PathError{Op: pathErr.Op, Path: rawurl, Err: pathErr.Err}
+}
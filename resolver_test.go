@@ -0,0 +1,73 @@
+package gistfs
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/jhchabran/gistfs/internal/gistserver"
+)
+
+func TestResolver(t *testing.T) {
+	srv := gistserver.New()
+	t.Cleanup(srv.Close)
+
+	srv.AddGist("first", map[string]string{"a.txt": "first"})
+	srv.AddGist("second", map[string]string{"a.txt": "second", "b__c.txt": "c"})
+
+	r := NewResolver(NewManager(srv.GithubClient(), WithPathSeparator("__")))
+
+	t.Run("OK read", func(t *testing.T) {
+		tests := map[string]string{
+			"gist://first/a.txt":    "first",
+			"gist://second/a.txt":   "second",
+			"gist://second/b/c.txt": "c",
+		}
+		for rawurl, want := range tests {
+			b, err := r.ReadFile(rawurl)
+			if err != nil || string(b) != want {
+				t.Fatalf("Reading %s, got %q (%v), want %q", rawurl, b, err, want)
+			}
+		}
+
+		if got, want := srv.Requests(), 2; got != want {
+			t.Fatalf("Reading files of two gists, got %d requests, want %d", got, want)
+		}
+	})
+
+	t.Run("OK open root", func(t *testing.T) {
+		for _, rawurl := range []string{"gist://first", "gist://first/"} {
+			f, err := r.Open(rawurl)
+			if err != nil {
+				t.Fatalf("Opening %s, expected no error but got %#v", rawurl, err)
+			}
+
+			if info, _ := f.Stat(); !info.IsDir() {
+				t.Fatalf("Opening %s, got %v, want the root directory", rawurl, info)
+			}
+		}
+	})
+
+	t.Run("NOK missing file", func(t *testing.T) {
+		_, err := r.Open("gist://first/missing.txt")
+
+		var pathErr *fs.PathError
+		if !errors.As(err, &pathErr) || pathErr.Path != "gist://first/missing.txt" || !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("Opening a missing file, got %#v, want a *fs.PathError holding its URL", err)
+		}
+	})
+
+	t.Run("NOK invalid URL", func(t *testing.T) {
+		for _, rawurl := range []string{"https://gist.github.com/first", "gist:///a.txt"} {
+			if _, err := r.ReadFile(rawurl); !errors.Is(err, fs.ErrInvalid) {
+				t.Fatalf("Reading %s, got %#v, want fs.ErrInvalid", rawurl, err)
+			}
+		}
+	})
+
+	t.Run("NOK missing gist", func(t *testing.T) {
+		if _, err := r.ReadFile("gist://missing/a.txt"); !errors.Is(err, ErrGistNotFound) {
+			t.Fatalf("Reading a file of a missing gist, got %#v, want %#v", err, ErrGistNotFound)
+		}
+	})
+}
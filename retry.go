@@ -0,0 +1,106 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// RetryPolicy describes how requests to Github failing with a transient
+// error are retried: server errors, secondary rate limits and network
+// errors.
+//
+// Attempts are spaced by an exponential backoff, starting at MinBackoff and
+// doubling until MaxBackoff, each delay being randomly jittered down to half
+// its value so that concurrent clients do not retry in lockstep.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// MinBackoff is the delay before the first retry.
+	MinBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is a RetryPolicy suitable for most uses.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	MinBackoff:  500 * time.Millisecond,
+	MaxBackoff:  10 * time.Second,
+}
+
+// WithRetry retries requests to Github failing with a transient error,
+// according to policy.
+func WithRetry(policy RetryPolicy) Option {
+	return func(fsys *FS) {
+		fsys.retryPolicy = policy
+	}
+}
+
+// backoff returns how long to wait before the given retry, starting at 1.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	d := p.MinBackoff
+	for i := 1; i < retry && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+
+	if d <= 1 {
+		return d
+	}
+
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)))
+}
+
+// retry calls fn until it succeeds, fails with an error that is not
+// transient, or the retry policy gives up.
+func (fsys *FS) retry(ctx context.Context, fn func() error) error {
+	policy := fsys.retryPolicy
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= policy.MaxAttempts || !isTransient(err) {
+			return err
+		}
+
+		wait := policy.backoff(attempt)
+
+		var abuseErr *github.AbuseRateLimitError
+		if errors.As(err, &abuseErr) && abuseErr.RetryAfter != nil && *abuseErr.RetryAfter > wait {
+			wait = *abuseErr.RetryAfter
+		}
+
+		fsys.log(ctx, levelWarn, "retrying request", "attempt", attempt, "delay", wait, "error", err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+// isTransient reports whether err is worth retrying.
+func isTransient(err error) bool {
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		return true
+	}
+
+	var respErr *github.ErrorResponse
+	if errors.As(err, &respErr) {
+		return respErr.Response != nil && respErr.Response.StatusCode >= 500
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
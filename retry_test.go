@@ -0,0 +1,77 @@
+package gistfs
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var testRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  time.Millisecond,
+	MaxBackoff:  2 * time.Millisecond,
+}
+
+func TestWithRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		status   int
+		wantErr  bool
+		requests int
+	}{
+		{"OK transient failures", 2, http.StatusBadGateway, false, 3},
+		{"NOK too many failures", 3, http.StatusServiceUnavailable, true, 3},
+		{"NOK permanent failure", 1, http.StatusNotFound, true, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+			fg.FailNext(test.failures, test.status)
+
+			gfs := NewWithClient(client, referenceGistID, WithRetry(testRetryPolicy))
+			err := gfs.Load(context.Background())
+
+			if got := err != nil; got != test.wantErr {
+				t.Fatalf("Loading with retries, got error %#v, want error: %v", err, test.wantErr)
+			}
+
+			if got, want := fg.Requests(), test.requests; got != want {
+				t.Fatalf("Loading with retries, got %d API requests, want %d", got, want)
+			}
+		})
+	}
+
+	t.Run("OK no retry by default", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		fg.FailNext(1, http.StatusBadGateway)
+
+		gfs := NewWithClient(client, referenceGistID)
+		if err := gfs.Load(context.Background()); err == nil {
+			t.Fatal("Loading without retries, got no error, want one")
+		}
+	})
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	tests := []struct {
+		retry int
+		max   time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{10, time.Second},
+	}
+
+	for _, test := range tests {
+		got := p.backoff(test.retry)
+		if got < test.max/2 || got > test.max {
+			t.Fatalf("Backoff of retry %d, got %v, want between %v and %v", test.retry, got, test.max/2, test.max)
+		}
+	}
+}
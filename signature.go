@@ -0,0 +1,213 @@
+package gistfs
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/google/go-github/v33/github"
+	"golang.org/x/crypto/blake2b"
+)
+
+// ErrSignature is matched by the errors returned when the signed manifest
+// of a gist doesn't vouch for its content.
+var ErrSignature = errors.New("signature verification failed")
+
+// SignatureError is returned when WithSignedManifest is set and the gist
+// lacks a valid signature of its manifest, or holds a file the manifest
+// doesn't list with the same SHA-256. It matches ErrSignature with
+// errors.Is.
+type SignatureError struct {
+	// File is the name of the file that failed verification, the manifest
+	// itself if its signature is invalid.
+	File string
+	// Err is the cause of the failure.
+	Err error
+}
+
+func (e *SignatureError) Error() string {
+	return fmt.Sprintf("%v: file %q: %v", ErrSignature, e.File, e.Err)
+}
+
+// Is makes SignatureError match ErrSignature.
+func (e *SignatureError) Is(target error) bool { return target == ErrSignature }
+
+func (e *SignatureError) Unwrap() error { return e.Err }
+
+// WithSignedManifest makes loads verify that the content of the gist was
+// signed, so that files such as scripts that get executed are
+// tamper-evident. The gist must hold a manifest listing the SHA-256 of all
+// its other files in the format of sha256sum, along with a detached
+// signature of the manifest, in the files named manifest and signature.
+//
+// Loads fail with a *SignatureError if verify rejects the signature, if a
+// file is missing from the manifest or doesn't match its SHA-256, or if a
+// file listed by the manifest is missing. Files whose content was deferred
+// by LoadMetadata are verified once fetched. MinisignVerifier returns
+// a verify function checking signatures made with minisign.
+//
+// Content is verified as received from Github, before WithTransform and
+// similar options rewrite it. Snapshots and caches hold content that was
+// verified when it was loaded.
+func WithSignedManifest(manifest, signature string, verify func(message, signature []byte) error) Option {
+	return func(fsys *FS) {
+		fsys.signedManifest = manifest
+		fsys.signatureFile = signature
+		fsys.verifySignature = verify
+	}
+}
+
+// verifySignedGist verifies the signature of the manifest of gist, and the
+// content of its files against it, and returns the SHA-256 the manifest
+// gives to each file. Truncated files are left to be verified once fetched.
+func (fsys *FS) verifySignedGist(gist *github.Gist) (map[github.GistFilename]string, error) {
+	signed, err := fsys.readSignedManifest(gist)
+	if err != nil {
+		return nil, &Error{Op: "load", ID: fsys.GetID(), Err: err}
+	}
+
+	for name, f := range gist.Files {
+		if string(name) == fsys.signedManifest || string(name) == fsys.signatureFile {
+			continue
+		}
+		if _, ok := signed[name]; !ok {
+			return nil, &Error{Op: "load", ID: fsys.GetID(), Err: &SignatureError{File: string(name), Err: errors.New("not in manifest")}}
+		}
+		if f.Content == nil || isTruncated(f) {
+			continue
+		}
+
+		if err := verifySigned(signed, string(name), f.GetContent()); err != nil {
+			return nil, &Error{Op: "load", ID: fsys.GetID(), Err: err}
+		}
+	}
+
+	for name := range signed {
+		if _, ok := gist.Files[name]; !ok {
+			return nil, &Error{Op: "load", ID: fsys.GetID(), Err: &SignatureError{File: string(name), Err: fs.ErrNotExist}}
+		}
+	}
+
+	return signed, nil
+}
+
+// readSignedManifest returns the SHA-256 of the files listed by the
+// manifest of gist, once its signature is verified.
+func (fsys *FS) readSignedManifest(gist *github.Gist) (map[github.GistFilename]string, error) {
+	manifest, ok := gist.Files[github.GistFilename(fsys.signedManifest)]
+	if !ok || manifest.Content == nil || isTruncated(manifest) {
+		return nil, &SignatureError{File: fsys.signedManifest, Err: fs.ErrNotExist}
+	}
+	signature, ok := gist.Files[github.GistFilename(fsys.signatureFile)]
+	if !ok || signature.Content == nil || isTruncated(signature) {
+		return nil, &SignatureError{File: fsys.signatureFile, Err: fs.ErrNotExist}
+	}
+
+	if err := fsys.verifySignature([]byte(manifest.GetContent()), []byte(signature.GetContent())); err != nil {
+		return nil, &SignatureError{File: fsys.signedManifest, Err: err}
+	}
+
+	signed := make(map[github.GistFilename]string)
+	for _, line := range strings.Split(manifest.GetContent(), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 {
+			return nil, &SignatureError{File: fsys.signedManifest, Err: fmt.Errorf("malformed line %q", line)}
+		}
+		// sha256sum separates names with " *" in binary mode.
+		name := strings.TrimPrefix(strings.TrimPrefix(fields[1], " "), "*")
+		signed[github.GistFilename(name)] = strings.ToLower(fields[0])
+	}
+
+	return signed, nil
+}
+
+// verifySigned checks that content matches the SHA-256 signed gives to the
+// named file.
+func verifySigned(signed map[github.GistFilename]string, name, content string) error {
+	want, ok := signed[github.GistFilename(name)]
+	if !ok {
+		return &SignatureError{File: name, Err: errors.New("not in manifest")}
+	}
+
+	sum := sha256.Sum256([]byte(content))
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return &SignatureError{File: name, Err: fmt.Errorf("has SHA-256 %s, want %s", got, want)}
+	}
+
+	return nil
+}
+
+// minisign signature algorithms: Ed25519 over the message, or over its
+// BLAKE2b-512 hash.
+const (
+	minisignLegacy    = "Ed"
+	minisignPrehashed = "ED"
+)
+
+// MinisignVerifier returns a verify function for WithSignedManifest,
+// checking signatures made by minisign with the secret key of publicKey,
+// given as printed by minisign, with or without its comment line.
+func MinisignVerifier(publicKey string) (func(message, signature []byte) error, error) {
+	b, err := base64.StdEncoding.DecodeString(lastLine(publicKey))
+	if err != nil || len(b) != 2+8+ed25519.PublicKeySize || string(b[:2]) != minisignLegacy {
+		return nil, errors.New("invalid minisign public key")
+	}
+	keyID, pub := b[2:10], ed25519.PublicKey(b[10:])
+
+	return func(message, signature []byte) error {
+		lines := strings.Split(strings.TrimSpace(string(signature)), "\n")
+		if len(lines) != 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+			return errors.New("malformed minisign signature")
+		}
+
+		sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+		if err != nil || len(sig) != 2+8+ed25519.SignatureSize {
+			return errors.New("malformed minisign signature")
+		}
+		if !bytes.Equal(sig[2:10], keyID) {
+			return errors.New("signed with another key")
+		}
+
+		switch string(sig[:2]) {
+		case minisignLegacy:
+		case minisignPrehashed:
+			sum := blake2b.Sum512(message)
+			message = sum[:]
+		default:
+			return fmt.Errorf("unsupported minisign algorithm %q", sig[:2])
+		}
+		if !ed25519.Verify(pub, message, sig[10:]) {
+			return errors.New("invalid signature")
+		}
+
+		// The global signature covers the trusted comment.
+		global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+		if err != nil {
+			return errors.New("malformed minisign signature")
+		}
+		trusted := strings.TrimSuffix(strings.TrimPrefix(lines[2], "trusted comment: "), "\r")
+		signed := append(append([]byte(nil), sig[10:]...), trusted...)
+		if !ed25519.Verify(pub, signed, global) {
+			return errors.New("invalid trusted comment signature")
+		}
+
+		return nil
+	}, nil
+}
+
+// lastLine returns the last non-empty line of s, trimmed.
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
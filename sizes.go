@@ -0,0 +1,34 @@
+package gistfs
+
+// TotalSize returns the total size of the files of the gist, in bytes, or
+// zero if the filesystem is not loaded. As Sizes does, it counts files whose
+// content was deferred by LoadMetadata, but not virtual files.
+func (fsys *FS) TotalSize() int64 {
+	s := fsys.state()
+	if s.gist == nil {
+		return 0
+	}
+
+	return int64(gistSize(s.gist))
+}
+
+// Sizes returns the size of the files of the gist, in bytes, by the path
+// they are served at, without opening them. It returns nil if the
+// filesystem is not loaded.
+//
+// Files whose content was deferred by LoadMetadata report the size Github
+// gives, which is the one they have once fetched. Virtual files are left
+// out.
+func (fsys *FS) Sizes() map[string]int64 {
+	s := fsys.state()
+	if s.gist == nil {
+		return nil
+	}
+
+	sizes := make(map[string]int64, len(s.gist.Files))
+	for _, f := range s.gist.Files {
+		sizes[fsys.filePath(f.GetFilename())] = int64(f.GetSize())
+	}
+
+	return sizes
+}
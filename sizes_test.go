@@ -0,0 +1,43 @@
+package gistfs
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSizes(t *testing.T) {
+	getter := &stubGetter{
+		gist: &Gist{
+			ID: referenceGistID,
+			Files: map[string]GistFile{
+				"a.txt":         {Filename: "a.txt", Content: "a", Size: 1},
+				"docs__big.txt": {Filename: "docs__big.txt", Content: "big", Size: 9, Truncated: true, RawURL: "https://raw/big.txt"},
+			},
+		},
+		raw: map[string]string{"https://raw/big.txt": "big file!"},
+	}
+
+	gfs := NewWithGetter(getter, referenceGistID, WithPathSeparator("__"), WithMetaFile())
+	if got := gfs.Sizes(); got != nil {
+		t.Errorf("Sizes before loading, got %v, want nil", got)
+	}
+	if got := gfs.TotalSize(); got != 0 {
+		t.Errorf("TotalSize before loading, got %d, want 0", got)
+	}
+
+	if err := gfs.LoadMetadata(context.Background()); err != nil {
+		t.Fatalf("Loading metadata, expected no error but got %#v", err)
+	}
+
+	want := map[string]int64{"a.txt": 1, "docs/big.txt": 9}
+	if got := gfs.Sizes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Sizes, got %v, want %v", got, want)
+	}
+	if got := gfs.TotalSize(); got != 10 {
+		t.Errorf("TotalSize, got %d, want 10", got)
+	}
+	if getter.rawCalls != 0 {
+		t.Errorf("Getting sizes, got %d raw fetches, want none", getter.rawCalls)
+	}
+}
@@ -0,0 +1,31 @@
+//go:build go1.21
+// +build go1.21
+
+package gistfs
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithLogger logs the work of the filesystem to l, with the gist ID, the
+// durations, the sizes and the remaining rate limit as attributes. Loads
+// are logged at the info level, or at the debug level when Github reports
+// the gist unchanged, along with background refreshes and raw content
+// fetches. Retries and failures are logged at the warn level.
+//
+// It requires Go 1.21 or later.
+func WithLogger(l *slog.Logger) Option {
+	return func(fsys *FS) {
+		fsys.logger = slogLogger{l}
+	}
+}
+
+// slogLogger adapts a slog.Logger to the logger interface.
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (s slogLogger) log(ctx context.Context, level logLevel, msg string, args ...interface{}) {
+	s.l.Log(ctx, slog.Level(level), msg, args...)
+}
@@ -0,0 +1,134 @@
+//go:build go1.21
+// +build go1.21
+
+package gistfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+)
+
+// logRecords decodes the records written by a JSON slog handler.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var records []map[string]interface{}
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var r map[string]interface{}
+		if err := dec.Decode(&r); err != nil {
+			t.Fatalf("Decoding log records, expected no error but got %#v", err)
+		}
+		records = append(records, r)
+	}
+
+	return records
+}
+
+func TestWithLogger(t *testing.T) {
+	newLogger := func() (*slog.Logger, *bytes.Buffer) {
+		var buf bytes.Buffer
+		return slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})), &buf
+	}
+
+	t.Run("OK loads", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "abc", "b.txt": "de"})
+		l, buf := newLogger()
+
+		gfs := NewWithClient(client, referenceGistID, WithLogger(l))
+		for i := 0; i < 2; i++ {
+			if err := gfs.Load(context.Background()); err != nil {
+				t.Fatalf("Load, expected no error but got %#v", err)
+			}
+		}
+
+		records := logRecords(t, buf)
+		if got, want := len(records), 2; got != want {
+			t.Fatalf("Logged %d records, want %d: %v", got, want, records)
+		}
+
+		first, second := records[0], records[1]
+		if first["msg"] != "gist loaded" || first["level"] != "INFO" || first["gist"] != referenceGistID {
+			t.Fatalf("First load logged %v, want an info gist loaded record", first)
+		}
+		if first["files"] != 2.0 || first["bytes"] != 5.0 || first["not_modified"] != false {
+			t.Fatalf("First load logged %v, want 2 files of 5 bytes", first)
+		}
+		if _, ok := first["rate_limit_remaining"]; !ok {
+			t.Fatalf("First load logged %v, want the remaining rate limit", first)
+		}
+		if second["level"] != "DEBUG" || second["not_modified"] != true {
+			t.Fatalf("Second load logged %v, want a debug not modified record", second)
+		}
+	})
+
+	t.Run("OK retries and failures", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		fg.FailNext(3, http.StatusBadGateway)
+		l, buf := newLogger()
+
+		gfs := NewWithClient(client, referenceGistID, WithRetry(testRetryPolicy), WithLogger(l))
+		if err := gfs.Load(context.Background()); err == nil {
+			t.Fatal("Load, expected an error but got none")
+		}
+
+		var msgs []string
+		for _, r := range logRecords(t, buf) {
+			if r["level"] != "WARN" {
+				t.Fatalf("Failing load logged %v, want a warn record", r)
+			}
+			msgs = append(msgs, r["msg"].(string))
+		}
+
+		want := []string{"retrying request", "retrying request", "gist load failed"}
+		if len(msgs) != len(want) {
+			t.Fatalf("Failing load logged %q, want %q", msgs, want)
+		}
+		for i := range want {
+			if msgs[i] != want[i] {
+				t.Fatalf("Failing load logged %q, want %q", msgs, want)
+			}
+		}
+	})
+
+	t.Run("OK raw fetches", func(t *testing.T) {
+		getter := &stubGetter{
+			gist: &Gist{
+				ID: referenceGistID,
+				Files: map[string]GistFile{
+					"big.txt": {Filename: "big.txt", Content: "big", Size: 9, Truncated: true, RawURL: "https://raw/big.txt"},
+				},
+			},
+			raw: map[string]string{"https://raw/big.txt": "big file!"},
+		}
+		l, buf := newLogger()
+
+		gfs := NewWithGetter(getter, referenceGistID, WithLogger(l))
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Load, expected no error but got %#v", err)
+		}
+
+		records := logRecords(t, buf)
+		if len(records) == 0 {
+			t.Fatal("Load logged no records, want a raw fetch one")
+		}
+
+		r := records[0]
+		if r["msg"] != "raw content fetched" || r["file"] != "big.txt" || r["bytes"] != 9.0 {
+			t.Fatalf("Raw fetch logged %v, want 9 bytes fetched for big.txt", r)
+		}
+	})
+
+	t.Run("OK no logger", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+
+		gfs := NewWithClient(client, referenceGistID)
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Load, expected no error but got %#v", err)
+		}
+	})
+}
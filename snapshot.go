@@ -0,0 +1,84 @@
+package gistfs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// snapshotVersion is the version of the snapshot format, bumped whenever it
+// changes in an incompatible way.
+const snapshotVersion = 2
+
+// snapshot is the serialized state of a loaded filesystem.
+type snapshot struct {
+	Version  int          `json:"version"`
+	ETag     string       `json:"etag,omitempty"`
+	Revision string       `json:"revision,omitempty"`
+	ForkOf   *GistRef     `json:"fork_of,omitempty"`
+	LoadedAt time.Time    `json:"loaded_at"`
+	Gist     *github.Gist `json:"gist"`
+	// Transformed is set when the content of the gist was transformed by
+	// the filesystem the snapshot was taken from.
+	Transformed bool `json:"transformed,omitempty"`
+	// Signed holds the SHA-256 of files by name, as given by the manifest
+	// verified with WithSignedManifest, if it was.
+	Signed map[github.GistFilename]string `json:"signed,omitempty"`
+}
+
+// SaveSnapshot writes the loaded gist, along with when it was loaded and
+// the ETag identifying its content, so that it can be restored later with
+// LoadSnapshot.
+func (fsys *FS) SaveSnapshot(w io.Writer) error {
+	s := fsys.state()
+	if s.gist == nil {
+		return ErrNotLoaded
+	}
+
+	gist, err := fsys.plainGist(s)
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(w).Encode(&snapshot{
+		Version:  snapshotVersion,
+		ETag:     s.etag,
+		Revision: s.extra.sha,
+		ForkOf:   s.extra.forkOf,
+		LoadedAt: s.loadedAt,
+		Gist:     gist,
+
+		Transformed: fsys.transformsContent(),
+		Signed:      s.extra.signed,
+	})
+}
+
+// LoadSnapshot restores the filesystem from a snapshot written by
+// SaveSnapshot, making it ready for use without reaching Github. It is
+// meant to boot a service from its last known state, before refreshing it
+// with Load, which then only downloads the gist if it changed since the
+// snapshot was taken.
+//
+// The gist is checked as LoadFromJSON checks it. Transformed content is
+// trusted to have been verified when the snapshot was taken, and the hashes
+// of the signed manifest it was verified with are restored along with it.
+func (fsys *FS) LoadSnapshot(r io.Reader) error {
+	var snap snapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return fmt.Errorf("decoding snapshot: %w", err)
+	}
+
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("decoding snapshot: unsupported version %d", snap.Version)
+	}
+
+	if snap.Gist == nil || snap.Gist.GetID() != fsys.GetID() {
+		return fmt.Errorf("decoding snapshot: got gist %q, want %q", snap.Gist.GetID(), fsys.GetID())
+	}
+
+	extra := gistExtra{sha: snap.Revision, forkOf: snap.ForkOf, signed: snap.Signed}
+	return fsys.restore(snap.Gist, snap.ETag, extra, snap.LoadedAt, snap.Transformed)
+}
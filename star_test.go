@@ -0,0 +1,46 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStar(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+	gfs := NewWithClient(client, referenceGistID)
+	ctx := context.Background()
+
+	steps := []struct {
+		name string
+		do   func(context.Context) error
+		want bool
+	}{
+		{"Star", gfs.Star, true},
+		{"Star again", gfs.Star, true},
+		{"Unstar", gfs.Unstar, false},
+	}
+
+	for _, step := range steps {
+		if err := step.do(ctx); err != nil {
+			t.Fatalf("%s, expected no error but got %#v", step.name, err)
+		}
+
+		starred, err := gfs.IsStarred(ctx)
+		if err != nil {
+			t.Fatalf("Checking star after %s, expected no error but got %#v", step.name, err)
+		}
+
+		if got := starred; got != step.want {
+			t.Fatalf("Checking star after %s, got %v, want %v", step.name, got, step.want)
+		}
+	}
+
+	t.Run("NOK missing gist", func(t *testing.T) {
+		fg.RemoveGist(referenceGistID)
+
+		if err := gfs.Star(ctx); !errors.Is(err, ErrGistNotFound) {
+			t.Fatalf("Starring a missing gist, got %#v, want %#v", err, ErrGistNotFound)
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package gistfs
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Template is a set of templates parsed by ParseTemplates, either
+// a *html/template.Template or a *text/template.Template.
+type Template interface {
+	Name() string
+	Execute(w io.Writer, data interface{}) error
+	ExecuteTemplate(w io.Writer, name string, data interface{}) error
+	DefinedTemplates() string
+}
+
+// TemplateOptions configures how ParseTemplates parses templates.
+type TemplateOptions struct {
+	// Text parses text/template templates instead of html/template ones.
+	Text bool
+	// Funcs is added to the function map of the templates.
+	Funcs map[string]interface{}
+	// LeftDelim and RightDelim are the action delimiters, "{{" and "}}"
+	// when empty.
+	LeftDelim, RightDelim string
+}
+
+// TemplateError reports a file whose template could not be parsed.
+type TemplateError struct {
+	File string
+	Err  error
+}
+
+func (e *TemplateError) Error() string { return e.File + ": " + e.Err.Error() }
+
+func (e *TemplateError) Unwrap() error { return e.Err }
+
+// TemplateErrors reports all the files ParseTemplates failed to parse.
+type TemplateErrors []*TemplateError
+
+func (errs TemplateErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// ParseTemplates parses the files of fsys matching pattern, as
+// template.ParseFS does: each file defines a template named after its base
+// name, and the returned set is named after the first one.
+//
+// Unlike template.ParseFS, it does not stop at the first invalid file but
+// reports all of them in TemplateErrors, along with the path of the files.
+// When fsys is a *FS, the error is an *Error giving the ID of the gist.
+func ParseTemplates(fsys fs.FS, pattern string, opts TemplateOptions) (Template, error) {
+	tmpl, err := parseTemplates(fsys, pattern, opts)
+	if err != nil {
+		if gfs, ok := fsys.(*FS); ok {
+			return nil, &Error{Op: "parse templates", ID: gfs.GetID(), Err: err}
+		}
+		return nil, err
+	}
+
+	return tmpl, nil
+}
+
+func parseTemplates(fsys fs.FS, pattern string, opts TemplateOptions) (Template, error) {
+	matches, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, &TemplateError{File: pattern, Err: fs.ErrNotExist}
+	}
+
+	var (
+		tmpl  Template
+		parse func(name, text string) error
+	)
+	if opts.Text {
+		t := texttemplate.New(path.Base(matches[0])).Delims(opts.LeftDelim, opts.RightDelim).Funcs(opts.Funcs)
+		tmpl, parse = t, func(name, text string) error {
+			if name != t.Name() {
+				_, err := t.New(name).Parse(text)
+				return err
+			}
+			_, err := t.Parse(text)
+			return err
+		}
+	} else {
+		t := htmltemplate.New(path.Base(matches[0])).Delims(opts.LeftDelim, opts.RightDelim).Funcs(opts.Funcs)
+		tmpl, parse = t, func(name, text string) error {
+			if name != t.Name() {
+				_, err := t.New(name).Parse(text)
+				return err
+			}
+			_, err := t.Parse(text)
+			return err
+		}
+	}
+
+	var errs TemplateErrors
+	for _, p := range matches {
+		b, err := fs.ReadFile(fsys, p)
+		if err == nil {
+			err = parse(path.Base(p), string(b))
+		}
+		if err != nil {
+			errs = append(errs, &TemplateError{File: p, Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return tmpl, nil
+}
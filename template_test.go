@@ -0,0 +1,93 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	htmltemplate "html/template"
+	"strings"
+	"testing"
+	"testing/fstest"
+	texttemplate "text/template"
+)
+
+func TestParseTemplates(t *testing.T) {
+	_, client := newFakeGist(t, map[string]string{
+		"index.html":  `<p>[[ shout .Name ]]</p>[[ template "footer.html" ]]`,
+		"footer.html": `<footer>bye</footer>`,
+		"bad1.tmpl":   `[[ if ]]`,
+		"bad2.tmpl":   `[[ end ]]`,
+	})
+
+	gfs := NewWithClient(client, referenceGistID)
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+
+	opts := TemplateOptions{
+		Funcs:     map[string]interface{}{"shout": strings.ToUpper},
+		LeftDelim: "[[", RightDelim: "]]",
+	}
+	data := struct{ Name string }{"<b>"}
+
+	t.Run("OK html", func(t *testing.T) {
+		tmpl, err := ParseTemplates(gfs, "*.html", opts)
+		if err != nil {
+			t.Fatalf("Parsing, expected no error but got %#v", err)
+		}
+		if _, ok := tmpl.(*htmltemplate.Template); !ok {
+			t.Fatalf("Parsing, got %T, want a *html/template.Template", tmpl)
+		}
+
+		var b strings.Builder
+		if err := tmpl.ExecuteTemplate(&b, "index.html", data); err != nil {
+			t.Fatalf("Executing, expected no error but got %#v", err)
+		}
+		if got, want := b.String(), "<p>&lt;B&gt;</p><footer>bye</footer>"; got != want {
+			t.Fatalf("Executing, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK text", func(t *testing.T) {
+		opts := opts
+		opts.Text = true
+
+		tmpl, err := ParseTemplates(gfs, "*.html", opts)
+		if err != nil {
+			t.Fatalf("Parsing, expected no error but got %#v", err)
+		}
+		if _, ok := tmpl.(*texttemplate.Template); !ok {
+			t.Fatalf("Parsing, got %T, want a *text/template.Template", tmpl)
+		}
+
+		var b strings.Builder
+		if err := tmpl.ExecuteTemplate(&b, "index.html", data); err != nil {
+			t.Fatalf("Executing, expected no error but got %#v", err)
+		}
+		if got, want := b.String(), "<p><B></p><footer>bye</footer>"; got != want {
+			t.Fatalf("Executing, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("NOK all errors", func(t *testing.T) {
+		_, err := ParseTemplates(gfs, "*.tmpl", opts)
+
+		var gistErr *Error
+		if !errors.As(err, &gistErr) || gistErr.ID != referenceGistID {
+			t.Fatalf("Parsing, got %#v, want an *Error for the gist", err)
+		}
+
+		var errs TemplateErrors
+		if !errors.As(err, &errs) || len(errs) != 2 || errs[0].File != "bad1.tmpl" || errs[1].File != "bad2.tmpl" {
+			t.Fatalf("Parsing, got %v, want errors for bad1.tmpl and bad2.tmpl", err)
+		}
+	})
+
+	t.Run("NOK no match", func(t *testing.T) {
+		_, err := ParseTemplates(fstest.MapFS{}, "*.html", TemplateOptions{})
+
+		var tmplErr *TemplateError
+		if !errors.As(err, &tmplErr) || tmplErr.File != "*.html" {
+			t.Fatalf("Parsing, got %#v, want a *TemplateError for the pattern", err)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+// Package templatereload keeps HTML templates parsed from a gist up to date
+// as the gist changes:
+//
+//	gfs := gistfs.New(id, gistfs.WithTTL(time.Minute))
+//	r, err := templatereload.New(ctx, gfs, []string{"*.html"})
+//	...
+//	r.Template().ExecuteTemplate(w, "index.html", data)
+//
+// The templates are parsed again whenever a load of the filesystem, such as
+// a refresh, changes its files.
+package templatereload
+
+import (
+	"context"
+	"html/template"
+	"sync"
+
+	"github.com/jhchabran/gistfs"
+)
+
+// Option configures a Reloader.
+type Option func(*Reloader)
+
+// WithFuncs adds funcs to the function map of the templates, which must be
+// done before parsing templates calling them.
+func WithFuncs(funcs template.FuncMap) Option {
+	return func(r *Reloader) {
+		r.funcs = funcs
+	}
+}
+
+// Reloader holds templates parsed from a gistfs filesystem and parses them
+// again when its files change.
+type Reloader struct {
+	fsys     *gistfs.FS
+	patterns []string
+	funcs    template.FuncMap
+
+	mu   sync.RWMutex
+	tmpl *template.Template
+	err  error
+}
+
+// New parses the templates of fsys matching patterns, as template.ParseFS
+// does, and keeps them up to date until ctx is done. The filesystem must be
+// loaded, and an error is returned if the templates cannot be parsed.
+func New(ctx context.Context, fsys *gistfs.FS, patterns []string, opts ...Option) (*Reloader, error) {
+	r := &Reloader{
+		fsys:     fsys,
+		patterns: patterns,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	tmpl, err := r.parse()
+	if err != nil {
+		return nil, err
+	}
+	r.tmpl = tmpl
+
+	events, err := fsys.Watch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	go r.watch(events)
+
+	return r, nil
+}
+
+// Template returns the templates parsed from the current files of the
+// filesystem. If parsing them failed after a change, the last templates
+// parsed successfully are returned, and Err reports why.
+func (r *Reloader) Template() *template.Template {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.tmpl
+}
+
+// Err returns the error of the last attempt at parsing the templates, or nil
+// if it succeeded.
+func (r *Reloader) Err() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.err
+}
+
+func (r *Reloader) parse() (*template.Template, error) {
+	return template.New("").Funcs(r.funcs).ParseFS(r.fsys, r.patterns...)
+}
+
+// watch parses the templates again after each change, until events is
+// closed. Changes coming from a single load are handled at once.
+func (r *Reloader) watch(events <-chan gistfs.ChangeEvent) {
+	for range events {
+		if !drain(events) {
+			return
+		}
+
+		tmpl, err := r.parse()
+
+		r.mu.Lock()
+		if err == nil {
+			r.tmpl = tmpl
+		}
+		r.err = err
+		r.mu.Unlock()
+	}
+}
+
+// drain receives the events already waiting on events, returning false if
+// it is closed.
+func drain(events <-chan gistfs.ChangeEvent) bool {
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return false
+			}
+		default:
+			return true
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package templatereload
+
+import (
+	"context"
+	"html/template"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jhchabran/gistfs"
+	"github.com/jhchabran/gistfs/gisttest"
+)
+
+func execute(t *testing.T, r *Reloader, name string) string {
+	t.Helper()
+
+	var b strings.Builder
+	if err := r.Template().ExecuteTemplate(&b, name, nil); err != nil {
+		t.Fatalf("Executing %s, expected no error but got %#v", name, err)
+	}
+
+	return b.String()
+}
+
+// eventually fails the test if cond does not hold within a second.
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestReloader(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := gisttest.NewServer(t)
+	gist := srv.AddGist(gisttest.GistID, map[string]string{
+		"index.html": `{{ shout "hello" }}`,
+		"notes.txt":  "not a template",
+	})
+
+	gfs := gistfs.NewWithClient(srv.GithubClient(), gisttest.GistID)
+	if err := gfs.Load(ctx); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+
+	funcs := template.FuncMap{"shout": strings.ToUpper}
+	r, err := New(ctx, gfs, []string{"*.html"}, WithFuncs(funcs))
+	if err != nil {
+		t.Fatalf("Parsing, expected no error but got %#v", err)
+	}
+
+	if got, want := execute(t, r, "index.html"), "HELLO"; got != want {
+		t.Fatalf("Executing, got %#v, want %#v", got, want)
+	}
+
+	t.Run("OK reload", func(t *testing.T) {
+		gist.SetFiles(map[string]string{"index.html": `{{ shout "bye" }}`})
+		if err := gfs.Load(ctx); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		eventually(t, func() bool { return execute(t, r, "index.html") == "BYE" })
+		if err := r.Err(); err != nil {
+			t.Fatalf("Reloading, expected no error but got %#v", err)
+		}
+	})
+
+	t.Run("NOK reload", func(t *testing.T) {
+		gist.SetFiles(map[string]string{"index.html": `{{ shout `})
+		if err := gfs.Load(ctx); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		eventually(t, func() bool { return r.Err() != nil })
+		if got, want := execute(t, r, "index.html"), "BYE"; got != want {
+			t.Fatalf("Executing after a failed reload, got %#v, want the previous templates %#v", got, want)
+		}
+	})
+}
+
+func TestNew(t *testing.T) {
+	gfs, _ := gisttest.NewFS(t, map[string]string{"index.html": "{{ if }}"})
+
+	if _, err := New(context.Background(), gfs, []string{"*.html"}); err == nil {
+		t.Fatal("Parsing invalid templates, expected an error but got none")
+	}
+}
@@ -0,0 +1,80 @@
+package gistfs
+
+import (
+	"context"
+
+	"github.com/google/go-github/v33/github"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the name of the tracer creating the spans of filesystems.
+const tracerName = "github.com/jhchabran/gistfs"
+
+// WithTracerProvider records OpenTelemetry spans with tracers from tp for
+// loads, background refreshes, raw content fetches, and calls to Open and
+// ReadFile. Spans carry the gist ID, the file name and the number of bytes
+// read, and record the ID of every request made to Github as an event.
+//
+// Open and ReadFile take no context, so their spans are the roots of their
+// traces, while the others are children of the spans in the context given
+// to Load.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(fsys *FS) {
+		fsys.tracer = tp.Tracer(tracerName)
+	}
+}
+
+// noopSpan is returned by startSpan when tracing is disabled.
+var noopSpan = trace.SpanFromContext(context.Background())
+
+// startSpan starts a span called name as a child of the one in ctx, for
+// the given file if not empty. It returns ctx and a span doing nothing if
+// tracing is disabled.
+func (fsys *FS) startSpan(ctx context.Context, name, file string) (context.Context, trace.Span) {
+	if fsys.tracer == nil {
+		return ctx, noopSpan
+	}
+
+	attrs := []attribute.KeyValue{attribute.String("gist.id", fsys.GetID())}
+	if file != "" {
+		attrs = append(attrs, attribute.String("gist.file", file))
+	}
+
+	return fsys.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan ends span, recording the number of bytes read if not negative,
+// and err if any.
+func endSpan(span trace.Span, size int, err error) {
+	if span.IsRecording() {
+		if size >= 0 {
+			span.SetAttributes(attribute.Int("gist.bytes", size))
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+
+	span.End()
+}
+
+// traceResponse records the Github request that produced resp as an event
+// of the span in ctx.
+func (fsys *FS) traceResponse(ctx context.Context, resp *github.Response) {
+	if fsys.tracer == nil || resp == nil || resp.Response == nil {
+		return
+	}
+
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	span.AddEvent("github.request", trace.WithAttributes(
+		attribute.String("github.request_id", requestID(resp, nil)),
+		attribute.Int("http.status_code", resp.StatusCode),
+	))
+}
@@ -0,0 +1,144 @@
+package gistfs
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// spanAttr returns the value of the attribute key of span.
+func spanAttr(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+
+	return attribute.Value{}, false
+}
+
+func TestWithTracerProvider(t *testing.T) {
+	newProvider := func() (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+		sr := tracetest.NewSpanRecorder()
+		return sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)), sr
+	}
+
+	t.Run("OK load", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "abc", "b.txt": "de"})
+		tp, sr := newProvider()
+
+		gfs := NewWithClient(client, referenceGistID, WithTracerProvider(tp))
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Load, expected no error but got %#v", err)
+		}
+
+		spans := sr.Ended()
+		if got, want := len(spans), 1; got != want {
+			t.Fatalf("Load ended %d spans, want %d", got, want)
+		}
+
+		span := spans[0]
+		if got, want := span.Name(), "gistfs.Load"; got != want {
+			t.Fatalf("Load span named %#v, want %#v", got, want)
+		}
+		if v, _ := spanAttr(span, "gist.id"); v.AsString() != referenceGistID {
+			t.Fatalf("Load span has gist ID %#v, want %#v", v.AsString(), referenceGistID)
+		}
+		if v, _ := spanAttr(span, "gist.bytes"); v.AsInt64() != 5 {
+			t.Fatalf("Load span has %d bytes, want 5", v.AsInt64())
+		}
+
+		events := span.Events()
+		if len(events) != 1 || events[0].Name != "github.request" {
+			t.Fatalf("Load span has events %v, want a Github request", events)
+		}
+		if id := events[0].Attributes[0].Value.AsString(); !strings.HasPrefix(id, "FAKE:") {
+			t.Fatalf("Load span has request ID %#v, want the one given by Github", id)
+		}
+	})
+
+	t.Run("NOK load", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		fg.FailNext(1, http.StatusNotFound)
+		tp, sr := newProvider()
+
+		gfs := NewWithClient(client, referenceGistID, WithTracerProvider(tp))
+		if err := gfs.Load(context.Background()); err == nil {
+			t.Fatal("Load, expected an error but got none")
+		}
+
+		spans := sr.Ended()
+		if len(spans) != 1 || spans[0].Status().Code != codes.Error {
+			t.Fatalf("Failing load ended spans %v, want one with an error status", spans)
+		}
+	})
+
+	t.Run("OK read file", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "abc"})
+		tp, sr := newProvider()
+
+		gfs := NewWithClient(client, referenceGistID, WithTracerProvider(tp))
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Load, expected no error but got %#v", err)
+		}
+		if _, err := gfs.ReadFile("a.txt"); err != nil {
+			t.Fatalf("ReadFile, expected no error but got %#v", err)
+		}
+		if _, err := gfs.Open("missing.txt"); err == nil {
+			t.Fatal("Open, expected an error but got none")
+		}
+
+		spans := sr.Ended()
+		if got, want := len(spans), 3; got != want {
+			t.Fatalf("Ended %d spans, want %d", got, want)
+		}
+
+		read, open := spans[1], spans[2]
+		if v, _ := spanAttr(read, "gist.file"); read.Name() != "gistfs.ReadFile" || v.AsString() != "a.txt" {
+			t.Fatalf("ReadFile ended span %#v for %#v, want gistfs.ReadFile for a.txt", read.Name(), v.AsString())
+		}
+		if v, _ := spanAttr(read, "gist.bytes"); v.AsInt64() != 3 {
+			t.Fatalf("ReadFile span has %d bytes, want 3", v.AsInt64())
+		}
+		if open.Name() != "gistfs.Open" || open.Status().Code != codes.Error {
+			t.Fatalf("Open ended span %#v with status %v, want gistfs.Open with an error", open.Name(), open.Status())
+		}
+	})
+
+	t.Run("OK raw fetch", func(t *testing.T) {
+		getter := &stubGetter{
+			gist: &Gist{
+				ID: referenceGistID,
+				Files: map[string]GistFile{
+					"big.txt": {Filename: "big.txt", Content: "big", Size: 9, Truncated: true, RawURL: "https://raw/big.txt"},
+				},
+			},
+			raw: map[string]string{"https://raw/big.txt": "big file!"},
+		}
+		tp, sr := newProvider()
+
+		gfs := NewWithGetter(getter, referenceGistID, WithTracerProvider(tp))
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Load, expected no error but got %#v", err)
+		}
+
+		spans := sr.Ended()
+		if got, want := len(spans), 2; got != want {
+			t.Fatalf("Load ended %d spans, want %d", got, want)
+		}
+
+		raw, load := spans[0], spans[1]
+		if raw.Name() != "gistfs.GetRaw" || raw.Parent().SpanID() != load.SpanContext().SpanID() {
+			t.Fatalf("Load ended span %#v, want a gistfs.GetRaw child", raw.Name())
+		}
+		if v, _ := spanAttr(raw, "gist.bytes"); v.AsInt64() != 9 {
+			t.Fatalf("Raw fetch span has %d bytes, want 9", v.AsInt64())
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package gistfs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// contentTransform rewrites the content of the named file when it is
+// loaded, before being served.
+type contentTransform func(name, content string) (string, error)
+
+// WithTransform rewrites the content of the files with fn when they are
+// loaded, before they are served, enabling templating, secret injection or
+// format conversion. fn is given the name of each file in the gist and its
+// content, and returns the content to serve instead. Returning an error
+// fails the load, or the read of a file whose content was deferred by
+// LoadMetadata.
+//
+// Content is transformed once per load rather than on every read, so sizes,
+// hashes and archives reflect the transformed content. Transforms run in
+// the order their options are given, after the ones of
+// WithNormalizeNewlines and WithStripBOM given before them, and after files
+// are decompressed by WithGunzip.
+func WithTransform(fn func(name string, data []byte) ([]byte, error)) Option {
+	return func(fsys *FS) {
+		fsys.transforms = append(fsys.transforms, func(name, content string) (string, error) {
+			b, err := fn(name, []byte(content))
+			if err != nil {
+				return "", err
+			}
+
+			return string(b), nil
+		})
+	}
+}
+
+// WithNormalizeNewlines converts the CRLF line endings of the files to LF
+// when they are loaded, so that content written on Windows reads like any
+// other. Sizes, hashes and archives reflect the converted content.
+func WithNormalizeNewlines() Option {
+	return func(fsys *FS) {
+		fsys.transforms = append(fsys.transforms, normalizeNewlines)
+	}
+}
+
+func normalizeNewlines(name, content string) (string, error) {
+	return strings.ReplaceAll(content, "\r\n", "\n"), nil
+}
+
+// utf8BOM is the byte order mark some editors start UTF-8 files with.
+const utf8BOM = "\xef\xbb\xbf"
+
+// WithStripBOM removes the UTF-8 byte order mark the files may start with
+// when they are loaded, as many parsers reject it. Sizes, hashes and
+// archives reflect the stripped content.
+func WithStripBOM() Option {
+	return func(fsys *FS) {
+		fsys.transforms = append(fsys.transforms, stripBOM)
+	}
+}
+
+func stripBOM(name, content string) (string, error) {
+	return strings.TrimPrefix(content, utf8BOM), nil
+}
+
+// transformContent applies the transforms of the filesystem to the content
+// of the named file.
+func (fsys *FS) transformContent(name, content string) (string, error) {
+	for _, t := range fsys.transforms {
+		var err error
+		if content, err = t(name, content); err != nil {
+			return "", fmt.Errorf("transforming %s: %w", name, err)
+		}
+	}
+
+	return content, nil
+}
+
+// transformGist returns a copy of gist with the files decompressed by
+// WithGunzip and the transforms of the filesystem applied to their content,
+// or gist itself if there is nothing to do. Truncated files are left as is,
+// to be transformed once their content is fetched in full.
+func (fsys *FS) transformGist(gist *github.Gist) (*github.Gist, error) {
+	if !fsys.transformsContent() {
+		return gist, nil
+	}
+
+	transformed := *gist
+	transformed.Files = make(map[github.GistFilename]github.GistFile, len(gist.Files))
+	for name, f := range gist.Files {
+		if f.Content == nil || isTruncated(f) {
+			transformed.Files[name] = f
+			continue
+		}
+
+		plain, ok, err := fsys.gunzipFile(gist, f)
+		if err != nil {
+			return nil, &Error{Op: "load", ID: fsys.GetID(), Err: err}
+		}
+		if ok {
+			// The compressed content is served as is, if at all.
+			if fsys.gunzipNames == GzipBoth {
+				transformed.Files[name] = f
+			}
+			f, name = plain, github.GistFilename(plain.GetFilename())
+		}
+
+		if f, err = fsys.transformFile(f); err != nil {
+			return nil, &Error{Op: "load", ID: fsys.GetID(), Err: err}
+		}
+		transformed.Files[name] = f
+	}
+
+	return &transformed, nil
+}
+
+// transformsContent reports whether the filesystem serves files with
+// another content than the one stored in the gist, transformed or
+// decompressed.
+func (fsys *FS) transformsContent() bool {
+	return len(fsys.transforms) > 0 || fsys.gunzipNames != 0
+}
+
+// transformFile returns f with the transforms of the filesystem applied to
+// its content.
+func (fsys *FS) transformFile(f github.GistFile) (github.GistFile, error) {
+	if len(fsys.transforms) == 0 {
+		return f, nil
+	}
+
+	content, err := fsys.transformContent(f.GetFilename(), f.GetContent())
+	if err != nil {
+		return f, err
+	}
+
+	f.Content = github.String(content)
+	f.Size = github.Int(len(content))
+
+	return f, nil
+}
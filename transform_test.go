@@ -0,0 +1,158 @@
+package gistfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	files := map[string]string{
+		"a.yaml": "\xef\xbb\xbfa: 1\r\nb: 2\r\n",
+		"b.txt":  "no\nchange",
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want map[string]string
+	}{
+		{"OK newlines", []Option{WithNormalizeNewlines()}, map[string]string{"a.yaml": "\xef\xbb\xbfa: 1\nb: 2\n", "b.txt": "no\nchange"}},
+		{"OK BOM", []Option{WithStripBOM()}, map[string]string{"a.yaml": "a: 1\r\nb: 2\r\n", "b.txt": "no\nchange"}},
+		{"OK both compressed", []Option{WithStripBOM(), WithNormalizeNewlines(), WithCompression()}, map[string]string{"a.yaml": "a: 1\nb: 2\n", "b.txt": "no\nchange"}},
+		{"OK none", nil, files},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, client := newFakeGist(t, files)
+
+			gfs := NewWithClient(client, referenceGistID, test.opts...)
+			if err := gfs.Load(context.Background()); err != nil {
+				t.Fatalf("Loading, expected no error but got %#v", err)
+			}
+
+			for name, want := range test.want {
+				b, err := gfs.ReadFile(name)
+				if err != nil {
+					t.Fatalf("Reading %s, expected no error but got %#v", name, err)
+				}
+				if got := string(b); got != want {
+					t.Fatalf("Reading %s, got %#v, want %#v", name, got, want)
+				}
+
+				info, err := fs.Stat(gfs, name)
+				if err != nil {
+					t.Fatalf("Stating %s, expected no error but got %#v", name, err)
+				}
+				if got, want := info.Size(), int64(len(want)); got != want {
+					t.Fatalf("Stating %s, got size %d, want %d", name, got, want)
+				}
+				if info.Sys().(*FileMetadata).Truncated {
+					t.Fatalf("Stating %s, got a truncated file, want a full one", name)
+				}
+			}
+		})
+	}
+
+	t.Run("OK deferred content", func(t *testing.T) {
+		getter := &stubGetter{
+			gist: &Gist{
+				ID: referenceGistID,
+				Files: map[string]GistFile{
+					"big.txt": {Filename: "big.txt", Content: "\xef\xbb\xbfbig", Size: 13, Truncated: true, RawURL: "https://raw/big.txt"},
+				},
+			},
+			raw: map[string]string{"https://raw/big.txt": "\xef\xbb\xbfbig\r\nfile"},
+		}
+
+		gfs := NewWithGetter(getter, referenceGistID, WithStripBOM(), WithNormalizeNewlines())
+		if err := gfs.LoadMetadata(context.Background()); err != nil {
+			t.Fatalf("Loading metadata, expected no error but got %#v", err)
+		}
+
+		b, err := gfs.ReadFile("big.txt")
+		if err != nil {
+			t.Fatalf("Reading, expected no error but got %#v", err)
+		}
+		if got, want := string(b), "big\nfile"; got != want {
+			t.Fatalf("Reading, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK from JSON", func(t *testing.T) {
+		gfs := New(referenceGistID, WithNormalizeNewlines())
+		err := gfs.LoadFromJSON(strings.NewReader(`{"id":"` + referenceGistID + `","files":{"a.txt":{"filename":"a.txt","content":"a\r\n","size":3}}}`))
+		if err != nil {
+			t.Fatalf("Loading from JSON, expected no error but got %#v", err)
+		}
+
+		if b, _ := gfs.ReadFile("a.txt"); string(b) != "a\n" {
+			t.Fatalf("Reading, got %#v, want %#v", string(b), "a\n")
+		}
+	})
+}
+
+func TestWithTransform(t *testing.T) {
+	upper := func(name string, data []byte) ([]byte, error) {
+		if name == "secret.txt" {
+			return nil, errors.New("no secret")
+		}
+		return bytes.ToUpper(data), nil
+	}
+	exclaim := func(name string, data []byte) ([]byte, error) {
+		return append(data, '!'), nil
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a\r\n"})
+
+		gfs := NewWithClient(client, referenceGistID, WithNormalizeNewlines(), WithTransform(upper), WithTransform(exclaim))
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		if b, _ := gfs.ReadFile("a.txt"); string(b) != "A\n!" {
+			t.Fatalf("Reading, got %#v, want %#v", string(b), "A\n!")
+		}
+		if info, _ := fs.Stat(gfs, "a.txt"); info.Size() != 3 {
+			t.Fatalf("Stat, got a size of %d, want the size of the transformed content %d", info.Size(), 3)
+		}
+	})
+
+	t.Run("NOK load", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a", "secret.txt": "s"})
+
+		gfs := NewWithClient(client, referenceGistID, WithTransform(upper))
+		err := gfs.Load(context.Background())
+
+		var gistErr *Error
+		if !errors.As(err, &gistErr) || gfs.IsLoaded() {
+			t.Fatalf("Loading, got %#v, want an *Error and nothing loaded", err)
+		}
+	})
+
+	t.Run("NOK deferred content", func(t *testing.T) {
+		getter := &stubGetter{
+			gist: &Gist{
+				ID: referenceGistID,
+				Files: map[string]GistFile{
+					"secret.txt": {Filename: "secret.txt", Content: "big", Size: 9, Truncated: true, RawURL: "https://raw/secret.txt"},
+				},
+			},
+			raw: map[string]string{"https://raw/secret.txt": "big file!"},
+		}
+
+		gfs := NewWithGetter(getter, referenceGistID, WithTransform(upper))
+		if err := gfs.LoadMetadata(context.Background()); err != nil {
+			t.Fatalf("Loading metadata, expected no error but got %#v", err)
+		}
+
+		if _, err := gfs.ReadFile("secret.txt"); err == nil {
+			t.Fatal("Reading, expected an error but got none")
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package gistfs
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Unload drops the loaded gist from memory, to be loaded again by the next
+// read, or by Load. It gives memory back in long running servers holding
+// many rarely used gists. The filesystem keeps reporting being loaded, and
+// watchers are notified of the changes found once the gist is loaded again,
+// as for a gist evicted by an LRU.
+//
+// With a cache set by WithCache, such as WithDiskCache, the gist is loaded
+// again from the cache along with its ETag, which Github is asked about with
+// a conditional request, answered without content and without counting
+// against the rate limit if the gist did not change. Without a cache, it is
+// downloaded again.
+func (fsys *FS) Unload() {
+	fsys.stopIdleTimer()
+	fsys.unload()
+}
+
+// unload drops the loaded gist, as if it was evicted by the LRU of the
+// filesystem, which stops tracking it.
+func (fsys *FS) unload() {
+	if fsys.lru != nil {
+		fsys.lru.remove(fsys)
+	}
+
+	fsys.evict()
+}
+
+// WithIdleTimeout unloads the gist, as Unload does, once it has not been
+// read for d. It is loaded again by the next read.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(fsys *FS) {
+		fsys.idleTimeout = d
+		fsys.idle = &idleTimer{}
+	}
+}
+
+// idleTimer unloads a filesystem once it is idle, see WithIdleTimeout.
+type idleTimer struct {
+	// lastUsed is when the filesystem was last read, in nanoseconds since
+	// the Unix epoch. It comes first to be aligned for atomic operations.
+	lastUsed int64
+
+	mu    sync.Mutex
+	timer *time.Timer
+}
+
+// markUsed records that the filesystem is being read, if it is unloaded
+// when idle.
+func (fsys *FS) markUsed() {
+	if fsys.idle != nil {
+		atomic.StoreInt64(&fsys.idle.lastUsed, fsys.now().UnixNano())
+	}
+}
+
+// startIdleTimer starts waiting for the filesystem to become idle, if it is
+// unloaded when idle and not already waiting.
+func (fsys *FS) startIdleTimer() {
+	if fsys.idle == nil {
+		return
+	}
+
+	fsys.markUsed()
+
+	fsys.idle.mu.Lock()
+	defer fsys.idle.mu.Unlock()
+
+	if fsys.idle.timer == nil {
+		fsys.idle.timer = time.AfterFunc(fsys.idleTimeout, fsys.checkIdle)
+	}
+}
+
+// stopIdleTimer stops waiting for the filesystem to become idle.
+func (fsys *FS) stopIdleTimer() {
+	if fsys.idle == nil {
+		return
+	}
+
+	fsys.idle.mu.Lock()
+	defer fsys.idle.mu.Unlock()
+
+	if fsys.idle.timer != nil {
+		fsys.idle.timer.Stop()
+		fsys.idle.timer = nil
+	}
+}
+
+// checkIdle unloads the filesystem if it was not read for its idle timeout,
+// and waits for the rest of it otherwise.
+func (fsys *FS) checkIdle() {
+	idle := fsys.now().Sub(time.Unix(0, atomic.LoadInt64(&fsys.idle.lastUsed)))
+
+	fsys.idle.mu.Lock()
+	if fsys.idle.timer == nil {
+		// Stopped meanwhile.
+		fsys.idle.mu.Unlock()
+		return
+	}
+	if idle < fsys.idleTimeout {
+		fsys.idle.timer.Reset(fsys.idleTimeout - idle)
+		fsys.idle.mu.Unlock()
+		return
+	}
+	fsys.idle.timer = nil
+	fsys.idle.mu.Unlock()
+
+	fsys.unload()
+}
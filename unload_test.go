@@ -0,0 +1,59 @@
+package gistfs
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestUnload(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+	ctx := context.Background()
+
+	t.Run("OK conditional reload", func(t *testing.T) {
+		l := NewLRU(0, 0)
+		gfs := NewWithClient(client, referenceGistID, WithCache(NewMemoryCache()), WithLRU(l))
+		if err := gfs.Load(ctx); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		gfs.Unload()
+		if gfs.state().gist != nil || !gfs.IsLoaded() {
+			t.Fatal("Unloading, the gist is still held, or the filesystem reports not being loaded")
+		}
+		if got, want := l.Len(), 0; got != want {
+			t.Fatalf("Unloading, got %d gists held by the LRU, want %d", got, want)
+		}
+
+		requests := fg.Requests()
+		if b, err := gfs.ReadFile("a.txt"); err != nil || string(b) != "a" {
+			t.Fatalf("Reading after unloading, got %q (%v), want %q", b, err, "a")
+		}
+		if got, want := fg.Requests()-requests, 1; got != want {
+			t.Fatalf("Reading after unloading, got %d requests, want %d", got, want)
+		}
+		if fg.LastHeader().Get("If-None-Match") == "" {
+			t.Fatal("Reading after unloading, the gist was not loaded with a conditional request")
+		}
+	})
+
+	t.Run("OK idle timeout", func(t *testing.T) {
+		gfs := NewWithClient(client, referenceGistID, WithIdleTimeout(20*time.Millisecond))
+		if err := gfs.Load(ctx); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		deadline := time.Now().Add(2 * time.Second)
+		for gfs.state().gist != nil {
+			if time.Now().After(deadline) {
+				t.Fatal("Waiting for the idle timeout, the gist was never unloaded")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		if b, err := gfs.ReadFile("a.txt"); err != nil || string(b) != "a" {
+			t.Fatalf("Reading after an idle timeout, got %q (%v), want %q", b, err, "a")
+		}
+		gfs.Unload()
+	})
+}
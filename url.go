@@ -0,0 +1,103 @@
+package gistfs
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// gistIDPattern matches gist IDs, which are hexadecimal for modern gists
+// and decimal for the oldest ones.
+var gistIDPattern = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+
+// ParseURL extracts the gist ID and, if the URL points to a specific
+// revision, the revision SHA from a gist URL. It understands the URLs of
+// gist web pages, raw files, the Github API and git remotes, such as:
+//
+//	https://gist.github.com/ded2f6727d98e6b0095e62a7813aa7cf
+//	https://gist.github.com/jhchabran/ded2f6727d98e6b0095e62a7813aa7cf/<revision>
+//	https://gist.githubusercontent.com/jhchabran/ded2f6727d98e6b0095e62a7813aa7cf/raw/<revision>/test1.txt
+//	https://api.github.com/gists/ded2f6727d98e6b0095e62a7813aa7cf
+//	git@gist.github.com:ded2f6727d98e6b0095e62a7813aa7cf.git
+//
+// Fragments, such as the "#file-test1-txt" anchors of web pages, are ignored.
+func ParseURL(rawurl string) (id string, revision string, err error) {
+	if strings.HasPrefix(rawurl, "git@") {
+		// scp-like git remote, turn it into an URL that net/url understands.
+		rawurl = "ssh://" + strings.Replace(rawurl, ":", "/", 1)
+	}
+
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "", "", err
+	}
+
+	var parts []string
+	for _, p := range strings.Split(u.Path, "/") {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+
+	if len(parts) > 0 && u.Host == "api.github.com" {
+		if parts[0] != "gists" {
+			return "", "", fmt.Errorf("%q is not a gist API URL", rawurl)
+		}
+		parts = parts[1:]
+	}
+
+	for i := range parts {
+		parts[i] = strings.TrimSuffix(strings.TrimSuffix(parts[i], ".git"), ".js")
+	}
+
+	// the owner is optional and comes first when present, telling it apart
+	// from a gist ID followed by a revision, since owners can look like IDs.
+	if len(parts) > 1 && (!isGistID(parts[0]) || (isGistID(parts[1]) && !isRevision(parts[1]))) {
+		parts = parts[1:]
+	}
+
+	if len(parts) == 0 || !isGistID(parts[0]) {
+		return "", "", fmt.Errorf("%q does not contain a gist ID", rawurl)
+	}
+
+	id, rest := parts[0], parts[1:]
+	if len(rest) > 0 && rest[0] == "raw" {
+		// raw/<revision>/<file> pins a revision, raw/<file> does not.
+		rest = rest[1:]
+		if len(rest) < 2 {
+			rest = nil
+		}
+	}
+
+	if len(rest) > 0 && isRevision(rest[0]) {
+		revision = rest[0]
+	}
+
+	return id, revision, nil
+}
+
+func isGistID(s string) bool {
+	return gistIDPattern.MatchString(s)
+}
+
+// isRevision reports whether s looks like a revision SHA.
+func isRevision(s string) bool {
+	return len(s) == 40 && isGistID(s)
+}
+
+// NewFromURL returns a FS based on the gist a URL points to, as understood
+// by ParseURL. If the URL points to a specific revision, the filesystem is
+// pinned to it, see WithRevision.
+func NewFromURL(rawurl string, opts ...Option) (*FS, error) {
+	id, revision, err := ParseURL(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	if revision != "" {
+		opts = append([]Option{WithRevision(revision)}, opts...)
+	}
+
+	return New(id, opts...), nil
+}
@@ -0,0 +1,89 @@
+package gistfs
+
+import (
+	"context"
+	"testing"
+)
+
+const referenceRevision = "a4b8a4f7e2b5b3a4b1c0d9e8f7a6b5c4d3e2f1a0"
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		id       string
+		revision string
+	}{
+		{"https://gist.github.com/ded2f6727d98e6b0095e62a7813aa7cf", referenceGistID, ""},
+		{"https://gist.github.com/jhchabran/ded2f6727d98e6b0095e62a7813aa7cf", referenceGistID, ""},
+		{"https://gist.github.com/jhchabran/ded2f6727d98e6b0095e62a7813aa7cf#file-test1-txt", referenceGistID, ""},
+		{"https://gist.github.com/jhchabran/ded2f6727d98e6b0095e62a7813aa7cf/" + referenceRevision, referenceGistID, referenceRevision},
+		{"https://gist.github.com/ded2f6727d98e6b0095e62a7813aa7cf/" + referenceRevision, referenceGistID, referenceRevision},
+		{"https://gist.github.com/cafe/ded2f6727d98e6b0095e62a7813aa7cf", referenceGistID, ""},
+		{"https://gist.github.com/jhchabran/ded2f6727d98e6b0095e62a7813aa7cf.js", referenceGistID, ""},
+		{"https://gist.githubusercontent.com/jhchabran/ded2f6727d98e6b0095e62a7813aa7cf/raw/" + referenceRevision + "/test1.txt", referenceGistID, referenceRevision},
+		{"https://gist.githubusercontent.com/jhchabran/ded2f6727d98e6b0095e62a7813aa7cf/raw/test1.txt", referenceGistID, ""},
+		{"https://api.github.com/gists/ded2f6727d98e6b0095e62a7813aa7cf", referenceGistID, ""},
+		{"https://api.github.com/gists/ded2f6727d98e6b0095e62a7813aa7cf/" + referenceRevision, referenceGistID, referenceRevision},
+		{"https://gist.github.com/ded2f6727d98e6b0095e62a7813aa7cf.git", referenceGistID, ""},
+		{"git@gist.github.com:ded2f6727d98e6b0095e62a7813aa7cf.git", referenceGistID, ""},
+		{"ded2f6727d98e6b0095e62a7813aa7cf", referenceGistID, ""},
+	}
+
+	for _, test := range tests {
+		id, revision, err := ParseURL(test.url)
+		if err != nil {
+			t.Fatalf("Parsing %#v, expected no error but got %#v", test.url, err)
+		}
+
+		if id != test.id || revision != test.revision {
+			t.Fatalf("Parsing %#v, got (%#v, %#v), want (%#v, %#v)", test.url, id, revision, test.id, test.revision)
+		}
+	}
+
+	t.Run("NOK", func(t *testing.T) {
+		tests := []string{
+			"https://gist.github.com/",
+			"https://gist.github.com/jhchabran",
+			"https://api.github.com/users/jhchabran",
+			"://",
+		}
+
+		for _, test := range tests {
+			if _, _, err := ParseURL(test); err == nil {
+				t.Fatalf("Parsing %#v, got no error, want one", test)
+			}
+		}
+	})
+}
+
+func TestNewFromURL(t *testing.T) {
+	t.Run("OK pinned revision", func(t *testing.T) {
+		gfs, err := NewFromURL("https://gist.github.com/jhchabran/ded2f6727d98e6b0095e62a7813aa7cf/" + referenceRevision)
+		if err != nil {
+			t.Fatalf("Creating from URL, expected no error but got %#v", err)
+		}
+
+		if got, want := gfs.GetID(), referenceGistID; got != want {
+			t.Fatalf("NewFromURL returned a FS with ID=%#v, want %#v", got, want)
+		}
+
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "latest"})
+		fg.AddRevision(referenceRevision, map[string]string{"a.txt": "pinned"})
+		gfs.client.BaseURL = client.BaseURL
+
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading a pinned revision, expected no error but got %#v", err)
+		}
+
+		b, _ := gfs.ReadFile("a.txt")
+		if got, want := string(b), "pinned"; got != want {
+			t.Fatalf("Reading a pinned revision, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("NOK invalid URL", func(t *testing.T) {
+		if _, err := NewFromURL("https://gist.github.com/"); err == nil {
+			t.Fatal("Creating from an invalid URL, got no error, want one")
+		}
+	})
+}
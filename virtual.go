@@ -0,0 +1,235 @@
+package gistfs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// virtualFiles generates files served alongside the ones of the gist, by
+// path, given the state of the filesystem.
+type virtualFiles func(fsys *FS, s *state) map[string]github.GistFile
+
+// files returns all the files served by the filesystem by path: the files of
+// the gist and the virtual ones, which cannot hide a file of the gist.
+func (fsys *FS) files(s *state) map[string]github.GistFile {
+	files := make(map[string]github.GistFile, len(s.gist.Files))
+	for _, gen := range fsys.virtuals {
+		for p, f := range gen(fsys, s) {
+			files[p] = f
+		}
+	}
+
+	for name, f := range s.gist.Files {
+		files[fsys.filePath(string(name))] = f
+	}
+
+	return files
+}
+
+// filePath returns the path a file of the gist is served at.
+func (fsys *FS) filePath(name string) string {
+	if fsys.pathSeparator == "" {
+		return name
+	}
+
+	p := strings.ReplaceAll(name, fsys.pathSeparator, "/")
+	if !fs.ValidPath(p) {
+		return name
+	}
+
+	return p
+}
+
+// aliasFiles returns the files of the gist served under the given aliases.
+func (fsys *FS) aliasFiles(s *state, aliases map[string]string) map[string]github.GistFile {
+	byPath := make(map[string]github.GistFile, len(s.gist.Files))
+	for name, f := range s.gist.Files {
+		byPath[fsys.filePath(string(name))] = f
+	}
+
+	files := make(map[string]github.GistFile, len(aliases))
+	for alias, target := range aliases {
+		if f, ok := byPath[target]; ok {
+			files[alias] = f
+		}
+	}
+
+	return files
+}
+
+// indexPage is the name of the file http.FileServer serves for directories.
+const indexPage = "index.html"
+
+// indexFiles returns the first of the given files found in each directory of
+// the gist that can be served as its index page.
+func (fsys *FS) indexFiles(s *state, names []string) map[string]github.GistFile {
+	byPath := make(map[string]github.GistFile, len(s.gist.Files))
+	dirs := map[string]bool{".": true}
+	for name, f := range s.gist.Files {
+		p := fsys.filePath(string(name))
+		byPath[p] = f
+		for d := path.Dir(p); d != "."; d = path.Dir(d) {
+			dirs[d] = true
+		}
+	}
+
+	files := make(map[string]github.GistFile)
+	for d := range dirs {
+		index := path.Join(d, indexPage)
+		if _, ok := byPath[index]; ok {
+			continue
+		}
+
+		for _, name := range names {
+			if f, ok := fsys.indexFile(s, index, byPath[path.Join(d, name)]); ok {
+				files[index] = f
+				break
+			}
+		}
+	}
+
+	return files
+}
+
+// indexFile returns f, a file of the gist, as the index page served at p.
+// HTML files are served as is, and Markdown ones rendered to HTML. Other
+// files, and Markdown ones whose content was deferred by LoadMetadata, can't
+// be.
+func (fsys *FS) indexFile(s *state, p string, f github.GistFile) (github.GistFile, bool) {
+	switch strings.ToLower(path.Ext(f.GetFilename())) {
+	case ".html", ".htm":
+		return f, true
+	case ".md", ".markdown":
+		if isTruncated(f) {
+			return github.GistFile{}, false
+		}
+
+		content, err := fsys.content(s, &f)
+		if err != nil {
+			return github.GistFile{}, false
+		}
+		page, err := renderMarkdownPage(goldmarkRenderer, f.GetFilename(), []byte(content))
+		if err != nil {
+			return github.GistFile{}, false
+		}
+
+		return virtualFile(p, page, "HTML"), true
+	}
+
+	return github.GistFile{}, false
+}
+
+// ErrCaseCollision is returned by Load when the filesystem is created with
+// WithCaseInsensitive and the gist holds files whose names only differ by
+// their case.
+var ErrCaseCollision = errors.New("file names only differ by case")
+
+// checkCaseCollisions returns an error wrapping ErrCaseCollision if two files
+// of gist are served at paths only differing by case.
+func (fsys *FS) checkCaseCollisions(gist *github.Gist) error {
+	seen := make(map[string]string, len(gist.Files))
+	for name := range gist.Files {
+		p := fsys.filePath(string(name))
+		key := strings.ToLower(p)
+		if other, ok := seen[key]; ok {
+			if other > p {
+				other, p = p, other
+			}
+			return &Error{Op: "load", ID: fsys.GetID(), Err: fmt.Errorf("%w: %q and %q", ErrCaseCollision, other, p)}
+		}
+		seen[key] = p
+	}
+
+	return nil
+}
+
+// resolve returns the path of the file or directory name refers to in s,
+// ignoring case if the filesystem is case insensitive. Names that do not
+// refer to anything are returned as is.
+func (fsys *FS) resolve(s *state, name string) string {
+	if !fsys.caseInsensitive {
+		return name
+	}
+
+	return s.index.fold(name)
+}
+
+// virtualFile returns a file holding content, named after the base name of p.
+func virtualFile(p string, content []byte, language string) github.GistFile {
+	return github.GistFile{
+		Filename: github.String(path.Base(p)),
+		Language: optionalString(language),
+		Size:     github.Int(len(content)),
+		Content:  github.String(string(content)),
+	}
+}
+
+// MetaFile is the path of the virtual file added by WithMetaFile.
+const MetaFile = ".gist/meta.json"
+
+// WithMetaFile exposes the metadata of the gist as JSON in a virtual file
+// at MetaFile, so that consumers only dealing with fs.FS can access them.
+// It holds the GistMetadata of the gist, also returned by the Sys method of
+// the FileInfo of the root directory.
+func WithMetaFile() Option {
+	return func(fsys *FS) {
+		fsys.virtuals = append(fsys.virtuals, metaFile)
+	}
+}
+
+func metaFile(fsys *FS, s *state) map[string]github.GistFile {
+	b, err := json.MarshalIndent(newGistMetadata(s), "", "  ")
+	if err != nil {
+		return nil
+	}
+
+	return map[string]github.GistFile{
+		MetaFile: virtualFile(MetaFile, append(b, '\n'), "JSON"),
+	}
+}
+
+// ReadmeFile is the default name of the virtual file added by WithReadme.
+const ReadmeFile = "README.md"
+
+// WithReadme exposes the description of the gist as a virtual Markdown file
+// at the root of the filesystem, named name or ReadmeFile if empty, so that
+// tools expecting a README find one. It is only served when the description
+// is not empty and the gist holds no file with the same name, ignoring case.
+func WithReadme(name string) Option {
+	if name == "" {
+		name = ReadmeFile
+	}
+
+	return func(fsys *FS) {
+		fsys.virtuals = append(fsys.virtuals, func(fsys *FS, s *state) map[string]github.GistFile {
+			return fsys.readmeFile(s, name)
+		})
+	}
+}
+
+func (fsys *FS) readmeFile(s *state, name string) map[string]github.GistFile {
+	desc := s.gist.GetDescription()
+	if desc == "" {
+		return nil
+	}
+
+	for n := range s.gist.Files {
+		if strings.EqualFold(fsys.filePath(string(n)), name) {
+			return nil
+		}
+	}
+
+	if !strings.HasSuffix(desc, "\n") {
+		desc += "\n"
+	}
+
+	return map[string]github.GistFile{
+		name: virtualFile(name, []byte(desc), "Markdown"),
+	}
+}
@@ -0,0 +1,168 @@
+package gistfs
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"testing"
+)
+
+func TestWithMetaFile(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+	fg.Description = "my gist"
+	fg.Owner = "jhchabran"
+
+	gfs := NewWithClient(client, referenceGistID, WithMetaFile())
+	if err := gfs.Load(context.Background()); err != nil {
+		t.Fatalf("Loading, expected no error but got %#v", err)
+	}
+
+	t.Run("OK read", func(t *testing.T) {
+		b, err := gfs.ReadFile(MetaFile)
+		if err != nil {
+			t.Fatalf("Reading the meta file, expected no error but got %#v", err)
+		}
+
+		var meta GistMetadata
+		if err := json.Unmarshal(b, &meta); err != nil {
+			t.Fatalf("Decoding the meta file, expected no error but got %#v", err)
+		}
+
+		want := GistMetadata{
+			ID:          referenceGistID,
+			Description: "my gist",
+			Owner:       "jhchabran",
+			Public:      fg.Public,
+			HTMLURL:     "https://gist.github.com/" + referenceGistID,
+			CreatedAt:   meta.CreatedAt,
+			UpdatedAt:   fakeUpdatedAt,
+			Revision:    fg.Revisions()[0],
+		}
+		if meta != want {
+			t.Fatalf("Reading the meta file, got %+v, want %+v", meta, want)
+		}
+	})
+
+	t.Run("OK metadata", func(t *testing.T) {
+		info, err := fs.Stat(gfs, MetaFile)
+		if err != nil {
+			t.Fatalf("Stating the meta file, expected no error but got %#v", err)
+		}
+
+		meta := info.Sys().(*FileMetadata)
+		if got, want := meta.MIMEType, "application/json"; got != want {
+			t.Fatalf("Stating the meta file, got MIME type %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK directories", func(t *testing.T) {
+		entries, err := gfs.ReadDir(".")
+		if err != nil {
+			t.Fatalf("Reading root directory, expected no error but got %#v", err)
+		}
+
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		if got, want := names, []string{".gist", "a.txt"}; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+			t.Fatalf("Reading root directory, got %v, want %v", got, want)
+		}
+
+		if !entries[0].IsDir() {
+			t.Fatal("Reading root directory, got .gist as a file, want a directory")
+		}
+
+		matches, err := fs.Glob(gfs, ".gist/*")
+		if err != nil || len(matches) != 1 || matches[0] != MetaFile {
+			t.Fatalf("Globbing .gist, got %v (%v), want [%s]", matches, err, MetaFile)
+		}
+
+		f, err := gfs.Open(".gist")
+		if err != nil {
+			t.Fatalf("Opening .gist, expected no error but got %#v", err)
+		}
+
+		stat, _ := f.Stat()
+		if got, want := stat.Name(), ".gist"; got != want || !stat.IsDir() {
+			t.Fatalf("Stat of .gist, got %#v (dir: %v), want a %#v directory", got, stat.IsDir(), want)
+		}
+	})
+
+	t.Run("OK hidden by default", func(t *testing.T) {
+		plain := NewWithClient(client, referenceGistID)
+		plain.Load(context.Background())
+
+		if _, err := plain.ReadFile(MetaFile); err == nil {
+			t.Fatal("Reading the meta file without the option, got no error, want one")
+		}
+
+		if _, err := plain.Open(".gist"); err == nil {
+			t.Fatal("Opening .gist without the option, got no error, want one")
+		}
+	})
+}
+
+func TestWithReadme(t *testing.T) {
+	t.Run("OK description", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		fg.Description = "my gist"
+
+		gfs := NewWithClient(client, referenceGistID, WithReadme(""))
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		b, err := gfs.ReadFile(ReadmeFile)
+		if err != nil {
+			t.Fatalf("Reading the readme, expected no error but got %#v", err)
+		}
+		if got, want := string(b), "my gist\n"; got != want {
+			t.Fatalf("Reading the readme, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK custom name", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		fg.Description = "my gist"
+
+		gfs := NewWithClient(client, referenceGistID, WithReadme("index.md"))
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		if _, err := fs.Stat(gfs, "index.md"); err != nil {
+			t.Fatalf("Stating the readme, expected no error but got %#v", err)
+		}
+		if _, err := fs.Stat(gfs, ReadmeFile); err == nil {
+			t.Fatalf("Stating %s, expected an error but got none", ReadmeFile)
+		}
+	})
+
+	t.Run("OK gist readme", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"readme.md": "mine"})
+		fg.Description = "my gist"
+
+		gfs := NewWithClient(client, referenceGistID, WithReadme(""))
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		if _, err := fs.Stat(gfs, ReadmeFile); err == nil {
+			t.Fatalf("Stating %s, expected an error but got none", ReadmeFile)
+		}
+	})
+
+	t.Run("OK no description", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+
+		gfs := NewWithClient(client, referenceGistID, WithReadme(""))
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		if _, err := fs.Stat(gfs, ReadmeFile); err == nil {
+			t.Fatalf("Stating %s, expected an error but got none", ReadmeFile)
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package gistfs
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// ErrVisibility is returned by Load when the filesystem is created with
+// WithSecretOnly and the gist is public, or with WithPublicOnly and the gist
+// is secret.
+var ErrVisibility = errors.New("gist visibility not allowed")
+
+// visibility restricts the gists a filesystem loads by their visibility.
+type visibility int
+
+const (
+	anyVisibility visibility = iota
+	secretOnly
+	publicOnly
+)
+
+// WithSecretOnly makes Load fail with ErrVisibility if the gist is public,
+// keeping the previously loaded content, if any. It guards deployments that
+// must only read secret gists, such as ones loading credentials, against
+// being pointed at a public gist by mistake.
+func WithSecretOnly() Option {
+	return func(fsys *FS) {
+		fsys.visibility = secretOnly
+	}
+}
+
+// WithPublicOnly makes Load fail with ErrVisibility if the gist is secret,
+// keeping the previously loaded content, if any.
+func WithPublicOnly() Option {
+	return func(fsys *FS) {
+		fsys.visibility = publicOnly
+	}
+}
+
+// checkVisibility returns an error wrapping ErrVisibility if gist can't be
+// served given the visibility required by the filesystem.
+func (fsys *FS) checkVisibility(gist *github.Gist) error {
+	switch {
+	case fsys.visibility == secretOnly && gist.GetPublic():
+		return &Error{Op: "load", ID: fsys.GetID(), Err: fmt.Errorf("%w: gist is public", ErrVisibility)}
+	case fsys.visibility == publicOnly && !gist.GetPublic():
+		return &Error{Op: "load", ID: fsys.GetID(), Err: fmt.Errorf("%w: gist is secret", ErrVisibility)}
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestVisibility(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+
+	t.Run("OK not loaded", func(t *testing.T) {
+		gfs := NewWithClient(client, referenceGistID)
+		if gfs.Public() || gfs.IsSecret() {
+			t.Fatalf("Before loading, got Public %v and IsSecret %v, want neither", gfs.Public(), gfs.IsSecret())
+		}
+	})
+
+	t.Run("OK secret only", func(t *testing.T) {
+		fg.SetPublic(false)
+
+		gfs := NewWithClient(client, referenceGistID, WithSecretOnly())
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading a secret gist, expected no error but got %#v", err)
+		}
+		if gfs.Public() || !gfs.IsSecret() {
+			t.Fatalf("Loading a secret gist, got Public %v and IsSecret %v, want a secret gist", gfs.Public(), gfs.IsSecret())
+		}
+
+		fg.SetPublic(true)
+		fg.SetFiles(map[string]string{"a.txt": "b"})
+
+		if err := gfs.Load(context.Background()); !errors.Is(err, ErrVisibility) {
+			t.Fatalf("Loading a public gist, got %#v, want %#v", err, ErrVisibility)
+		}
+
+		b, _ := gfs.ReadFile("a.txt")
+		if got, want := string(b), "a"; got != want {
+			t.Fatalf("Reading after loading a public gist, got %#v, want the previous content %#v", got, want)
+		}
+	})
+
+	t.Run("NOK public only", func(t *testing.T) {
+		fg.SetPublic(false)
+
+		gfs := NewWithClient(client, referenceGistID, WithPublicOnly())
+		if err := gfs.Load(context.Background()); !errors.Is(err, ErrVisibility) {
+			t.Fatalf("Loading a secret gist, got %#v, want %#v", err, ErrVisibility)
+		}
+		if gfs.IsLoaded() {
+			t.Fatal("Loading a secret gist, the filesystem is loaded")
+		}
+	})
+}
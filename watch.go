@@ -0,0 +1,171 @@
+package gistfs
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// ChangeOp describes what happened to a file between two loads of a gist.
+type ChangeOp int
+
+const (
+	// Added means the file did not exist in the previous load.
+	Added ChangeOp = iota + 1
+	// Modified means the file content changed since the previous load.
+	Modified
+	// Removed means the file existed in the previous load but is now gone.
+	Removed
+)
+
+func (op ChangeOp) String() string {
+	switch op {
+	case Added:
+		return "added"
+	case Modified:
+		return "modified"
+	case Removed:
+		return "removed"
+	default:
+		return "unknown"
+	}
+}
+
+// ChangeEvent reports a change on a single file of the gist.
+type ChangeEvent struct {
+	Op   ChangeOp
+	Name string
+}
+
+// watchBufferSize is the number of events a watcher can lag behind before
+// Load blocks on delivering them.
+const watchBufferSize = 16
+
+type watcher struct {
+	ctx context.Context
+	ch  chan ChangeEvent
+}
+
+// watchers are the receivers of the changes found by the loads of
+// a filesystem.
+type watchers struct {
+	mu   sync.Mutex
+	list []*watcher
+}
+
+// Watch returns a channel on which a ChangeEvent is sent for every file
+// that was added, modified or removed by a subsequent Load. Events of
+// a given Load are sent in filename order.
+//
+// The channel is closed once ctx is done. Slow receivers delay Load, which
+// waits for the events to be delivered or for ctx to be done.
+func (fsys *FS) Watch(ctx context.Context) (<-chan ChangeEvent, error) {
+	return fsys.watchers.watch(ctx)
+}
+
+// notify delivers events to all current watchers.
+func (fsys *FS) notify(events []ChangeEvent) {
+	fsys.watchers.notify(events)
+}
+
+// watch adds a watcher receiving events until ctx is done.
+func (ws *watchers) watch(ctx context.Context) (<-chan ChangeEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	w := &watcher{
+		ctx: ctx,
+		ch:  make(chan ChangeEvent, watchBufferSize),
+	}
+
+	ws.mu.Lock()
+	ws.list = append(ws.list, w)
+	ws.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		ws.mu.Lock()
+		defer ws.mu.Unlock()
+
+		for i, other := range ws.list {
+			if other == w {
+				ws.list = append(ws.list[:i], ws.list[i+1:]...)
+				break
+			}
+		}
+		close(w.ch)
+	}()
+
+	return w.ch, nil
+}
+
+// notify delivers events to all current watchers.
+func (ws *watchers) notify(events []ChangeEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	for _, w := range ws.list {
+		for _, ev := range events {
+			select {
+			case w.ch <- ev:
+			case <-w.ctx.Done():
+			}
+		}
+	}
+}
+
+// diffGists compares the files of two gists and returns the changes needed
+// to go from old to new. A nil old gist is treated as an empty one.
+func diffGists(old *github.Gist, oldExtra gistExtra, new *github.Gist, newExtra gistExtra) []ChangeEvent {
+	var oldFiles, newFiles map[github.GistFilename]github.GistFile
+	if old != nil {
+		oldFiles = old.Files
+	}
+	if new != nil {
+		newFiles = new.Files
+	}
+
+	var events []ChangeEvent
+	for name, f := range newFiles {
+		prev, ok := oldFiles[name]
+		switch {
+		case !ok:
+			events = append(events, ChangeEvent{Op: Added, Name: string(name)})
+		case oldExtra.contentKey(name, prev) != newExtra.contentKey(name, f):
+			events = append(events, ChangeEvent{Op: Modified, Name: string(name)})
+		}
+	}
+
+	for name := range oldFiles {
+		if _, ok := newFiles[name]; !ok {
+			events = append(events, ChangeEvent{Op: Removed, Name: string(name)})
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].Name < events[j].Name })
+
+	return events
+}
+
+// contentKey identifies the content of a file, so that files of gists
+// stored alike can be compared without decompressing them.
+type contentKey struct {
+	compressed bool
+	content    string
+}
+
+func (e gistExtra) contentKey(name github.GistFilename, f github.GistFile) contentKey {
+	if z, ok := e.compressed[name]; ok && f.Content == nil {
+		return contentKey{compressed: true, content: z}
+	}
+
+	return contentKey{content: f.GetContent()}
+}
@@ -0,0 +1,112 @@
+package gistfs
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	t.Run("OK events", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{
+			"a.txt": "a",
+			"b.txt": "b",
+		})
+		gfs := NewWithClient(client, referenceGistID)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ch, err := gfs.Watch(ctx)
+		if err != nil {
+			t.Fatalf("Watching, expected no error but got %#v", err)
+		}
+
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		want := []ChangeEvent{{Added, "a.txt"}, {Added, "b.txt"}}
+		if got := receiveEvents(t, ch, len(want)); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Watching first load, got %v, want %v", got, want)
+		}
+
+		fg.SetFiles(map[string]string{
+			"b.txt": "bb",
+			"c.txt": "c",
+		})
+
+		if err := gfs.Load(context.Background()); err != nil {
+			t.Fatalf("Loading, expected no error but got %#v", err)
+		}
+
+		want = []ChangeEvent{{Removed, "a.txt"}, {Modified, "b.txt"}, {Added, "c.txt"}}
+		if got := receiveEvents(t, ch, len(want)); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Watching refresh, got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("OK no events when unchanged", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		gfs := NewWithClient(client, referenceGistID)
+		gfs.Load(context.Background())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ch, _ := gfs.Watch(ctx)
+		gfs.Load(context.Background())
+
+		select {
+		case ev := <-ch:
+			t.Fatalf("Reloading an unchanged gist, got event %v, want none", ev)
+		default:
+		}
+	})
+
+	t.Run("OK closed on cancel", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		gfs := NewWithClient(client, referenceGistID)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		ch, _ := gfs.Watch(ctx)
+		cancel()
+
+		select {
+		case _, ok := <-ch:
+			if ok {
+				t.Fatal("Receiving after cancel, got an event, want a closed channel")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Receiving after cancel, channel was not closed")
+		}
+	})
+
+	t.Run("NOK done context", func(t *testing.T) {
+		gfs := New(referenceGistID)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if _, err := gfs.Watch(ctx); err != context.Canceled {
+			t.Fatalf("Watching with a done context, got %#v, want %#v", err, context.Canceled)
+		}
+	})
+}
+
+func receiveEvents(t *testing.T, ch <-chan ChangeEvent, n int) []ChangeEvent {
+	t.Helper()
+
+	var events []ChangeEvent
+	for len(events) < n {
+		select {
+		case ev := <-ch:
+			events = append(events, ev)
+		case <-time.After(time.Second):
+			t.Fatalf("Waiting for events, got %v, want %d events", events, n)
+		}
+	}
+
+	return events
+}
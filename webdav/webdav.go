@@ -0,0 +1,135 @@
+// Package webdav exposes a gistfs.FS over WebDAV, so that a gist can be
+// mounted by the file managers of most operating systems:
+//
+//	http.ListenAndServe(":8080", webdav.Handler(gfs))
+//
+// The filesystem is read-only, as gists can't be written through gistfs.
+package webdav
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/jhchabran/gistfs"
+	"golang.org/x/net/webdav"
+)
+
+// writeFlags are the flags of os.OpenFile asking for write access.
+const writeFlags = os.O_WRONLY | os.O_RDWR | os.O_CREATE | os.O_TRUNC | os.O_APPEND
+
+// fileSystem is the webdav.FileSystem returned by FileSystem.
+type fileSystem struct {
+	fsys *gistfs.FS
+}
+
+// FileSystem returns a read-only webdav.FileSystem serving the files of
+// fsys, which must be loaded beforehand. Operations modifying it fail with
+// os.ErrPermission.
+func FileSystem(fsys *gistfs.FS) webdav.FileSystem {
+	return &fileSystem{fsys: fsys}
+}
+
+// Handler returns a webdav.Handler serving the files of fsys, which must be
+// loaded beforehand, read-only.
+func Handler(fsys *gistfs.FS) http.Handler {
+	return &webdav.Handler{
+		FileSystem: FileSystem(fsys),
+		LockSystem: webdav.NewMemLS(),
+	}
+}
+
+// fsName converts a WebDAV name, which is slash-separated and rooted, into
+// the name of a file of fs.FS.
+func fsName(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return "."
+	}
+
+	return name
+}
+
+func (fsys *fileSystem) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrPermission}
+}
+
+func (fsys *fileSystem) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	if flag&writeFlags != 0 {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+
+	f, err := fsys.fsys.OpenContext(ctx, fsName(name))
+	if err != nil {
+		return nil, err
+	}
+
+	return &file{File: f, name: name}, nil
+}
+
+func (fsys *fileSystem) RemoveAll(ctx context.Context, name string) error {
+	return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrPermission}
+}
+
+func (fsys *fileSystem) Rename(ctx context.Context, oldName, newName string) error {
+	return &fs.PathError{Op: "rename", Path: oldName, Err: fs.ErrPermission}
+}
+
+func (fsys *fileSystem) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	f, err := fsys.fsys.OpenContext(ctx, fsName(name))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return f.Stat()
+}
+
+// file adapts an fs.File opened from a gistfs.FS to webdav.File.
+type file struct {
+	fs.File
+	name string
+}
+
+func (f *file) Write(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "write", Path: f.name, Err: fs.ErrPermission}
+}
+
+// Seek seeks within files. Directories can only be seeked to their start,
+// which is a no-op.
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	if s, ok := f.File.(io.Seeker); ok {
+		return s.Seek(offset, whence)
+	}
+
+	if offset == 0 && whence == io.SeekStart {
+		return 0, nil
+	}
+	return 0, &fs.PathError{Op: "seek", Path: f.name, Err: errors.New("is a directory")}
+}
+
+// Readdir returns the FileInfo of the next count entries of a directory, or
+// of all the remaining ones if count <= 0.
+func (f *file) Readdir(count int) ([]os.FileInfo, error) {
+	d, ok := f.File.(fs.ReadDirFile)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: f.name, Err: errors.New("not implemented")}
+	}
+
+	entries, err := d.ReadDir(count)
+	infos := make([]os.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return infos, err
+		}
+		infos = append(infos, info)
+	}
+
+	return infos, err
+}
@@ -0,0 +1,155 @@
+package webdav
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jhchabran/gistfs"
+	"github.com/jhchabran/gistfs/gisttest"
+)
+
+func TestFileSystem(t *testing.T) {
+	gfs, _ := gisttest.NewFS(t, map[string]string{
+		"a.txt": "0123456789",
+		"b.md":  "# b",
+	}, gistfs.WithCompression())
+	wfs := FileSystem(gfs)
+	ctx := context.Background()
+
+	t.Run("OK stat", func(t *testing.T) {
+		info, err := wfs.Stat(ctx, "/a.txt")
+		if err != nil {
+			t.Fatalf("Stating a file, expected no error but got %#v", err)
+		}
+		if got, want := info.Size(), int64(10); got != want {
+			t.Fatalf("Stating a file, got size %d, want %d", got, want)
+		}
+
+		info, err = wfs.Stat(ctx, "/")
+		if err != nil {
+			t.Fatalf("Stating the root, expected no error but got %#v", err)
+		}
+		if !info.IsDir() {
+			t.Fatalf("Stating the root, got a file, want a directory")
+		}
+	})
+
+	t.Run("OK seek", func(t *testing.T) {
+		f, err := wfs.OpenFile(ctx, "/a.txt", os.O_RDONLY, 0)
+		if err != nil {
+			t.Fatalf("Opening a file, expected no error but got %#v", err)
+		}
+		defer f.Close()
+
+		if _, err := f.Seek(-3, io.SeekEnd); err != nil {
+			t.Fatalf("Seeking, expected no error but got %#v", err)
+		}
+		b, err := io.ReadAll(f)
+		if err != nil {
+			t.Fatalf("Reading, expected no error but got %#v", err)
+		}
+		if got, want := string(b), "789"; got != want {
+			t.Fatalf("Reading after seeking, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK readdir", func(t *testing.T) {
+		f, err := wfs.OpenFile(ctx, "/", os.O_RDONLY, 0)
+		if err != nil {
+			t.Fatalf("Opening the root, expected no error but got %#v", err)
+		}
+		defer f.Close()
+
+		infos, err := f.Readdir(0)
+		if err != nil {
+			t.Fatalf("Listing the root, expected no error but got %#v", err)
+		}
+		if got, want := len(infos), 2; got != want {
+			t.Fatalf("Listing the root, got %d entries, want %d", got, want)
+		}
+	})
+
+	t.Run("NOK read-only", func(t *testing.T) {
+		errs := map[string]error{
+			"mkdir":  wfs.Mkdir(ctx, "/dir", 0755),
+			"remove": wfs.RemoveAll(ctx, "/a.txt"),
+			"rename": wfs.Rename(ctx, "/a.txt", "/c.txt"),
+		}
+		_, errs["create"] = wfs.OpenFile(ctx, "/c.txt", os.O_RDWR|os.O_CREATE, 0644)
+
+		f, err := wfs.OpenFile(ctx, "/a.txt", os.O_RDONLY, 0)
+		if err != nil {
+			t.Fatalf("Opening a file, expected no error but got %#v", err)
+		}
+		defer f.Close()
+		_, errs["write"] = f.Write([]byte("a"))
+
+		for op, err := range errs {
+			if !errors.Is(err, fs.ErrPermission) {
+				t.Fatalf("Attempting to %s, got %#v, want fs.ErrPermission", op, err)
+			}
+		}
+	})
+
+	t.Run("NOK not found", func(t *testing.T) {
+		if _, err := wfs.Stat(ctx, "/c.txt"); !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("Stating a missing file, got %#v, want fs.ErrNotExist", err)
+		}
+	})
+}
+
+func TestHandler(t *testing.T) {
+	gfs, _ := gisttest.NewFS(t, map[string]string{"a.txt": "a"})
+	srv := httptest.NewServer(Handler(gfs))
+	defer srv.Close()
+
+	do := func(method, path string) (*http.Response, string) {
+		req, err := http.NewRequest(method, srv.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Depth", "1")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Requesting, expected no error but got %#v", err)
+		}
+		defer resp.Body.Close()
+
+		b, _ := io.ReadAll(resp.Body)
+		return resp, string(b)
+	}
+
+	t.Run("OK get", func(t *testing.T) {
+		resp, body := do(http.MethodGet, "/a.txt")
+		if got, want := resp.StatusCode, http.StatusOK; got != want {
+			t.Fatalf("Getting a file, got status %d, want %d", got, want)
+		}
+		if got, want := body, "a"; got != want {
+			t.Fatalf("Getting a file, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK propfind", func(t *testing.T) {
+		resp, body := do("PROPFIND", "/")
+		if got, want := resp.StatusCode, http.StatusMultiStatus; got != want {
+			t.Fatalf("Listing the root, got status %d, want %d", got, want)
+		}
+		if !strings.Contains(body, "<D:href>/a.txt</D:href>") {
+			t.Fatalf("Listing the root, expected a.txt to be listed but got %s", body)
+		}
+	})
+
+	t.Run("NOK put", func(t *testing.T) {
+		if resp, _ := do(http.MethodPut, "/b.txt"); resp.StatusCode < 400 {
+			t.Fatalf("Putting a file, got status %d, want an error", resp.StatusCode)
+		}
+	})
+}
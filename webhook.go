@@ -0,0 +1,108 @@
+package gistfs
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxWebhookPayload is the largest payload Github delivers to webhooks.
+const maxWebhookPayload = 25 << 20
+
+// WebhookHandler is an http.Handler receiving Github webhook deliveries and
+// refreshing the filesystems whose gist is mentioned in the payload.
+//
+// Deliveries are authenticated by verifying their X-Hub-Signature-256 header
+// against the secret configured on the webhook.
+type WebhookHandler struct {
+	secret []byte
+	fss    map[string][]*FS
+}
+
+// NewWebhookHandler returns a WebhookHandler refreshing the given filesystems,
+// authenticating deliveries with secret.
+func NewWebhookHandler(secret []byte, fss ...*FS) *WebhookHandler {
+	h := &WebhookHandler{
+		secret: secret,
+		fss:    make(map[string][]*FS),
+	}
+
+	for _, fsys := range fss {
+		h.fss[fsys.GetID()] = append(h.fss[fsys.GetID()], fsys)
+	}
+
+	return h
+}
+
+// webhookPayload is the subset of a webhook delivery that is needed to find
+// which gist changed.
+type webhookPayload struct {
+	Gist *struct {
+		ID string `json:"id"`
+	} `json:"gist"`
+}
+
+// ServeHTTP verifies the delivery and synchronously reloads the filesystems
+// of the gist it refers to. Deliveries that do not refer to a known gist,
+// such as ping events, are acknowledged and ignored.
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookPayload))
+	if err != nil {
+		http.Error(w, "cannot read payload", http.StatusBadRequest)
+		return
+	}
+
+	if !h.validSignature(r.Header.Get("X-Hub-Signature-256"), body) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var payload webhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	if payload.Gist == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	for _, fsys := range h.fss[payload.Gist.ID] {
+		if err := fsys.Load(r.Context()); err != nil {
+			http.Error(w, "cannot refresh gist", http.StatusBadGateway)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validSignature reports whether signature, in the "sha256=<hex>" format
+// used by Github, matches the HMAC of body computed with the secret.
+func (h *WebhookHandler) validSignature(signature string, body []byte) bool {
+	const prefix = "sha256="
+	if !strings.HasPrefix(signature, prefix) {
+		return false
+	}
+
+	got, err := hex.DecodeString(signature[len(prefix):])
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, h.secret)
+	mac.Write(body)
+
+	return hmac.Equal(got, mac.Sum(nil))
+}
@@ -0,0 +1,87 @@
+package gistfs
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func signPayload(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestWebhookHandler(t *testing.T) {
+	fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+	gfs := NewWithClient(client, referenceGistID)
+	gfs.Load(context.Background())
+
+	h := NewWebhookHandler([]byte("s3cr3t"), gfs)
+	payload := `{"action":"update","gist":{"id":"` + referenceGistID + `"}}`
+
+	t.Run("OK refresh", func(t *testing.T) {
+		fg.SetFiles(map[string]string{"a.txt": "updated"})
+
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(payload))
+		req.Header.Set("X-Hub-Signature-256", signPayload("s3cr3t", payload))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if got, want := rec.Code, http.StatusNoContent; got != want {
+			t.Fatalf("Delivering a webhook, got status %d, want %d", got, want)
+		}
+
+		b, _ := gfs.ReadFile("a.txt")
+		if got, want := string(b), "updated"; got != want {
+			t.Fatalf("Reading after a webhook, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK unknown gist", func(t *testing.T) {
+		before := fg.Requests()
+		body := `{"gist":{"id":"unknown"}}`
+
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("X-Hub-Signature-256", signPayload("s3cr3t", body))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if got, want := rec.Code, http.StatusNoContent; got != want {
+			t.Fatalf("Delivering a webhook for another gist, got status %d, want %d", got, want)
+		}
+
+		if got, want := fg.Requests(), before; got != want {
+			t.Fatalf("Delivering a webhook for another gist, got %d API requests, want %d", got, want)
+		}
+	})
+
+	t.Run("NOK bad signature", func(t *testing.T) {
+		tests := []string{"", "sha256=zz", signPayload("wrong", payload), "sha1=abcd"}
+
+		for _, sig := range tests {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(payload))
+			req.Header.Set("X-Hub-Signature-256", sig)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if got, want := rec.Code, http.StatusUnauthorized; got != want {
+				t.Fatalf("Delivering with signature %#v, got status %d, want %d", sig, got, want)
+			}
+		}
+	})
+
+	t.Run("NOK method", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if got, want := rec.Code, http.StatusMethodNotAllowed; got != want {
+			t.Fatalf("Delivering with GET, got status %d, want %d", got, want)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// WithWriteDebounce makes WriteFile buffer the files it is given, and store
+// them into the gist with a single edit once no file was written for d, so
+// that tools saving on every keystroke don't make a request each time.
+// Flush stores the buffered files right away.
+//
+// Buffered files are not served until they are stored. Storing them in the
+// background can't report errors to WriteFile, so the next call to Flush
+// returns them, and keeps the files that failed to be stored buffered.
+func WithWriteDebounce(d time.Duration) Option {
+	return func(fsys *FS) {
+		fsys.writeDebounce = d
+	}
+}
+
+// writeBuffer holds the files written by WriteFile until they are stored,
+// when writes are debounced.
+type writeBuffer struct {
+	mu    sync.Mutex
+	files map[github.GistFilename]github.GistFile
+	timer *time.Timer
+	// err is the error of the last flush made in the background.
+	err error
+
+	// flushMu serializes flushes, so that files are stored in the order
+	// they were written.
+	flushMu sync.Mutex
+}
+
+// WriteFile stores data as the content of the named file of the gist,
+// creating the file if needed, and refreshes the filesystem with the updated
+// gist. With WithWriteDebounce, it buffers the file and returns right away.
+//
+// It requires the filesystem to be created with WithWritable. Because gists
+// are flat and only store text, name can't be in a directory and data must
+// be non-empty UTF-8 text.
+func (fsys *FS) WriteFile(ctx context.Context, name string, data []byte) error {
+	if !fsys.writable {
+		return ErrReadOnly
+	}
+
+	switch {
+	case !fs.ValidPath(name) || name == ".":
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	case path.Dir(name) != ".":
+		return &fs.PathError{Op: "write", Path: name, Err: errors.New("gists cannot have directories")}
+	case len(data) == 0:
+		return &fs.PathError{Op: "write", Path: name, Err: errors.New("gists cannot store empty files")}
+	case !utf8.Valid(data):
+		return &fs.PathError{Op: "write", Path: name, Err: errors.New("gists cannot store binary files")}
+	}
+
+	content := string(data)
+	files := map[github.GistFilename]github.GistFile{github.GistFilename(name): {Content: &content}}
+
+	if fsys.writeDebounce <= 0 {
+		return fsys.commitFiles(ctx, "write", files, GitCommit{})
+	}
+
+	fsys.writes.mu.Lock()
+	defer fsys.writes.mu.Unlock()
+
+	fsys.writes.add(files)
+	if fsys.writes.timer != nil {
+		fsys.writes.timer.Stop()
+	}
+	fsys.writes.timer = time.AfterFunc(fsys.writeDebounce, func() {
+		ctx, cancel := fsys.withLoadTimeout(context.Background())
+		defer cancel()
+
+		if err := fsys.flush(ctx); err != nil {
+			fsys.log(ctx, levelWarn, "storing written files failed", "error", err)
+
+			fsys.writes.mu.Lock()
+			fsys.writes.err = err
+			fsys.writes.mu.Unlock()
+		}
+	})
+
+	return nil
+}
+
+// Flush stores the files buffered by WriteFile with WithWriteDebounce into
+// the gist, and returns the error of doing so, or the one of the last flush
+// made in the background if it failed since the previous call. It does
+// nothing without WithWriteDebounce.
+func (fsys *FS) Flush(ctx context.Context) error {
+	fsys.writes.mu.Lock()
+	if fsys.writes.timer != nil {
+		fsys.writes.timer.Stop()
+		fsys.writes.timer = nil
+	}
+	bgErr := fsys.writes.err
+	fsys.writes.err = nil
+	fsys.writes.mu.Unlock()
+
+	if err := fsys.flush(ctx); err != nil {
+		return err
+	}
+
+	return bgErr
+}
+
+// flush stores the buffered files into the gist. Files that fail to be
+// stored are buffered again, unless they were written again meanwhile.
+func (fsys *FS) flush(ctx context.Context) error {
+	fsys.writes.flushMu.Lock()
+	defer fsys.writes.flushMu.Unlock()
+
+	fsys.writes.mu.Lock()
+	files := fsys.writes.files
+	fsys.writes.files = nil
+	fsys.writes.mu.Unlock()
+
+	if len(files) == 0 {
+		return nil
+	}
+
+	err := fsys.commitFiles(ctx, "write", files, GitCommit{})
+	if err != nil {
+		fsys.writes.mu.Lock()
+		for name, f := range fsys.writes.files {
+			files[name] = f
+		}
+		fsys.writes.files = files
+		fsys.writes.mu.Unlock()
+	}
+
+	return err
+}
+
+// add buffers files, replacing the ones with the same names.
+func (b *writeBuffer) add(files map[github.GistFilename]github.GistFile) {
+	if b.files == nil {
+		b.files = make(map[github.GistFilename]github.GistFile)
+	}
+	for name, f := range files {
+		b.files[name] = f
+	}
+}
@@ -0,0 +1,183 @@
+package gistfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWriteFile(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		gfs := NewWithClient(client, referenceGistID, WithWritable())
+		gfs.Load(ctx)
+
+		if err := gfs.WriteFile(ctx, "b.txt", []byte("b")); err != nil {
+			t.Fatalf("Writing, expected no error but got %#v", err)
+		}
+
+		b, _ := gfs.ReadFile("b.txt")
+		if got, want := string(b), "b"; got != want {
+			t.Fatalf("Reading a written file, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK truncated by the API", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		fg.SetTruncateSize(4)
+		gfs := NewWithClient(client, referenceGistID, WithWritable())
+		gfs.Load(ctx)
+
+		if err := gfs.WriteFile(ctx, "large.txt", []byte("0123456789")); err != nil {
+			t.Fatalf("Writing, expected no error but got %#v", err)
+		}
+
+		b, _ := gfs.ReadFile("large.txt")
+		if got, want := string(b), "0123456789"; got != want {
+			t.Fatalf("Reading a written file truncated by the API, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("NOK checks", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		gfs := NewWithClient(client, referenceGistID, WithWritable(), WithCaseInsensitive())
+		gfs.Load(ctx)
+
+		if err := gfs.WriteFile(ctx, "A.txt", []byte("A")); !errors.Is(err, ErrCaseCollision) {
+			t.Fatalf("Writing a file colliding with another, got %#v, want %#v", err, ErrCaseCollision)
+		}
+		if b, _ := gfs.ReadFile("a.txt"); string(b) != "a" {
+			t.Fatalf("Reading after a rejected write, got %q, want %q", b, "a")
+		}
+	})
+
+	t.Run("NOK invalid", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		gfs := NewWithClient(client, referenceGistID, WithWritable())
+		gfs.Load(ctx)
+
+		tests := map[string][]byte{
+			"dir/a.txt": []byte("a"),
+			"../a.txt":  []byte("a"),
+			"empty.txt": nil,
+			"bin.dat":   {0xff, 0xfe},
+		}
+		for name, data := range tests {
+			var pathErr *fs.PathError
+			if err := gfs.WriteFile(ctx, name, data); !errors.As(err, &pathErr) {
+				t.Fatalf("Writing %s, got %#v, want a *fs.PathError", name, err)
+			}
+		}
+	})
+
+	t.Run("NOK read-only", func(t *testing.T) {
+		_, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		gfs := NewWithClient(client, referenceGistID)
+		gfs.Load(ctx)
+
+		if err := gfs.WriteFile(ctx, "a.txt", []byte("b")); !errors.Is(err, ErrReadOnly) {
+			t.Fatalf("Writing, got %#v, want ErrReadOnly", err)
+		}
+	})
+}
+
+func TestWriteDebounce(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK flush", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		gfs := NewWithClient(client, referenceGistID, WithWritable(), WithWriteDebounce(time.Hour))
+		gfs.Load(ctx)
+
+		for _, content := range []string{"b", "bc", "bcd"} {
+			if err := gfs.WriteFile(ctx, "a.txt", []byte(content)); err != nil {
+				t.Fatalf("Writing, expected no error but got %#v", err)
+			}
+		}
+		gfs.WriteFile(ctx, "b.txt", []byte("b"))
+
+		if got, want := fg.Requests(), 1; got != want {
+			t.Fatalf("Writing with debounce, got %d API requests, want %d", got, want)
+		}
+		if b, _ := gfs.ReadFile("a.txt"); string(b) != "a" {
+			t.Fatalf("Reading before flush, got %q, want %q", b, "a")
+		}
+
+		if err := gfs.Flush(ctx); err != nil {
+			t.Fatalf("Flushing, expected no error but got %#v", err)
+		}
+		if got, want := fg.Requests(), 2; got != want {
+			t.Fatalf("Flushing, got %d API requests, want %d", got, want)
+		}
+		if b, _ := gfs.ReadFile("a.txt"); string(b) != "bcd" {
+			t.Fatalf("Reading after flush, got %q, want %q", b, "bcd")
+		}
+		if b, _ := gfs.ReadFile("b.txt"); string(b) != "b" {
+			t.Fatalf("Reading after flush, got %q, want %q", b, "b")
+		}
+
+		if err := gfs.Flush(ctx); err != nil || fg.Requests() != 2 {
+			t.Fatalf("Flushing nothing, got %#v and %d API requests, want none", err, fg.Requests()-2)
+		}
+	})
+
+	t.Run("OK flush truncated by the API", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		fg.SetTruncateSize(4)
+		gfs := NewWithClient(client, referenceGistID, WithWritable(), WithWriteDebounce(time.Hour))
+		gfs.Load(ctx)
+
+		gfs.WriteFile(ctx, "large.txt", []byte("0123456789"))
+		if err := gfs.Flush(ctx); err != nil {
+			t.Fatalf("Flushing, expected no error but got %#v", err)
+		}
+
+		b, _ := gfs.ReadFile("large.txt")
+		if got, want := string(b), "0123456789"; got != want {
+			t.Fatalf("Reading a flushed file truncated by the API, got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("OK quiet period", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		gfs := NewWithClient(client, referenceGistID, WithWritable(), WithWriteDebounce(10*time.Millisecond))
+		gfs.Load(ctx)
+
+		gfs.WriteFile(ctx, "a.txt", []byte("b"))
+		gfs.WriteFile(ctx, "a.txt", []byte("c"))
+
+		eventually(t, func() bool {
+			b, _ := gfs.ReadFile("a.txt")
+			return string(b) == "c"
+		})
+		if got, want := fg.Requests(), 2; got != want {
+			t.Fatalf("Writing with debounce, got %d API requests, want %d", got, want)
+		}
+	})
+
+	t.Run("NOK flush fails", func(t *testing.T) {
+		fg, client := newFakeGist(t, map[string]string{"a.txt": "a"})
+		gfs := NewWithClient(client, referenceGistID, WithWritable(), WithWriteDebounce(time.Hour))
+		gfs.Load(ctx)
+
+		gfs.WriteFile(ctx, "a.txt", []byte("b"))
+
+		fg.SetStatus(http.StatusUnprocessableEntity)
+		if err := gfs.Flush(ctx); err == nil {
+			t.Fatal("Flushing, expected an error but got none")
+		}
+
+		fg.SetStatus(0)
+		if err := gfs.Flush(ctx); err != nil {
+			t.Fatalf("Flushing again, expected no error but got %#v", err)
+		}
+		if b, _ := gfs.ReadFile("a.txt"); string(b) != "b" {
+			t.Fatalf("Reading after flush, got %q, want %q", b, "b")
+		}
+	})
+}